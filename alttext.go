@@ -0,0 +1,267 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxAltTextContext is the number of runes of text around an image given
+// to captioners.
+const maxAltTextContext = 500
+
+// AltTextRequest is an image lacking alternative text.
+type AltTextRequest struct {
+	Image *BookImage
+	// Document is the zip path of the content document showing the image.
+	Document string
+	// Context is the caption of the figure holding the image, or else the
+	// text of the block around it, whitespace collapsed.
+	Context string
+	// Language is the language declared for the image.
+	Language string
+}
+
+// Captioner describes images for their alternative text. It is the
+// integration point for captioning services and local models; an empty
+// caption leaves the image untouched.
+type Captioner interface {
+	Caption(ctx context.Context, request AltTextRequest) (string, error)
+}
+
+// CaptionerFunc adapts a function to the Captioner interface.
+type CaptionerFunc func(ctx context.Context, request AltTextRequest) (string, error)
+
+// Caption calls f.
+func (f CaptionerFunc) Caption(ctx context.Context, request AltTextRequest) (string, error) {
+	return f(ctx, request)
+}
+
+// AltTextOptions tunes WriteWithAltText.
+type AltTextOptions struct {
+	Captioner Captioner
+	// EmbedAudit appends the audit record of the repair to the audit log of
+	// the book at AuditPath.
+	EmbedAudit bool
+}
+
+// GeneratedAltText is an alternative text added to an image.
+type GeneratedAltText struct {
+	Document string
+	Image    string
+	Text     string
+}
+
+// AltTextResult reports the alternative texts added.
+type AltTextResult struct {
+	Generated []GeneratedAltText
+	Audit     AuditRecord
+}
+
+// WriteWithAltText writes to w a copy of the book whose img elements
+// without an alt attribute get the alternative text of the captioner. An
+// empty alt, which marks decorative images, is kept. Each image is
+// captioned once, for its first use.
+func (epubReader *EpubReader) WriteWithAltText(ctx context.Context, w io.Writer, options AltTextOptions) (AltTextResult, error) {
+	result := AltTextResult{Audit: AuditRecord{Operation: "alt-text", Time: time.Now().UTC()}}
+	if options.Captioner == nil {
+		return result, fmt.Errorf("epub: %s: no captioner", epubReader.Name)
+	}
+
+	replacements := make(map[string][]byte)
+	captions := make(map[string]string)
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.MediaType != "application/xhtml+xml" && item.MediaType != "text/html" {
+			continue
+		}
+		name := epubReader.itemPath(item.Href)
+		requests, err := epubReader.altTextRequests(name)
+		if err != nil {
+			return result, err
+		}
+		if len(requests) == 0 {
+			continue
+		}
+
+		texts := make([]string, len(requests))
+		var details []string
+		for i, request := range requests {
+			if request.Image == nil {
+				continue
+			}
+			text, ok := captions[request.Image.Path]
+			if !ok {
+				caption, err := options.Captioner.Caption(ctx, request)
+				if err != nil {
+					return result, fmt.Errorf("epub: %s: caption '%s': %w", epubReader.Name, request.Image.Path, err)
+				}
+				text = collapseSpace(caption)
+				captions[request.Image.Path] = text
+			}
+			if text == "" {
+				continue
+			}
+			texts[i] = text
+			details = append(details, request.Image.Path)
+			result.Generated = append(result.Generated, GeneratedAltText{Document: name, Image: request.Image.Path, Text: text})
+		}
+		if len(details) == 0 {
+			continue
+		}
+
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return result, err
+		}
+		repaired := insertAltTexts(buffer.Bytes(), texts)
+		replacements[name] = repaired
+		result.Audit.Files = append(result.Audit.Files, AuditFile{
+			Path:    name,
+			Change:  AuditModified,
+			Before:  int64(buffer.Len()),
+			After:   int64(len(repaired)),
+			Details: details,
+		})
+		result.Audit.SavedBytes += int64(buffer.Len() - len(repaired))
+	}
+
+	if options.EmbedAudit {
+		var err error
+		if replacements[AuditPath], err = epubReader.appendAuditLog(result.Audit); err != nil {
+			return result, err
+		}
+	}
+
+	return result, epubReader.writeZip(w, replacements)
+}
+
+// AltTextFile adds the missing alternative texts of the EPUB at filename
+// in place, with the guarantees of SafeWriteFile.
+func AltTextFile(ctx context.Context, filename string, options AltTextOptions) (AltTextResult, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return AltTextResult{}, err
+	}
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteWithAltText(ctx, &buffer, options)
+	reader.Close()
+	if err != nil {
+		return result, err
+	}
+
+	return result, SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// altTextRequests returns a request for each img element of the document
+// name, in document order: nil images for those having an alt attribute
+// or showing no image of the book.
+func (epubReader *EpubReader) altTextRequests(name string) ([]AltTextRequest, error) {
+	document, err := epubReader.parseDocument(name)
+	if err != nil {
+		return nil, err
+	}
+
+	language := strings.TrimSpace(firstText(epubReader.Rootfiles[0].Metadata.Language))
+	var requests []AltTextRequest
+	document.walk(func(n *domNode) bool {
+		if n.name != "img" {
+			return true
+		}
+		request := AltTextRequest{Document: name}
+		src, ok := localReference(name, n.attr("src"))
+		if !ok || hasAttr(n, "alt") {
+			requests = append(requests, request)
+			return false
+		}
+
+		request.Image = &BookImage{Path: src, epubReader: epubReader}
+		if item, ok := epubReader.itemByPath(src); ok {
+			request.Image.MediaType = item.MediaType
+		}
+		request.Context = altTextContext(n)
+		request.Language = declaredLanguage(n, language)
+		requests = append(requests, request)
+		return false
+	})
+
+	return requests, nil
+}
+
+func hasAttr(node *domNode, name string) bool {
+	for _, a := range node.attrs {
+		if a.Name.Local == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// altTextContext returns the caption of the figure holding img, or else
+// the text of its nearest block ancestor.
+func altTextContext(img *domNode) string {
+	context := ""
+	for n := img.parent; n != nil && n.name != "body" && n.name != "#document"; n = n.parent {
+		if n.name == "figure" {
+			if caption := n.find(func(c *domNode) bool { return c.name == "figcaption" }); caption != nil {
+				context = collapseSpace(caption.textContent())
+				break
+			}
+		}
+		if blockElements[n.name] {
+			if context = collapseSpace(n.textContent()); context != "" {
+				break
+			}
+		}
+	}
+
+	if runes := []rune(context); len(runes) > maxAltTextContext {
+		context = string(runes[:maxAltTextContext])
+	}
+
+	return context
+}
+
+// insertAltTexts adds to the img elements of data, in document order, the
+// alt attributes of texts, skipping the empty ones.
+func insertAltTexts(data []byte, texts []string) []byte {
+	decoder := rawOffsets(newXHTMLDecoder(bytes.NewReader(data)))
+
+	output := editedBuffer(data)
+	last, i := 0, 0
+	for i < len(texts) {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || !strings.EqualFold(start.Name.Local, "img") {
+			continue
+		}
+		if text := texts[i]; text != "" && offset < len(data) && data[offset] == '<' {
+			// The attribute follows the element name.
+			end := offset + 1
+			for end < len(data) && !isCSSSpace(data[end]) && data[end] != '/' && data[end] != '>' {
+				end++
+			}
+			output.Write(data[last:end])
+			output.WriteString(` alt="`)
+			xml.EscapeText(output, []byte(text))
+			output.WriteString(`"`)
+			last = end
+		}
+		i++
+	}
+	output.Write(data[last:])
+
+	return output.Bytes()
+}
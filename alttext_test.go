@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteWithAltText(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/chapter2.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" lang="fr">
+<head><title>Chapter Two</title></head>
+<body>
+<figure><img src="images/cover.jpg"/><figcaption>The "old" house</figcaption></figure>
+<p>Before <IMG src="images/map.png" /> after.</p>
+<p><img src="images/rule.png" alt=""/><img src="http://example.com/remote.png"/></p>
+</body>
+</html>`})
+
+	var requests []AltTextRequest
+	captioner := CaptionerFunc(func(ctx context.Context, request AltTextRequest) (string, error) {
+		requests = append(requests, request)
+		if strings.HasSuffix(request.Image.Path, "map.png") {
+			return "", nil
+		}
+		return "A house & its  garden", nil
+	})
+
+	var b bytes.Buffer
+	result, err := reader.WriteWithAltText(context.Background(), &b, AltTextOptions{Captioner: captioner, EmbedAudit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 || requests[0].Context != `The "old" house` || requests[0].Language != "fr" ||
+		requests[0].Image.MediaType != "image/jpeg" || requests[1].Context != "Before after." {
+		t.Errorf("requests = %+v", requests)
+	}
+	want := []GeneratedAltText{{Document: "OEBPS/chapter2.xhtml", Image: "OEBPS/images/cover.jpg", Text: "A house & its garden"}}
+	if !reflect.DeepEqual(result.Generated, want) {
+		t.Errorf("Generated = %+v", result.Generated)
+	}
+
+	repaired, err := OpenBuffer(b.Bytes(), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repaired.Close()
+	chapter, err := repaired.readFile("OEBPS/chapter2.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(chapter.String(), `<img alt="A house &amp; its garden" src="images/cover.jpg"/>`) ||
+		!strings.Contains(chapter.String(), `<IMG src="images/map.png" />`) {
+		t.Errorf("chapter2 = %s", chapter)
+	}
+	if records, err := repaired.AuditLog(); err != nil || len(records) != 1 || records[0].Operation != "alt-text" {
+		t.Errorf("AuditLog() = %+v, %v", records, err)
+	}
+
+	failing := CaptionerFunc(func(ctx context.Context, request AltTextRequest) (string, error) {
+		return "", ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := reader.WriteWithAltText(ctx, &b, AltTextOptions{Captioner: failing}); !errors.Is(err, context.Canceled) {
+		t.Errorf("WriteWithAltText(canceled) = %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package epub
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnchorOptions tunes WriteWithAnchors.
+type AnchorOptions struct {
+	// Elements are the local names of the elements given an id, such as
+	// "p", "li" or "blockquote"; "p" if empty.
+	Elements []string
+	// EmbedAudit appends the audit record of the transform to the audit
+	// log of the book at AuditPath.
+	EmbedAudit bool
+}
+
+// Anchor is an id added to an element.
+type Anchor struct {
+	Document string
+	Element  string
+	ID       string
+}
+
+// AnchorResult reports the ids added.
+type AnchorResult struct {
+	Anchors []Anchor
+	Audit   AuditRecord
+}
+
+// WriteWithAnchors writes to w a copy of the book whose spine documents
+// give an id to the elements of options.Elements lacking one and holding
+// text, so that annotations and deep links can target them. Ids derive
+// from the element name and a hash of its text, such as "p-1f3a9c2e",
+// rather than from the position of the element: they stay the same when
+// other elements are edited, added or removed. Elements with the same text
+// get the suffixes -2, -3 and so on, in document order.
+func (epubReader *EpubReader) WriteWithAnchors(w io.Writer, options AnchorOptions) (AnchorResult, error) {
+	result := AnchorResult{Audit: AuditRecord{Operation: "anchors", Time: time.Now().UTC()}}
+	elements := make(map[string]bool)
+	for _, element := range options.Elements {
+		elements[strings.ToLower(element)] = true
+	}
+	if len(elements) == 0 {
+		elements["p"] = true
+	}
+
+	replacements := make(map[string][]byte)
+	for _, item := range epubReader.SpineItems() {
+		name := epubReader.itemPath(item.Href)
+		if _, done := replacements[name]; done {
+			continue
+		}
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return result, err
+		}
+
+		anchored, anchors := insertAnchors(buffer.Bytes(), elements)
+		if len(anchors) == 0 {
+			continue
+		}
+		replacements[name] = anchored
+		var details []string
+		for i := range anchors {
+			anchors[i].Document = name
+			details = append(details, anchors[i].ID)
+		}
+		result.Anchors = append(result.Anchors, anchors...)
+		result.Audit.Files = append(result.Audit.Files, AuditFile{
+			Path:    name,
+			Change:  AuditModified,
+			Before:  int64(buffer.Len()),
+			After:   int64(len(anchored)),
+			Details: details,
+		})
+		result.Audit.SavedBytes += int64(buffer.Len() - len(anchored))
+	}
+
+	if options.EmbedAudit {
+		var err error
+		if replacements[AuditPath], err = epubReader.appendAuditLog(result.Audit); err != nil {
+			return result, err
+		}
+	}
+
+	return result, epubReader.writeZip(w, replacements)
+}
+
+// insertAnchors adds an id attribute to the elements of data whose local
+// name is in elements and which have text but no id. Markup that cannot be
+// parsed past an element leaves it unchanged.
+func insertAnchors(data []byte, elements map[string]bool) ([]byte, []Anchor) {
+	type candidate struct {
+		offset int
+		name   string
+		depth  int
+		text   strings.Builder
+		closed bool
+	}
+	var candidates []*candidate
+	ids := make(map[string]bool)
+
+	decoder := rawOffsets(newXHTMLDecoder(bytes.NewReader(data)))
+	var open []*candidate
+	depth := 0
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			if id := attr(t, "id"); id != "" {
+				ids[id] = true
+			} else if name := strings.ToLower(t.Name.Local); elements[name] && offset < len(data) && data[offset] == '<' {
+				c := &candidate{offset: offset, name: name, depth: depth}
+				candidates = append(candidates, c)
+				open = append(open, c)
+			}
+		case xml.CharData:
+			for _, c := range open {
+				c.text.Write(t)
+			}
+		case xml.EndElement:
+			if n := len(open); n > 0 && open[n-1].depth == depth {
+				open[n-1].closed = true
+				open = open[:n-1]
+			}
+			depth--
+		}
+	}
+
+	var anchors []Anchor
+	output := editedBuffer(data)
+	last := 0
+	for _, c := range candidates {
+		text := collapseSpace(c.text.String())
+		if !c.closed || text == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(text))
+		base := c.name + "-" + hex.EncodeToString(sum[:4])
+		id := base
+		for n := 2; ids[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		ids[id] = true
+		anchors = append(anchors, Anchor{Element: c.name, ID: id})
+
+		// The attribute follows the element name.
+		end := c.offset + 1
+		for end < len(data) && !isCSSSpace(data[end]) && data[end] != '/' && data[end] != '>' {
+			end++
+		}
+		output.Write(data[last:end])
+		output.WriteString(` id="` + id + `"`)
+		last = end
+	}
+	output.Write(data[last:])
+
+	return output.Bytes(), anchors
+}
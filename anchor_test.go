@@ -0,0 +1,62 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteWithAnchors(t *testing.T) {
+	chapter := `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter One</title></head>
+<body>
+<h1>Chapter One</h1>
+<p>It was a dark &amp; <em>stormy</em> night.</p>
+<p id="kept">Already anchored.</p>
+<p>* * *</p>
+<p>
+</p>
+<blockquote><p>* * *</p></blockquote>
+</body>
+</html>`
+	reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", chapter})
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteWithAnchors(&buffer, AnchorOptions{Elements: []string{"p", "blockquote"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, anchor := range result.Anchors {
+		if anchor.Document == "OEBPS/chapter1.xhtml" {
+			ids = append(ids, anchor.ID)
+		}
+	}
+	if len(ids) != 4 || !strings.HasPrefix(ids[0], "p-") || ids[2] != "blockquote-"+ids[1][2:] || ids[3] != ids[1]+"-2" {
+		t.Fatalf("WriteWithAnchors() ids = %q", ids)
+	}
+
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, _ := written.readFile("OEBPS/chapter1.xhtml")
+	for _, want := range []string{
+		`<p id="` + ids[0] + `">It was a dark &amp; <em>stormy</em> night.</p>`,
+		`<p id="kept">`,
+		`<blockquote id="` + ids[2] + `"><p id="` + ids[3] + `">* * *</p></blockquote>`,
+		"<p>\n</p>",
+	} {
+		if !strings.Contains(document.String(), want) {
+			t.Errorf("chapter1.xhtml lacks %q:\n%s", want, document)
+		}
+	}
+
+	// Ids do not depend on the position of the paragraphs.
+	edited := strings.Replace(chapter, "<h1>Chapter One</h1>", "<h1>Chapter One</h1>\n<p>A new first paragraph.</p>", 1)
+	result, err = openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", edited}).WriteWithAnchors(&bytes.Buffer{}, AnchorOptions{})
+	if err != nil || len(result.Anchors) < 2 || result.Anchors[1].ID != ids[0] {
+		t.Errorf("WriteWithAnchors() of the edited chapter = %+v, %v", result.Anchors, err)
+	}
+}
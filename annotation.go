@@ -0,0 +1,229 @@
+package epub
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Annotation is a highlight or a note, as stored in an annotations sidecar
+// file: a JSON array of annotations.
+type Annotation struct {
+	CFI     string    `json:"cfi"`
+	Note    string    `json:"note,omitempty"`
+	Color   string    `json:"color,omitempty"`
+	Created time.Time `json:"created,omitempty"`
+}
+
+// ReadAnnotations reads an annotations sidecar file.
+func ReadAnnotations(r io.Reader) ([]Annotation, error) {
+	var annotations []Annotation
+	if err := json.NewDecoder(r).Decode(&annotations); err != nil {
+		return nil, fmt.Errorf("epub: read annotations: %w", err)
+	}
+
+	return annotations, nil
+}
+
+// Highlight is an annotation resolved against the book.
+type Highlight struct {
+	Annotation
+	// Quote is the highlighted text.
+	Quote string
+	// Chapter is the title of the content document holding the highlight.
+	Chapter string
+	// Err is set when the annotation CFI could not be resolved.
+	Err error
+
+	spine  int
+	offset int
+}
+
+// Highlights resolves annotations and returns them in reading order;
+// annotations that cannot be resolved come last, with Err set.
+func (epubReader *EpubReader) Highlights(annotations []Annotation) []Highlight {
+	spine := make(map[string]int)
+	for i, item := range epubReader.SpineItems() {
+		spine[item.ID] = i
+	}
+	titles := make(map[string]string)
+
+	highlights := make([]Highlight, 0, len(annotations))
+	for _, annotation := range annotations {
+		highlight := Highlight{Annotation: annotation, spine: len(spine)}
+
+		cfi, err := ParseCFI(annotation.CFI)
+		var target CFITarget
+		if err == nil {
+			target, err = epubReader.ResolveCFI(cfi)
+		}
+		if err != nil {
+			highlight.Err = err
+			highlights = append(highlights, highlight)
+			continue
+		}
+
+		highlight.Quote = target.Text
+		highlight.spine = spine[target.Item.ID]
+		highlight.offset = target.Start
+		if _, ok := titles[target.Item.ID]; !ok {
+			titles[target.Item.ID] = epubReader.documentTitle(target.Item)
+		}
+		highlight.Chapter = titles[target.Item.ID]
+		highlights = append(highlights, highlight)
+	}
+
+	sort.SliceStable(highlights, func(i, j int) bool {
+		if highlights[i].spine != highlights[j].spine {
+			return highlights[i].spine < highlights[j].spine
+		}
+		return highlights[i].offset < highlights[j].offset
+	})
+
+	return highlights
+}
+
+// documentTitle returns the first heading of a content document, or its
+// title element, or the item id.
+func (epubReader *EpubReader) documentTitle(item Item) string {
+	if title, ok := epubReader.headingTitle(item); ok {
+		return title
+	}
+
+	return item.ID
+}
+
+// headingTitle returns the first h1, h2 or h3 heading of a content
+// document, or its title element.
+func (epubReader *EpubReader) headingTitle(item Item) (string, bool) {
+	reader, err := epubReader.openFile(epubReader.itemPath(item.Href))
+	if err != nil {
+		return "", false
+	}
+	defer reader.Close()
+
+	document, err := parseDOM(reader)
+	if err != nil {
+		return "", false
+	}
+
+	return documentHeading(document)
+}
+
+// documentHeading returns the first h1, h2 or h3 heading of document, or
+// its title element.
+func documentHeading(document *domNode) (string, bool) {
+	for _, match := range []func(*domNode) bool{
+		func(n *domNode) bool {
+			return (n.name == "h1" || n.name == "h2" || n.name == "h3") && collapseSpace(n.textContent()) != ""
+		},
+		func(n *domNode) bool { return n.name == "title" && collapseSpace(n.textContent()) != "" },
+	} {
+		if node := document.find(match); node != nil {
+			return collapseSpace(node.textContent()), true
+		}
+	}
+
+	return "", false
+}
+
+// Highlight export formats.
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// WriteHighlights resolves annotations and writes them as a digest in the
+// given format, grouped by chapter in reading order.
+func (epubReader *EpubReader) WriteHighlights(w io.Writer, annotations []Annotation, format string) error {
+	digest := highlightDigest{
+		Title:  firstTitle(epubReader.Rootfiles[0].Metadata.Title),
+		Author: firstCreator(epubReader.Rootfiles[0].Metadata.Creator).Text,
+	}
+	for _, highlight := range epubReader.Highlights(annotations) {
+		if highlight.Err != nil {
+			digest.Unresolved = append(digest.Unresolved, highlight)
+			continue
+		}
+		if n := len(digest.Chapters); n == 0 || digest.Chapters[n-1].Title != highlight.Chapter {
+			digest.Chapters = append(digest.Chapters, highlightChapter{Title: highlight.Chapter})
+		}
+		chapter := &digest.Chapters[len(digest.Chapters)-1]
+		chapter.Highlights = append(chapter.Highlights, highlight)
+	}
+
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdownDigest(w, digest)
+	case FormatHTML:
+		return htmlDigest.Execute(w, digest)
+	}
+
+	return fmt.Errorf("epub: unknown highlight format %s", format)
+}
+
+type highlightDigest struct {
+	Title      string
+	Author     string
+	Chapters   []highlightChapter
+	Unresolved []Highlight
+}
+
+type highlightChapter struct {
+	Title      string
+	Highlights []Highlight
+}
+
+func writeMarkdownDigest(w io.Writer, digest highlightDigest) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", digest.Title)
+	if digest.Author != "" {
+		fmt.Fprintf(&b, "*%s*\n\n", digest.Author)
+	}
+	for _, chapter := range digest.Chapters {
+		fmt.Fprintf(&b, "## %s\n\n", chapter.Title)
+		for _, highlight := range chapter.Highlights {
+			fmt.Fprintf(&b, "> %s\n\n", highlight.Quote)
+			if highlight.Note != "" {
+				fmt.Fprintf(&b, "%s\n\n", highlight.Note)
+			}
+		}
+	}
+	if len(digest.Unresolved) > 0 {
+		b.WriteString("## Unresolved\n\n")
+		for _, highlight := range digest.Unresolved {
+			fmt.Fprintf(&b, "- `%s`", highlight.CFI)
+			if highlight.Note != "" {
+				fmt.Fprintf(&b, ": %s", highlight.Note)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+var htmlDigest = template.Must(template.New("highlights").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Author}}<p><em>{{.Author}}</em></p>
+{{end}}{{range .Chapters}}<h2>{{.Title}}</h2>
+{{range .Highlights}}<blockquote{{if .Color}} style="border-left: 4px solid {{.Color}}"{{end}}>{{.Quote}}</blockquote>
+{{if .Note}}<p>{{.Note}}</p>
+{{end}}{{end}}{{end}}{{if .Unresolved}}<h2>Unresolved</h2>
+<ul>
+{{range .Unresolved}}<li><code>{{.CFI}}</code>{{if .Note}}: {{.Note}}{{end}}</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,46 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHighlights(t *testing.T) {
+	reader := openTestEpub(t)
+
+	annotations, err := ReadAnnotations(strings.NewReader(`[
+  {"cfi": "epubcfi(/6/4!/4/4/1:0)", "note": "short ending"},
+  {"cfi": "epubcfi(/6/2!/4/4,/1:9,/1:13)", "note": "<b>bold</b> choice", "color": "yellow"},
+  {"cfi": "epubcfi(/6/40!/4)", "note": "lost"}
+]`))
+	if err != nil {
+		t.Fatalf("ReadAnnotations() = %v", err)
+	}
+
+	var markdown bytes.Buffer
+	if err := reader.WriteHighlights(&markdown, annotations, FormatMarkdown); err != nil {
+		t.Fatalf("WriteHighlights(markdown) = %v", err)
+	}
+	want := "# The Test Book\n\n*Jane Doe*\n\n" +
+		"## Chapter One\n\n> dark\n\n<b>bold</b> choice\n\n" +
+		"## Chapter Two\n\n> The end.\n\nshort ending\n\n" +
+		"## Unresolved\n\n- `epubcfi(/6/40!/4)`: lost\n\n"
+	if markdown.String() != want {
+		t.Errorf("WriteHighlights(markdown) = %q", markdown.String())
+	}
+
+	var html bytes.Buffer
+	if err := reader.WriteHighlights(&html, annotations, FormatHTML); err != nil {
+		t.Fatalf("WriteHighlights(html) = %v", err)
+	}
+	for _, s := range []string{"<h2>Chapter One</h2>", "<blockquote style=\"border-left: 4px solid yellow\">dark</blockquote>", "&lt;b&gt;bold&lt;/b&gt; choice"} {
+		if !strings.Contains(html.String(), s) {
+			t.Errorf("WriteHighlights(html) lacks %q:\n%s", s, html.String())
+		}
+	}
+
+	if err := reader.WriteHighlights(&html, annotations, "pdf"); err == nil {
+		t.Errorf("WriteHighlights(pdf) = no error")
+	}
+}
@@ -0,0 +1,107 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrBadAppName occurs when an application name is not a reverse domain
+// name, such as com.example.reader.
+var ErrBadAppName = errors.New("epub: invalid application name")
+
+// appNamePattern matches reverse domain names, which cannot clash with the
+// files the EPUB specification reserves in META-INF.
+var appNamePattern = regexp.MustCompile(`^[a-z0-9-]+(\.[a-z0-9-]+)+$`)
+
+// AppDataPath returns the file of a book holding the data of the
+// application app, a reverse domain name: META-INF/<app>.json.
+func AppDataPath(app string) (string, error) {
+	if !appNamePattern.MatchString(app) {
+		return "", fmt.Errorf("%w: '%s'", ErrBadAppName, app)
+	}
+
+	return "META-INF/" + app + ".json", nil
+}
+
+// AppData decodes into v the JSON data the application app stored in the
+// book. ok is false when the book holds no data of the application.
+func (epubReader *EpubReader) AppData(app string, v interface{}) (ok bool, err error) {
+	name, err := AppDataPath(app)
+	if err != nil {
+		return false, err
+	}
+	buffer, err := epubReader.readFile(name)
+	if errors.Is(err, ErrorFileMissing) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(buffer.Bytes(), v); err != nil {
+		return false, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, name, err)
+	}
+
+	return true, nil
+}
+
+// WriteWithAppData writes to w a copy of the book holding v, encoded as
+// JSON, as the data of the application app; a nil v removes the data of
+// the application. Other transformations of this package keep the data of
+// applications.
+func (epubReader *EpubReader) WriteWithAppData(w io.Writer, app string, v interface{}) error {
+	name, err := AppDataPath(app)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if v != nil {
+		if data, err = json.MarshalIndent(v, "", "  "); err != nil {
+			return fmt.Errorf("epub: %s: encode '%s': %w", epubReader.Name, name, err)
+		}
+	}
+
+	return epubReader.writeZip(w, map[string][]byte{name: data})
+}
+
+// SetAppDataFile stores v as the data of the application app in the EPUB at
+// filename, in place, with the guarantees of SafeWriteFile.
+func SetAppDataFile(filename, app string, v interface{}) error {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	err = reader.WriteWithAppData(&buffer, app, v)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	return SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// AppDataNames returns the applications whose data the book holds.
+func (epubReader *EpubReader) AppDataNames() []string {
+	var apps []string
+	for _, name := range epubReader.FileNames() {
+		if !strings.HasPrefix(name, "META-INF/") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if app := strings.TrimSuffix(strings.TrimPrefix(name, "META-INF/"), ".json"); appNamePattern.MatchString(app) {
+			apps = append(apps, app)
+		}
+	}
+
+	return apps
+}
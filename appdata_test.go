@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAppData(t *testing.T) {
+	type shelf struct {
+		Shelf  string   `json:"shelf"`
+		Rating int      `json:"rating"`
+		Tags   []string `json:"tags"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(filename, buildTestEpub(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := shelf{Shelf: "favourites", Rating: 5, Tags: []string{"mystery"}}
+	if err := SetAppDataFile(filename, "com.example.reader", want); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAppDataFile(filename, "audit", want); !errors.Is(err, ErrBadAppName) {
+		t.Errorf("SetAppDataFile(audit) = %v, want ErrBadAppName", err)
+	}
+
+	// Other transformations keep the data.
+	if _, err := ScrubFile(filename, ScrubOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var got shelf
+	if ok, err := reader.AppData("com.example.reader", &got); !ok || err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("AppData() = %+v, %v, %v", got, ok, err)
+	}
+	if ok, err := reader.AppData("org.example.other", &got); ok || err != nil {
+		t.Errorf("AppData() of another application = %v, %v", ok, err)
+	}
+	if apps := reader.AppDataNames(); !reflect.DeepEqual(apps, []string{"com.example.reader"}) {
+		t.Errorf("AppDataNames() = %q", apps)
+	}
+	if issues := reader.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %+v", issues)
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteWithAppData(&buffer, "com.example.reader", nil); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := removed.AppData("com.example.reader", &got); ok || err != nil {
+		t.Errorf("AppData() after removal = %v, %v", ok, err)
+	}
+}
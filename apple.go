@@ -0,0 +1,277 @@
+package epub
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Files Apple Books adds to the books it sells or exports.
+const (
+	ITunesMetadataPath      = "iTunesMetadata.plist"
+	AppleDisplayOptionsPath = "META-INF/com.apple.ibooks.display-options.xml"
+)
+
+// ITunesMetadata is the catalog metadata of a book bought from Apple, read
+// from iTunesMetadata.plist. The account and purchase details the file
+// also holds are left out.
+type ITunesMetadata struct {
+	ItemID      int64
+	Kind        string
+	Title       string
+	Artist      string
+	Genre       string
+	ReleaseDate string
+	SortTitle   string
+	SortArtist  string
+	// Values holds all the values of the file but the account and purchase
+	// details, by key: strings, int64, float64, bool, time.Time, []byte,
+	// []interface{} and map[string]interface{}.
+	Values map[string]interface{}
+}
+
+// iTunesPrivateKeys match the keys of the account and purchase details of
+// iTunesMetadata.plist.
+var iTunesPrivateKeys = regexp.MustCompile(`(?i)account|purchase|apple-?id|download|dsperson|owner|user`)
+
+// ITunesMetadata returns the iTunes metadata of the book, if it has an
+// iTunesMetadata.plist file.
+func (epubReader *EpubReader) ITunesMetadata() (ITunesMetadata, bool, error) {
+	reader, err := epubReader.openFile(ITunesMetadataPath)
+	if errors.Is(err, ErrorFileMissing) {
+		return ITunesMetadata{}, false, nil
+	}
+	if err != nil {
+		return ITunesMetadata{}, false, err
+	}
+	defer reader.Close()
+
+	value, err := decodePlist(reader)
+	if err != nil {
+		return ITunesMetadata{}, false, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, ITunesMetadataPath, err)
+	}
+	dict, ok := value.(map[string]interface{})
+	if !ok {
+		return ITunesMetadata{}, false, fmt.Errorf("epub: %s: '%s': not a dictionary", epubReader.Name, ITunesMetadataPath)
+	}
+
+	metadata := ITunesMetadata{Values: make(map[string]interface{})}
+	for key, value := range dict {
+		if !iTunesPrivateKeys.MatchString(key) {
+			metadata.Values[key] = value
+		}
+	}
+	text := func(key string) string {
+		s, _ := metadata.Values[key].(string)
+		return s
+	}
+	metadata.ItemID, _ = metadata.Values["itemId"].(int64)
+	metadata.Kind = text("kind")
+	metadata.Title = text("itemName")
+	metadata.Artist = text("artistName")
+	metadata.Genre = text("genre")
+	metadata.ReleaseDate = text("releaseDate")
+	metadata.SortTitle = text("sort-name")
+	metadata.SortArtist = text("sort-artist")
+
+	return metadata, true, nil
+}
+
+// AppleDisplayOptions are the display options of Apple Books, read from
+// com.apple.ibooks.display-options.xml.
+type AppleDisplayOptions struct {
+	// Platforms maps the platform names, "*" for all of them, "iphone" or
+	// "ipad", to their options by name.
+	Platforms map[string]map[string]string
+}
+
+// Option returns the value of the named option for platform, or for all
+// platforms when platform does not set it.
+func (options AppleDisplayOptions) Option(platform, name string) (string, bool) {
+	if value, ok := options.Platforms[platform][name]; ok {
+		return value, true
+	}
+	value, ok := options.Platforms["*"][name]
+
+	return value, ok
+}
+
+func (options AppleDisplayOptions) flag(platform, name string) bool {
+	value, _ := options.Option(platform, name)
+
+	return strings.EqualFold(value, "true")
+}
+
+// SpecifiedFonts reports whether the embedded fonts of the book must be
+// used instead of the fonts of the reader.
+func (options AppleDisplayOptions) SpecifiedFonts(platform string) bool {
+	return options.flag(platform, "specified-fonts")
+}
+
+// FixedLayout reports whether the book is fixed layout, the Apple
+// counterpart of the pre-paginated layout of EPUB 3.
+func (options AppleDisplayOptions) FixedLayout(platform string) bool {
+	return options.flag(platform, "fixed-layout")
+}
+
+// OpenToSpread reports whether the fixed layout book opens to a two page
+// spread.
+func (options AppleDisplayOptions) OpenToSpread(platform string) bool {
+	return options.flag(platform, "open-to-spread")
+}
+
+// Interactive reports whether the book has interactive content.
+func (options AppleDisplayOptions) Interactive(platform string) bool {
+	return options.flag(platform, "interactive")
+}
+
+// OrientationLock returns the orientation the book is locked to,
+// "portrait-only" or "landscape-only", or "none".
+func (options AppleDisplayOptions) OrientationLock(platform string) string {
+	if value, ok := options.Option(platform, "orientation-lock"); ok {
+		return value
+	}
+
+	return "none"
+}
+
+// AppleDisplayOptions returns the Apple Books display options of the book,
+// if it has a com.apple.ibooks.display-options.xml file.
+func (epubReader *EpubReader) AppleDisplayOptions() (AppleDisplayOptions, bool, error) {
+	buffer, err := epubReader.readFile(AppleDisplayOptionsPath)
+	if errors.Is(err, ErrorFileMissing) {
+		return AppleDisplayOptions{}, false, nil
+	}
+	if err != nil {
+		return AppleDisplayOptions{}, false, err
+	}
+
+	var document struct {
+		Platforms []struct {
+			Name    string `xml:"name,attr"`
+			Options []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"option"`
+		} `xml:"platform"`
+	}
+	if err := decodeXML(AppleDisplayOptionsPath, buffer.Bytes(), &document); err != nil {
+		return AppleDisplayOptions{}, false, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, AppleDisplayOptionsPath, err)
+	}
+
+	options := AppleDisplayOptions{Platforms: make(map[string]map[string]string)}
+	for _, platform := range document.Platforms {
+		values := options.Platforms[platform.Name]
+		if values == nil {
+			values = make(map[string]string)
+			options.Platforms[platform.Name] = values
+		}
+		for _, option := range platform.Options {
+			values[option.Name] = strings.TrimSpace(option.Value)
+		}
+	}
+
+	return options, true, nil
+}
+
+// isFixedLayout reports whether the book is fixed layout, by its EPUB 3
+// rendition metadata or its Apple display options.
+func (epubReader *EpubReader) isFixedLayout() bool {
+	if epubReader.metaValue("layout") == "pre-paginated" {
+		return true
+	}
+	options, ok, _ := epubReader.AppleDisplayOptions()
+
+	return ok && options.FixedLayout("*")
+}
+
+// decodePlist decodes an XML property list.
+func decodePlist(r io.Reader) (interface{}, error) {
+	decoder := newXMLDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(decoder, start)
+		}
+	}
+}
+
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]interface{})
+		key := ""
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		array := []interface{}{}
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", decoder.Skip()
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(text, 10, 64)
+	case "real":
+		return strconv.ParseFloat(text, 64)
+	case "date":
+		return time.Parse(time.RFC3339, text)
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	}
+
+	return nil, fmt.Errorf("unknown plist element %s", start.Name.Local)
+}
@@ -0,0 +1,128 @@
+package epub
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testITunesMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>artistName</key>
+	<string>Jane Doe</string>
+	<key>book-info</key>
+	<dict>
+		<key>cover-image-path</key>
+		<string>OEBPS/images/cover.jpg</string>
+		<key>mime-type</key>
+		<string>application/epub+zip</string>
+	</dict>
+	<key>com.apple.iTunesStore.downloadInfo</key>
+	<dict>
+		<key>accountInfo</key>
+		<dict>
+			<key>AppleID</key>
+			<string>jane@example.com</string>
+		</dict>
+		<key>purchaseDate</key>
+		<date>2020-01-02T03:04:05Z</date>
+	</dict>
+	<key>explicit</key>
+	<false/>
+	<key>genre</key>
+	<string>Fiction</string>
+	<key>itemId</key>
+	<integer>123456789</integer>
+	<key>itemName</key>
+	<string>The Test Book</string>
+	<key>kind</key>
+	<string>ebook</string>
+	<key>releaseDate</key>
+	<string>2019-05-01T07:00:00Z</string>
+	<key>sort-name</key>
+	<string>Test Book</string>
+	<key>rating</key>
+	<real>4.5</real>
+	<key>tags</key>
+	<array>
+		<string>a</string>
+		<data>aGk=</data>
+	</array>
+	<key>updated</key>
+	<date>2020-02-03T04:05:06Z</date>
+</dict>
+</plist>`
+
+func TestITunesMetadata(t *testing.T) {
+	if _, ok, err := openTestEpub(t).ITunesMetadata(); ok || err != nil {
+		t.Errorf("ITunesMetadata() = %v, %v", ok, err)
+	}
+
+	reader := openTestEpub(t, testFile{ITunesMetadataPath, testITunesMetadata})
+	metadata, ok, err := reader.ITunesMetadata()
+	if !ok || err != nil {
+		t.Fatalf("ITunesMetadata() = %v, %v", ok, err)
+	}
+	if metadata.ItemID != 123456789 || metadata.Kind != "ebook" || metadata.Title != "The Test Book" ||
+		metadata.Artist != "Jane Doe" || metadata.Genre != "Fiction" || metadata.SortTitle != "Test Book" ||
+		metadata.ReleaseDate != "2019-05-01T07:00:00Z" || metadata.SortArtist != "" {
+		t.Errorf("ITunesMetadata() = %+v", metadata)
+	}
+	if _, ok := metadata.Values["com.apple.iTunesStore.downloadInfo"]; ok {
+		t.Errorf("ITunesMetadata() has the download info")
+	}
+	if got := metadata.Values["book-info"].(map[string]interface{})["cover-image-path"]; got != "OEBPS/images/cover.jpg" {
+		t.Errorf("cover-image-path = %v", got)
+	}
+	if got := metadata.Values["tags"]; !reflect.DeepEqual(got, []interface{}{"a", []byte("hi")}) {
+		t.Errorf("tags = %#v", got)
+	}
+	if metadata.Values["explicit"] != false || metadata.Values["rating"] != 4.5 ||
+		!metadata.Values["updated"].(time.Time).Equal(time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)) {
+		t.Errorf("Values = %v", metadata.Values)
+	}
+
+	reader = openTestEpub(t, testFile{ITunesMetadataPath, "<plist><dict><key>a</key><integer>x</integer></dict></plist>"})
+	if _, _, err := reader.ITunesMetadata(); err == nil {
+		t.Errorf("ITunesMetadata(bad integer) = no error")
+	}
+}
+
+func TestAppleDisplayOptions(t *testing.T) {
+	reader := openTestEpub(t)
+	if _, ok, err := reader.AppleDisplayOptions(); ok || err != nil {
+		t.Errorf("AppleDisplayOptions() = %v, %v", ok, err)
+	}
+	if reader.isFixedLayout() {
+		t.Errorf("isFixedLayout() = true")
+	}
+
+	reader = openTestEpub(t, testFile{AppleDisplayOptionsPath, `<?xml version="1.0" encoding="UTF-8"?>
+<display_options>
+  <platform name="*">
+    <option name="specified-fonts">true</option>
+    <option name="fixed-layout">true</option>
+    <option name="open-to-spread">false</option>
+  </platform>
+  <platform name="iphone">
+    <option name="orientation-lock">landscape-only</option>
+    <option name="specified-fonts">false</option>
+  </platform>
+</display_options>`})
+	options, ok, err := reader.AppleDisplayOptions()
+	if !ok || err != nil {
+		t.Fatalf("AppleDisplayOptions() = %v, %v", ok, err)
+	}
+	if !options.SpecifiedFonts("ipad") || options.SpecifiedFonts("iphone") || !options.FixedLayout("iphone") ||
+		options.OpenToSpread("*") || options.Interactive("*") {
+		t.Errorf("AppleDisplayOptions() = %v", options.Platforms)
+	}
+	if options.OrientationLock("iphone") != "landscape-only" || options.OrientationLock("ipad") != "none" {
+		t.Errorf("OrientationLock() = %s, %s", options.OrientationLock("iphone"), options.OrientationLock("ipad"))
+	}
+	if !reader.isFixedLayout() {
+		t.Errorf("isFixedLayout() = false")
+	}
+}
@@ -0,0 +1,193 @@
+package epub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoMediaOverlay occurs when audio is requested from a book without
+// media overlays.
+var ErrNoMediaOverlay = errors.New("epub: no media overlay")
+
+// Audiobook is the narration of a book, laid out as one timeline: its audio
+// files played one after the other.
+type Audiobook struct {
+	Title  string
+	Artist string
+	// Files are the audio files of the narration, in playing order.
+	Files    []AudioFile
+	Chapters []AudioChapter
+	Duration time.Duration
+}
+
+// AudioFile is an audio file of the narration.
+type AudioFile struct {
+	// Path is the zip path of the file.
+	Path string
+	// Start is the offset of the file in the timeline. Duration is the end
+	// of its last clip, which is usually the length of the file.
+	Start    time.Duration
+	Duration time.Duration
+}
+
+// AudioChapter is a chapter mark of the timeline.
+type AudioChapter struct {
+	Title string
+	// Href is the zip path and fragment of the chapter text.
+	Href  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Audiobook returns the narration of the book from the media overlays of
+// the spine, with a chapter for each entry of the table of contents whose
+// text is narrated. Entries pointing to a fragment without a clip start at
+// the first clip of their document.
+func (epubReader *EpubReader) Audiobook() (Audiobook, error) {
+	metadata := epubReader.Rootfiles[0].Metadata
+	audiobook := Audiobook{Title: firstTitle(metadata.Title), Artist: firstCreator(metadata.Creator).Text}
+
+	var cues []OverlayCue
+	files := make(map[string]int)
+	for _, item := range epubReader.SpineItems() {
+		if item.MediaOverlay == "" {
+			continue
+		}
+		itemCues, err := epubReader.MediaOverlay(item.ID)
+		if err != nil {
+			return audiobook, err
+		}
+		for _, cue := range itemCues {
+			i, ok := files[cue.Audio]
+			if !ok {
+				i = len(audiobook.Files)
+				files[cue.Audio] = i
+				audiobook.Files = append(audiobook.Files, AudioFile{Path: cue.Audio})
+			}
+			if cue.End > audiobook.Files[i].Duration {
+				audiobook.Files[i].Duration = cue.End
+			}
+		}
+		cues = append(cues, itemCues...)
+	}
+	if len(cues) == 0 {
+		return audiobook, fmt.Errorf("epub: %s: %w", epubReader.Name, ErrNoMediaOverlay)
+	}
+
+	for i := range audiobook.Files {
+		audiobook.Files[i].Start = audiobook.Duration
+		audiobook.Duration += audiobook.Files[i].Duration
+	}
+
+	// Start of the first clip of each text fragment and document.
+	starts := make(map[string]time.Duration)
+	for _, cue := range cues {
+		start := audiobook.Files[files[cue.Audio]].Start + cue.Begin
+		document := cue.TextSrc
+		if i := strings.IndexByte(document, '#'); i >= 0 {
+			document = document[:i]
+		}
+		for _, key := range []string{cue.TextSrc, document} {
+			if previous, ok := starts[key]; !ok || start < previous {
+				starts[key] = start
+			}
+		}
+	}
+
+	toc, err := epubReader.TOC()
+	if err != nil {
+		return audiobook, err
+	}
+	seen := make(map[time.Duration]bool)
+	var add func(entries []TOCEntry)
+	add = func(entries []TOCEntry) {
+		for _, entry := range entries {
+			if entry.HasLink() {
+				href := epubReader.itemPath(entry.Href)
+				if i := strings.IndexByte(entry.Href, '#'); i >= 0 {
+					href += entry.Href[i:]
+				}
+				start, ok := starts[href]
+				if !ok {
+					start, ok = starts[epubReader.itemPath(entry.Href)]
+				}
+				if ok && !seen[start] {
+					seen[start] = true
+					audiobook.Chapters = append(audiobook.Chapters, AudioChapter{Title: entry.Title, Href: href, Start: start})
+				}
+			}
+			add(entry.Children)
+		}
+	}
+	add(toc)
+
+	sort.SliceStable(audiobook.Chapters, func(i, j int) bool { return audiobook.Chapters[i].Start < audiobook.Chapters[j].Start })
+	for i := range audiobook.Chapters {
+		audiobook.Chapters[i].End = audiobook.Duration
+		if i+1 < len(audiobook.Chapters) {
+			audiobook.Chapters[i].End = audiobook.Chapters[i+1].Start
+		}
+	}
+
+	return audiobook, nil
+}
+
+// WriteFFMetadata writes the title, artist and chapters of the audiobook
+// in the FFMETADATA format of ffmpeg, with millisecond chapter marks.
+func (audiobook Audiobook) WriteFFMetadata(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString(";FFMETADATA1\n")
+	if audiobook.Title != "" {
+		fmt.Fprintf(&b, "title=%s\n", ffmetadataEscape(audiobook.Title))
+	}
+	if audiobook.Artist != "" {
+		fmt.Fprintf(&b, "artist=%s\n", ffmetadataEscape(audiobook.Artist))
+	}
+	for _, chapter := range audiobook.Chapters {
+		fmt.Fprintf(&b, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			chapter.Start.Milliseconds(), chapter.End.Milliseconds(), ffmetadataEscape(chapter.Title))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+var ffmetadataEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n")
+
+func ffmetadataEscape(s string) string {
+	return ffmetadataEscaper.Replace(s)
+}
+
+// WriteChaptersJSON writes the chapters of the audiobook in the JSON
+// chapters format of podcasts, with times in seconds.
+func (audiobook Audiobook) WriteChaptersJSON(w io.Writer) error {
+	type jsonChapter struct {
+		StartTime float64 `json:"startTime"`
+		EndTime   float64 `json:"endTime"`
+		Title     string  `json:"title"`
+	}
+	chapters := struct {
+		Version  string        `json:"version"`
+		Chapters []jsonChapter `json:"chapters"`
+	}{Version: "1.2.0", Chapters: []jsonChapter{}}
+
+	for _, chapter := range audiobook.Chapters {
+		chapters.Chapters = append(chapters.Chapters, jsonChapter{
+			StartTime: chapter.Start.Seconds(),
+			EndTime:   chapter.End.Seconds(),
+			Title:     chapter.Title,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(chapters)
+}
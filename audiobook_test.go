@@ -0,0 +1,80 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testChapterSMIL(chapter, audio string, clips ...string) string {
+	var b strings.Builder
+	b.WriteString(`<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"><body>`)
+	for i := 0; i+1 < len(clips); i += 2 {
+		b.WriteString(`<par><text src="../` + chapter + `#p` + clips[i] + `"/><audio src="../audio/` + audio + `" clipBegin="` + clips[i] + `s" clipEnd="` + clips[i+1] + `s"/></par>`)
+	}
+	b.WriteString(`</body></smil>`)
+
+	return b.String()
+}
+
+func TestAudiobook(t *testing.T) {
+	opf := strings.NewReplacer(
+		`media-type="application/xhtml+xml"/>`, `media-type="application/xhtml+xml" media-overlay="smil-%"/>`,
+		`<item id="css"`, `<item id="smil-1" href="smil/1.smil" media-type="application/smil+xml"/>
+    <item id="smil-2" href="smil/2.smil" media-type="application/smil+xml"/>
+    <item id="css"`,
+	).Replace(testOPF)
+	opf = strings.Replace(opf, "smil-%", "smil-1", 1)
+	opf = strings.Replace(opf, "smil-%", "smil-2", 1)
+
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/smil/1.smil", testChapterSMIL("chapter1.xhtml", "one.mp3", "0", "10", "10", "65.5")},
+		testFile{"OEBPS/smil/2.smil", testChapterSMIL("chapter2.xhtml", "two.mp3", "1", "30")},
+		testFile{"OEBPS/toc.ncx", strings.Replace(testNCX, `<content src="chapter2.xhtml"/>`,
+			`<content src="chapter2.xhtml"/><navPoint><navLabel><text>Part</text></navLabel><content src="chapter2.xhtml#p1"/></navPoint>`, 1)},
+	)
+
+	audiobook, err := reader.Audiobook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []AudioFile{
+		{"OEBPS/audio/one.mp3", 0, 65500 * time.Millisecond},
+		{"OEBPS/audio/two.mp3", 65500 * time.Millisecond, 30 * time.Second},
+	}
+	chapters := []AudioChapter{
+		{"Chapter One", "OEBPS/chapter1.xhtml", 0, 66500 * time.Millisecond},
+		{"Chapter Two", "OEBPS/chapter2.xhtml", 66500 * time.Millisecond, 95500 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(audiobook.Files, files) || !reflect.DeepEqual(audiobook.Chapters, chapters) || audiobook.Duration != 95500*time.Millisecond {
+		t.Fatalf("Audiobook() = %+v", audiobook)
+	}
+
+	audiobook.Title = "A = B; #1"
+	var ffmetadata bytes.Buffer
+	if err := audiobook.WriteFFMetadata(&ffmetadata); err != nil {
+		t.Fatal(err)
+	}
+	want := ";FFMETADATA1\ntitle=A \\= B\\; \\#1\nartist=Jane Doe\n\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=66500\ntitle=Chapter One\n\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=66500\nEND=95500\ntitle=Chapter Two\n"
+	if ffmetadata.String() != want {
+		t.Errorf("WriteFFMetadata() = %q", ffmetadata.String())
+	}
+
+	var chaptersJSON bytes.Buffer
+	if err := audiobook.WriteChaptersJSON(&chaptersJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(chaptersJSON.String(), `"startTime": 66.5,`) || !strings.Contains(chaptersJSON.String(), `"version": "1.2.0"`) {
+		t.Errorf("WriteChaptersJSON() = %s", chaptersJSON.String())
+	}
+}
+
+func TestAudiobookNoOverlay(t *testing.T) {
+	if _, err := openTestEpub(t).Audiobook(); !errors.Is(err, ErrNoMediaOverlay) {
+		t.Errorf("Audiobook() error = %v, want ErrNoMediaOverlay", err)
+	}
+}
@@ -0,0 +1,79 @@
+package epub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuditPath is the file of a book holding the log of the transformations
+// applied to it.
+const AuditPath = "META-INF/audit.json"
+
+// Changes of an audited file.
+const (
+	AuditModified = "modified"
+	AuditAdded    = "added"
+	AuditRemoved  = "removed"
+)
+
+// AuditRecord is the machine-readable record of a transformation applied
+// to a book, such as an optimization, for provenance.
+type AuditRecord struct {
+	Operation string          `json:"operation"`
+	Time      time.Time       `json:"time"`
+	Files     []AuditFile     `json:"files,omitempty"`
+	Metadata  []AuditMetadata `json:"metadata,omitempty"`
+	// SavedBytes is the size difference of the changed files, before
+	// compression.
+	SavedBytes int64 `json:"savedBytes"`
+}
+
+// AuditFile is a file touched by a transformation, with its sizes before
+// and after it.
+type AuditFile struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+	// Details describe the change, such as the CSS selectors removed.
+	Details []string `json:"details,omitempty"`
+}
+
+// AuditMetadata is a metadata field changed by a transformation.
+type AuditMetadata struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AuditLog returns the records embedded in the book at AuditPath, oldest
+// first, or nil if it has none.
+func (epubReader *EpubReader) AuditLog() ([]AuditRecord, error) {
+	buffer, err := epubReader.readFile(AuditPath)
+	if errors.Is(err, ErrorFileMissing) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []AuditRecord
+	if err := json.Unmarshal(buffer.Bytes(), &records); err != nil {
+		return nil, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, AuditPath, err)
+	}
+
+	return records, nil
+}
+
+// appendAuditLog returns the audit log of the book with record appended,
+// ready to be written to AuditPath.
+func (epubReader *EpubReader) appendAuditLog(record AuditRecord) ([]byte, error) {
+	records, err := epubReader.AuditLog()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(append(records, record), "", "  ")
+}
@@ -0,0 +1,53 @@
+package epub
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/style.css", "p { margin: 0; } .dead { color: red; }"})
+
+	if records, err := reader.AuditLog(); err != nil || records != nil {
+		t.Errorf("AuditLog() = %v, %v", records, err)
+	}
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteOptimized(&buffer, OptimizeOptions{EmbedAudit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditFile{{Path: "OEBPS/style.css", Change: AuditModified, Before: 38, After: 16, Details: []string{".dead"}}}
+	if result.Audit.Operation != "optimize" || result.Audit.SavedBytes != 22 || !reflect.DeepEqual(result.Audit.Files, want) {
+		t.Errorf("Audit = %+v", result.Audit)
+	}
+
+	optimized, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := optimized.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+	records, err := optimized.AuditLog()
+	if err != nil || len(records) != 1 || !reflect.DeepEqual(records[0].Files, want) || !records[0].Time.Equal(result.Audit.Time) {
+		t.Fatalf("AuditLog() = %+v, %v", records, err)
+	}
+
+	// Records accumulate, even for optimizations that change nothing.
+	buffer.Reset()
+	if _, err := optimized.WriteOptimized(&buffer, OptimizeOptions{EmbedAudit: true}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records, err := again.AuditLog(); err != nil || len(records) != 2 || len(records[1].Files) != 0 {
+		t.Errorf("AuditLog() = %+v, %v", records, err)
+	}
+	if names := again.FileNames(); len(names) != len(reader.FileNames())+1 {
+		t.Errorf("FileNames() = %v", names)
+	}
+}
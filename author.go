@@ -0,0 +1,96 @@
+package epub
+
+import (
+	"strings"
+	"unicode"
+)
+
+// nameParticles are the words kept with the family name that follows them.
+var nameParticles = map[string]bool{
+	"al": true, "bin": true, "da": true, "dal": true, "de": true, "del": true,
+	"della": true, "den": true, "der": true, "des": true, "di": true, "do": true,
+	"dos": true, "du": true, "el": true, "ibn": true, "la": true, "le": true,
+	"st.": true, "te": true, "ten": true, "ter": true, "van": true, "von": true,
+	"zu": true,
+}
+
+// nameSuffixes are the generational and academic suffixes of names.
+var nameSuffixes = map[string]bool{
+	"jr": true, "jr.": true, "sr": true, "sr.": true, "ii": true, "iii": true,
+	"iv": true, "phd": true, "ph.d.": true, "md": true, "m.d.": true,
+}
+
+// organizationWords mark the names of organizations, which are not
+// inverted.
+var organizationWords = map[string]bool{
+	"association": true, "books": true, "club": true, "college": true,
+	"committee": true, "company": true, "corporation": true, "council": true,
+	"department": true, "editions": true, "foundation": true, "group": true,
+	"inc": true, "inc.": true, "institute": true, "llc": true, "ltd": true,
+	"ltd.": true, "ministry": true, "press": true, "publishing": true,
+	"society": true, "staff": true, "team": true, "university": true,
+}
+
+// AuthorSort returns the sort key of the first creator of the book: its
+// file-as attribute when present, or else AuthorSort of its name.
+func (epubReader *EpubReader) AuthorSort() string {
+	creator := firstCreator(epubReader.Creators())
+	if fileAs := collapseSpace(creator.FileAs); fileAs != "" {
+		return fileAs
+	}
+
+	return AuthorSort(creator.Text)
+}
+
+// AuthorSort returns the "Last, First" sort key of a personal name: the
+// family name, with the particles preceding it ("de la Rue", "Le Guin"),
+// then the given names and the suffixes ("King, Martin Luther, Jr.").
+// Names already inverted, single words, names of organizations and names
+// written in CJK scripts, whose family name comes first, are returned
+// as they are.
+func AuthorSort(name string) string {
+	name = collapseSpace(name)
+	if isCJKName(name) {
+		return name
+	}
+	words := strings.Fields(name)
+	for _, word := range words {
+		if organizationWords[strings.ToLower(strings.Trim(word, ",&"))] {
+			return name
+		}
+	}
+
+	var suffixes []string
+	for len(words) > 2 && nameSuffixes[strings.ToLower(words[len(words)-1])] {
+		suffixes = append([]string{words[len(words)-1]}, suffixes...)
+		words = words[:len(words)-1]
+		words[len(words)-1] = strings.TrimSuffix(words[len(words)-1], ",")
+	}
+	if len(words) < 2 || strings.Contains(strings.Join(words, " "), ",") {
+		return name
+	}
+
+	family := len(words) - 1
+	for family > 1 && nameParticles[strings.ToLower(words[family-1])] {
+		family--
+	}
+
+	key := strings.Join(words[family:], " ") + ", " + strings.Join(words[:family], " ")
+	for _, suffix := range suffixes {
+		key += ", " + suffix
+	}
+
+	return key
+}
+
+// isCJKName reports whether name is written with Chinese, Japanese or Korean
+// characters.
+func isCJKName(name string) bool {
+	for _, r := range name {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuthorSortName(t *testing.T) {
+	for _, test := range []struct {
+		name, want string
+	}{
+		{"Alain Damasio", "Damasio, Alain"},
+		{"Ursula K. Le Guin", "Le Guin, Ursula K."},
+		{"Ludwig van Beethoven", "van Beethoven, Ludwig"},
+		{"Anne  de la Rue", "de la Rue, Anne"},
+		{"Martin Luther King, Jr.", "King, Martin Luther, Jr."},
+		{"King, Martin Luther, Jr.", "King, Martin Luther, Jr."},
+		{"Le Guin, Ursula K.", "Le Guin, Ursula K."},
+		{"Voltaire", "Voltaire"},
+		{"村上春樹", "村上春樹"},
+		{"Oxford University Press", "Oxford University Press"},
+		{"", ""},
+	} {
+		if got := AuthorSort(test.name); got != test.want {
+			t.Errorf("AuthorSort(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAuthorSortFileAs(t *testing.T) {
+	epubReader := openTestEpub(t)
+	defer epubReader.Close()
+	if got := epubReader.AuthorSort(); got != "Doe, Jane" {
+		t.Errorf("AuthorSort() = %q, want file-as", got)
+	}
+
+	opf := strings.Replace(testOPF, ` opf:file-as="Doe, Jane"`, "", 1)
+	epubReader = openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	defer epubReader.Close()
+	if got := epubReader.AuthorSort(); got != "Doe, Jane" {
+		t.Errorf("AuthorSort() = %q, want name inverted", got)
+	}
+}
@@ -0,0 +1,190 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// BatchStep is an operation applied in place to each file of a batch.
+type BatchStep struct {
+	Name  string
+	Apply func(filename string) error
+}
+
+// BatchOptions tunes RunBatch.
+type BatchOptions struct {
+	// BackupDir receives a copy of each file before the first step. A
+	// temporary directory, removed once the batch is over, is used when
+	// empty.
+	BackupDir string
+}
+
+// BatchResult is the outcome of a batch for one file.
+type BatchResult struct {
+	Path string
+	// Before and After are the hex SHA-256 of the file before the first
+	// step and once the batch is over, rolled back or not.
+	Before string
+	After  string
+	// Backup is the copy of the original file.
+	Backup     string
+	RolledBack bool
+	// Step and Err report the step that failed on this file, if any.
+	Step string
+	Err  error
+}
+
+// Changed reports whether the file differs from its original.
+func (result BatchResult) Changed() bool {
+	return result.Before != result.After
+}
+
+// BatchReport sums up a batch.
+type BatchReport struct {
+	Results []BatchResult
+	// Err is the first step failure, after which the changed files were
+	// rolled back.
+	Err error
+}
+
+// RolledBack reports whether the batch failed and was rolled back.
+func (report BatchReport) RolledBack() bool {
+	return report.Err != nil
+}
+
+// RunBatch applies the steps, one after the other, to all files. Files are
+// backed up first; when a step fails on a file, the remaining steps are not
+// run and every changed file is restored from its backup, so that the
+// batch applies to all files or to none.
+func RunBatch(paths []string, steps []BatchStep, options BatchOptions) BatchReport {
+	report := BatchReport{Results: make([]BatchResult, len(paths))}
+
+	backupDir := options.BackupDir
+	if backupDir == "" {
+		dir, err := os.MkdirTemp("", "epub-batch-")
+		if err != nil {
+			report.Err = fmt.Errorf("epub: batch: %w", err)
+			return report
+		}
+		defer os.RemoveAll(dir)
+		backupDir = dir
+	} else if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		report.Err = fmt.Errorf("epub: batch: %w", err)
+		return report
+	}
+
+	for i, path := range paths {
+		result := &report.Results[i]
+		result.Path = path
+		result.Backup = filepath.Join(backupDir, fmt.Sprintf("%04d-%s", i, filepath.Base(path)))
+
+		var err error
+		if result.Before, err = copyHashed(path, result.Backup); err != nil {
+			report.Err = fmt.Errorf("epub: batch: backup %s: %w", path, err)
+			return report
+		}
+		result.After = result.Before
+	}
+
+steps:
+	for _, step := range steps {
+		for i := range report.Results {
+			result := &report.Results[i]
+			if err := step.Apply(result.Path); err != nil {
+				result.Step, result.Err = step.Name, err
+				report.Err = fmt.Errorf("epub: batch: %s: %s: %w", step.Name, result.Path, err)
+				break steps
+			}
+		}
+	}
+
+	for i := range report.Results {
+		result := &report.Results[i]
+		after, err := hashFile(result.Path)
+		if err != nil {
+			after = ""
+		}
+		result.After = after
+
+		if report.Err == nil || result.After == result.Before {
+			continue
+		}
+		if result.After, err = copyHashed(result.Backup, result.Path); err != nil {
+			result.Err = fmt.Errorf("epub: batch: rollback %s: %w", result.Path, err)
+			continue
+		}
+		result.RolledBack = true
+	}
+
+	return report
+}
+
+// WriteSummary writes a table of the results: path, status and hashes.
+func (report BatchReport) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tSTATUS\tBEFORE\tAFTER")
+	changed, rolledBack := 0, 0
+	for _, result := range report.Results {
+		status := "unchanged"
+		switch {
+		case result.RolledBack:
+			status = "rolled back"
+			rolledBack++
+		case result.Err != nil:
+			status = "failed: " + result.Err.Error()
+		case result.Changed():
+			status = "changed"
+			changed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.12s\t%.12s\n", result.Path, status, result.Before, result.After)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if report.Err != nil {
+		_, err := fmt.Fprintf(w, "%d files, %d rolled back: %v\n", len(report.Results), rolledBack, report.Err)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d files, %d changed\n", len(report.Results), changed)
+
+	return err
+}
+
+// copyHashed safely copies source to target and returns the hash of the
+// content.
+func copyHashed(source, target string) (string, error) {
+	in, err := os.Open(longPath(source))
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	hash := sha256.New()
+	err = SafeWriteFile(target, SaveOptions{}, func(w io.Writer) error {
+		_, err := io.Copy(io.MultiWriter(w, hash), in)
+		return err
+	})
+
+	return hex.EncodeToString(hash.Sum(nil)), err
+}
+
+func hashFile(filename string) (string, error) {
+	file, err := os.Open(longPath(filename))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,75 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.epub", "b.epub", "c.epub"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	appendStep := func(suffix string) BatchStep {
+		return BatchStep{Name: "append " + suffix, Apply: func(filename string) error {
+			data, err := os.ReadFile(filename)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(filename, append(data, suffix...), 0o644)
+		}}
+	}
+
+	report := RunBatch(paths, []BatchStep{appendStep("!")}, BatchOptions{})
+	if report.RolledBack() {
+		t.Fatalf("RunBatch() = %v", report.Err)
+	}
+	for _, result := range report.Results {
+		if data, _ := os.ReadFile(result.Path); !strings.HasSuffix(string(data), "!") || !result.Changed() {
+			t.Errorf("%s = %q, %+v", result.Path, data, result)
+		}
+	}
+
+	failure := errors.New("disk on fire")
+	failing := BatchStep{Name: "fail on c", Apply: func(filename string) error {
+		if strings.HasSuffix(filename, "c.epub") {
+			return failure
+		}
+		return nil
+	}}
+	backups := filepath.Join(dir, "backups")
+	report = RunBatch(paths, []BatchStep{appendStep("?"), failing, appendStep("#")}, BatchOptions{BackupDir: backups})
+	if !errors.Is(report.Err, failure) {
+		t.Fatalf("RunBatch() = %v", report.Err)
+	}
+	for _, result := range report.Results {
+		data, _ := os.ReadFile(result.Path)
+		if want := filepath.Base(result.Path) + "!"; string(data) != want || !result.RolledBack || result.Changed() {
+			t.Errorf("%s = %q, want %q (%+v)", result.Path, data, want, result)
+		}
+	}
+	if result := report.Results[2]; result.Step != "fail on c" || !errors.Is(result.Err, failure) {
+		t.Errorf("failed result = %+v", result)
+	}
+	if entries, _ := os.ReadDir(backups); len(entries) != 3 {
+		t.Errorf("backups = %v", entries)
+	}
+
+	var summary bytes.Buffer
+	if err := report.WriteSummary(&summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(summary.String(), "rolled back") || !strings.Contains(summary.String(), "3 files, 3 rolled back: ") {
+		t.Errorf("summary =\n%s", summary.String())
+	}
+}
@@ -0,0 +1,32 @@
+package epub
+
+import (
+	"mime"
+)
+
+// Binding associates a foreign media type with the manifest id of the XHTML
+// document handling it (EPUB 3.0 bindings element).
+type Binding struct {
+	MediaType string `xml:"media-type,attr"`
+	Handler   string `xml:"handler,attr"`
+}
+
+// Bindings returns the media type handlers declared in the package.
+func (epubReader *EpubReader) Bindings() []Binding {
+	return epubReader.Rootfiles[0].Bindings.MediaType
+}
+
+// Handler returns the manifest item registered as handler for mediaType.
+func (epubReader *EpubReader) Handler(mediaType string) (Item, bool) {
+	if base, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = base
+	}
+
+	for _, binding := range epubReader.Bindings() {
+		if binding.MediaType == mediaType {
+			return epubReader.ItemByID(binding.Handler)
+		}
+	}
+
+	return Item{}, false
+}
@@ -0,0 +1,27 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBindings(t *testing.T) {
+	opf := strings.Replace(testOPF, `<manifest>`, `<manifest>
+    <item id="impl" href="scripts/handler.xhtml" media-type="application/xhtml+xml" properties="scripted"/>`, 1)
+	opf = strings.Replace(opf, `</spine>`, `</spine>
+  <bindings>
+    <mediaType media-type="application/x-demo-slideshow" handler="impl"/>
+  </bindings>`, 1)
+
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+
+	if bindings := reader.Bindings(); len(bindings) != 1 {
+		t.Fatalf("Bindings() = %v", bindings)
+	}
+	if item, ok := reader.Handler("application/x-demo-slideshow; v=1"); !ok || item.Href != "scripts/handler.xhtml" {
+		t.Errorf("Handler() = %v, %v", item, ok)
+	}
+	if _, ok := reader.Handler("image/png"); ok {
+		t.Errorf("Handler(image/png) = found")
+	}
+}
@@ -0,0 +1,234 @@
+package epub
+
+import (
+	"container/list"
+	"os"
+	"sync"
+	"time"
+)
+
+// BookCacheOptions bounds a BookCache. Zero values mean no bound.
+type BookCacheOptions struct {
+	MaxEntries int
+	// MaxBytes bounds the total size of the files of the cached books, a
+	// proxy for the memory they take.
+	MaxBytes int64
+	// IdleTTL is the time after which a book nobody used is evicted.
+	IdleTTL time.Duration
+}
+
+// BookCache keeps books open for a reading server, so that it does not
+// reopen and reparse a book on every request. Books are evicted least
+// recently used first, when the cache exceeds its bounds, and when idle
+// for longer than the TTL. It is safe for concurrent use.
+type BookCache struct {
+	options BookCacheOptions
+	// now is time.Now, replaced by tests.
+	now func() time.Time
+
+	mutex   sync.Mutex
+	entries map[bookCacheKey]*list.Element
+	lru     *list.List
+	bytes   int64
+	// closing holds the books removed while holding the lock, closed by
+	// unlock once it is released.
+	closing []*SharedBook
+}
+
+// bookCacheKey identifies a version of a book file, so that a book opened
+// while its file changes is not taken for another version.
+type bookCacheKey struct {
+	path    string
+	size    int64
+	modTime int64
+}
+
+func newBookCacheKey(path string, info os.FileInfo) bookCacheKey {
+	return bookCacheKey{path: path, size: info.Size(), modTime: info.ModTime().UnixNano()}
+}
+
+type bookCacheEntry struct {
+	key  bookCacheKey
+	book *SharedBook
+	used time.Time
+	// refs counts the callers of Get that did not release the book yet;
+	// an evicted book is closed when it drops to zero.
+	refs    int
+	evicted bool
+}
+
+// NewBookCache returns an empty cache.
+func NewBookCache(options BookCacheOptions) *BookCache {
+	return &BookCache{
+		options: options,
+		now:     time.Now,
+		entries: make(map[bookCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the book at path, opening it unless it is cached and its
+// file did not change since. The book stays open until release is called,
+// even if it is evicted meanwhile: callers release it once they are done
+// with it and with the item readers they opened.
+func (cache *BookCache) Get(path string) (book *SharedBook, release func(), err error) {
+	info, err := os.Stat(longPath(path))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := newBookCacheKey(path, info)
+
+	cache.mutex.Lock()
+	cache.evictIdle()
+	if element, ok := cache.entries[key]; ok {
+		defer cache.unlock()
+		return cache.use(element)
+	}
+	// Other versions of the file are stale.
+	cache.evictPath(path)
+	cache.unlock()
+
+	// Books are opened without holding the lock, so that a slow open
+	// does not block the requests for other books.
+	book, err = OpenShared(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cache.mutex.Lock()
+	defer cache.unlock()
+	if element, ok := cache.entries[key]; ok {
+		// Opened concurrently by another request.
+		cache.closing = append(cache.closing, book)
+		return cache.use(element)
+	}
+	entry := &bookCacheEntry{key: key, book: book, used: cache.now(), refs: 1}
+	cache.entries[key] = cache.lru.PushFront(entry)
+	cache.bytes += key.size
+	cache.evictOverflow()
+
+	return book, cache.releaser(entry), nil
+}
+
+// use returns the book of element, as Get does.
+func (cache *BookCache) use(element *list.Element) (*SharedBook, func(), error) {
+	entry := element.Value.(*bookCacheEntry)
+	cache.lru.MoveToFront(element)
+	entry.used = cache.now()
+	entry.refs++
+
+	return entry.book, cache.releaser(entry), nil
+}
+
+// unlock releases the lock, then closes the books removed meanwhile.
+func (cache *BookCache) unlock() {
+	closing := cache.closing
+	cache.closing = nil
+	cache.mutex.Unlock()
+
+	for _, book := range closing {
+		book.Close()
+	}
+}
+
+func (cache *BookCache) releaser(entry *bookCacheEntry) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			cache.mutex.Lock()
+			entry.refs--
+			closing := entry.evicted && entry.refs == 0
+			cache.mutex.Unlock()
+			if closing {
+				entry.book.Close()
+			}
+		})
+	}
+}
+
+// Evict removes the book at path from the cache.
+func (cache *BookCache) Evict(path string) {
+	cache.mutex.Lock()
+	defer cache.unlock()
+
+	cache.evictPath(path)
+}
+
+// EvictIdle evicts the books idle for longer than the TTL, which Get also
+// does, and returns their number. Servers with quiet periods can call it
+// periodically to free the books sooner.
+func (cache *BookCache) EvictIdle() int {
+	cache.mutex.Lock()
+	defer cache.unlock()
+
+	return cache.evictIdle()
+}
+
+// Len returns the number of cached books.
+func (cache *BookCache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	return cache.lru.Len()
+}
+
+// Close evicts all books.
+func (cache *BookCache) Close() {
+	cache.mutex.Lock()
+	defer cache.unlock()
+
+	for cache.lru.Len() > 0 {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+func (cache *BookCache) evictIdle() int {
+	if cache.options.IdleTTL <= 0 {
+		return 0
+	}
+
+	n := 0
+	deadline := cache.now().Add(-cache.options.IdleTTL)
+	for element := cache.lru.Back(); element != nil; element = cache.lru.Back() {
+		if !element.Value.(*bookCacheEntry).used.Before(deadline) {
+			break
+		}
+		cache.remove(element)
+		n++
+	}
+
+	return n
+}
+
+// evictPath evicts the versions of the book at path.
+func (cache *BookCache) evictPath(path string) {
+	for key, element := range cache.entries {
+		if key.path == path {
+			cache.remove(element)
+		}
+	}
+}
+
+// evictOverflow evicts the least recently used books while the cache
+// exceeds its bounds, keeping at least the most recent one.
+func (cache *BookCache) evictOverflow() {
+	for cache.lru.Len() > 1 &&
+		(cache.options.MaxEntries > 0 && cache.lru.Len() > cache.options.MaxEntries ||
+			cache.options.MaxBytes > 0 && cache.bytes > cache.options.MaxBytes) {
+		cache.remove(cache.lru.Back())
+	}
+}
+
+// remove evicts the entry of element, whose book unlock closes unless it
+// is in use.
+func (cache *BookCache) remove(element *list.Element) {
+	entry := cache.lru.Remove(element).(*bookCacheEntry)
+	delete(cache.entries, entry.key)
+	cache.bytes -= entry.key.size
+	entry.evicted = true
+	if entry.refs == 0 {
+		cache.closing = append(cache.closing, entry.book)
+	}
+}
@@ -0,0 +1,137 @@
+package epub
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBookCache(t *testing.T) {
+	dir := t.TempDir()
+	book := buildTestEpub(t)
+	var paths []string
+	for _, name := range []string{"a.epub", "b.epub", "c.epub"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, book, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewBookCache(BookCacheOptions{MaxEntries: 2, IdleTTL: time.Minute})
+	cache.now = func() time.Time { return now }
+	defer cache.Close()
+
+	a, releaseA, err := cache.Get(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, release, _ := cache.Get(paths[0]); again != a {
+		t.Errorf("Get() reopened a cached book")
+	} else {
+		release()
+	}
+	_, releaseB, _ := cache.Get(paths[1])
+	releaseB()
+
+	// c evicts a, the least recently used, which stays open until
+	// released.
+	_, releaseC, _ := cache.Get(paths[2])
+	releaseC()
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if reader, err := a.Open("OEBPS/chapter1.xhtml"); err != nil {
+		t.Errorf("evicted book in use: %v", err)
+	} else {
+		reader.Close()
+	}
+	releaseA()
+	releaseA()
+	if _, err := a.Open("OEBPS/chapter1.xhtml"); !errors.Is(err, ErrBookClosed) {
+		t.Errorf("evicted book released: Open() = %v", err)
+	}
+
+	// A changed file is reopened.
+	b, releaseB, _ := cache.Get(paths[1])
+	releaseB()
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(paths[1], later, later); err != nil {
+		t.Fatal(err)
+	}
+	if again, release, _ := cache.Get(paths[1]); again == b {
+		t.Errorf("Get() returned the book of a changed file")
+	} else {
+		release()
+	}
+
+	now = now.Add(2 * time.Minute)
+	if n := cache.EvictIdle(); n != 2 || cache.Len() != 0 {
+		t.Errorf("EvictIdle() = %d, Len() = %d", n, cache.Len())
+	}
+
+	if _, _, err := cache.Get(filepath.Join(dir, "missing.epub")); err == nil {
+		t.Errorf("Get(missing) = no error")
+	}
+}
+
+func TestBookCacheMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+	book := buildTestEpub(t)
+	cache := NewBookCache(BookCacheOptions{MaxBytes: int64(len(book)) * 3 / 2})
+	defer cache.Close()
+
+	for _, name := range []string{"a.epub", "b.epub"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, book, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_, release, err := cache.Get(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
+
+// TestBookCacheConcurrent checks that a book opened by concurrent requests
+// is cached once.
+func TestBookCacheConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.epub")
+	if err := ioutil.WriteFile(path, buildTestEpub(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewBookCache(BookCacheOptions{})
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			book, release, err := cache.Get(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer release()
+			if reader, err := book.Open("OEBPS/chapter1.xhtml"); err != nil {
+				t.Errorf("Open() = %v", err)
+			} else {
+				reader.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
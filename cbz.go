@@ -0,0 +1,152 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoPageImages occurs when a book exported as a comic has no page image.
+var ErrNoPageImages = errors.New("epub: no page images")
+
+// ComicInfo is the ComicInfo.xml metadata of comic book archives.
+type ComicInfo struct {
+	XMLName     xml.Name    `xml:"ComicInfo"`
+	Title       string      `xml:"Title,omitempty"`
+	Series      string      `xml:"Series,omitempty"`
+	Number      string      `xml:"Number,omitempty"`
+	Summary     string      `xml:"Summary,omitempty"`
+	Year        int         `xml:"Year,omitempty"`
+	Month       int         `xml:"Month,omitempty"`
+	Day         int         `xml:"Day,omitempty"`
+	Writer      string      `xml:"Writer,omitempty"`
+	Publisher   string      `xml:"Publisher,omitempty"`
+	Genre       string      `xml:"Genre,omitempty"`
+	LanguageISO string      `xml:"LanguageISO,omitempty"`
+	PageCount   int         `xml:"PageCount"`
+	Manga       string      `xml:"Manga,omitempty"`
+	Pages       []ComicPage `xml:"Pages>Page"`
+}
+
+// ComicPage describes a page image of a comic book archive.
+type ComicPage struct {
+	Image int    `xml:"Image,attr"`
+	Type  string `xml:"Type,attr,omitempty"`
+}
+
+var datePattern = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2}))?)?`)
+
+// ComicInfo returns the ComicInfo.xml metadata of the book, for the given
+// page images.
+func (epubReader *EpubReader) ComicInfo(pages []string) ComicInfo {
+	metadata := epubReader.Rootfiles[0].Metadata
+	series, number := epubReader.series()
+	info := ComicInfo{
+		Title:       firstTitle(metadata.Title),
+		Series:      series,
+		Number:      number,
+		Summary:     firstText(metadata.Description),
+		Writer:      firstCreator(metadata.Creator).Text,
+		Publisher:   firstText(metadata.Publisher),
+		Genre:       strings.Join(epubReader.subjects(), ", "),
+		LanguageISO: firstText(metadata.Language),
+		PageCount:   len(pages),
+	}
+
+	if match := datePattern.FindStringSubmatch(strings.TrimSpace(firstDate(metadata.Date))); match != nil {
+		info.Year, _ = strconv.Atoi(match[1])
+		info.Month, _ = strconv.Atoi(match[2])
+		info.Day, _ = strconv.Atoi(match[3])
+	}
+	if epubReader.Rootfiles[0].Spine.PageProgressionDirection == "rtl" {
+		info.Manga = "YesAndRightToLeft"
+	}
+
+	cover, hasCover := epubReader.Cover()
+	for i, page := range pages {
+		comicPage := ComicPage{Image: i}
+		if hasCover && page == epubReader.itemPath(cover.Href) {
+			comicPage.Type = "FrontCover"
+		}
+		info.Pages = append(info.Pages, comicPage)
+	}
+
+	return info
+}
+
+// PageImages returns the zip paths of the page images of an image-per-page
+// book, such as a fixed-layout comic: the images of the spine documents,
+// and the images in the spine itself, in reading order.
+func (epubReader *EpubReader) PageImages() ([]string, error) {
+	var pages []string
+	seen := make(map[string]bool)
+
+	for _, item := range epubReader.SpineItems() {
+		name := epubReader.itemPath(item.Href)
+		images := []string{name}
+		if !isImage(item) {
+			var err error
+			if images, err = epubReader.documentImages(name); err != nil {
+				return pages, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+			}
+		}
+
+		for _, image := range images {
+			if !seen[image] && epubReader.hasFile(image) {
+				seen[image] = true
+				pages = append(pages, image)
+			}
+		}
+	}
+
+	return pages, nil
+}
+
+// WriteCBZ writes the page images of the book to w as a comic book archive,
+// named after their position in reading order, with a ComicInfo.xml built
+// from the metadata. It returns the number of pages written.
+func (epubReader *EpubReader) WriteCBZ(w io.Writer) (int, error) {
+	pages, err := epubReader.PageImages()
+	if err != nil {
+		return 0, err
+	}
+	if len(pages) == 0 {
+		return 0, fmt.Errorf("epub: %s: %w", epubReader.Name, ErrNoPageImages)
+	}
+
+	writer := zip.NewWriter(w)
+	width := len(strconv.Itoa(len(pages)))
+	if width < 3 {
+		width = 3
+	}
+
+	for i, page := range pages {
+		name := fmt.Sprintf("%0*d%s", width, i+1, strings.ToLower(path.Ext(page)))
+		entry, err := writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			return i, fmt.Errorf("epub: %s: write '%s': %w", epubReader.Name, name, err)
+		}
+		if err := epubReader.copyFile(entry, page); err != nil {
+			return i, fmt.Errorf("epub: %s: write '%s': %w", epubReader.Name, name, err)
+		}
+	}
+
+	entry, err := writer.Create("ComicInfo.xml")
+	if err != nil {
+		return len(pages), err
+	}
+	io.WriteString(entry, xml.Header)
+	encoder := xml.NewEncoder(entry)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(epubReader.ComicInfo(pages)); err != nil {
+		return len(pages), fmt.Errorf("epub: %s: write ComicInfo.xml: %w", epubReader.Name, err)
+	}
+
+	return len(pages), writer.Close()
+}
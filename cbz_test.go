@@ -0,0 +1,82 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteCBZ(t *testing.T) {
+	opf := strings.NewReplacer(
+		`<dc:publisher>`, `<dc:date>2021-03-04</dc:date><meta name="calibre:series" content="Tests"/><meta name="calibre:series_index" content="2"/><dc:publisher>`,
+		`<item id="css"`, `<item id="page2" href="images/page2.png" media-type="image/png"/><item id="css"`,
+		`<spine toc="ncx">`, `<spine toc="ncx" page-progression-direction="rtl">`,
+		`<itemref idref="chapter2"/>`, `<itemref idref="chapter2"/><itemref idref="page2"/>`,
+	).Replace(testOPF)
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/chapter2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><image xlink:href="images/page1.JPG"/></svg>
+<img src="images/missing.png"/></body></html>`},
+		testFile{"OEBPS/images/page1.JPG", "page1"},
+		testFile{"OEBPS/images/page2.png", "page2"},
+	)
+
+	var buffer bytes.Buffer
+	pages, err := reader.WriteCBZ(&buffer)
+	if err != nil || pages != 3 {
+		t.Fatalf("WriteCBZ() = %d, %v", pages, err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"001.jpg", "002.jpg", "003.png", "ComicInfo.xml"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("entries = %v", names)
+	}
+
+	r, err := archive.File[3].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	var info ComicInfo
+	if err := xml.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	want := ComicInfo{
+		XMLName:     xml.Name{Local: "ComicInfo"},
+		Title:       "The Test Book",
+		Series:      "Tests",
+		Number:      "2",
+		Year:        2021,
+		Month:       3,
+		Day:         4,
+		Writer:      "Jane Doe",
+		Publisher:   "Test Press",
+		LanguageISO: "en",
+		PageCount:   3,
+		Manga:       "YesAndRightToLeft",
+		Pages:       []ComicPage{{Image: 0, Type: "FrontCover"}, {Image: 1}, {Image: 2}},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("ComicInfo = %+v", info)
+	}
+}
+
+func TestWriteCBZNoPages(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", testChapter2})
+	if _, err := reader.WriteCBZ(io.Discard); !errors.Is(err, ErrNoPageImages) {
+		t.Errorf("WriteCBZ() error = %v, want ErrNoPageImages", err)
+	}
+}
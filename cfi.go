@@ -0,0 +1,348 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrBadCFI occurs when a CFI cannot be parsed or does not match the book.
+var ErrBadCFI = errors.New("epub: invalid CFI")
+
+// CFI is a parsed EPUB canonical fragment identifier, such as
+// epubcfi(/6/4[chap01]!/4[body01]/10/3:10) for a position or
+// epubcfi(/6/4!/4/10,/3:5,/3:20) for a range.
+//
+// Character offsets count Unicode code points. Temporal and spatial
+// offsets and side bias assertions are parsed but ignored.
+type CFI struct {
+	raw     string
+	parent  cfiLocation
+	start   cfiLocation
+	end     cfiLocation
+	isRange bool
+}
+
+type cfiStep struct {
+	index int
+	// assertion is the id assertion of the step, if any.
+	assertion string
+	// indirect is set for the first step following a '!'.
+	indirect bool
+}
+
+type cfiLocation struct {
+	steps []cfiStep
+	// offset is the character offset, or -1.
+	offset int
+}
+
+// ParseCFI parses s, with or without its epubcfi(...) wrapper.
+func ParseCFI(s string) (CFI, error) {
+	cfi := CFI{raw: s}
+
+	body := s
+	if strings.HasPrefix(body, "epubcfi(") && strings.HasSuffix(body, ")") {
+		body = body[len("epubcfi(") : len(body)-1]
+	}
+
+	parts := splitCFI(body)
+	var err error
+	switch len(parts) {
+	case 1:
+		cfi.parent, err = parseCFILocation(parts[0])
+	case 3:
+		cfi.isRange = true
+		if cfi.parent, err = parseCFILocation(parts[0]); err == nil {
+			if cfi.start, err = parseCFILocation(parts[1]); err == nil {
+				cfi.end, err = parseCFILocation(parts[2])
+			}
+		}
+	default:
+		err = errors.New("expected a path or a range")
+	}
+	if err == nil && len(cfi.parent.steps) == 0 {
+		err = errors.New("empty path")
+	}
+	if err != nil {
+		return CFI{}, fmt.Errorf("%w: %s: %v", ErrBadCFI, s, err)
+	}
+
+	return cfi, nil
+}
+
+// String returns the CFI as it was parsed.
+func (cfi CFI) String() string {
+	return cfi.raw
+}
+
+// IsRange reports whether the CFI designates a range rather than a
+// position.
+func (cfi CFI) IsRange() bool {
+	return cfi.isRange
+}
+
+// splitCFI splits s at the commas that are not escaped or inside brackets.
+func splitCFI(s string) []string {
+	var parts []string
+	depth, last := 0, 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '^':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+
+	return append(parts, s[last:])
+}
+
+func parseCFILocation(s string) (cfiLocation, error) {
+	location := cfiLocation{offset: -1}
+	indirect := false
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '!':
+			indirect = true
+			i++
+		case '/':
+			j := i + 1
+			for j < len(s) && '0' <= s[j] && s[j] <= '9' {
+				j++
+			}
+			index, err := strconv.Atoi(s[i+1 : j])
+			if err != nil {
+				return location, fmt.Errorf("bad step at %d", i)
+			}
+			step := cfiStep{index: index, indirect: indirect}
+			indirect = false
+			if j < len(s) && s[j] == '[' {
+				var assertion string
+				if assertion, j, err = parseCFIAssertion(s, j); err != nil {
+					return location, err
+				}
+				if k := strings.IndexByte(assertion, ';'); k >= 0 {
+					assertion = assertion[:k]
+				}
+				step.assertion = assertion
+			}
+			location.steps = append(location.steps, step)
+			i = j
+		case ':':
+			j := i + 1
+			for j < len(s) && '0' <= s[j] && s[j] <= '9' {
+				j++
+			}
+			offset, err := strconv.Atoi(s[i+1 : j])
+			if err != nil {
+				return location, fmt.Errorf("bad offset at %d", i)
+			}
+			location.offset = offset
+			if j < len(s) && s[j] == '[' {
+				if _, j, err = parseCFIAssertion(s, j); err != nil {
+					return location, err
+				}
+			}
+			i = j
+		case '~', '@':
+			return location, nil
+		default:
+			return location, fmt.Errorf("unexpected %q at %d", s[i], i)
+		}
+	}
+
+	return location, nil
+}
+
+// parseCFIAssertion returns the unescaped assertion starting with the '['
+// at s[i], and the index following its ']'.
+func parseCFIAssertion(s string, i int) (string, int, error) {
+	var b strings.Builder
+
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '^':
+			if j+1 < len(s) {
+				j++
+				b.WriteByte(s[j])
+			}
+		case ']':
+			return b.String(), j + 1, nil
+		default:
+			b.WriteByte(s[j])
+		}
+	}
+
+	return "", len(s), errors.New("unterminated assertion")
+}
+
+// CFITarget is what a CFI designates in a book.
+type CFITarget struct {
+	// Item is the spine item holding the target.
+	Item Item
+	// Text is the text of the range, or for a position the text of the
+	// element holding it, with white space collapsed.
+	Text string
+	// Start and End are the offsets of the target in the text of the
+	// content document; they are equal for a position.
+	Start int
+	End   int
+}
+
+// ResolveCFI returns the content a CFI designates.
+func (epubReader *EpubReader) ResolveCFI(cfi CFI) (CFITarget, error) {
+	var target CFITarget
+
+	if !cfi.isRange {
+		item, _, node, offset, err := epubReader.resolveCFILocation(cfi, cfi.parent)
+		if err != nil {
+			return target, err
+		}
+		element := node
+		if element.name == "" && element.parent != nil {
+			element = element.parent
+		}
+		return CFITarget{Item: item, Text: collapseSpace(element.textContent()), Start: offset, End: offset}, nil
+	}
+
+	item, document, _, start, err := epubReader.resolveCFILocation(cfi, joinCFI(cfi.parent, cfi.start))
+	if err != nil {
+		return target, err
+	}
+	_, _, _, end, err := epubReader.resolveCFILocation(cfi, joinCFI(cfi.parent, cfi.end))
+	if err != nil {
+		return target, err
+	}
+	if end < start {
+		start, end = end, start
+	}
+
+	return CFITarget{Item: item, Text: collapseSpace(document.textBetween(start, end)), Start: start, End: end}, nil
+}
+
+func joinCFI(parent, local cfiLocation) cfiLocation {
+	return cfiLocation{
+		steps:  append(append([]cfiStep(nil), parent.steps...), local.steps...),
+		offset: local.offset,
+	}
+}
+
+// resolveCFILocation returns the spine item, its parsed content document,
+// the node and the text offset designated by location.
+func (epubReader *EpubReader) resolveCFILocation(cfi CFI, location cfiLocation) (Item, *domNode, *domNode, int, error) {
+	fail := func(format string, args ...interface{}) (Item, *domNode, *domNode, int, error) {
+		return Item{}, nil, nil, 0, fmt.Errorf("%w: %s: %s", ErrBadCFI, cfi.raw, fmt.Sprintf(format, args...))
+	}
+
+	split := -1
+	for i, step := range location.steps {
+		if step.indirect {
+			split = i
+			break
+		}
+	}
+	if split < 1 {
+		return fail("no content document step")
+	}
+
+	// The last step before the indirection selects the itemref.
+	itemrefs := epubReader.Rootfiles[0].Spine.Itemref
+	spineStep := location.steps[split-1]
+	idref := ""
+	if k := spineStep.index/2 - 1; spineStep.index%2 == 0 && k >= 0 && k < len(itemrefs) {
+		idref = itemrefs[k].Idref
+	}
+	if spineStep.assertion != "" {
+		for _, itemref := range itemrefs {
+			if itemref.Idref == spineStep.assertion {
+				idref = itemref.Idref
+			}
+		}
+	}
+	item, ok := epubReader.ItemByID(idref)
+	if !ok {
+		return fail("no spine item at step %d", spineStep.index)
+	}
+
+	reader, err := epubReader.openFile(epubReader.itemPath(item.Href))
+	if err != nil {
+		return Item{}, nil, nil, 0, err
+	}
+	document, err := parseDOM(reader)
+	reader.Close()
+	if err != nil {
+		return Item{}, nil, nil, 0, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, item.Href, err)
+	}
+
+	roots := document.elements()
+	if len(roots) == 0 {
+		return fail("empty content document")
+	}
+	node := roots[0]
+	for _, step := range location.steps[split:] {
+		if step.assertion != "" {
+			if element := document.byID(step.assertion); element != nil {
+				node = element
+				continue
+			}
+		}
+		if node.name == "" {
+			return fail("step %d below a text node", step.index)
+		}
+
+		if step.index%2 == 0 {
+			elements := node.elements()
+			k := step.index/2 - 1
+			if k < 0 || k >= len(elements) {
+				return fail("no element at step %d", step.index)
+			}
+			node = elements[k]
+			continue
+		}
+
+		// Odd steps designate the text before the ((index+1)/2)th element
+		// child, or after the last one.
+		chunk := (step.index - 1) / 2
+		var text *domNode
+		offset := node.start
+		count := 0
+		for _, child := range node.children {
+			if child.name != "" {
+				if count == chunk {
+					offset = child.start
+					break
+				}
+				count++
+				offset = child.end
+			} else if count == chunk {
+				text = child
+				break
+			}
+		}
+		if text == nil {
+			return item, document, node, offset, nil
+		}
+		node = text
+	}
+
+	offset := node.start
+	if location.offset >= 0 {
+		offset += location.offset
+		if offset > node.end {
+			offset = node.end
+		}
+	}
+
+	return item, document, node, offset, nil
+}
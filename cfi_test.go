@@ -0,0 +1,62 @@
+package epub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCFI(t *testing.T) {
+	for _, s := range []string{
+		"epubcfi(/6/4[chap01ref]!/4[body01]/10[para05]/3:10)",
+		"epubcfi(/6/4!/4/10,/3:5,/3:20)",
+		"/6/4!/4/2[a^]b]/1:0[;s=b]",
+		"epubcfi(/6/4!/4/2~23.5@50:50)",
+	} {
+		if _, err := ParseCFI(s); err != nil {
+			t.Errorf("ParseCFI(%q) = %v", s, err)
+		}
+	}
+
+	cfi, _ := ParseCFI("/6/4!/4/2[a^]b]/1:0")
+	if cfi.parent.steps[3].assertion != "a]b" || !cfi.parent.steps[2].indirect {
+		t.Errorf("ParseCFI() steps = %+v", cfi.parent.steps)
+	}
+
+	for _, s := range []string{"", "epubcfi()", "/6/x", "/6/4,/1", "/6/4[open"} {
+		if _, err := ParseCFI(s); !errors.Is(err, ErrBadCFI) {
+			t.Errorf("ParseCFI(%q) = %v", s, err)
+		}
+	}
+}
+
+func TestResolveCFI(t *testing.T) {
+	reader := openTestEpub(t)
+
+	for _, test := range []struct {
+		cfi  string
+		id   string
+		text string
+	}{
+		{"epubcfi(/6/2!/4/4/1:12)", "chapter1", "It was a dark & stormy night."},
+		{"epubcfi(/6/2!/4/4,/1:9,/1:13)", "chapter1", "dark"},
+		{"epubcfi(/6/2!/4,/2/1:0,/4/1:6)", "chapter1", "Chapter One It was"},
+		{"epubcfi(/6/4[chapter2]!/4/4/1:0)", "chapter2", "The end."},
+		{"epubcfi(/6/8[chapter2]!/4/2)", "chapter2", "Chapter Two"},
+	} {
+		cfi, err := ParseCFI(test.cfi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		target, err := reader.ResolveCFI(cfi)
+		if err != nil || target.Item.ID != test.id || target.Text != test.text {
+			t.Errorf("ResolveCFI(%s) = %+v, %v", test.cfi, target, err)
+		}
+	}
+
+	for _, s := range []string{"epubcfi(/6/20!/4)", "epubcfi(/6/2!/4/40)", "epubcfi(/6/2/4)"} {
+		cfi, _ := ParseCFI(s)
+		if _, err := reader.ResolveCFI(cfi); !errors.Is(err, ErrBadCFI) {
+			t.Errorf("ResolveCFI(%s) = %v", s, err)
+		}
+	}
+}
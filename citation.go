@@ -0,0 +1,332 @@
+package epub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Citation export formats.
+const (
+	FormatBibTeX  = "bibtex"
+	FormatCSLJSON = "csl-json"
+	FormatRIS     = "ris"
+)
+
+// Citation is the bibliographic data of a book, for reference managers.
+type Citation struct {
+	// Key identifies the citation, e.g. "doe2019test". WriteCitations
+	// makes the keys of a list unique.
+	Key string
+	// Authors are in "Family, Given" form when they are personal names
+	// whose parts are known, as written otherwise.
+	Authors   []string
+	Editors   []string
+	Title     string
+	Publisher string
+	// Date is the publication date, in its YYYY, YYYY-MM or YYYY-MM-DD
+	// prefix.
+	Date     string
+	ISBN     string
+	DOI      string
+	Language string
+}
+
+var (
+	doiPattern      = regexp.MustCompile(`(?i)^(?:urn:doi:|doi:|https?://(?:dx\.)?doi\.org/)?(10\.\d{4,9}/\S+)$`)
+	citationKeyChar = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+var citationStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "le": true, "la": true, "les": true,
+	"l": true, "un": true, "une": true, "der": true, "die": true, "das": true,
+	"el": true, "los": true, "las": true, "il": true, "lo": true,
+}
+
+// Citation returns the bibliographic data of the book, from its metadata.
+// Creators are authors unless their role says otherwise; editors are the
+// creators and contributors with the edt role.
+func (epubReader *EpubReader) Citation() Citation {
+	metadata := epubReader.Rootfiles[0].Metadata
+	citation := Citation{
+		Title:     collapseSpace(firstTitle(metadata.Title)),
+		Publisher: collapseSpace(firstText(metadata.Publisher)),
+		Language:  strings.TrimSpace(firstText(metadata.Language)),
+	}
+	if match := datePattern.FindString(strings.TrimSpace(firstDate(metadata.Date))); match != "" {
+		citation.Date = match
+	}
+
+	for _, creator := range epubReader.creators() {
+		name := collapseSpace(creator.FileAs)
+		if name == "" {
+			name = AuthorSort(collapseSpace(creator.Text))
+		}
+		switch {
+		case name == "":
+		case creator.Role == "edt":
+			citation.Editors = append(citation.Editors, name)
+		case !creator.Contributor && (creator.Role == "" || creator.Role == "aut"):
+			citation.Authors = append(citation.Authors, name)
+		}
+	}
+
+	citation.ISBN, _ = epubReader.GetISBN()
+	for _, id := range metadata.Identifier {
+		text := strings.TrimSpace(id.Text)
+		switch scheme := strings.ToUpper(id.Scheme); {
+		case citation.DOI == "" && scheme == "DOI":
+			citation.DOI = text
+			if match := doiPattern.FindStringSubmatch(text); match != nil {
+				citation.DOI = match[1]
+			}
+		case citation.DOI == "" && doiPattern.MatchString(text):
+			citation.DOI = doiPattern.FindStringSubmatch(text)[1]
+		}
+	}
+
+	citation.Key = citation.defaultKey()
+
+	return citation
+}
+
+// packageCreator is a creator or contributor of the package metadata.
+type packageCreator struct {
+	Creator
+	Contributor bool
+}
+
+// creators returns the creators then the contributors of the book.
+func (epubReader *EpubReader) creators() []packageCreator {
+	var creators []packageCreator
+	for _, creator := range epubReader.Creators() {
+		creators = append(creators, packageCreator{Creator: creator})
+	}
+	for _, contributor := range epubReader.Contributors() {
+		creators = append(creators, packageCreator{Creator: contributor, Contributor: true})
+	}
+
+	return creators
+}
+
+// defaultKey returns a key made of the family name of the first author,
+// the year and the first significant word of the title.
+func (citation Citation) defaultKey() string {
+	key := ""
+	if len(citation.Authors) > 0 {
+		family, _ := splitName(citation.Authors[0])
+		key = citationKeyPart(strings.Fields(family)[0])
+	}
+	if len(citation.Date) >= 4 {
+		key += citation.Date[:4]
+	}
+	for _, word := range strings.Fields(citation.Title) {
+		word = citationKeyPart(word)
+		if word != "" && !citationStopWords[word] {
+			key += word
+			break
+		}
+	}
+	if key == "" {
+		return "book"
+	}
+
+	return key
+}
+
+func citationKeyPart(s string) string {
+	return citationKeyChar.ReplaceAllString(SortKey(s, BasicTransliterator), "")
+}
+
+// splitName returns the family and given names of a name in "Family,
+// Given" form, or the name and nothing.
+func splitName(name string) (family, given string) {
+	if i := strings.Index(name, ", "); i > 0 {
+		return name[:i], name[i+2:]
+	}
+
+	return name, ""
+}
+
+// WriteCitation writes the citation of the book in the given format.
+func (epubReader *EpubReader) WriteCitation(w io.Writer, format string) error {
+	return WriteCitations(w, []Citation{epubReader.Citation()}, format)
+}
+
+// WriteCitations writes the citations in the given format: FormatBibTeX,
+// FormatCSLJSON or FormatRIS. Keys shared by several citations get a
+// letter suffix.
+func WriteCitations(w io.Writer, citations []Citation, format string) error {
+	citations = uniqueCitationKeys(citations)
+
+	switch format {
+	case FormatBibTeX:
+		var b strings.Builder
+		for i, citation := range citations {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			writeBibTeX(&b, citation)
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	case FormatCSLJSON:
+		items := make([]map[string]interface{}, len(citations))
+		for i, citation := range citations {
+			items[i] = cslItem(citation)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(items)
+	case FormatRIS:
+		var b strings.Builder
+		for _, citation := range citations {
+			writeRIS(&b, citation)
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	return fmt.Errorf("epub: unknown citation format %s", format)
+}
+
+func uniqueCitationKeys(citations []Citation) []Citation {
+	counts := make(map[string]int)
+	for _, citation := range citations {
+		counts[citation.Key]++
+	}
+
+	unique := make([]Citation, len(citations))
+	seen := make(map[string]int)
+	for i, citation := range citations {
+		if key := citation.Key; counts[key] > 1 {
+			citation.Key = key + string(rune('a'+seen[key]%26))
+			seen[key]++
+		}
+		unique[i] = citation
+	}
+
+	return unique
+}
+
+var bibTeXEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`, `{`, `\{`, `}`, `\}`, `&`, `\&`, `%`, `\%`,
+	`$`, `\$`, `#`, `\#`, `_`, `\_`, `~`, `\textasciitilde{}`, `^`, `\textasciicircum{}`,
+)
+
+func writeBibTeX(b *strings.Builder, citation Citation) {
+	fmt.Fprintf(b, "@book{%s,\n", citation.Key)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(b, "  %s = {%s},\n", name, bibTeXEscaper.Replace(value))
+		}
+	}
+	names := func(names []string) string {
+		braced := make([]string, len(names))
+		for i, name := range names {
+			// Names that are not in "Family, Given" form, such as
+			// organizations, are braced to be taken as a whole.
+			if _, given := splitName(name); given == "" && strings.Contains(name, " ") {
+				braced[i] = "{" + bibTeXEscaper.Replace(name) + "}"
+			} else {
+				braced[i] = bibTeXEscaper.Replace(name)
+			}
+		}
+		return strings.Join(braced, " and ")
+	}
+
+	if len(citation.Authors) > 0 {
+		fmt.Fprintf(b, "  author = {%s},\n", names(citation.Authors))
+	}
+	if len(citation.Editors) > 0 {
+		fmt.Fprintf(b, "  editor = {%s},\n", names(citation.Editors))
+	}
+	field("title", citation.Title)
+	field("publisher", citation.Publisher)
+	if len(citation.Date) >= 4 {
+		field("year", citation.Date[:4])
+	}
+	if len(citation.Date) >= 7 {
+		month, _ := strconv.Atoi(citation.Date[5:7])
+		if month >= 1 && month <= 12 {
+			fmt.Fprintf(b, "  month = %s,\n", []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}[month-1])
+		}
+	}
+	field("isbn", citation.ISBN)
+	field("doi", citation.DOI)
+	field("language", citation.Language)
+	b.WriteString("}\n")
+}
+
+func cslItem(citation Citation) map[string]interface{} {
+	item := map[string]interface{}{"id": citation.Key, "type": "book"}
+	set := func(name, value string) {
+		if value != "" {
+			item[name] = value
+		}
+	}
+	names := func(names []string) []map[string]string {
+		list := make([]map[string]string, len(names))
+		for i, name := range names {
+			if family, given := splitName(name); given != "" {
+				list[i] = map[string]string{"family": family, "given": given}
+			} else {
+				list[i] = map[string]string{"literal": name}
+			}
+		}
+		return list
+	}
+
+	set("title", citation.Title)
+	set("publisher", citation.Publisher)
+	set("ISBN", citation.ISBN)
+	set("DOI", citation.DOI)
+	set("language", citation.Language)
+	if len(citation.Authors) > 0 {
+		item["author"] = names(citation.Authors)
+	}
+	if len(citation.Editors) > 0 {
+		item["editor"] = names(citation.Editors)
+	}
+	if citation.Date != "" {
+		var parts []int
+		for _, part := range strings.Split(citation.Date, "-") {
+			n, _ := strconv.Atoi(part)
+			parts = append(parts, n)
+		}
+		item["issued"] = map[string]interface{}{"date-parts": [][]int{parts}}
+	}
+
+	return item
+}
+
+// writeRIS writes a RIS record, whose lines end with CRLF.
+func writeRIS(b *strings.Builder, citation Citation) {
+	tag := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(b, "%s  - %s\r\n", name, value)
+		}
+	}
+
+	tag("TY", "BOOK")
+	tag("ID", citation.Key)
+	for _, author := range citation.Authors {
+		tag("AU", author)
+	}
+	for _, editor := range citation.Editors {
+		tag("ED", editor)
+	}
+	tag("TI", citation.Title)
+	tag("PB", citation.Publisher)
+	if len(citation.Date) >= 4 {
+		tag("PY", citation.Date[:4])
+		tag("DA", strings.ReplaceAll(citation.Date, "-", "/"))
+	}
+	tag("SN", citation.ISBN)
+	tag("DO", citation.DOI)
+	tag("LA", citation.Language)
+	b.WriteString("ER  - \r\n")
+}
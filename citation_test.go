@@ -0,0 +1,99 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCitation(t *testing.T) {
+	opf := strings.Replace(testOPF, `<dc:language>en</dc:language>`, `<dc:creator opf:role="aut">Richard Roe</dc:creator>
+    <dc:creator opf:role="ill">Ann Artist</dc:creator>
+    <dc:contributor opf:role="edt">Ed Itor</dc:contributor>
+    <dc:identifier>doi:10.1000/182</dc:identifier>
+    <dc:date>2019-05-01</dc:date>
+    <dc:language>en</dc:language>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+
+	want := Citation{
+		Key:       "doe2019test",
+		Authors:   []string{"Doe, Jane", "Roe, Richard"},
+		Editors:   []string{"Itor, Ed"},
+		Title:     "The Test Book",
+		Publisher: "Test Press",
+		Date:      "2019-05-01",
+		ISBN:      "9780306406157",
+		DOI:       "10.1000/182",
+		Language:  "en",
+	}
+	if got := reader.Citation(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Citation() = %+v", got)
+	}
+}
+
+func TestWriteCitations(t *testing.T) {
+	citations := []Citation{
+		{
+			Key:       "doe2019test",
+			Authors:   []string{"Doe, Jane", "Acme Press Staff"},
+			Title:     "Cats & Dogs_100%",
+			Publisher: "Test Press",
+			Date:      "2019-05",
+			ISBN:      "9780306406157",
+		},
+		{Key: "doe2019test", Title: "Another"},
+	}
+
+	var b bytes.Buffer
+	if err := WriteCitations(&b, citations, FormatBibTeX); err != nil {
+		t.Fatal(err)
+	}
+	if want := `@book{doe2019testa,
+  author = {Doe, Jane and {Acme Press Staff}},
+  title = {Cats \& Dogs\_100\%},
+  publisher = {Test Press},
+  year = {2019},
+  month = may,
+  isbn = {9780306406157},
+}
+
+@book{doe2019testb,
+  title = {Another},
+}
+`; b.String() != want {
+		t.Errorf("BibTeX = %s", b.String())
+	}
+
+	b.Reset()
+	if err := WriteCitations(&b, citations[:1], FormatRIS); err != nil {
+		t.Fatal(err)
+	}
+	if want := "TY  - BOOK\r\nID  - doe2019test\r\nAU  - Doe, Jane\r\nAU  - Acme Press Staff\r\nTI  - Cats & Dogs_100%\r\n" +
+		"PB  - Test Press\r\nPY  - 2019\r\nDA  - 2019/05\r\nSN  - 9780306406157\r\nER  - \r\n"; b.String() != want {
+		t.Errorf("RIS = %q", b.String())
+	}
+
+	b.Reset()
+	if err := WriteCitations(&b, citations[:1], FormatCSLJSON); err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &items); err != nil || len(items) != 1 {
+		t.Fatalf("CSL-JSON = %s, %v", b.String(), err)
+	}
+	authors := []interface{}{
+		map[string]interface{}{"family": "Doe", "given": "Jane"},
+		map[string]interface{}{"literal": "Acme Press Staff"},
+	}
+	issued := map[string]interface{}{"date-parts": []interface{}{[]interface{}{2019.0, 5.0}}}
+	if item := items[0]; item["id"] != "doe2019test" || item["type"] != "book" || item["ISBN"] != "9780306406157" ||
+		!reflect.DeepEqual(item["author"], authors) || !reflect.DeepEqual(item["issued"], issued) {
+		t.Errorf("CSL-JSON = %s", b.String())
+	}
+
+	if err := WriteCitations(&b, citations, "endnote"); err == nil {
+		t.Errorf("WriteCitations(endnote) = no error")
+	}
+}
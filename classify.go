@@ -0,0 +1,171 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Structure is a rough genre or structure label for a book, meant to choose
+// rendering defaults.
+type Structure string
+
+const (
+	StructureNovel     Structure = "novel"
+	StructureReference Structure = "reference"
+	StructureTextbook  Structure = "textbook"
+	StructureComic     Structure = "comic"
+	StructurePoetry    Structure = "poetry"
+)
+
+// StructureFeatures are the measurements Classify bases its label on.
+type StructureFeatures struct {
+	Documents int
+	Words     int
+	// Headings is the number of h1-h6 elements, HeadingLevels the number of
+	// distinct levels used.
+	Headings      int
+	HeadingLevels int
+	Images        int
+	Tables        int
+	Figures       int
+	// LineBreaks counts br elements, Verse the elements marked as poems,
+	// verses or stanzas by epub:type or class.
+	LineBreaks int
+	Verse      int
+	// Reference counts glossary, dictionary and index semantics, Exercises
+	// exercise, practice and assessment semantics.
+	Reference   int
+	Exercises   int
+	FixedLayout bool
+}
+
+var referenceTypes = map[string]bool{
+	"glossary": true, "glossterm": true, "glossdef": true, "dictionary": true,
+	"dictentry": true, "index": true, "index-entry": true,
+}
+
+var exerciseTypes = map[string]bool{
+	"exercise": true, "practice": true, "practices": true, "assessment": true,
+	"assessments": true, "learning-objective": true, "learning-objectives": true,
+}
+
+var verseClasses = []string{"poem", "verse", "stanza"}
+
+// Classify returns the structure of the book, guessed from the shape of its
+// headings, its epub:type semantics and the density of tables, figures and
+// images, with the features it measured.
+func (epubReader *EpubReader) Classify() (Structure, StructureFeatures, error) {
+	features := StructureFeatures{
+		FixedLayout: epubReader.isFixedLayout(),
+	}
+	levels := make(map[string]bool)
+
+	for _, item := range epubReader.SpineItems() {
+		if err := epubReader.measureStructure(item, &features, levels); err != nil {
+			return "", features, err
+		}
+	}
+	features.HeadingLevels = len(levels)
+
+	return features.structure(), features, nil
+}
+
+// IsReferenceLike reports whether the book is a reference work or a
+// textbook, which readers consult rather than read through.
+func (epubReader *EpubReader) IsReferenceLike() bool {
+	structure, _, err := epubReader.Classify()
+
+	return err == nil && (structure == StructureReference || structure == StructureTextbook)
+}
+
+func (epubReader *EpubReader) measureStructure(item Item, features *StructureFeatures, levels map[string]bool) error {
+	name := epubReader.itemPath(item.Href)
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	features.Documents++
+
+	decoder := newXHTMLDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch local := strings.ToLower(t.Name.Local); local {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				features.Headings++
+				levels[local] = true
+			case "img", "image":
+				features.Images++
+			case "table":
+				features.Tables++
+			case "figure":
+				features.Figures++
+			case "br":
+				features.LineBreaks++
+			}
+
+			verse := false
+			for _, semantic := range epubType(t) {
+				if i := strings.IndexByte(semantic, ':'); i >= 0 {
+					semantic = semantic[i+1:]
+				}
+				switch {
+				case referenceTypes[semantic]:
+					features.Reference++
+				case exerciseTypes[semantic]:
+					features.Exercises++
+				case semantic == "poem" || semantic == "verse":
+					verse = true
+				}
+			}
+			for _, class := range strings.Fields(strings.ToLower(attr(t, "class"))) {
+				for _, v := range verseClasses {
+					verse = verse || strings.Contains(class, v)
+				}
+			}
+			if verse {
+				features.Verse++
+			}
+		case xml.CharData:
+			features.Words += len(strings.Fields(string(t)))
+		}
+	}
+}
+
+// structure labels the features. Thresholds are per document or per
+// thousand words, so that book length does not matter.
+func (features StructureFeatures) structure() Structure {
+	if features.Documents == 0 {
+		return StructureNovel
+	}
+	words := features.Words
+	if words == 0 {
+		words = 1
+	}
+	perThousand := func(n int) float64 { return float64(n) * 1000 / float64(words) }
+
+	switch {
+	case features.Images >= features.Documents && (features.FixedLayout || features.Words/features.Documents < 60):
+		return StructureComic
+	case features.Reference >= 10 || features.Reference > 0 && perThousand(features.Headings) > 20:
+		return StructureReference
+	case features.Exercises > 0 || perThousand(features.Tables+features.Figures) > 2 && features.HeadingLevels >= 3:
+		return StructureTextbook
+	case features.Verse > 0 && perThousand(features.LineBreaks+features.Verse) > 50 ||
+		features.LineBreaks > 20 && perThousand(features.LineBreaks) > 125:
+		return StructurePoetry
+	}
+
+	return StructureNovel
+}
@@ -0,0 +1,42 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	page := func(body string) string {
+		return `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>` + body + `</body></html>`
+	}
+	prose := strings.Repeat("<p>"+strings.Repeat("word ", 100)+"</p>", 5)
+
+	for _, test := range []struct {
+		name  string
+		files []testFile
+		want  Structure
+	}{
+		{"novel", []testFile{{"OEBPS/chapter1.xhtml", page("<h1>One</h1>" + prose)}}, StructureNovel},
+		{"comic", []testFile{
+			{"OEBPS/chapter1.xhtml", page(`<img src="images/cover.jpg"/>`)},
+			{"OEBPS/chapter2.xhtml", page(`<img src="images/cover.jpg"/><p>Bang!</p>`)},
+		}, StructureComic},
+		{"reference", []testFile{{"OEBPS/chapter1.xhtml", page(`<dl epub:type="glossary">` +
+			strings.Repeat(`<dt epub:type="glossterm">term</dt><dd epub:type="glossdef">a short definition</dd>`, 6) + `</dl>`)}}, StructureReference},
+		{"textbook", []testFile{{"OEBPS/chapter1.xhtml", page(prose + `<section epub:type="practice"><p>Solve it.</p></section>`)}}, StructureTextbook},
+		{"poetry", []testFile{{"OEBPS/chapter1.xhtml", page(`<div class="poem">` +
+			strings.Repeat(`<p class="stanza">Roses are red<br/>violets are blue<br/>sugar is sweet<br/>and so are you</p>`, 8) + `</div>`)}}, StructurePoetry},
+	} {
+		structure, features, err := openTestEpub(t, test.files...).Classify()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if structure != test.want {
+			t.Errorf("%s: Classify() = %s, want %s (%+v)", test.name, structure, test.want, features)
+		}
+	}
+
+	if openTestEpub(t).IsReferenceLike() {
+		t.Error("IsReferenceLike() = true for the test book")
+	}
+}
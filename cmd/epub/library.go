@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/jeanmarcboite/epub"
+)
+
+func scan(args []string) int {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	filename := flags.String("library", "", "library file")
+	lang := flags.String("language", "", "language of the catalog, used to sort listings")
+	flags.Parse(args)
+
+	if *filename == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	library, err := epub.LoadLibrary(*filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *lang != "" {
+		library.Language = *lang
+	}
+
+	var roots []epub.LibraryRoot
+	for _, root := range flags.Args() {
+		roots = append(roots, epub.LibraryRoot{Path: root})
+	}
+
+	// An interrupted scan is resumed by the next one.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	changes, err := library.ScanRoots(ctx, roots, epub.ScanOptions{})
+	for _, change := range changes {
+		fmt.Printf("%s %s\n", change.Kind, change.Book.Path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func collection(args []string) int {
+	flags := flag.NewFlagSet("collection", flag.ExitOnError)
+	filename := flags.String("library", "", "library file")
+	define := flags.String("define", "", "save the named collection with this expression")
+	remove := flags.Bool("remove", false, "remove the named collection")
+	flags.Parse(args)
+
+	if *filename == "" || flags.NArg() > 1 || (*define != "" || *remove) && flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	library, err := epub.LoadLibrary(*filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch {
+	case *define != "":
+		collection, err := epub.ParseCollection(flags.Arg(0), *define)
+		if err == nil {
+			err = library.SetCollection(collection)
+		}
+		if err == nil {
+			err = library.Save()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	case *remove:
+		if !library.RemoveCollection(flags.Arg(0)) {
+			fmt.Fprintf(os.Stderr, "no collection %s\n", flags.Arg(0))
+			return 1
+		}
+		if err := library.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	case flags.NArg() == 1:
+		query, ok := library.CollectionQuery(flags.Arg(0))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "no collection %s\n", flags.Arg(0))
+			return 1
+		}
+		for _, book := range query.SortBy(epub.SortByAuthor, false).SortBy(epub.SortByTitle, false).Run().Books {
+			fmt.Printf("%s\t%s\t%s\n", book.Path, book.Creator, book.Title)
+		}
+	default:
+		for _, collection := range library.Collections {
+			query, _ := library.CollectionQuery(collection.Name)
+			fmt.Printf("%s\t%d\n", collection.Name, query.Run().Total)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,345 @@
+// Command epub inspects EPUB files.
+//
+// Usage:
+//
+//	epub validate [-format text|junit|sarif|html] [-workers n] [-rules file] [-strict] [-quiet] [-o file] path...
+//	epub scan -library file [-language tag] root...
+//	epub collection -library file [-define expression | -remove] [name]
+//	epub stats [-format json|html] [-workers n] path...
+//	epub scrub [-audit] [-images] file...
+//	epub diff old new
+//	epub cite [-format bibtex|csl-json|ris] file...
+//
+// A rules file holds one house rule per line, as an id, a severity (error,
+// warning or info) and an expression:
+//
+//	publisher error metadata.publisher must be non-empty
+//
+// The html format of validate writes a standalone report, with the
+// statistics of the books, for editors.
+//
+// The stats command prints the counts of the features and anomalies of
+// the books as JSON, or as an HTML report.
+//
+// The scrub command removes in place the vendor files, watermarks and
+// personal data of the books, and lists what it removed. With -images, it
+// also removes the EXIF, XMP and text metadata of their images.
+//
+// The diff command lists the paragraphs added, removed or changed between
+// two editions of a book.
+//
+// The collection command lists the smart collections of a library, or the
+// books of the named collection; -define saves the named collection:
+//
+//	epub collection -library books.json -define 'language is fr and year after 2010' recent-french
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jeanmarcboite/epub"
+	"github.com/rs/zerolog"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: epub validate [flags] path...\n")
+	fmt.Fprintf(os.Stderr, "       epub scan -library file [-language tag] root...\n")
+	fmt.Fprintf(os.Stderr, "       epub collection -library file [-define expression | -remove] [name]\n")
+	fmt.Fprintf(os.Stderr, "       epub stats [-format json|html] [-workers n] path...\n")
+	fmt.Fprintf(os.Stderr, "       epub scrub [-audit] [-images] file...\n")
+	fmt.Fprintf(os.Stderr, "       epub diff old new\n")
+	fmt.Fprintf(os.Stderr, "       epub cite [-format bibtex|csl-json|ris] file...\n")
+	os.Exit(2)
+}
+
+func main() {
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "validate":
+		os.Exit(validate(os.Args[2:]))
+	case "scan":
+		os.Exit(scan(os.Args[2:]))
+	case "collection":
+		os.Exit(collection(os.Args[2:]))
+	case "stats":
+		os.Exit(stats(os.Args[2:]))
+	case "scrub":
+		os.Exit(scrub(os.Args[2:]))
+	case "diff":
+		os.Exit(diff(os.Args[2:]))
+	case "cite":
+		os.Exit(cite(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	format := flags.String("format", "text", "output format: text, junit, sarif or html")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of files validated concurrently")
+	output := flags.String("o", "", "write the report to this file instead of stdout")
+	rules := flags.String("rules", "", "load additional rules from this file")
+	var options epub.ValidateOptions
+	flags.BoolVar(&options.Strict, "strict", false, "report warnings as errors")
+	flags.BoolVar(&options.Quiet, "quiet", false, "only report errors")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if *rules != "" {
+		if err := loadRules(*rules); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	reports, err := epub.ValidatePaths(flags.Args(), *workers, options)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(w, reports)
+	case "junit":
+		err = epub.WriteJUnit(w, reports)
+	case "sarif":
+		err = epub.WriteSARIF(w, reports)
+	case "html":
+		var stats epub.CorpusStats
+		if stats, err = epub.CollectCorpusStats(flags.Args(), *workers); err == nil {
+			err = epub.WriteHTMLReport(w, epub.HealthReport{Title: "Validation report", Generated: time.Now(), Reports: reports, Stats: &stats})
+		}
+	default:
+		err = fmt.Errorf("unknown format %s", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, report := range reports {
+		if report.HasErrors() {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func writeText(w io.Writer, reports []epub.FileReport) error {
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			if _, err := fmt.Fprintf(w, "%s: %s\n", report.Path, issue); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func loadRules(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	severities := map[string]epub.Severity{
+		"error":   epub.SeverityError,
+		"warning": epub.SeverityWarning,
+		"info":    epub.SeverityInfo,
+	}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 3)
+		if fields[0] == "" || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return fmt.Errorf("%s:%d: expected id, severity and expression", filename, line)
+		}
+		severity, ok := severities[fields[1]]
+		if !ok {
+			return fmt.Errorf("%s:%d: unknown severity %s", filename, line, fields[1])
+		}
+		rule, err := epub.ExpressionRule(fields[0], severity, fields[2])
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		if err := epub.RegisterRule(rule); err != nil {
+			return fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func stats(args []string) int {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := flags.String("format", "json", "output format: json or html")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of files read concurrently")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	stats, err := epub.CollectCorpusStats(flags.Args(), *workers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch *format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(stats)
+	case "html":
+		err = epub.WriteHTMLReport(os.Stdout, epub.HealthReport{Title: "Library statistics", Generated: time.Now(), Stats: &stats})
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %s\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func scrub(args []string) int {
+	flags := flag.NewFlagSet("scrub", flag.ExitOnError)
+	var options epub.ScrubOptions
+	flags.BoolVar(&options.EmbedAudit, "audit", false, "record the removals in the audit log of the book")
+	flags.BoolVar(&options.StripImageMetadata, "images", false, "remove the metadata of the images")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, filename := range flags.Args() {
+		result, err := epub.ScrubFile(filename, options)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		for _, scrubbed := range result.Removed {
+			if scrubbed.Text == "" {
+				fmt.Printf("%s: removed %s %s\n", filename, scrubbed.Kind, scrubbed.Path)
+			} else {
+				fmt.Printf("%s: removed %s from %s: %s\n", filename, scrubbed.Kind, scrubbed.Path, scrubbed.Text)
+			}
+		}
+	}
+
+	return status
+}
+
+func diff(args []string) int {
+	if len(args) != 2 {
+		usage()
+	}
+
+	var readers [2]*epub.EpubReaderCloser
+	for i, filename := range args {
+		reader, err := epub.OpenReader(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer reader.Close()
+		readers[i] = reader
+	}
+
+	diff, err := epub.DiffText(&readers[0].EpubReader, &readers[1].EpubReader)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	for _, change := range diff.Changes {
+		switch change.Kind {
+		case epub.ParagraphAdded:
+			fmt.Printf("@@ %s #%d\n+ %s\n", change.NewDocument, change.NewIndex, change.New)
+		case epub.ParagraphRemoved:
+			fmt.Printf("@@ %s #%d\n- %s\n", change.OldDocument, change.OldIndex, change.Old)
+		default:
+			fmt.Printf("@@ %s #%d\n- %s\n+ %s\n", change.OldDocument, change.OldIndex, change.Old, change.New)
+		}
+	}
+	if len(diff.Changes) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func cite(args []string) int {
+	flags := flag.NewFlagSet("cite", flag.ExitOnError)
+	format := flags.String("format", epub.FormatBibTeX, "citation format: bibtex, csl-json or ris")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	var citations []epub.Citation
+	for _, filename := range flags.Args() {
+		reader, err := epub.OpenReader(filename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		citations = append(citations, reader.Citation())
+		reader.Close()
+	}
+
+	if err := epub.WriteCitations(os.Stdout, citations, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	return status
+}
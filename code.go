@@ -0,0 +1,176 @@
+package epub
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CodeBlock is a code listing of a content document: a pre element, or a
+// multi-line code element outside of one.
+type CodeBlock struct {
+	// Href is the content document, Index the position of the listing in
+	// it, from 0, and ID the id attribute of the listing, if any.
+	Href  string
+	Index int
+	ID    string
+	// Offset is the position of the listing in the text of the document, in
+	// runes.
+	Offset int
+	// Language is the language hinted by a class (language-go, lang-go,
+	// brush: go, sourceCode go) or a data-lang attribute, lowercased.
+	Language string
+	Code     string
+}
+
+// CodeStats sums up the code listings of a book.
+type CodeStats struct {
+	Blocks int
+	Lines  int
+	// CodeRunes and ProseRunes count the non-space characters of the
+	// listings and of the rest of the text.
+	CodeRunes  int
+	ProseRunes int
+	// Languages counts the listings per hinted language; listings without
+	// a hint are counted under "".
+	Languages map[string]int
+}
+
+// Ratio returns the code-to-prose ratio of the book, the share of its text
+// made of code.
+func (stats CodeStats) Ratio() float64 {
+	if stats.CodeRunes+stats.ProseRunes == 0 {
+		return 0
+	}
+
+	return float64(stats.CodeRunes) / float64(stats.CodeRunes+stats.ProseRunes)
+}
+
+var languageClassPattern = regexp.MustCompile(`(?i)(?:^|\s)(?:language-|lang-|highlight-|brush:\s*|sourcecode\s+)([\w+#.-]+)`)
+
+// codeExtensions maps hinted languages to file extensions.
+var codeExtensions = map[string]string{
+	"bash": ".sh", "c": ".c", "c++": ".cpp", "cpp": ".cpp", "csharp": ".cs",
+	"c#": ".cs", "css": ".css", "go": ".go", "golang": ".go", "html": ".html",
+	"java": ".java", "javascript": ".js", "js": ".js", "json": ".json",
+	"kotlin": ".kt", "python": ".py", "py": ".py", "ruby": ".rb",
+	"rust": ".rs", "shell": ".sh", "sh": ".sh", "sql": ".sql",
+	"swift": ".swift", "typescript": ".ts", "xml": ".xml", "yaml": ".yaml",
+}
+
+// Filename returns a file name for the listing, made of the document name,
+// the index of the listing and an extension for its language.
+func (block CodeBlock) Filename() string {
+	base := strings.TrimSuffix(path.Base(block.Href), path.Ext(block.Href))
+	ext, ok := codeExtensions[block.Language]
+	if !ok {
+		ext = ".txt"
+	}
+
+	return fmt.Sprintf("%s-%03d%s", base, block.Index+1, ext)
+}
+
+// CodeBlocks returns the code listings of the spine documents, in reading
+// order, with statistics.
+func (epubReader *EpubReader) CodeBlocks() ([]CodeBlock, CodeStats, error) {
+	var blocks []CodeBlock
+	stats := CodeStats{Languages: make(map[string]int)}
+
+	for _, item := range epubReader.SpineItems() {
+		name := epubReader.itemPath(item.Href)
+		reader, err := epubReader.openFile(name)
+		if err != nil {
+			return blocks, stats, err
+		}
+		document, err := parseDOM(reader)
+		reader.Close()
+		if err != nil {
+			return blocks, stats, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		index := 0
+		document.walk(func(n *domNode) bool {
+			switch {
+			case n.name == "" && n.parent.name != "head" && n.parent.name != "style" && n.parent.name != "script":
+				stats.ProseRunes += countNonSpace(n.text)
+			case n.name == "pre" || n.name == "code" && strings.Contains(strings.TrimSpace(n.textContent()), "\n"):
+				block := codeBlock(n, item.Href, index)
+				blocks = append(blocks, block)
+				index++
+
+				stats.Blocks++
+				stats.Lines += strings.Count(block.Code, "\n") + 1
+				stats.CodeRunes += countNonSpace(block.Code)
+				stats.Languages[block.Language]++
+				return false
+			}
+			return true
+		})
+	}
+
+	return blocks, stats, nil
+}
+
+func codeBlock(node *domNode, href string, index int) CodeBlock {
+	block := CodeBlock{Href: href, Index: index, ID: node.attr("id"), Offset: node.start}
+
+	code := strings.ReplaceAll(node.textContent(), "\r\n", "\n")
+	code = strings.TrimPrefix(code, "\n")
+	block.Code = strings.TrimRight(code, " \t\n")
+
+	node.walk(func(n *domNode) bool {
+		if block.Language == "" && n.name != "" {
+			block.Language = codeLanguage(n)
+		}
+		return block.Language == "" && (n == node || n.name == "code")
+	})
+
+	return block
+}
+
+func codeLanguage(node *domNode) string {
+	if lang := node.attr("data-lang"); lang != "" {
+		return strings.ToLower(lang)
+	}
+	if lang := node.attr("data-language"); lang != "" {
+		return strings.ToLower(lang)
+	}
+	if match := languageClassPattern.FindStringSubmatch(node.attr("class")); match != nil {
+		return strings.ToLower(match[1])
+	}
+
+	return ""
+}
+
+func countNonSpace(s string) int {
+	n := 0
+	for _, r := range s {
+		if r != ' ' && r != '\t' && r != '\n' && r != '\r' {
+			n++
+		}
+	}
+
+	return n
+}
+
+// WriteCodeBlocks writes each listing to a file of dir named after
+// CodeBlock.Filename, creating dir if needed, and returns the paths written.
+func WriteCodeBlocks(dir string, blocks []CodeBlock) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, block := range blocks {
+		filename := filepath.Join(dir, block.Filename())
+		if err := os.WriteFile(filename, []byte(block.Code+"\n"), 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, filename)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,61 @@
+package epub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testCode = `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<p>Say hello:</p>
+<pre id="hello"><code class="language-Go">
+package main
+
+func main() {
+	println("hi &amp; bye")
+}
+</code></pre>
+<p>Then run:</p>
+<pre class="programlisting" data-lang="bash">go run .</pre>
+<div><code>one
+two</code></div>
+<p>Inline <code>x</code> is prose.</p>
+</body></html>`
+
+func TestCodeBlocks(t *testing.T) {
+	blocks, stats, err := openTestEpub(t, testFile{"OEBPS/chapter2.xhtml", testCode}).CodeBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("CodeBlocks() returned %d blocks, want 3", len(blocks))
+	}
+
+	code := "package main\n\nfunc main() {\n\tprintln(\"hi & bye\")\n}"
+	if block := blocks[0]; block.ID != "hello" || block.Language != "go" || block.Code != code || block.Filename() != "chapter2-001.go" {
+		t.Errorf("blocks[0] = %+v", block)
+	}
+	if block := blocks[1]; block.Language != "bash" || block.Code != "go run ." || block.Filename() != "chapter2-002.sh" {
+		t.Errorf("blocks[1] = %+v", block)
+	}
+	if block := blocks[2]; block.Language != "" || block.Code != "one\ntwo" || block.Filename() != "chapter2-003.txt" {
+		t.Errorf("blocks[2] = %+v", block)
+	}
+
+	if stats.Blocks != 3 || stats.Lines != 8 || stats.Languages["go"] != 1 || stats.Languages[""] != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if ratio := stats.Ratio(); ratio <= 0.3 || ratio >= 0.7 {
+		t.Errorf("Ratio() = %f", ratio)
+	}
+
+	dir := t.TempDir()
+	paths, err := WriteCodeBlocks(dir, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "chapter2-001.go"))
+	if len(paths) != 3 || err != nil || string(data) != code+"\n" {
+		t.Errorf("WriteCodeBlocks() = %v, %v, wrote %q", paths, err, data)
+	}
+}
@@ -0,0 +1,296 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrBadCollection occurs when a collection expression cannot be parsed.
+var ErrBadCollection = errors.New("epub: invalid collection")
+
+// Collection is a smart collection: the books of a library matching rules.
+// Collections hold no books; they are evaluated when queried, and follow
+// the changes of the library.
+type Collection struct {
+	Name string `json:"name"`
+	// Any matches the books matching any rule, instead of all of them.
+	Any   bool             `json:"any,omitempty"`
+	Rules []CollectionRule `json:"rules"`
+}
+
+// CollectionRule is a test on a field of the books.
+//
+// Fields are title, author, series, publisher, language, tag, year, read
+// and cover. Operators are "is", "is not" and "contains" for all fields,
+// "before" and "after" for the year. The read and cover fields take yes or
+// no. Language values match their subtags, so that fr matches fr-CA; tag
+// rules match any subject of the book. Comparisons ignore case.
+type CollectionRule struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+var collectionFields = map[string]bool{
+	"title": true, "author": true, "series": true, "publisher": true, "language": true,
+	"tag": true, "year": true, "read": true, "cover": true,
+}
+
+// collectionOperators are ordered so that "is not" is tried before "is".
+var collectionOperators = []string{"is not", "is", "contains", "before", "after"}
+
+// ParseCollection builds a collection from rules joined by "and", or by
+// "or" to match any of them, such as
+//
+//	read is no
+//	language is fr and tag contains "science fiction" and year after 2010
+//	cover is no or author is ""
+//
+// Values with spaces, or made of the words and and or, are quoted.
+func ParseCollection(name, expression string) (Collection, error) {
+	collection := Collection{Name: name}
+
+	words, err := collectionWords(expression)
+	if err != nil {
+		return collection, fmt.Errorf("%w: %s: %v", ErrBadCollection, expression, err)
+	}
+
+	var clause []string
+	joiner := ""
+	for i := 0; i <= len(words); i++ {
+		if i < len(words) && words[i] != "and" && words[i] != "or" {
+			clause = append(clause, words[i])
+			continue
+		}
+		if i < len(words) {
+			if joiner != "" && joiner != words[i] {
+				return collection, fmt.Errorf("%w: %s: mixed and/or", ErrBadCollection, expression)
+			}
+			joiner = words[i]
+		}
+
+		rule, err := parseCollectionRule(clause)
+		if err != nil {
+			return collection, fmt.Errorf("%w: %s: %v", ErrBadCollection, expression, err)
+		}
+		collection.Rules = append(collection.Rules, rule)
+		clause = nil
+	}
+	collection.Any = joiner == "or"
+
+	return collection, nil
+}
+
+// collectionWords splits an expression in words, unquoting quoted ones.
+// Quoted words are returned with a leading NUL so that they are never
+// taken for keywords.
+func collectionWords(expression string) ([]string, error) {
+	var words []string
+	for rest := strings.TrimSpace(expression); rest != ""; rest = strings.TrimSpace(rest) {
+		if rest[0] != '"' {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			words = append(words, rest[:end])
+			rest = rest[end:]
+			continue
+		}
+
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		word, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, "\x00"+word)
+		rest = rest[end+1:]
+	}
+
+	return words, nil
+}
+
+func parseCollectionRule(words []string) (CollectionRule, error) {
+	if len(words) < 2 {
+		return CollectionRule{}, fmt.Errorf("incomplete rule '%s'", strings.Join(words, " "))
+	}
+
+	rule := CollectionRule{Field: strings.ToLower(words[0])}
+	clause := strings.Join(words[1:], " ")
+	for _, op := range collectionOperators {
+		if strings.HasPrefix(clause, op+" ") {
+			rule.Op = op
+			clause = strings.TrimPrefix(clause, op+" ")
+			break
+		}
+	}
+	value := strings.TrimPrefix(clause, "\x00")
+	if strings.Contains(value, "\x00") {
+		return rule, fmt.Errorf("rule '%s' has several values", strings.Join(words, " "))
+	}
+	rule.Value = value
+
+	return rule, rule.check()
+}
+
+// check reports whether the rule is valid.
+func (rule CollectionRule) check() error {
+	if !collectionFields[rule.Field] {
+		return fmt.Errorf("unknown field '%s'", rule.Field)
+	}
+	switch rule.Op {
+	case "is", "is not", "contains":
+	case "before", "after":
+		if rule.Field != "year" {
+			return fmt.Errorf("'%s' only applies to the year", rule.Op)
+		}
+	default:
+		return fmt.Errorf("unknown operator '%s'", rule.Op)
+	}
+	if rule.Field == "year" && rule.Op != "contains" {
+		if _, err := strconv.Atoi(rule.Value); err != nil {
+			return fmt.Errorf("invalid year '%s'", rule.Value)
+		}
+	}
+	if rule.Field == "read" || rule.Field == "cover" {
+		if _, ok := parseYesNo(rule.Value); !ok {
+			return fmt.Errorf("%s takes yes or no, not '%s'", rule.Field, rule.Value)
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether the book belongs to the collection.
+func (collection Collection) Match(book LibraryBook) bool {
+	for _, rule := range collection.Rules {
+		if rule.Match(book) == collection.Any {
+			return collection.Any
+		}
+	}
+
+	return !collection.Any
+}
+
+// Match reports whether the book passes the rule.
+func (rule CollectionRule) Match(book LibraryBook) bool {
+	if rule.Op == "is not" {
+		return !CollectionRule{rule.Field, "is", rule.Value}.Match(book)
+	}
+
+	switch rule.Field {
+	case "year":
+		year, _ := strconv.Atoi(rule.Value)
+		switch rule.Op {
+		case "before":
+			return book.Year != 0 && book.Year < year
+		case "after":
+			return book.Year > year
+		}
+		return matchText(strconv.Itoa(book.Year), rule.Op, rule.Value)
+	case "read", "cover":
+		want, _ := parseYesNo(rule.Value)
+		if rule.Field == "read" {
+			return book.Read == want
+		}
+		return book.HasCover == want
+	case "language":
+		if rule.Op == "is" {
+			return languageMatches(book.Language, rule.Value)
+		}
+		return matchText(book.Language, rule.Op, rule.Value)
+	case "tag":
+		for _, subject := range book.Subjects {
+			if matchText(subject, rule.Op, rule.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	values := map[string]string{"title": book.Title, "author": book.Creator, "series": book.Series, "publisher": book.Publisher}
+
+	return matchText(values[rule.Field], rule.Op, rule.Value)
+}
+
+func matchText(value, op, want string) bool {
+	if op == "contains" {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(want))
+	}
+
+	return strings.EqualFold(value, want)
+}
+
+// languageMatches reports whether language is want or one of its subtags.
+func languageMatches(language, want string) bool {
+	return strings.EqualFold(language, want) ||
+		len(language) > len(want) && language[len(want)] == '-' && strings.EqualFold(language[:len(want)], want)
+}
+
+func parseYesNo(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "yes", "true":
+		return true, true
+	case "no", "false":
+		return false, true
+	}
+
+	return false, false
+}
+
+// SetCollection adds the collection to the library, replacing the one with
+// the same name.
+func (library *Library) SetCollection(collection Collection) error {
+	for _, rule := range collection.Rules {
+		if err := rule.check(); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrBadCollection, collection.Name, err)
+		}
+	}
+
+	for i, existing := range library.Collections {
+		if existing.Name == collection.Name {
+			library.Collections[i] = collection
+			return nil
+		}
+	}
+	library.Collections = append(library.Collections, collection)
+	sort.Slice(library.Collections, func(i, j int) bool { return library.Collections[i].Name < library.Collections[j].Name })
+
+	return nil
+}
+
+// RemoveCollection removes the collection with the given name, and reports
+// whether it existed.
+func (library *Library) RemoveCollection(name string) bool {
+	for i, collection := range library.Collections {
+		if collection.Name == name {
+			library.Collections = append(library.Collections[:i], library.Collections[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// CollectionQuery returns a query of the books of the named collection,
+// which can be refined, sorted and paginated like any query.
+func (library *Library) CollectionQuery(name string) (*LibraryQuery, bool) {
+	for _, collection := range library.Collections {
+		if collection.Name == name {
+			return library.Query().Where(collection.Match), true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCollection(t *testing.T) {
+	collection, err := ParseCollection("french sf", `language is fr and tag contains "science" and year after 2010`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Collection{Name: "french sf", Rules: []CollectionRule{
+		{"language", "is", "fr"}, {"tag", "contains", "science"}, {"year", "after", "2010"},
+	}}
+	if !reflect.DeepEqual(collection, want) {
+		t.Errorf("ParseCollection() = %+v", collection)
+	}
+
+	if collection, err = ParseCollection("odd", `cover is no or author is not "and"`); err != nil || !collection.Any || collection.Rules[1] != (CollectionRule{"author", "is not", "and"}) {
+		t.Errorf("ParseCollection() = %+v, %v", collection, err)
+	}
+
+	for _, expression := range []string{
+		"", "title", "pages is 3", "title before 2000", "year after soon", "read is maybe",
+		"read is no and cover is no or year after 2000", `title is "unterminated`, `title is "a" "b"`,
+	} {
+		if _, err := ParseCollection("bad", expression); !errors.Is(err, ErrBadCollection) {
+			t.Errorf("ParseCollection(%q) error = %v", expression, err)
+		}
+	}
+}
+
+func TestCollectionQuery(t *testing.T) {
+	library := testLibrary()
+	library.Books[0].Read = true
+	library.Books[3].HasCover = true
+	library.Books[4].HasCover = true
+
+	for expression, want := range map[string]string{
+		"read is no":  "bcde",
+		"cover is no": "abc",
+		"language is fr and tag contains science and year after 2010": "b",
+		"series is earthsea or year before 1970":                      "de",
+		"author is not \"alain damasio\"":                             "ade",
+	} {
+		collection, err := ParseCollection(expression, expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := library.SetCollection(collection); err != nil {
+			t.Fatal(err)
+		}
+		query, ok := library.CollectionQuery(expression)
+		if !ok {
+			t.Fatalf("CollectionQuery(%q) not found", expression)
+		}
+		if got := pagePaths(query.Run()); got != want {
+			t.Errorf("%s: got %q, want %q", expression, got, want)
+		}
+	}
+
+	// Collections follow the library.
+	library.SetRead("b", true)
+	query, _ := library.CollectionQuery("read is no")
+	if got := pagePaths(query.Run()); got != "cde" {
+		t.Errorf("after SetRead: got %q", got)
+	}
+
+	if !library.RemoveCollection("read is no") || len(library.Collections) != 4 {
+		t.Errorf("RemoveCollection() left %d collections", len(library.Collections))
+	}
+}
+
+func TestCollectionSaved(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "library.json")
+	library, err := LoadLibrary(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection, _ := ParseCollection("unread", "read is no")
+	if err := library.SetCollection(collection); err != nil {
+		t.Fatal(err)
+	}
+	if err := library.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if library, err = LoadLibrary(filename); err != nil || !reflect.DeepEqual(library.Collections, []Collection{collection}) {
+		t.Errorf("LoadLibrary() = %+v, %v", library, err)
+	}
+}
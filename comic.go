@@ -0,0 +1,297 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif" // page images may be GIFs
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ComicOptions tunes the import of comics.
+type ComicOptions struct {
+	// Title, Creator, Language and Identifier default to the values of the
+	// ComicInfo.xml of the comic, if any, then to the file name of the
+	// comic, no creator, "und" and a random urn:uuid.
+	Title      string
+	Creator    string
+	Language   string
+	Identifier string
+	// RightToLeft sets a right-to-left page progression, for manga. It is
+	// also set when ComicInfo.xml marks the comic as right-to-left manga.
+	RightToLeft bool
+	// Spread is the rendition:spread of the book, "landscape" when empty.
+	Spread string
+	// Modified is the dcterms:modified date, the current time when zero.
+	Modified time.Time
+}
+
+var comicMediaTypes = map[string]string{
+	".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".gif": "image/gif",
+}
+
+// comicPage is a page of an imported comic.
+type comicPage struct {
+	Number    int
+	Image     string
+	MediaType string
+	Width     int
+	Height    int
+	// Spread is the rendition:page-spread-* property of the page.
+	Spread string
+}
+
+func (page comicPage) Document() string {
+	return fmt.Sprintf("pages/page-%03d.xhtml", page.Number)
+}
+
+// ImportComic writes to w a fixed-layout EPUB built from the images of
+// source, a directory or a CBZ archive. See WriteComicEPUB.
+func ImportComic(w io.Writer, source string, options ComicOptions) (int, error) {
+	info, err := os.Stat(longPath(source))
+	if err != nil {
+		return 0, err
+	}
+
+	var images fs.FS
+	if info.IsDir() {
+		images = os.DirFS(source)
+	} else {
+		archive, err := zip.OpenReader(longPath(source))
+		if err != nil {
+			return 0, fmt.Errorf("epub: open zip %s: %w", source, err)
+		}
+		defer archive.Close()
+		images = archive
+	}
+
+	options.applyComicInfo(images)
+	if options.Title == "" {
+		options.Title = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	}
+
+	return WriteComicEPUB(w, images, options)
+}
+
+// WriteComicEPUB writes to w a fixed-layout EPUB 3 with one page per JPEG,
+// PNG or GIF image of images, in natural file name order: each page is an
+// XHTML wrapper sized to its image by a viewport meta. The first image is
+// the cover; the navigation document lists every page. It returns the
+// number of pages written.
+func WriteComicEPUB(w io.Writer, images fs.FS, options ComicOptions) (int, error) {
+	var names []string
+	err := fs.WalkDir(images, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && comicMediaTypes[strings.ToLower(path.Ext(name))] != "" {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("epub: import comic: %w", err)
+	}
+	if len(names) == 0 {
+		return 0, fmt.Errorf("epub: import comic: %w", ErrNoPageImages)
+	}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	options.applyComicInfo(images)
+	if options.Identifier == "" {
+		options.Identifier = randomURN()
+	}
+	if options.Modified.IsZero() {
+		options.Modified = time.Now()
+	}
+
+	pages := make([]comicPage, len(names))
+	for i, name := range names {
+		ext := strings.ToLower(path.Ext(name))
+		pages[i] = comicPage{Number: i + 1, Image: fmt.Sprintf("images/page-%03d%s", i+1, ext), MediaType: comicMediaTypes[ext]}
+		if pages[i].Width, pages[i].Height, err = imageSize(images, name); err != nil {
+			return 0, fmt.Errorf("epub: import comic: %s: %w", name, err)
+		}
+		pages[i].Spread = pageSpread(i, options.RightToLeft)
+	}
+
+	epubWriter := NewWriter(WriterMetadata{
+		Identifier: options.Identifier,
+		Title:      options.Title,
+		Language:   options.Language,
+		Cover:      pages[0].Image,
+		Modified:   options.Modified,
+		Meta: []Meta{
+			{Property: "rendition:layout", Text: "pre-paginated"},
+			{Property: "rendition:orientation", Text: "auto"},
+			{Property: "rendition:spread", Text: options.Spread},
+		},
+		Prefix: "rendition: http://www.idpf.org/vocab/rendition/#",
+	})
+	if options.Creator != "" {
+		epubWriter.Metadata.Creators = []string{options.Creator}
+	}
+	if options.Spread == "" {
+		epubWriter.Metadata.Meta[2].Text = "landscape"
+	}
+	if options.RightToLeft {
+		epubWriter.Metadata.PageProgressionDirection = "rtl"
+	}
+	for i, page := range pages {
+		var document bytes.Buffer
+		if err := comicDocument.Execute(&document, page); err != nil {
+			return 0, fmt.Errorf("epub: import comic: %w", err)
+		}
+		number := fmt.Sprintf("%03d", page.Number)
+		if err := epubWriter.AddItemWith(page.Document(), "application/xhtml+xml", &document, WriterItemOptions{
+			ID:              "page-" + number,
+			Title:           fmt.Sprintf("Page %d", page.Number),
+			SpineProperties: page.Spread,
+		}); err != nil {
+			return 0, fmt.Errorf("epub: import comic: %w", err)
+		}
+		name := names[i]
+		epubWriter.addItem(page.Image, page.MediaType, WriterItemOptions{ID: "image-" + number}, nil, func() (io.ReadCloser, error) {
+			return images.Open(name)
+		})
+	}
+
+	if _, err := epubWriter.WriteTo(w); err != nil {
+		return 0, fmt.Errorf("epub: import comic: %w", err)
+	}
+
+	return len(pages), nil
+}
+
+// applyComicInfo fills the options left empty from the ComicInfo.xml of
+// images, if any.
+func (options *ComicOptions) applyComicInfo(images fs.FS) {
+	data, err := fs.ReadFile(images, "ComicInfo.xml")
+	if err != nil {
+		return
+	}
+	var info ComicInfo
+	if decodeXML("ComicInfo.xml", data, &info) != nil {
+		return
+	}
+
+	if options.Title == "" {
+		switch {
+		case info.Title != "":
+			options.Title = info.Title
+		case info.Series != "" && info.Number != "":
+			options.Title = info.Series + " " + info.Number
+		default:
+			options.Title = info.Series
+		}
+	}
+	if options.Creator == "" {
+		options.Creator = info.Writer
+	}
+	if options.Language == "" {
+		options.Language = info.LanguageISO
+	}
+	if info.Manga == "YesAndRightToLeft" {
+		options.RightToLeft = true
+	}
+}
+
+// pageSpread returns the page spread property of the page at index: the
+// cover is centered, the other pages pair up starting on the side the
+// reading begins with.
+func pageSpread(index int, rightToLeft bool) string {
+	if index == 0 {
+		return "rendition:page-spread-center"
+	}
+	if (index%2 == 1) != rightToLeft {
+		return "rendition:page-spread-left"
+	}
+
+	return "rendition:page-spread-right"
+}
+
+func imageSize(images fs.FS, name string) (int, int, error) {
+	file, err := images.Open(name)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+
+	return config.Width, config.Height, err
+}
+
+// randomURN returns a random (version 4) UUID URN.
+func randomURN() string {
+	var uuid [16]byte
+	rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// naturalLess compares file names, comparing runs of digits by value, so
+// that page2 sorts before page10.
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da > 0 && db > 0 {
+			na, nb := strings.TrimLeft(a[:da], "0"), strings.TrimLeft(b[:db], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return len(a) < len(b)
+}
+
+func digitPrefix(s string) int {
+	n := 0
+	for n < len(s) && '0' <= s[n] && s[n] <= '9' {
+		n++
+	}
+
+	return n
+}
+
+func escapeXML(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
+
+var comicDocument = template.Must(template.New("page").Funcs(templateFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head>
+<title>Page {{.Number}}</title>
+<meta name="viewport" content="width={{.Width}}, height={{.Height}}"/>
+<style>body { margin: 0; } img { display: block; width: {{.Width}}px; height: {{.Height}}px; }</style>
+</head>
+<body><img src="../{{.Image}}" alt="Page {{.Number}}"/></body>
+</html>
+`))
@@ -0,0 +1,118 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestImportComicDir(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"page10.png", "page2.png", "page1.png"} {
+		if err := os.WriteFile(filepath.Join(dir, name), testPNG(t, 10+i, 20), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buffer bytes.Buffer
+	pages, err := ImportComic(&buffer, dir, ComicOptions{Creator: "Jane Doe", Modified: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	if err != nil || pages != 3 {
+		t.Fatalf("ImportComic() = %d, %v", pages, err)
+	}
+
+	reader, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := reader.Rootfiles[0].Metadata
+	if firstTitle(metadata.Title) != filepath.Base(dir) || len(metadata.Creator) != 1 || metadata.Creator[0].Text != "Jane Doe" || firstText(metadata.Language) != "und" {
+		t.Errorf("metadata = %+v", metadata)
+	}
+	if reader.metaValue("layout") != "pre-paginated" || reader.metaValue("modified") != "2024-01-02T03:04:05Z" {
+		t.Errorf("layout = %q, modified = %q", reader.metaValue("layout"), reader.metaValue("modified"))
+	}
+	if issues := reader.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+
+	images, err := reader.PageImages()
+	if want := []string{"OEBPS/images/page-001.png", "OEBPS/images/page-002.png", "OEBPS/images/page-003.png"}; err != nil || !reflect.DeepEqual(images, want) {
+		t.Errorf("PageImages() = %v, %v", images, err)
+	}
+	// page1.png, the cover, is 12 pixels wide.
+	page, err := reader.readFile("OEBPS/pages/page-001.xhtml")
+	if err != nil || !bytes.Contains(page.Bytes(), []byte(`content="width=12, height=20"`)) {
+		t.Errorf("page 1 = %s, %v", page, err)
+	}
+	if cover, ok := reader.Cover(); !ok || cover.Href != "images/page-001.png" {
+		t.Errorf("Cover() = %+v, %v", cover, ok)
+	}
+
+	toc, err := reader.TOC()
+	if err != nil || len(toc) != 3 || toc[2].Title != "Page 3" || toc[2].Href != "pages/page-003.xhtml" {
+		t.Errorf("TOC() = %+v, %v", toc, err)
+	}
+}
+
+func TestImportComicCBZ(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "comic.cbz")
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, data := range map[string][]byte{
+		"001.png":       testPNG(t, 4, 4),
+		"002.png":       testPNG(t, 4, 4),
+		"003.png":       testPNG(t, 4, 4),
+		"ComicInfo.xml": []byte(`<ComicInfo><Series>Tests</Series><Number>3</Number><LanguageISO>ja</LanguageISO><Manga>YesAndRightToLeft</Manga></ComicInfo>`),
+	} {
+		w, _ := writer.Create(name)
+		w.Write(data)
+	}
+	writer.Close()
+	if err := os.WriteFile(filename, buffer.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer.Reset()
+	if _, err := ImportComic(&buffer, filename, ComicOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := reader.Rootfiles[0].Package
+	if firstTitle(pkg.Metadata.Title) != "Tests 3" || firstText(pkg.Metadata.Language) != "ja" || pkg.Spine.PageProgressionDirection != "rtl" {
+		t.Errorf("title = %q, language = %q, direction = %q", firstTitle(pkg.Metadata.Title), firstText(pkg.Metadata.Language), pkg.Spine.PageProgressionDirection)
+	}
+	if got := []string{pageSpread(0, true), pageSpread(1, true), pageSpread(2, true)}; !reflect.DeepEqual(got, []string{
+		"rendition:page-spread-center", "rendition:page-spread-right", "rendition:page-spread-left",
+	}) {
+		t.Errorf("page spreads = %v", got)
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	names := []string{"p10.jpg", "p2.jpg", "p02b.jpg", "a.jpg", "p1.jpg"}
+	sort.Slice(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+	if want := []string{"a.jpg", "p1.jpg", "p2.jpg", "p02b.jpg", "p10.jpg"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("sorted = %v", names)
+	}
+}
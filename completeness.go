@@ -0,0 +1,151 @@
+package epub
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+)
+
+// Metadata criteria, in the order of MetadataScore.Criteria.
+const (
+	CriterionTitle       = "title"
+	CriterionAuthors     = "authors"
+	CriterionDescription = "description"
+	CriterionSubjects    = "subjects"
+	CriterionSeries      = "series"
+	CriterionCover       = "cover"
+	CriterionLanguage    = "language"
+)
+
+// Cover images smaller than the minimum of the Kindle store are scored as
+// undersized.
+const (
+	minCoverWidth  = 625
+	minCoverHeight = 1000
+)
+
+// Descriptions of fewer runes are scored as short.
+const minDescriptionLength = 200
+
+// MetadataCriterion is the score of the book on one criterion.
+type MetadataCriterion struct {
+	Name string `json:"name"`
+	// Score is out of Weight.
+	Score  int `json:"score"`
+	Weight int `json:"weight"`
+	// Detail says what is missing, empty when Score is Weight.
+	Detail string `json:"detail,omitempty"`
+}
+
+// MetadataScore assesses the completeness of the metadata of a book.
+type MetadataScore struct {
+	// Score is the sum of the scores of the criteria, out of 100.
+	Score    int                 `json:"score"`
+	Criteria []MetadataCriterion `json:"criteria"`
+}
+
+// MetadataScore scores the completeness of the metadata of the book, for
+// libraries to fix the worst books first. The weights of the criteria add
+// up to 100:
+//   - title (15)
+//   - authors (20), half of it for creators without a role
+//   - description (15), 7 for a description shorter than 200 runes
+//   - subjects (10), half of it for fewer than 3 subjects
+//   - series (10)
+//   - cover (20), half of it for an image smaller than 625×1000 pixels
+//   - language (10), a valid BCP 47 tag other than "und"
+func (epubReader *EpubReader) MetadataScore() MetadataScore {
+	metadata := epubReader.Rootfiles[0].Metadata
+	var score MetadataScore
+	add := func(name string, points, weight int, detail string) {
+		if points == weight {
+			detail = ""
+		}
+		score.Criteria = append(score.Criteria, MetadataCriterion{Name: name, Score: points, Weight: weight, Detail: detail})
+		score.Score += points
+	}
+
+	switch title := strings.ToLower(collapseSpace(firstTitle(metadata.Title))); title {
+	case "", "unknown", "untitled":
+		add(CriterionTitle, 0, 15, "no title")
+	default:
+		add(CriterionTitle, 15, 15, "")
+	}
+
+	authors, roles := 0, 0
+	for _, creator := range epubReader.creators() {
+		if creator.Contributor || collapseSpace(creator.Text) == "" {
+			continue
+		}
+		authors++
+		if strings.TrimSpace(creator.Role) != "" {
+			roles++
+		}
+	}
+	switch {
+	case authors == 0:
+		add(CriterionAuthors, 0, 20, "no creator")
+	case roles < authors:
+		add(CriterionAuthors, 10, 20, "creators without a role")
+	default:
+		add(CriterionAuthors, 20, 20, "")
+	}
+
+	switch length := utf8.RuneCountInString(descriptionText(firstText(metadata.Description))); {
+	case length == 0:
+		add(CriterionDescription, 0, 15, "no description")
+	case length < minDescriptionLength:
+		add(CriterionDescription, 7, 15, "short description")
+	default:
+		add(CriterionDescription, 15, 15, "")
+	}
+
+	switch subjects := len(epubReader.subjects()); {
+	case subjects == 0:
+		add(CriterionSubjects, 0, 10, "no subject")
+	case subjects < 3:
+		add(CriterionSubjects, 5, 10, "fewer than 3 subjects")
+	default:
+		add(CriterionSubjects, 10, 10, "")
+	}
+
+	if series, _ := epubReader.series(); series != "" {
+		add(CriterionSeries, 10, 10, "")
+	} else {
+		add(CriterionSeries, 0, 10, "no series")
+	}
+
+	if cover, ok := epubReader.CoverImage(); !ok {
+		add(CriterionCover, 0, 20, "no cover")
+	} else if config, err := cover.Config(); err != nil {
+		add(CriterionCover, 10, 20, "unreadable cover image")
+	} else if config.Width < minCoverWidth || config.Height < minCoverHeight {
+		add(CriterionCover, 10, 20, "cover image smaller than 625×1000")
+	} else {
+		add(CriterionCover, 20, 20, "")
+	}
+
+	tag := strings.TrimSpace(firstText(metadata.Language))
+	if parsed, err := language.Parse(tag); tag == "" {
+		add(CriterionLanguage, 0, 10, "no language")
+	} else if err != nil || parsed == language.Und {
+		add(CriterionLanguage, 0, 10, "invalid language "+tag)
+	} else {
+		add(CriterionLanguage, 10, 10, "")
+	}
+
+	return score
+}
+
+// descriptionText returns the text of a description, which may hold
+// markup, whitespace collapsed.
+func descriptionText(description string) string {
+	if strings.ContainsRune(description, '<') {
+		if document, err := parseDOM(strings.NewReader("<div>" + description + "</div>")); err == nil {
+			return collapseSpace(document.textContent())
+		}
+	}
+
+	return collapseSpace(description)
+}
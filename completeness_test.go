@@ -0,0 +1,61 @@
+package epub
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestMetadataScore(t *testing.T) {
+	score := openTestEpub(t).MetadataScore()
+	details := make(map[string]string)
+	for _, criterion := range score.Criteria {
+		details[criterion.Name] = criterion.Detail
+	}
+	want := map[string]string{
+		CriterionTitle:       "",
+		CriterionAuthors:     "",
+		CriterionDescription: "no description",
+		CriterionSubjects:    "no subject",
+		CriterionSeries:      "no series",
+		CriterionCover:       "unreadable cover image",
+		CriterionLanguage:    "",
+	}
+	if score.Score != 55 || len(details) != len(want) {
+		t.Errorf("MetadataScore() = %+v, want 55", score)
+	}
+	for name, detail := range want {
+		if details[name] != detail {
+			t.Errorf("%s detail = %q, want %q", name, details[name], detail)
+		}
+	}
+
+	var cover bytes.Buffer
+	if err := png.Encode(&cover, image.NewGray(image.Rect(0, 0, minCoverWidth, minCoverHeight))); err != nil {
+		t.Fatal(err)
+	}
+	description := "&lt;p&gt;" + strings.Repeat("A long description. ", 12) + "&lt;/p&gt;"
+	opf := strings.Replace(testOPF, `<dc:language>en</dc:language>`, `<dc:language>en</dc:language>
+    <dc:description>`+description+`</dc:description>
+    <dc:subject>Fiction</dc:subject>
+    <dc:subject>Mystery</dc:subject>
+    <dc:subject>Detective</dc:subject>
+    <meta name="calibre:series" content="Test Series"/>`, 1)
+	opf = strings.Replace(opf, `href="images/cover.jpg" media-type="image/jpeg"`, `href="images/cover.png" media-type="image/png"`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/images/cover.png", cover.String()})
+	if score := reader.MetadataScore(); score.Score != 100 {
+		t.Errorf("complete MetadataScore() = %+v, want 100", score)
+	}
+
+	opf = strings.Replace(testOPF, `<dc:language>en</dc:language>`, `<dc:language>english</dc:language>
+    <dc:description>Short.</dc:description>
+    <dc:subject>Fiction</dc:subject>`, 1)
+	opf = strings.Replace(opf, ` opf:role="aut"`, "", 1)
+	score = openTestEpub(t, testFile{"OEBPS/content.opf", opf}).MetadataScore()
+	// title 15, authors 10, description 7, subjects 5, cover 10.
+	if score.Score != 47 {
+		t.Errorf("partial MetadataScore() = %+v, want 47", score)
+	}
+}
@@ -0,0 +1,148 @@
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+)
+
+// Cover returns the image item declared as cover: the EPUB 3 cover-image
+// item, the item named by the EPUB 2 <meta name="cover"> element, or the
+// first image of the guide cover page.
+func (epubReader *EpubReader) Cover() (Item, bool) {
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if epubReader.ItemHasProperty(item, ItemVocabulary+"cover-image") {
+			return item, true
+		}
+	}
+
+	for _, meta := range epubReader.Rootfiles[0].Metadata.Meta {
+		if meta.Name == "cover" {
+			if item, ok := epubReader.ItemByID(meta.Content); ok && isImage(item) {
+				return item, true
+			}
+		}
+	}
+
+	for _, reference := range epubReader.Rootfiles[0].Guide.Reference {
+		if GuideLandmark(reference.Type) != "cover" {
+			continue
+		}
+		name := epubReader.itemPath(reference.Href)
+		if item, ok := epubReader.itemByPath(name); ok && isImage(item) {
+			return item, true
+		}
+		if images, err := epubReader.documentImages(name); err == nil && len(images) > 0 {
+			if item, ok := epubReader.itemByPath(images[0]); ok {
+				return item, true
+			}
+		}
+	}
+
+	return Item{}, false
+}
+
+// CoverGuess is a candidate cover image found by GuessCover.
+type CoverGuess struct {
+	Item Item
+	// Method names the heuristic that found the image: "declared",
+	// "filename", "first-image" or "largest-image".
+	Method string
+	// Confidence ranges from 0 to 1; only declared covers reach 1.
+	Confidence float64
+}
+
+// GuessCover returns the declared cover if there is one, and otherwise
+// applies heuristics, from the most to the least reliable: an image named
+// cover.*, the first image of the first spine document, and the largest
+// image of the first three spine documents.
+func (epubReader *EpubReader) GuessCover() (CoverGuess, bool) {
+	if item, ok := epubReader.Cover(); ok {
+		return CoverGuess{Item: item, Method: "declared", Confidence: 1}, true
+	}
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		base := strings.ToLower(path.Base(epubReader.itemPath(item.Href)))
+		if isImage(item) && strings.TrimSuffix(base, path.Ext(base)) == "cover" {
+			return CoverGuess{Item: item, Method: "filename", Confidence: 0.8}, true
+		}
+	}
+
+	spine := epubReader.SpineItems()
+	if len(spine) > 3 {
+		spine = spine[:3]
+	}
+
+	var largest Item
+	var largestSize uint64
+	for i, document := range spine {
+		images, err := epubReader.documentImages(epubReader.itemPath(document.Href))
+		if err != nil {
+			continue
+		}
+		for j, image := range images {
+			item, ok := epubReader.itemByPath(image)
+			if !ok {
+				continue
+			}
+			if i == 0 && j == 0 {
+				return CoverGuess{Item: item, Method: "first-image", Confidence: 0.6}, true
+			}
+			if size := epubReader.fileSize(image); size > largestSize {
+				largest, largestSize = item, size
+			}
+		}
+	}
+	if largestSize > 0 {
+		return CoverGuess{Item: largest, Method: "largest-image", Confidence: 0.4}, true
+	}
+
+	return CoverGuess{}, false
+}
+
+func isImage(item Item) bool {
+	return strings.HasPrefix(item.MediaType, "image/")
+}
+
+// itemByPath returns the manifest item stored in the zip entry name.
+func (epubReader *EpubReader) itemByPath(name string) (Item, bool) {
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if epubReader.itemPath(item.Href) == name {
+			return item, true
+		}
+	}
+
+	return Item{}, false
+}
+
+// documentImages returns the zip paths of the images referenced by the
+// content document name, in document order.
+func (epubReader *EpubReader) documentImages(name string) ([]string, error) {
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var images []string
+	decoder := newXHTMLDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return images, nil
+		}
+		if err != nil {
+			return images, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			switch strings.ToLower(start.Name.Local) {
+			case "img":
+				images = append(images, resolvePath(name, attr(start, "src")))
+			case "image":
+				images = append(images, resolvePath(name, attr(start, "href")))
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCover(t *testing.T) {
+	reader := openTestEpub(t)
+	if item, ok := reader.Cover(); !ok || item.ID != "cover-image" {
+		t.Errorf("Cover() = %v, %v", item, ok)
+	}
+
+	opf := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, ``, 1)
+	opf = strings.Replace(opf, `<item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>`,
+		`<item id="cover-image" href="images/cover.jpg" media-type="image/jpeg" properties="cover-image"/>`, 1)
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	if item, ok := reader.Cover(); !ok || item.ID != "cover-image" {
+		t.Errorf("Cover(EPUB 3) = %v, %v", item, ok)
+	}
+
+	opf = strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, ``, 1)
+	opf = strings.Replace(opf, `</spine>`, `</spine>
+  <guide><reference type="cover" title="Cover" href="chapter1.xhtml"/></guide>`, 1)
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	if item, ok := reader.Cover(); !ok || item.ID != "cover-image" {
+		t.Errorf("Cover(guide) = %v, %v", item, ok)
+	}
+}
+
+func TestGuessCover(t *testing.T) {
+	noCover := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, ``, 1)
+
+	for _, test := range []struct {
+		name   string
+		files  []testFile
+		method string
+		id     string
+	}{
+		{"declared", nil, "declared", "cover-image"},
+		{"filename", []testFile{{"OEBPS/content.opf", noCover}}, "filename", "cover-image"},
+		{"first-image", []testFile{
+			{"OEBPS/content.opf", strings.Replace(noCover, "images/cover.jpg", "images/front.jpg", 1)},
+			{"OEBPS/images/front.jpg", "JPEG"},
+			{"OEBPS/chapter1.xhtml", `<html><body><img src="images/front.jpg"/></body></html>`},
+		}, "first-image", "cover-image"},
+		{"largest-image", []testFile{
+			{"OEBPS/content.opf", strings.Replace(strings.Replace(noCover, "images/cover.jpg", "images/front.jpg", 1),
+				`</manifest>`, `<item id="small" href="small.png" media-type="image/png"/></manifest>`, 1)},
+			{"OEBPS/images/front.jpg", "a much larger JPEG"},
+			{"OEBPS/small.png", "PNG"},
+			{"OEBPS/chapter1.xhtml", `<html><body><p>no image</p></body></html>`},
+			{"OEBPS/chapter2.xhtml", `<html><body><img src="small.png"/><img src="images/front.jpg"/></body></html>`},
+		}, "largest-image", "cover-image"},
+	} {
+		guess, ok := openTestEpub(t, test.files...).GuessCover()
+		if !ok || guess.Method != test.method || guess.Item.ID != test.id {
+			t.Errorf("%s: GuessCover() = %+v, %v", test.name, guess, ok)
+		}
+	}
+}
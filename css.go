@@ -0,0 +1,595 @@
+package epub
+
+import (
+	"strings"
+)
+
+// pruneCSS returns css without the style rules whose selectors keep
+// rejects, and the selectors removed. Selectors of a rule that keep rejects
+// are dropped from its selector list, the rule itself when none is left.
+// Rules nested in @media and @supports are pruned too; other at-rules and
+// comments are kept verbatim.
+func pruneCSS(css string, keep func(selector string) bool) (string, []string) {
+	var b strings.Builder
+	removed := pruneCSSBlock(&b, css, keep)
+
+	return b.String(), removed
+}
+
+// pruneCSSBlock writes the pruned rules of css to b.
+func pruneCSSBlock(b *strings.Builder, css string, keep func(selector string) bool) []string {
+	var removed []string
+
+	for i := 0; i < len(css); {
+		// White space is only written with the rule that follows it, so
+		// that removed rules leave no blank lines behind.
+		start := i
+		for i < len(css) && isCSSSpace(css[i]) {
+			i++
+		}
+		if i >= len(css) {
+			b.WriteString(css[start:])
+			break
+		}
+		if strings.HasPrefix(css[i:], "/*") {
+			i = skipCSSComment(css, i)
+			b.WriteString(css[start:i])
+			continue
+		}
+
+		prelude := scanCSS(css, i, "{;")
+		if prelude >= len(css) || css[prelude] == ';' {
+			end := prelude + 1
+			if end > len(css) {
+				end = len(css)
+			}
+			b.WriteString(css[start:end])
+			i = end
+			continue
+		}
+		end := closingBrace(css, prelude)
+
+		if css[i] == '@' {
+			if !isConditionalAtRule(css[i:prelude]) {
+				b.WriteString(css[start:end])
+				i = end
+				continue
+			}
+			var inner strings.Builder
+			innerEnd := end
+			if innerEnd > prelude+1 && css[innerEnd-1] == '}' {
+				innerEnd--
+			}
+			removed = append(removed, pruneCSSBlock(&inner, css[prelude+1:innerEnd], keep)...)
+			if hasCSSRule(inner.String()) {
+				b.WriteString(css[start : prelude+1])
+				b.WriteString(inner.String())
+				b.WriteString(css[innerEnd:end])
+			}
+			i = end
+			continue
+		}
+
+		var kept []string
+		selectors := splitSelectors(css[i:prelude])
+		for _, selector := range selectors {
+			if keep(selector) {
+				kept = append(kept, selector)
+			} else {
+				removed = append(removed, selector)
+			}
+		}
+		switch {
+		case len(kept) == len(selectors):
+			b.WriteString(css[start:end])
+		case len(kept) > 0:
+			b.WriteString(css[start:i])
+			b.WriteString(strings.Join(kept, ", "))
+			b.WriteString(" ")
+			b.WriteString(css[prelude:end])
+		}
+		i = end
+	}
+
+	return removed
+}
+
+// walkCSSRules calls fn with the selectors and the declaration block of
+// each style rule of css, in order, rules nested in @media and @supports
+// included.
+func walkCSSRules(css string, fn func(selectors []string, declarations string)) {
+	for i := 0; i < len(css); {
+		if isCSSSpace(css[i]) {
+			i++
+			continue
+		}
+		if strings.HasPrefix(css[i:], "/*") {
+			i = skipCSSComment(css, i)
+			continue
+		}
+
+		prelude := scanCSS(css, i, "{;")
+		if prelude >= len(css) {
+			return
+		}
+		if css[prelude] == ';' {
+			i = prelude + 1
+			continue
+		}
+		end := closingBrace(css, prelude)
+		block := css[prelude+1 : end]
+		if end > prelude+1 && css[end-1] == '}' {
+			block = css[prelude+1 : end-1]
+		}
+
+		if css[i] != '@' {
+			fn(splitSelectors(css[i:prelude]), block)
+		} else if isConditionalAtRule(css[i:prelude]) {
+			walkCSSRules(block, fn)
+		}
+		i = end
+	}
+}
+
+// cssDeclarations returns the properties of a declaration block, lowercased,
+// and their values, without !important. Later declarations win.
+func cssDeclarations(block string) map[string]string {
+	declarations := make(map[string]string)
+	for len(block) > 0 {
+		i := scanCSS(block, 0, ";")
+		if colon := strings.IndexByte(block[:i], ':'); colon > 0 {
+			name := strings.ToLower(strings.TrimSpace(block[:colon]))
+			value := strings.TrimSpace(block[colon+1 : i])
+			if important := strings.LastIndex(value, "!"); important >= 0 && strings.EqualFold(strings.TrimSpace(value[important+1:]), "important") {
+				value = strings.TrimSpace(value[:important])
+			}
+			declarations[name] = value
+		}
+		if i >= len(block) {
+			break
+		}
+		block = block[i+1:]
+	}
+
+	return declarations
+}
+
+func isCSSSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// isConditionalAtRule reports whether the at-rule with the given prelude
+// holds style rules.
+func isConditionalAtRule(prelude string) bool {
+	name := strings.ToLower(prelude)
+	for _, rule := range []string{"@media", "@supports", "@document", "@-moz-document"} {
+		if strings.HasPrefix(name, rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasCSSRule reports whether css holds more than white space and comments.
+func hasCSSRule(css string) bool {
+	for i := 0; i < len(css); {
+		switch {
+		case isCSSSpace(css[i]):
+			i++
+		case strings.HasPrefix(css[i:], "/*"):
+			i = skipCSSComment(css, i)
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipCSSComment returns the offset following the comment starting at i.
+func skipCSSComment(css string, i int) int {
+	if end := strings.Index(css[i+2:], "*/"); end >= 0 {
+		return i + 2 + end + 2
+	}
+
+	return len(css)
+}
+
+// scanCSS returns the offset of the first byte of stops found from i
+// outside of strings, comments, brackets and parentheses, or len(css).
+func scanCSS(css string, i int, stops string) int {
+	depth := 0
+	for i < len(css) {
+		c := css[i]
+		switch {
+		case c == '\\':
+			i += 2
+			continue
+		case c == '"' || c == '\'':
+			i = skipCSSString(css, i)
+			continue
+		case strings.HasPrefix(css[i:], "/*"):
+			i = skipCSSComment(css, i)
+			continue
+		case c == '(' || c == '[':
+			depth++
+		case (c == ')' || c == ']') && depth > 0:
+			depth--
+		case depth == 0 && strings.IndexByte(stops, c) >= 0:
+			return i
+		}
+		i++
+	}
+
+	return len(css)
+}
+
+// skipCSSString returns the offset following the string starting at i.
+func skipCSSString(css string, i int) int {
+	quote := css[i]
+	for i++; i < len(css); i++ {
+		switch css[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i + 1
+		}
+	}
+
+	return len(css)
+}
+
+// closingBrace returns the offset following the brace closing the block
+// opened at i.
+func closingBrace(css string, i int) int {
+	depth := 0
+	for i < len(css) {
+		i = scanCSS(css, i, "{}")
+		if i >= len(css) {
+			break
+		}
+		if css[i] == '{' {
+			depth++
+		} else if depth--; depth == 0 {
+			return i + 1
+		}
+		i++
+	}
+
+	return len(css)
+}
+
+// splitSelectors splits a selector list on its top level commas.
+func splitSelectors(list string) []string {
+	var selectors []string
+	for {
+		i := scanCSS(list, 0, ",")
+		if selector := strings.TrimSpace(list[:i]); selector != "" {
+			selectors = append(selectors, selector)
+		}
+		if i >= len(list) {
+			return selectors
+		}
+		list = list[i+1:]
+	}
+}
+
+// cssSelector is a complex selector: compound selectors joined by
+// combinators.
+type cssSelector []cssCompound
+
+type cssCompound struct {
+	// combinator joins the compound to the previous one: ' ', '>', '+' or
+	// '~'; it is 0 for the first compound.
+	combinator byte
+	tag        string
+	id         string
+	classes    []string
+	attrs      []cssAttr
+}
+
+type cssAttr struct {
+	name, op, value string
+	fold            bool
+}
+
+// parseSelector parses the subset of CSS selectors the optimizer matches.
+// Pseudo-classes and pseudo-elements are ignored, so that the selector
+// matches at least the elements the full selector does; ok is false when
+// the selector uses anything else.
+func parseSelector(selector string) (_ cssSelector, ok bool) {
+	var parsed cssSelector
+	current := cssCompound{}
+	empty := true
+	var combinator byte
+
+	flush := func() bool {
+		if empty {
+			return combinator == 0 || combinator == ' '
+		}
+		current.combinator = combinator
+		if len(parsed) == 0 {
+			current.combinator = 0
+		}
+		parsed = append(parsed, current)
+		current, empty, combinator = cssCompound{}, true, 0
+		return true
+	}
+
+	for i := 0; i < len(selector); {
+		c := selector[i]
+		switch {
+		case isCSSSpace(c):
+			if !empty && !flush() {
+				return nil, false
+			}
+			if combinator == 0 && len(parsed) > 0 {
+				combinator = ' '
+			}
+			i++
+		case c == '>' || c == '+' || c == '~':
+			if !flush() || len(parsed) == 0 {
+				return nil, false
+			}
+			combinator = c
+			i++
+		case c == '*':
+			i++
+			if i < len(selector) && selector[i] == '|' {
+				i++
+				continue
+			}
+			empty = false
+		case c == '.' || c == '#':
+			name, n := cssIdent(selector[i+1:])
+			if n == 0 {
+				return nil, false
+			}
+			if c == '.' {
+				current.classes = append(current.classes, name)
+			} else {
+				current.id = name
+			}
+			empty = false
+			i += 1 + n
+		case c == '[':
+			end := scanCSS(selector, i+1, "]")
+			if end >= len(selector) {
+				return nil, false
+			}
+			attr, ok := parseAttrSelector(selector[i+1 : end])
+			if !ok {
+				return nil, false
+			}
+			current.attrs = append(current.attrs, attr)
+			empty = false
+			i = end + 1
+		case c == ':':
+			for i < len(selector) && selector[i] == ':' {
+				i++
+			}
+			_, n := cssIdent(selector[i:])
+			if n == 0 {
+				return nil, false
+			}
+			i += n
+			if i < len(selector) && selector[i] == '(' {
+				i = scanCSS(selector, i+1, ")") + 1
+			}
+			empty = false
+		default:
+			name, n := cssIdent(selector[i:])
+			if n == 0 {
+				return nil, false
+			}
+			i += n
+			if i < len(selector) && selector[i] == '|' {
+				if name, n = cssIdent(selector[i+1:]); n == 0 {
+					return nil, false
+				}
+				i += 1 + n
+			}
+			current.tag = strings.ToLower(name)
+			empty = false
+		}
+	}
+	if empty || !flush() {
+		return nil, false
+	}
+
+	return parsed, true
+}
+
+// parseAttrSelector parses the inside of an attribute selector.
+func parseAttrSelector(s string) (cssAttr, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '|'); i >= 0 && (i+1 >= len(s) || s[i+1] != '=') {
+		s = s[i+1:]
+	}
+	name, n := cssIdent(s)
+	if n == 0 {
+		return cssAttr{}, false
+	}
+	attr := cssAttr{name: name}
+	s = strings.TrimSpace(s[n:])
+	if s == "" {
+		return attr, true
+	}
+
+	for _, op := range []string{"~=", "|=", "^=", "$=", "*=", "="} {
+		if strings.HasPrefix(s, op) {
+			attr.op = op
+			s = strings.TrimSpace(s[len(op):])
+			break
+		}
+	}
+	if attr.op == "" || s == "" {
+		return cssAttr{}, false
+	}
+
+	if s[0] == '"' || s[0] == '\'' {
+		end := skipCSSString(s, 0)
+		attr.value = s[1 : end-1]
+		s = s[end:]
+	} else {
+		attr.value, n = cssIdent(s)
+		if n == 0 {
+			return cssAttr{}, false
+		}
+		s = s[n:]
+	}
+	switch strings.TrimSpace(strings.ToLower(s)) {
+	case "":
+	case "i":
+		attr.fold = true
+	case "s":
+	default:
+		return cssAttr{}, false
+	}
+
+	return attr, true
+}
+
+// cssIdent returns the identifier at the start of s and its length. Escaped
+// identifiers are not supported.
+func cssIdent(s string) (string, int) {
+	n := 0
+	for n < len(s) {
+		c := s[n]
+		if c == '-' || c == '_' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || n > 0 && '0' <= c && c <= '9' {
+			n++
+			continue
+		}
+		break
+	}
+
+	return s[:n], n
+}
+
+// matches reports whether the selector matches the element.
+func (selector cssSelector) matches(element *domNode) bool {
+	return selector.matchesAt(len(selector)-1, element)
+}
+
+func (selector cssSelector) matchesAt(i int, element *domNode) bool {
+	compound := selector[i]
+	if !compound.matches(element) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+
+	switch compound.combinator {
+	case '>':
+		parent := element.parent
+		return parent != nil && parent.name != "#document" && selector.matchesAt(i-1, parent)
+	case '+':
+		previous := previousElement(element)
+		return previous != nil && selector.matchesAt(i-1, previous)
+	case '~':
+		for previous := previousElement(element); previous != nil; previous = previousElement(previous) {
+			if selector.matchesAt(i-1, previous) {
+				return true
+			}
+		}
+	default:
+		for parent := element.parent; parent != nil && parent.name != "#document"; parent = parent.parent {
+			if selector.matchesAt(i-1, parent) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (compound cssCompound) matches(element *domNode) bool {
+	if compound.tag != "" && compound.tag != element.name {
+		return false
+	}
+	if compound.id != "" && element.attr("id") != compound.id {
+		return false
+	}
+	if len(compound.classes) > 0 {
+		classes := strings.Fields(element.attr("class"))
+		for _, class := range compound.classes {
+			if !containsString(classes, class) {
+				return false
+			}
+		}
+	}
+	for _, attr := range compound.attrs {
+		if !attr.matches(element) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (attr cssAttr) matches(element *domNode) bool {
+	var value string
+	found := false
+	for _, a := range element.attrs {
+		if strings.EqualFold(a.Name.Local, attr.name) {
+			value, found = a.Value, true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+
+	want := attr.value
+	if attr.fold {
+		value, want = strings.ToLower(value), strings.ToLower(want)
+	}
+	switch attr.op {
+	case "":
+		return true
+	case "=":
+		return value == want
+	case "~=":
+		return containsString(strings.Fields(value), want)
+	case "|=":
+		return value == want || strings.HasPrefix(value, want+"-")
+	case "^=":
+		return want != "" && strings.HasPrefix(value, want)
+	case "$=":
+		return want != "" && strings.HasSuffix(value, want)
+	case "*=":
+		return want != "" && strings.Contains(value, want)
+	}
+
+	return false
+}
+
+// previousElement returns the element sibling preceding node.
+func previousElement(node *domNode) *domNode {
+	if node.parent == nil {
+		return nil
+	}
+	var previous *domNode
+	for _, sibling := range node.parent.children {
+		if sibling == node {
+			return previous
+		}
+		if sibling.name != "" {
+			previous = sibling
+		}
+	}
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
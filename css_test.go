@@ -0,0 +1,85 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPruneCSS(t *testing.T) {
+	css := `@charset "utf-8";
+/* headings */
+h1, .unused { font-weight: bold; }
+.unused { content: "}"; }
+@media print {
+  .unused { display: none; }
+}
+@media screen {
+  p { margin: 0; }
+}
+@font-face { font-family: x; src: url(x.otf); }
+p::first-line { color: red; }`
+
+	got, removed := pruneCSS(css, func(selector string) bool { return !strings.Contains(selector, "unused") })
+	want := `@charset "utf-8";
+/* headings */
+h1 { font-weight: bold; }
+@media screen {
+  p { margin: 0; }
+}
+@font-face { font-family: x; src: url(x.otf); }
+p::first-line { color: red; }`
+	if got != want {
+		t.Errorf("pruneCSS() = %q", got)
+	}
+	if want := []string{".unused", ".unused", ".unused"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %q", removed)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	document, err := parseDOM(strings.NewReader(`<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<section epub:type="chapter" class="main text"><h1 id="title">T</h1><p lang="en-GB">a</p><p>b</p></section>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		selector string
+		want     bool
+	}{
+		{"section.main.text > h1#title", true},
+		{"body p", true},
+		{"h1 + p[lang|=en]", true},
+		{"h1 ~ p:last-child", true},
+		{`section[epub|type~="chapter"] p::before`, true},
+		{"*|p", true},
+		{"section > body", false},
+		{"p + h1", false},
+		{".main.other", false},
+		{`p[lang="EN-GB" i]`, true},
+		{`p[lang="EN-GB"]`, false},
+		{"aside, p", false},
+	} {
+		selector, ok := parseSelector(test.selector)
+		if !ok {
+			if test.want {
+				t.Errorf("parseSelector(%q) failed", test.selector)
+			}
+			continue
+		}
+		got := document.find(func(n *domNode) bool { return n.name != "" && n != document && selector.matches(n) }) != nil
+		if got != test.want {
+			t.Errorf("%q matches = %v, want %v", test.selector, got, test.want)
+		}
+	}
+}
+
+func TestCSSDeclarations(t *testing.T) {
+	got := cssDeclarations(` Writing-Mode : vertical-rl !important; content: "a;b"; ; color:red`)
+	want := map[string]string{"writing-mode": "vertical-rl", "content": `"a;b"`, "color": "red"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cssDeclarations() = %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// DeepLinkScheme is the URI scheme of deep links.
+const DeepLinkScheme = "epub"
+
+var (
+	// ErrBadDeepLink occurs when a deep link cannot be parsed.
+	ErrBadDeepLink = errors.New("epub: invalid deep link")
+	// ErrOtherBook occurs when a deep link designates another book.
+	ErrOtherBook = errors.New("epub: deep link to another book")
+)
+
+var fingerprintPattern = regexp.MustCompile(`^epubfp[0-9]+:[0-9a-f]+$`)
+
+// DeepLink designates a position or a range in a book, for applications
+// to share "open this book at this spot" links. Its URI form is
+// epub://<fingerprint>/<cfi>, such as
+// epub://epubfp1.9f86d0…/epubcfi(/6/4!/4/10/3:10): the colon of the
+// fingerprint is written as a dot, for the authority to stay a valid host.
+type DeepLink struct {
+	// Fingerprint identifies the book, see Fingerprint.
+	Fingerprint string
+	CFI         CFI
+}
+
+// DeepLink returns the deep link to the position or range cfi of the book.
+func (epubReader *EpubReader) DeepLink(cfi CFI) DeepLink {
+	return DeepLink{Fingerprint: epubReader.Fingerprint(), CFI: cfi}
+}
+
+// String returns the URI of the link.
+func (link DeepLink) String() string {
+	cfi := link.CFI.String()
+	if !strings.HasPrefix(cfi, "epubcfi(") {
+		cfi = "epubcfi(" + cfi + ")"
+	}
+
+	return DeepLinkScheme + "://" + strings.Replace(link.Fingerprint, ":", ".", 1) + "/" + escapeDeepLinkPath(cfi)
+}
+
+// ParseDeepLink parses the URI of a deep link.
+func ParseDeepLink(s string) (DeepLink, error) {
+	var link DeepLink
+
+	rest := strings.TrimPrefix(s, DeepLinkScheme+"://")
+	slash := strings.IndexByte(rest, '/')
+	if rest == s || slash < 0 {
+		return link, fmt.Errorf("%w: %s: expected %s://<fingerprint>/<cfi>", ErrBadDeepLink, s, DeepLinkScheme)
+	}
+
+	link.Fingerprint = strings.Replace(strings.ToLower(rest[:slash]), ".", ":", 1)
+	if !fingerprintPattern.MatchString(link.Fingerprint) {
+		return link, fmt.Errorf("%w: %s: bad fingerprint", ErrBadDeepLink, s)
+	}
+	path, err := url.PathUnescape(rest[slash+1:])
+	if err != nil {
+		return link, fmt.Errorf("%w: %s: %v", ErrBadDeepLink, s, err)
+	}
+	if link.CFI, err = ParseCFI(path); err != nil {
+		return link, fmt.Errorf("%w: %s: %v", ErrBadDeepLink, s, err)
+	}
+
+	return link, nil
+}
+
+// ResolveDeepLink returns the content the link designates. It returns
+// ErrOtherBook when the link was made for another book or edition.
+func (epubReader *EpubReader) ResolveDeepLink(link DeepLink) (CFITarget, error) {
+	if link.Fingerprint != epubReader.Fingerprint() {
+		return CFITarget{}, fmt.Errorf("epub: %s: %w: %s", epubReader.Name, ErrOtherBook, link.Fingerprint)
+	}
+
+	return epubReader.ResolveCFI(link.CFI)
+}
+
+// escapeDeepLinkPath percent-encodes the characters of a CFI that may not
+// appear in the path of a URI, such as the brackets of id assertions.
+func escapeDeepLinkPath(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~!$&'()*+,;=:@/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
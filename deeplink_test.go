@@ -0,0 +1,47 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeepLink(t *testing.T) {
+	reader := openTestEpub(t)
+	cfi, err := ParseCFI("/6/4[chapter2]!/4/4/1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := reader.DeepLink(cfi)
+	uri := link.String()
+	fingerprint := strings.TrimPrefix(reader.Fingerprint(), "epubfp1:")
+	if want := "epub://epubfp1." + fingerprint + "/epubcfi(/6/4%5Bchapter2%5D!/4/4/1:0)"; uri != want {
+		t.Errorf("String() = %s, want %s", uri, want)
+	}
+
+	parsed, err := ParseDeepLink(uri)
+	if err != nil || parsed.Fingerprint != reader.Fingerprint() || parsed.CFI.String() != "epubcfi(/6/4[chapter2]!/4/4/1:0)" {
+		t.Fatalf("ParseDeepLink(%s) = %+v, %v", uri, parsed, err)
+	}
+	if target, err := reader.ResolveDeepLink(parsed); err != nil || target.Item.ID != "chapter2" || target.Text != "The end." {
+		t.Errorf("ResolveDeepLink() = %+v, %v", target, err)
+	}
+
+	other := openTestEpub(t, testFile{"OEBPS/content.opf", strings.Replace(testOPF, "The Test Book", "Another Book", 1)})
+	if _, err := other.ResolveDeepLink(parsed); !errors.Is(err, ErrOtherBook) {
+		t.Errorf("ResolveDeepLink(other book) = %v", err)
+	}
+
+	for _, s := range []string{
+		"http://epubfp1.abc/epubcfi(/6/4)",
+		"epub://epubfp1.abc",
+		"epub://isbn.9780306406157/epubcfi(/6/4)",
+		"epub://epubfp1.abc/epubcfi(/6/4%ZZ)",
+		"epub://epubfp1.abc/not-a-cfi",
+	} {
+		if _, err := ParseDeepLink(s); !errors.Is(err, ErrBadDeepLink) {
+			t.Errorf("ParseDeepLink(%s) = %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package epub
+
+import (
+	"strings"
+)
+
+// Kinds of paragraph changes.
+const (
+	ParagraphAdded   = "added"
+	ParagraphRemoved = "removed"
+	ParagraphChanged = "changed"
+)
+
+// diffWindow is the number of paragraphs of the new edition searched for
+// the revision of a removed paragraph.
+const diffWindow = 10
+
+// minChangedSimilarity is the word similarity above which a removed and an
+// added paragraph are taken for one changed paragraph.
+const minChangedSimilarity = 0.5
+
+// ChapterAlignment pairs a document of the old edition with the documents
+// of the new edition its paragraphs went to. Old is empty for the
+// documents of the new edition with no paragraph from the old one.
+type ChapterAlignment struct {
+	Old string
+	New []string
+	// Similarity is the share of the paragraphs of the old document found
+	// unchanged in the new edition.
+	Similarity float64
+}
+
+// ParagraphChange is a paragraph added, removed or changed between two
+// editions. Documents are zip paths, and indexes count the paragraphs of
+// their document from 0; those of an edition missing the paragraph are
+// empty and -1.
+type ParagraphChange struct {
+	Kind        string
+	OldDocument string
+	OldIndex    int
+	Old         string
+	NewDocument string
+	NewIndex    int
+	New         string
+}
+
+// TextDiff is the text difference between two editions of a book.
+type TextDiff struct {
+	Chapters []ChapterAlignment
+	Changes  []ParagraphChange
+}
+
+// diffParagraph is a paragraph of the spine documents of an edition.
+type diffParagraph struct {
+	document string
+	index    int
+	text     string
+}
+
+// DiffText compares the text of two editions of a book, paragraph by
+// paragraph. The paragraphs of all the spine documents are compared as one
+// sequence, so that chapters are aligned even when the editions split them
+// differently; a removed paragraph followed by a similar added one is
+// reported as changed.
+func DiffText(old, revised *EpubReader) (TextDiff, error) {
+	a, err := old.diffParagraphs()
+	if err != nil {
+		return TextDiff{}, err
+	}
+	b, err := revised.diffParagraphs()
+	if err != nil {
+		return TextDiff{}, err
+	}
+
+	ids := make(map[string]int)
+	intern := func(paragraphs []diffParagraph) []int {
+		keys := make([]int, len(paragraphs))
+		for i, paragraph := range paragraphs {
+			id, ok := ids[paragraph.text]
+			if !ok {
+				id = len(ids)
+				ids[paragraph.text] = id
+			}
+			keys[i] = id
+		}
+		return keys
+	}
+	differ := &sequenceDiffer{a: intern(a), b: intern(b)}
+	differ.diff(0, len(a), 0, len(b))
+
+	var diff TextDiff
+	links := newChapterLinks()
+	i, j := 0, 0
+	for _, pair := range append(differ.pairs, [2]int{len(a), len(b)}) {
+		diff.Changes = append(diff.Changes, diffGap(a[i:pair[0]], b[j:pair[1]], links)...)
+		if pair[0] < len(a) {
+			links.add(a[pair[0]].document, b[pair[1]].document, true)
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+
+	diff.Chapters = links.alignments(a, b)
+
+	return diff, nil
+}
+
+// diffParagraphs returns the paragraphs of the spine documents, white space
+// collapsed.
+func (epubReader *EpubReader) diffParagraphs() ([]diffParagraph, error) {
+	extractor := epubReader.newTextExtractor(TextOptions{})
+
+	var paragraphs []diffParagraph
+	for _, item := range epubReader.SpineItems() {
+		text, err := extractor.itemText(item)
+		if err != nil {
+			return nil, err
+		}
+		document := epubReader.itemPath(item.Href)
+		index := 0
+		for _, block := range strings.Split(text, "\n\n") {
+			if block = collapseSpace(block); block != "" {
+				paragraphs = append(paragraphs, diffParagraph{document, index, block})
+				index++
+			}
+		}
+	}
+
+	return paragraphs, nil
+}
+
+// diffGap returns the changes between the paragraphs of the editions
+// between two matches, pairing the similar ones.
+func diffGap(a, b []diffParagraph, links *chapterLinks) []ParagraphChange {
+	var changes []ParagraphChange
+	added := func(paragraph diffParagraph) {
+		changes = append(changes, ParagraphChange{Kind: ParagraphAdded, OldIndex: -1, NewDocument: paragraph.document, NewIndex: paragraph.index, New: paragraph.text})
+	}
+
+	j := 0
+	for _, old := range a {
+		best, bestSimilarity := -1, 0.0
+		for k := j; k < len(b) && k < j+diffWindow; k++ {
+			if similarity := wordSimilarity(old.text, b[k].text); similarity >= minChangedSimilarity && similarity > bestSimilarity {
+				best, bestSimilarity = k, similarity
+			}
+		}
+		if best < 0 {
+			changes = append(changes, ParagraphChange{Kind: ParagraphRemoved, OldDocument: old.document, OldIndex: old.index, Old: old.text, NewIndex: -1})
+			continue
+		}
+
+		for ; j < best; j++ {
+			added(b[j])
+		}
+		changes = append(changes, ParagraphChange{
+			Kind:        ParagraphChanged,
+			OldDocument: old.document,
+			OldIndex:    old.index,
+			Old:         old.text,
+			NewDocument: b[best].document,
+			NewIndex:    b[best].index,
+			New:         b[best].text,
+		})
+		links.add(old.document, b[best].document, false)
+		j = best + 1
+	}
+	for ; j < len(b); j++ {
+		added(b[j])
+	}
+
+	return changes
+}
+
+// wordSimilarity returns the Dice coefficient of the words of a and b.
+func wordSimilarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(strings.ToLower(a)), strings.Fields(strings.ToLower(b))
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+
+	counts := make(map[string]int)
+	for _, word := range wordsA {
+		counts[word]++
+	}
+	common := 0
+	for _, word := range wordsB {
+		if counts[word] > 0 {
+			counts[word]--
+			common++
+		}
+	}
+
+	return 2 * float64(common) / float64(len(wordsA)+len(wordsB))
+}
+
+// chapterLinks counts the paragraphs going from each document of the old
+// edition to the documents of the new one.
+type chapterLinks struct {
+	targets   map[string][]string
+	unchanged map[string]int
+	linked    map[string]bool
+}
+
+func newChapterLinks() *chapterLinks {
+	return &chapterLinks{
+		targets:   make(map[string][]string),
+		unchanged: make(map[string]int),
+		linked:    make(map[string]bool),
+	}
+}
+
+func (links *chapterLinks) add(from, to string, unchanged bool) {
+	if !containsString(links.targets[from], to) {
+		links.targets[from] = append(links.targets[from], to)
+	}
+	if unchanged {
+		links.unchanged[from]++
+	}
+	links.linked[to] = true
+}
+
+// alignments returns the alignments of the documents of the old edition,
+// in reading order, followed by the documents of the new edition linked to
+// none.
+func (links *chapterLinks) alignments(a, b []diffParagraph) []ChapterAlignment {
+	var alignments []ChapterAlignment
+
+	for i := 0; i < len(a); {
+		document, count := a[i].document, 0
+		for ; i < len(a) && a[i].document == document; i++ {
+			count++
+		}
+		alignments = append(alignments, ChapterAlignment{
+			Old:        document,
+			New:        links.targets[document],
+			Similarity: float64(links.unchanged[document]) / float64(count),
+		})
+	}
+	for i, paragraph := range b {
+		if !links.linked[paragraph.document] && (i == 0 || b[i-1].document != paragraph.document) {
+			alignments = append(alignments, ChapterAlignment{New: []string{paragraph.document}})
+		}
+	}
+
+	return alignments
+}
+
+// sequenceDiffer finds a longest common subsequence of two sequences with
+// the linear space variant of the O(ND) algorithm of Myers.
+type sequenceDiffer struct {
+	a, b []int
+	// pairs are the indexes of the matching elements, in order.
+	pairs [][2]int
+}
+
+// diff appends the matches of a[a0:a1] and b[b0:b1] to the pairs.
+func (differ *sequenceDiffer) diff(a0, a1, b0, b1 int) {
+	for a0 < a1 && b0 < b1 && differ.a[a0] == differ.b[b0] {
+		differ.pairs = append(differ.pairs, [2]int{a0, b0})
+		a0, b0 = a0+1, b0+1
+	}
+	suffix := 0
+	for a1 > a0 && b1 > b0 && differ.a[a1-1] == differ.b[b1-1] {
+		a1, b1 = a1-1, b1-1
+		suffix++
+	}
+
+	if a0 < a1 && b0 < b1 {
+		x, y, u, v := differ.middleSnake(a0, a1, b0, b1)
+		differ.diff(a0, x, b0, y)
+		for ; x < u; x, y = x+1, y+1 {
+			differ.pairs = append(differ.pairs, [2]int{x, y})
+		}
+		differ.diff(u, a1, v, b1)
+	}
+
+	for i := 0; i < suffix; i++ {
+		differ.pairs = append(differ.pairs, [2]int{a1 + i, b1 + i})
+	}
+}
+
+// middleSnake returns the start and end of the middle snake of an optimal
+// path from (a0, b0) to (a1, b1), the ranges starting and ending with
+// differences.
+func (differ *sequenceDiffer) middleSnake(a0, a1, b0, b1 int) (x, y, u, v int) {
+	n, m := a1-a0, b1-b0
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && differ.a[a0+x] == differ.b[b0+y] {
+				x, y = x+1, y+1
+			}
+			forward[offset+k] = x
+			if odd && delta-k >= -(d-1) && delta-k <= d-1 && x+backward[offset+delta-k] >= n {
+				return a0 + startX, b0 + startY, a0 + x, b0 + y
+			}
+		}
+
+		// The backward search walks the reversed sequences.
+		for k := -d; k <= d; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -d || k != d && backward[offset+k-1] < backward[offset+k+1] {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && differ.a[a1-1-x] == differ.b[b1-1-y] {
+				x, y = x+1, y+1
+			}
+			backward[offset+k] = x
+			if !odd && delta-k >= -d && delta-k <= d && x+forward[offset+delta-k] >= n {
+				return a1 - x, b1 - y, a1 - startX, b1 - startY
+			}
+		}
+	}
+
+	// Unreachable: the paths meet within max differences.
+	return a0, b0, a0, b0
+}
@@ -0,0 +1,126 @@
+package epub
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDocument(paragraphs ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Chapter</title></head><body>
+<p>` + strings.Join(paragraphs, "</p>\n<p>") + `</p>
+</body></html>`
+}
+
+func TestDiffText(t *testing.T) {
+	old := openTestEpub(t,
+		testFile{"OEBPS/chapter1.xhtml", testDocument(
+			"It was a dark and stormy night.",
+			"The rain fell in torrents.",
+			"Except at occasional intervals, when it was checked by a violent gust of wind.",
+			"It swept up the streets.",
+		)},
+		testFile{"OEBPS/chapter2.xhtml", testDocument(
+			"Chapter two begins here.",
+			"A paragraph cut from the second edition.",
+		)},
+	)
+	// The second edition moves the last paragraph of chapter 1 to chapter
+	// 2, revises a paragraph and adds another.
+	revised := openTestEpub(t,
+		testFile{"OEBPS/chapter1.xhtml", testDocument(
+			"It was a dark and stormy night.",
+			"The rain fell in torrents.",
+			"Except at rare intervals, when it was checked by a violent gust of wind.",
+		)},
+		testFile{"OEBPS/chapter2.xhtml", testDocument(
+			"It swept up the streets.",
+			"Chapter two begins here.",
+			"An entirely new paragraph.",
+		)},
+	)
+
+	diff, err := DiffText(&old.EpubReader, &revised.EpubReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ParagraphChange{
+		{
+			Kind:        ParagraphChanged,
+			OldDocument: "OEBPS/chapter1.xhtml",
+			OldIndex:    2,
+			Old:         "Except at occasional intervals, when it was checked by a violent gust of wind.",
+			NewDocument: "OEBPS/chapter1.xhtml",
+			NewIndex:    2,
+			New:         "Except at rare intervals, when it was checked by a violent gust of wind.",
+		},
+		{Kind: ParagraphRemoved, OldDocument: "OEBPS/chapter2.xhtml", OldIndex: 1, Old: "A paragraph cut from the second edition.", NewIndex: -1},
+		{Kind: ParagraphAdded, OldIndex: -1, NewDocument: "OEBPS/chapter2.xhtml", NewIndex: 2, New: "An entirely new paragraph."},
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("Changes = %+v", diff.Changes)
+	}
+
+	chapters := []ChapterAlignment{
+		{Old: "OEBPS/chapter1.xhtml", New: []string{"OEBPS/chapter1.xhtml", "OEBPS/chapter2.xhtml"}, Similarity: 0.75},
+		{Old: "OEBPS/chapter2.xhtml", New: []string{"OEBPS/chapter2.xhtml"}, Similarity: 0.5},
+	}
+	if !reflect.DeepEqual(diff.Chapters, chapters) {
+		t.Errorf("Chapters = %+v", diff.Chapters)
+	}
+
+	if diff, err := DiffText(&old.EpubReader, &old.EpubReader); err != nil || len(diff.Changes) != 0 {
+		t.Errorf("DiffText(old, old) = %+v, %v", diff.Changes, err)
+	}
+}
+
+// lcsLength returns the length of a longest common subsequence of a and b.
+func lcsLength(a, b []int) int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] > lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
+
+func TestSequenceDiffer(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sequence := func() []int {
+		s := make([]int, random.Intn(30))
+		for i := range s {
+			s[i] = random.Intn(5)
+		}
+		return s
+	}
+
+	for n := 0; n < 500; n++ {
+		a, b := sequence(), sequence()
+		differ := &sequenceDiffer{a: a, b: b}
+		differ.diff(0, len(a), 0, len(b))
+
+		if len(differ.pairs) != lcsLength(a, b) {
+			t.Fatalf("diff(%v, %v) = %v, want %d pairs", a, b, differ.pairs, lcsLength(a, b))
+		}
+		for i, pair := range differ.pairs {
+			if a[pair[0]] != b[pair[1]] || i > 0 && (pair[0] <= differ.pairs[i-1][0] || pair[1] <= differ.pairs[i-1][1]) {
+				t.Fatalf("diff(%v, %v) = %v", a, b, differ.pairs)
+			}
+		}
+	}
+}
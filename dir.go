@@ -0,0 +1,136 @@
+package epub
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dirStorage is the Storage of an unpacked book, a directory holding the
+// files of the container.
+type dirStorage struct {
+	root  string
+	names []string
+}
+
+// OpenDir opens an unpacked book: a directory holding a mimetype file,
+// META-INF/container.xml and the package, as produced by ExtractAll or by
+// publishing tools.
+func OpenDir(dir string) (*EpubReaderCloser, error) {
+	storage, err := newDirStorage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := new(EpubReaderCloser)
+	reader.Name = dir
+	if err := reader.init(storage); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// newDirStorage lists the regular files below root. Names are sorted, with
+// the mimetype first as in a zip container.
+func newDirStorage(root string) (*dirStorage, error) {
+	storage := &dirStorage{root: root}
+
+	err := filepath.Walk(longPath(root), func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(longPath(root), name)
+		if err != nil {
+			return err
+		}
+		storage.names = append(storage.names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(storage.names, func(i, j int) bool {
+		if storage.names[i] == mimetypePath || storage.names[j] == mimetypePath {
+			return storage.names[i] == mimetypePath
+		}
+		return storage.names[i] < storage.names[j]
+	})
+
+	return storage, nil
+}
+
+// path returns the file of the storage for name, refusing names that are
+// not valid fs paths and could escape the root, and names through symbolic
+// links, which could lead out of it.
+func (storage *dirStorage) path(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	filename := storage.root
+	if name == "." {
+		return longPath(filename), nil
+	}
+	for _, element := range strings.Split(name, "/") {
+		filename = filepath.Join(filename, element)
+		info, err := os.Lstat(longPath(filename))
+		if err != nil {
+			// Missing files are reported by the caller.
+			break
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", &fs.PathError{Op: op, Path: name, Err: ErrUnsafePath}
+		}
+	}
+
+	return longPath(filepath.Join(storage.root, filepath.FromSlash(name))), nil
+}
+
+func (storage *dirStorage) Open(name string) (io.ReadCloser, error) {
+	filename, err := storage.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filename)
+}
+
+func (storage *dirStorage) Stat(name string) (fs.FileInfo, error) {
+	filename, err := storage.path("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Stat(filename)
+}
+
+func (storage *dirStorage) Names() []string {
+	return storage.names
+}
+
+// FS returns the files of the storage; unlike os.DirFS, it does not
+// follow symbolic links.
+func (storage *dirStorage) FS() fs.FS {
+	return dirFS{storage}
+}
+
+type dirFS struct {
+	storage *dirStorage
+}
+
+func (fsys dirFS) Open(name string) (fs.File, error) {
+	filename, err := fsys.storage.path("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filename)
+}
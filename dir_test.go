@@ -0,0 +1,92 @@
+package epub
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenDir(t *testing.T) {
+	dir := t.TempDir()
+	zipped := openTestEpub(t)
+	if err := zipped.ExtractAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	if firstTitle(reader.Rootfiles[0].Metadata.Title) != "The Test Book" {
+		t.Errorf("Title = %q", firstTitle(reader.Rootfiles[0].Metadata.Title))
+	}
+	names := reader.FileNames()
+	if len(names) != len(zipped.FileNames()) || names[0] != mimetypePath {
+		t.Errorf("FileNames() = %v", names)
+	}
+
+	toc, err := reader.TOC()
+	want, _ := zipped.TOC()
+	if err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("TOC() = %+v, %v", toc, err)
+	}
+	if text, err := reader.ExtractText(TextOptions{}); err != nil || text == "" {
+		t.Errorf("ExtractText() = %q, %v", text, err)
+	}
+
+	if _, err := reader.Storage().Open("../outside"); err == nil {
+		t.Error("Open(../outside) = no error")
+	}
+	if _, err := OpenDir(t.TempDir()); err == nil {
+		t.Error("OpenDir(empty) = no error")
+	}
+}
+
+func TestOpenDirSymlink(t *testing.T) {
+	dir := t.TempDir()
+	if err := openTestEpub(t).ExtractAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(secret, []byte("SECRET"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(dir, "OEBPS", "leak.txt")); err != nil {
+		t.Skip(err)
+	}
+	if err := os.Symlink(filepath.Dir(secret), filepath.Join(dir, "OEBPS", "outside")); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	for _, name := range []string{"OEBPS/leak.txt", "OEBPS/outside/secret.txt"} {
+		if _, err := reader.Storage().Open(name); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("Open(%s) = %v, want ErrUnsafePath", name, err)
+		}
+		if _, err := reader.Storage().Stat(name); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("Stat(%s) = %v, want ErrUnsafePath", name, err)
+		}
+		if _, err := fs.ReadFile(reader.FS(), name); err == nil {
+			t.Errorf("ReadFile(%s) = no error", name)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	reader.HTTPHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/leak.txt", nil))
+	if recorder.Code == http.StatusOK || strings.Contains(recorder.Body.String(), "SECRET") {
+		t.Errorf("GET /leak.txt = %d %q", recorder.Code, recorder.Body)
+	}
+}
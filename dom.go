@@ -0,0 +1,181 @@
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// domNode is an element or a text node of a parsed content document. Text
+// offsets count runes in the concatenated text of the document.
+type domNode struct {
+	// name is the local name of an element, "#document" for the document
+	// node and empty for text nodes.
+	name     string
+	attrs    []xml.Attr
+	parent   *domNode
+	children []*domNode
+	text     string
+	start    int
+	end      int
+}
+
+// parseDOM parses a content document into a tree. The returned node is the
+// document itself, whose element children are usually a single html
+// element. Adjacent character data is merged into one text node.
+func parseDOM(r io.Reader) (_ *domNode, err error) {
+	end := startSpan("parse", "")
+	defer func() { end(err) }()
+
+	document := &domNode{name: "#document"}
+	current := document
+
+	decoder := newXHTMLDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return document, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &domNode{name: strings.ToLower(t.Name.Local), attrs: t.Attr, parent: current}
+			current.children = append(current.children, element)
+			current = element
+		case xml.EndElement:
+			if current.parent != nil {
+				current = current.parent
+			}
+		case xml.CharData:
+			if current == document {
+				continue
+			}
+			if n := len(current.children); n > 0 && current.children[n-1].name == "" {
+				current.children[n-1].text += string(t)
+			} else {
+				current.children = append(current.children, &domNode{text: string(t), parent: current})
+			}
+		}
+	}
+
+	document.index(0)
+
+	return document, nil
+}
+
+// index sets the text offsets of the node and its descendants, starting at
+// offset, and returns the offset following the node.
+func (node *domNode) index(offset int) int {
+	node.start = offset
+	if node.name == "" {
+		offset += len([]rune(node.text))
+	}
+	for _, child := range node.children {
+		offset = child.index(offset)
+	}
+	node.end = offset
+
+	return offset
+}
+
+// attr returns the value of the attribute with the given local name.
+func (node *domNode) attr(name string) string {
+	for _, a := range node.attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// elements returns the element children of the node.
+func (node *domNode) elements() []*domNode {
+	var elements []*domNode
+	for _, child := range node.children {
+		if child.name != "" {
+			elements = append(elements, child)
+		}
+	}
+
+	return elements
+}
+
+// textContent returns the concatenated text of the node.
+func (node *domNode) textContent() string {
+	if node.name == "" {
+		return node.text
+	}
+
+	var b strings.Builder
+	node.walk(func(n *domNode) bool {
+		if n.name == "" {
+			b.WriteString(n.text)
+		}
+		return true
+	})
+
+	return b.String()
+}
+
+// walk calls fn on the node and its descendants in document order, not
+// descending into nodes for which fn returns false.
+func (node *domNode) walk(fn func(*domNode) bool) {
+	if !fn(node) {
+		return
+	}
+	for _, child := range node.children {
+		child.walk(fn)
+	}
+}
+
+// find returns the first node, in document order, for which match is true.
+func (node *domNode) find(match func(*domNode) bool) *domNode {
+	var found *domNode
+	node.walk(func(n *domNode) bool {
+		if found == nil && match(n) {
+			found = n
+		}
+		return found == nil
+	})
+
+	return found
+}
+
+// byID returns the element with the given id attribute.
+func (node *domNode) byID(id string) *domNode {
+	return node.find(func(n *domNode) bool { return n.name != "" && n.attr("id") == id })
+}
+
+// textBetween returns the document text between the offsets start and end.
+func (node *domNode) textBetween(start, end int) string {
+	var b strings.Builder
+	node.walk(func(n *domNode) bool {
+		if n.end <= start || n.start >= end {
+			return false
+		}
+		if n.name == "" {
+			runes := []rune(n.text)
+			from, to := start-n.start, end-n.start
+			if from < 0 {
+				from = 0
+			}
+			if to > len(runes) {
+				to = len(runes)
+			}
+			b.WriteString(string(runes[from:to]))
+		}
+		return true
+	})
+
+	return b.String()
+}
+
+// collapseSpace replaces runs of white space with a single space and trims
+// the result.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,168 @@
+package epub
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Files of the container describing the encryption of a book.
+const (
+	EncryptionPath = "META-INF/encryption.xml"
+	// RightsPath holds the Adobe ADEPT license.
+	RightsPath = "META-INF/rights.xml"
+	// LCPLicensePath holds the Readium LCP license.
+	LCPLicensePath = "META-INF/license.lcpl"
+	// FairPlayPath holds the Apple FairPlay keys.
+	FairPlayPath = "META-INF/sinf.xml"
+)
+
+// DRMScheme is the scheme protecting or obfuscating a resource.
+type DRMScheme string
+
+const (
+	DRMAdobeADEPT DRMScheme = "adobe-adept"
+	DRMReadiumLCP DRMScheme = "readium-lcp"
+	DRMFairPlay   DRMScheme = "apple-fairplay"
+	// DRMIDPFFontObfuscation and DRMAdobeFontObfuscation mangle the
+	// embedded fonts with the identifier of the book; they are not DRM
+	// and reading systems undo them.
+	DRMIDPFFontObfuscation  DRMScheme = "idpf-font-obfuscation"
+	DRMAdobeFontObfuscation DRMScheme = "adobe-font-obfuscation"
+	DRMUnknown              DRMScheme = "unknown"
+)
+
+// Algorithms of the encryption methods of encryption.xml.
+const (
+	idpfObfuscationAlgorithm  = "http://www.idpf.org/2008/embedding"
+	adobeObfuscationAlgorithm = "http://ns.adobe.com/pdf/enc#RC"
+	adeptNamespace            = "http://ns.adobe.com/adept"
+	lcpKeyType                = "http://readium.org/2014/01/lcp#EncryptedContentKey"
+)
+
+// EncryptedResource is a file of the container listed in encryption.xml.
+type EncryptedResource struct {
+	// Path is the zip path of the file.
+	Path      string
+	Algorithm string
+	Scheme    DRMScheme
+}
+
+// Obfuscated reports whether the resource is a font obfuscated rather than
+// encrypted.
+func (resource EncryptedResource) Obfuscated() bool {
+	return resource.Scheme == DRMIDPFFontObfuscation || resource.Scheme == DRMAdobeFontObfuscation
+}
+
+// DRM describes the encryption of a book.
+type DRM struct {
+	// Encrypted is set when resources are encrypted, not merely
+	// obfuscated, or a license of a DRM scheme is present.
+	Encrypted bool
+	// Scheme is the DRM scheme of the book, empty when it has none.
+	Scheme DRMScheme
+	// Resources are the resources listed in encryption.xml, obfuscated
+	// fonts included, in document order.
+	Resources []EncryptedResource
+}
+
+// Protects reports whether the file name, a zip path, is encrypted or
+// obfuscated, and cannot be read as is.
+func (drm DRM) Protects(name string) bool {
+	for _, resource := range drm.Resources {
+		if resource.Path == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encryptionDocument is the content of encryption.xml.
+type encryptionDocument struct {
+	EncryptedData []struct {
+		EncryptionMethod struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+		KeyInfo struct {
+			RetrievalMethod struct {
+				Type string `xml:"Type,attr"`
+			} `xml:"RetrievalMethod"`
+			Other []struct {
+				XMLName xml.Name
+			} `xml:",any"`
+		} `xml:"KeyInfo"`
+		CipherReference struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"CipherData>CipherReference"`
+	} `xml:"EncryptedData"`
+}
+
+// DRM returns the encryption of the book, read from encryption.xml and
+// the licenses of the DRM schemes in META-INF, so that protected books can
+// be skipped or flagged before their content fails to read. A book
+// without encryption.xml nor license has a zero DRM.
+func (epubReader *EpubReader) DRM() (DRM, error) {
+	var drm DRM
+	switch {
+	case epubReader.hasFile(LCPLicensePath):
+		drm.Scheme = DRMReadiumLCP
+	case epubReader.hasFile(RightsPath):
+		drm.Scheme = DRMAdobeADEPT
+	case epubReader.hasFile(FairPlayPath):
+		drm.Scheme = DRMFairPlay
+	}
+	drm.Encrypted = drm.Scheme != ""
+
+	buffer, err := epubReader.readFile(EncryptionPath)
+	if errors.Is(err, ErrorFileMissing) {
+		return drm, nil
+	}
+	if err != nil {
+		return drm, err
+	}
+	var document encryptionDocument
+	if err := decodeXML(EncryptionPath, buffer.Bytes(), &document); err != nil {
+		return drm, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, EncryptionPath, err)
+	}
+
+	for _, data := range document.EncryptedData {
+		uri := strings.TrimSpace(data.CipherReference.URI)
+		if name, err := url.PathUnescape(uri); err == nil {
+			uri = name
+		}
+		resource := EncryptedResource{
+			Path:      strings.TrimPrefix(uri, "/"),
+			Algorithm: strings.TrimSpace(data.EncryptionMethod.Algorithm),
+		}
+
+		switch resource.Algorithm {
+		case idpfObfuscationAlgorithm:
+			resource.Scheme = DRMIDPFFontObfuscation
+		case adobeObfuscationAlgorithm:
+			resource.Scheme = DRMAdobeFontObfuscation
+		default:
+			resource.Scheme = drm.Scheme
+			if strings.TrimSpace(data.KeyInfo.RetrievalMethod.Type) == lcpKeyType {
+				resource.Scheme = DRMReadiumLCP
+			}
+			for _, element := range data.KeyInfo.Other {
+				if element.XMLName.Space == adeptNamespace {
+					resource.Scheme = DRMAdobeADEPT
+				}
+			}
+			if resource.Scheme == "" {
+				resource.Scheme = DRMUnknown
+			}
+			drm.Encrypted = true
+			if drm.Scheme == "" || drm.Scheme == DRMUnknown {
+				drm.Scheme = resource.Scheme
+			}
+		}
+		drm.Resources = append(drm.Resources, resource)
+	}
+
+	return drm, nil
+}
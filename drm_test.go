@@ -0,0 +1,70 @@
+package epub
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDRM(t *testing.T) {
+	const obfuscated = `<?xml version="1.0" encoding="UTF-8"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.idpf.org/2008/embedding"/>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/fonts/Serif%20Bold.otf"/></enc:CipherData>
+  </enc:EncryptedData>
+</encryption>`
+	const adept = `<?xml version="1.0" encoding="UTF-8"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+    <KeyInfo xmlns="http://www.w3.org/2000/09/xmldsig#"><resource xmlns="http://ns.adobe.com/adept">urn:uuid:7b0a1e30-0000-0000-0000-000000000000</resource></KeyInfo>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/chapter1.xhtml"/></enc:CipherData>
+  </enc:EncryptedData>
+</encryption>`
+	const lcp = `<?xml version="1.0" encoding="UTF-8"?>
+<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#" xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+  <enc:EncryptedData>
+    <enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes256-cbc"/>
+    <ds:KeyInfo><ds:RetrievalMethod URI="license.lcpl#/encryption/content_key" Type="http://readium.org/2014/01/lcp#EncryptedContentKey"/></ds:KeyInfo>
+    <enc:CipherData><enc:CipherReference URI="OEBPS/chapter2.xhtml"/></enc:CipherData>
+  </enc:EncryptedData>
+</encryption>`
+
+	for _, test := range []struct {
+		name  string
+		files []testFile
+		want  DRM
+	}{
+		{"none", nil, DRM{}},
+		{"obfuscated", []testFile{{EncryptionPath, obfuscated}}, DRM{
+			Resources: []EncryptedResource{{"OEBPS/fonts/Serif Bold.otf", idpfObfuscationAlgorithm, DRMIDPFFontObfuscation}},
+		}},
+		{"adept", []testFile{{EncryptionPath, adept}, {RightsPath, "<rights/>"}}, DRM{
+			Encrypted: true,
+			Scheme:    DRMAdobeADEPT,
+			Resources: []EncryptedResource{{"OEBPS/chapter1.xhtml", "http://www.w3.org/2001/04/xmlenc#aes128-cbc", DRMAdobeADEPT}},
+		}},
+		{"lcp", []testFile{{EncryptionPath, lcp}}, DRM{
+			Encrypted: true,
+			Scheme:    DRMReadiumLCP,
+			Resources: []EncryptedResource{{"OEBPS/chapter2.xhtml", "http://www.w3.org/2001/04/xmlenc#aes256-cbc", DRMReadiumLCP}},
+		}},
+		{"fairplay", []testFile{{FairPlayPath, "<fairplay:sinf/>"}}, DRM{Encrypted: true, Scheme: DRMFairPlay}},
+	} {
+		reader := openTestEpub(t, test.files...)
+		drm, err := reader.DRM()
+		if err != nil {
+			t.Fatalf("%s: DRM() = %v", test.name, err)
+		}
+		if !reflect.DeepEqual(drm, test.want) {
+			t.Errorf("%s: DRM() = %+v, want %+v", test.name, drm, test.want)
+		}
+		if len(test.want.Resources) > 0 && !drm.Protects(test.want.Resources[0].Path) {
+			t.Errorf("%s: Protects(%s) = false", test.name, test.want.Resources[0].Path)
+		}
+	}
+
+	if _, err := openTestEpub(t, testFile{EncryptionPath, "<encryption>"}).DRM(); err == nil {
+		t.Error("DRM() with a malformed encryption.xml succeeded")
+	}
+}
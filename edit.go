@@ -0,0 +1,523 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoUniqueIdentifier occurs when the edited identifiers of a book lack
+// the one the unique-identifier attribute of its package names.
+var ErrNoUniqueIdentifier = errors.New("epub: unique identifier removed")
+
+var mediaTypeAttrPattern = regexp.MustCompile(`media-type\s*=\s*("[^"]*"|'[^']*')`)
+
+// coverExtensions are the extensions of the cover images SetCover takes.
+var coverExtensions = map[string]string{
+	"image/jpeg":    ".jpg",
+	"image/png":     ".png",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/svg+xml": ".svg",
+}
+
+// pendingCover is a cover image set by SetCover.
+type pendingCover struct {
+	mediaType string
+	data      []byte
+}
+
+// SetCover sets the cover image of the book, written by WriteEdited and
+// SaveAs: it replaces the file of the cover item, or is added to the
+// manifest as the cover when the book has none.
+func (epubReader *EpubReader) SetCover(mediaType string, r io.Reader) error {
+	if _, ok := coverExtensions[mediaType]; !ok {
+		return fmt.Errorf("epub: %s: cover: unsupported media type '%s'", epubReader.Name, mediaType)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("epub: %s: cover: %w", epubReader.Name, err)
+	}
+	epubReader.cover = &pendingCover{mediaType: mediaType, data: data}
+
+	return nil
+}
+
+// SaveAs writes the book with its edits, as WriteEdited does, to
+// filename, with the guarantees of SafeWriteFile. filename may be the file
+// the book was read from.
+func (epubReader *EpubReader) SaveAs(filename string) error {
+	var buffer bytes.Buffer
+	if err := epubReader.WriteEdited(&buffer); err != nil {
+		return err
+	}
+
+	return SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// WriteEdited writes to w a copy of the book with the changes made to the
+// metadata of the active rendition, Rootfiles[0].Metadata, and the cover
+// set by SetCover. Only the metadata elements of the changed fields are
+// rewritten in the package document, all the elements of a field at the
+// place of the first one, and the meta elements SetTranslation sets; EPUB 3
+// refinements of removed creators and identifiers are removed, those of
+// the others are kept unless replaced. The other files keep their
+// content, and the mimetype stays first and stored.
+func (epubReader *EpubReader) WriteEdited(w io.Writer) error {
+	rootfile := epubReader.Rootfiles[0]
+	buffer, err := epubReader.readFile(rootfile.FullPath)
+	if err != nil {
+		return err
+	}
+	var original Package
+	if err := decodeXML(rootfile.FullPath, buffer.Bytes(), &original); err != nil {
+		return fmt.Errorf("epub: %s: %w", epubReader.Name, err)
+	}
+
+	editor := newPackageEditor(buffer.Bytes(), epubReader.MajorVersion() >= 3)
+	before, after := original.Metadata, rootfile.Metadata
+	editor.elements("title", titleElements(before.Title), titleElements(after.Title))
+	for _, field := range []struct {
+		local         string
+		before, after []DCElement
+	}{
+		{"language", before.Language, after.Language},
+		{"publisher", before.Publisher, after.Publisher},
+		{"description", before.Description, after.Description},
+		{"source", before.Source, after.Source},
+		{"subject", before.Subject, after.Subject},
+		{"type", before.Type, after.Type},
+		{"format", before.Format, after.Format},
+		{"relation", before.Relation, after.Relation},
+		{"coverage", before.Coverage, after.Coverage},
+		{"rights", before.Rights, after.Rights},
+	} {
+		editor.elements(field.local, field.before, field.after)
+	}
+	if !reflect.DeepEqual(before.Date, after.Date) {
+		editor.dates(after.Date)
+	}
+	if !reflect.DeepEqual(before.Creator, after.Creator) {
+		editor.creators("creator", before.Creator, after.Creator)
+	}
+	if !reflect.DeepEqual(before.Contributor, after.Contributor) {
+		editor.creators("contributor", before.Contributor, after.Contributor)
+	}
+	if !reflect.DeepEqual(before.Identifier, after.Identifier) {
+		unique := false
+		for _, identifier := range after.Identifier {
+			unique = unique || identifier.ID == rootfile.UniqueIdentifier && strings.TrimSpace(identifier.Text) != ""
+		}
+		if !unique && rootfile.UniqueIdentifier != "" {
+			return fmt.Errorf("epub: %s: %w: '%s'", epubReader.Name, ErrNoUniqueIdentifier, rootfile.UniqueIdentifier)
+		}
+		editor.identifiers(before.Identifier, after.Identifier)
+	}
+	for _, keys := range [][]string{originalTitleKeys, originalLanguageKeys} {
+		editor.namedMeta(keys, findMeta(before.Meta, keys...), findMeta(after.Meta, keys...))
+	}
+	if !reflect.DeepEqual(before.Link, after.Link) {
+		var markups []string
+		for _, link := range after.Link {
+			markups = append(markups, link.markup())
+		}
+		editor.replace("link", markups)
+	}
+
+	replacements := make(map[string][]byte)
+	if cover := epubReader.cover; cover != nil {
+		if item, ok := epubReader.Cover(); ok {
+			replacements[epubReader.itemPath(item.Href)] = cover.data
+			if item.MediaType != cover.mediaType {
+				editor.setItemMediaType(item.ID, cover.mediaType)
+			}
+		} else {
+			href := "images/cover" + coverExtensions[cover.mediaType]
+			for n := 2; epubReader.hasFile(epubReader.itemPath(href)); n++ {
+				href = "images/cover-" + strconv.Itoa(n) + coverExtensions[cover.mediaType]
+			}
+			id := editor.newID("cover-image")
+			properties := ""
+			if editor.epub3 {
+				properties = ` properties="cover-image"`
+			}
+			editor.insert("manifest", fmt.Sprintf(`<item id="%s" href="%s" media-type="%s"%s/>`, id, escapeXML(escapeHref(href)), cover.mediaType, properties))
+			editor.insert("metadata", fmt.Sprintf(`<meta name="cover" content="%s"/>`, id))
+			replacements[epubReader.itemPath(href)] = cover.data
+		}
+	}
+
+	replacements[rootfile.FullPath] = editor.apply()
+
+	return epubReader.writeZip(w, replacements)
+}
+
+// packageEdit replaces the bytes from start to end of a package document
+// with text.
+type packageEdit struct {
+	start, end int
+	text       string
+}
+
+// packageEditor collects the edits of a package document.
+type packageEditor struct {
+	data  []byte
+	epub3 bool
+	edits []packageEdit
+	// ids holds the ids of the document and those given to new elements.
+	ids map[string]bool
+	// expansions holds the self-closing elements given content, by name.
+	expansions map[string]*packageExpansion
+}
+
+// packageExpansion is the edit replacing a self-closing element of a
+// package document with an element holding markups.
+type packageExpansion struct {
+	edit                 int
+	open, markups, close string
+}
+
+func newPackageEditor(data []byte, epub3 bool) *packageEditor {
+	editor := &packageEditor{data: data, epub3: epub3, ids: make(map[string]bool), expansions: make(map[string]*packageExpansion)}
+
+	decoder := newXMLDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if id := attr(start, "id"); id != "" {
+				editor.ids[id] = true
+			}
+		}
+	}
+
+	return editor
+}
+
+// newID returns an unused id starting with base.
+func (editor *packageEditor) newID(base string) string {
+	id := base
+	for n := 2; editor.ids[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	editor.ids[id] = true
+
+	return id
+}
+
+// children returns the spans of the elements named local of the element
+// named parent.
+func (editor *packageEditor) children(parent, local string) []markupSpan {
+	return findXMLElements(editor.data, func(stack []xml.StartElement) bool {
+		n := len(stack)
+		return n > 1 && stack[n-2].Name.Local == parent && stack[n-1].Name.Local == local
+	}, -1)
+}
+
+// qualifiedName returns the name of the element named local, with the
+// prefix of the existing elements of its kind, or else dc.
+func (editor *packageEditor) qualifiedName(spans []markupSpan, local string) string {
+	if len(spans) > 0 {
+		return editor.nameAt(spans[0].start)
+	}
+
+	return "dc:" + local
+}
+
+// nameAt returns the name of the element whose start tag is at offset.
+func (editor *packageEditor) nameAt(offset int) string {
+	start := offset + 1
+	end := start
+	for end < len(editor.data) && !isCSSSpace(editor.data[end]) && editor.data[end] != '/' && editor.data[end] != '>' {
+		end++
+	}
+
+	return string(editor.data[start:end])
+}
+
+// dcElement returns the markup of a Dublin Core element with extra
+// attributes.
+func (editor *packageEditor) dcElement(local string, element DCElement, attributes string) string {
+	name := editor.qualifiedName(editor.children("metadata", local), local)
+	var b strings.Builder
+	b.WriteString("<" + name)
+	if element.ID != "" {
+		b.WriteString(` id="` + escapeXML(element.ID) + `"`)
+	}
+	if element.Lang != "" {
+		b.WriteString(` xml:lang="` + escapeXML(element.Lang) + `"`)
+	}
+	if element.Dir != "" {
+		b.WriteString(` dir="` + escapeXML(element.Dir) + `"`)
+	}
+	b.WriteString(attributes + ">" + escapeXML(element.Text) + "</" + name + ">")
+
+	return b.String()
+}
+
+// elements replaces the metadata elements named local when they changed.
+func (editor *packageEditor) elements(local string, before, after []DCElement) {
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+
+	var markups []string
+	for _, element := range after {
+		markups = append(markups, editor.dcElement(local, element, ""))
+	}
+	editor.replace(local, markups)
+}
+
+// titleElements returns the elements of titles, whose types are
+// refinements kept with their ids.
+func titleElements(titles []DCTitle) []DCElement {
+	var elements []DCElement
+	for _, title := range titles {
+		elements = append(elements, title.DCElement)
+	}
+
+	return elements
+}
+
+// dates replaces the date elements, with their EPUB 2 events.
+func (editor *packageEditor) dates(dates []DCDate) {
+	var markups []string
+	for _, date := range dates {
+		var attributes string
+		if !editor.epub3 && date.Event != "" {
+			attributes = ` opf:event="` + escapeXML(date.Event) + `"`
+		}
+		markups = append(markups, editor.dcElement("date", date.DCElement, attributes))
+	}
+	editor.replace("date", markups)
+}
+
+// replace replaces the metadata elements named local with markups, at the
+// place of the first one.
+func (editor *packageEditor) replace(local string, markups []string) {
+	spans := editor.children("metadata", local)
+	if len(spans) == 0 {
+		for _, markup := range markups {
+			editor.insert("metadata", markup)
+		}
+		return
+	}
+
+	if len(markups) == 0 {
+		editor.remove(spans[0])
+	} else {
+		indent := lineIndent(editor.data, spans[0].start)
+		editor.edits = append(editor.edits, packageEdit{spans[0].start, spans[0].end, strings.Join(markups, "\n"+indent)})
+	}
+	for _, span := range spans[1:] {
+		editor.remove(span)
+	}
+}
+
+// remove removes an element, with its line when it is alone on it.
+func (editor *packageEditor) remove(span markupSpan) {
+	start, end := lineSpan(editor.data, span.start, span.end)
+	editor.edits = append(editor.edits, packageEdit{start, end, ""})
+}
+
+// insert adds markup at the end of the element named parent.
+func (editor *packageEditor) insert(parent, markup string) {
+	spans := findXMLElements(editor.data, func(stack []xml.StartElement) bool { return stack[len(stack)-1].Name.Local == parent }, 1)
+	if len(spans) == 0 {
+		return
+	}
+	span := spans[0]
+
+	indent := lineIndent(editor.data, span.start) + "  "
+	if span.inner == span.end {
+		// A self-closing element, such as <metadata/>, is replaced by a
+		// start and an end tag around the markups inserted.
+		expansion, ok := editor.expansions[parent]
+		if !ok {
+			tag := string(editor.data[span.start:span.end])
+			expansion = &packageExpansion{
+				edit:  len(editor.edits),
+				open:  strings.TrimRight(strings.TrimSuffix(tag, "/>"), " \t\r\n") + ">",
+				close: "\n" + lineIndent(editor.data, span.start) + "</" + editor.nameAt(span.start) + ">",
+			}
+			if parent == "metadata" && !strings.Contains(tag, "xmlns:dc") {
+				expansion.open = strings.TrimSuffix(expansion.open, ">") + ` xmlns:dc="http://purl.org/dc/elements/1.1/">`
+			}
+			editor.expansions[parent] = expansion
+			editor.edits = append(editor.edits, packageEdit{start: span.start, end: span.end})
+		}
+		expansion.markups += "\n" + indent + markup
+		editor.edits[expansion.edit].text = expansion.open + expansion.markups + expansion.close
+		return
+	}
+
+	if closing := lineIndent(editor.data, span.innerEnd); span.innerEnd-len(closing) > 0 && editor.data[span.innerEnd-len(closing)-1] == '\n' {
+		at := span.innerEnd - len(closing)
+		editor.edits = append(editor.edits, packageEdit{at, at, indent + markup + "\n"})
+		return
+	}
+	editor.edits = append(editor.edits, packageEdit{span.innerEnd, span.innerEnd, markup})
+}
+
+// refinements removes the EPUB 3 meta elements refining the ids of removed
+// elements, and those of the kept ones whose property is in replaced.
+func (editor *packageEditor) refinements(removed map[string]bool, replaced map[string]map[string]bool) {
+	for _, span := range editor.children("metadata", "meta") {
+		id := strings.TrimPrefix(strings.TrimSpace(attr(span.element, "refines")), "#")
+		if id == "" {
+			continue
+		}
+		if removed[id] || replaced[id][strings.TrimSpace(attr(span.element, "property"))] {
+			editor.remove(span)
+		}
+	}
+}
+
+// refine returns the markup of an EPUB 3 meta element refining id.
+func refine(id, property, scheme, value string) string {
+	if scheme != "" {
+		scheme = ` scheme="` + scheme + `"`
+	}
+
+	return `<meta refines="#` + escapeXML(id) + `" property="` + property + `"` + scheme + `>` + escapeXML(value) + `</meta>`
+}
+
+// creators replaces the creator or contributor elements.
+func (editor *packageEditor) creators(local string, before, after []Creator) {
+	removed := make(map[string]bool)
+	for _, creator := range before {
+		if creator.ID != "" {
+			removed[creator.ID] = true
+		}
+	}
+	replaced := make(map[string]map[string]bool)
+
+	var markups []string
+	for _, creator := range after {
+		if !editor.epub3 {
+			var attributes string
+			if creator.Role != "" {
+				attributes += ` opf:role="` + escapeXML(creator.Role) + `"`
+			}
+			if creator.FileAs != "" {
+				attributes += ` opf:file-as="` + escapeXML(creator.FileAs) + `"`
+			}
+			markups = append(markups, editor.dcElement(local, creator.DCElement, attributes))
+			continue
+		}
+
+		if creator.ID == "" && (creator.Role != "" || creator.FileAs != "") {
+			creator.ID = editor.newID(local)
+		}
+		delete(removed, creator.ID)
+		replaced[creator.ID] = map[string]bool{"role": creator.Role != "", "file-as": creator.FileAs != ""}
+		markups = append(markups, editor.dcElement(local, creator.DCElement, ""))
+		if creator.Role != "" {
+			markups = append(markups, refine(creator.ID, "role", "marc:relators", creator.Role))
+		}
+		if creator.FileAs != "" {
+			markups = append(markups, refine(creator.ID, "file-as", "", creator.FileAs))
+		}
+	}
+	if editor.epub3 {
+		editor.refinements(removed, replaced)
+	}
+	editor.replace(local, markups)
+}
+
+// identifiers replaces the identifier elements.
+func (editor *packageEditor) identifiers(before, after []DCIdentifier) {
+	removed := make(map[string]bool)
+	for _, identifier := range before {
+		if identifier.ID != "" {
+			removed[identifier.ID] = true
+		}
+	}
+	replaced := make(map[string]map[string]bool)
+
+	var markups []string
+	for _, identifier := range after {
+		if !editor.epub3 {
+			var attributes string
+			if identifier.Scheme != "" {
+				attributes = ` opf:scheme="` + escapeXML(identifier.Scheme) + `"`
+			}
+			markups = append(markups, editor.dcElement("identifier", identifier.DCElement, attributes))
+			continue
+		}
+
+		if identifier.ID == "" && identifier.Scheme != "" {
+			identifier.ID = editor.newID("identifier")
+		}
+		delete(removed, identifier.ID)
+		replaced[identifier.ID] = map[string]bool{"identifier-type": identifier.Scheme != ""}
+		markups = append(markups, editor.dcElement("identifier", identifier.DCElement, ""))
+		if identifier.Scheme != "" {
+			markups = append(markups, refine(identifier.ID, "identifier-type", "", identifier.Scheme))
+		}
+	}
+	if editor.epub3 {
+		editor.refinements(removed, replaced)
+	}
+	editor.replace("identifier", markups)
+}
+
+// namedMeta replaces the meta elements whose key is one of keys with one
+// named keys[0], when their value changed.
+func (editor *packageEditor) namedMeta(keys []string, before, after string) {
+	if before == after {
+		return
+	}
+
+	for _, span := range editor.children("metadata", "meta") {
+		if attr(span.element, "refines") == "" && containsString(keys, metaKey(attr(span.element, "property"), attr(span.element, "name"))) {
+			editor.remove(span)
+		}
+	}
+	if after != "" {
+		editor.insert("metadata", `<meta name="`+keys[0]+`" content="`+escapeXML(after)+`"/>`)
+	}
+}
+
+// setItemMediaType sets the media type of the manifest item id.
+func (editor *packageEditor) setItemMediaType(id, mediaType string) {
+	for _, span := range editor.children("manifest", "item") {
+		if attr(span.element, "id") == id {
+			tag := mediaTypeAttrPattern.ReplaceAllLiteralString(string(editor.data[span.start:span.inner]), `media-type="`+escapeXML(mediaType)+`"`)
+			editor.edits = append(editor.edits, packageEdit{span.start, span.inner, tag})
+			return
+		}
+	}
+}
+
+// apply returns the document with its edits. Edits overlapping a previous
+// one are dropped.
+func (editor *packageEditor) apply() []byte {
+	sort.SliceStable(editor.edits, func(i, j int) bool { return editor.edits[i].start < editor.edits[j].start })
+
+	var b bytes.Buffer
+	end := 0
+	for _, edit := range editor.edits {
+		if edit.start < end {
+			continue
+		}
+		b.Write(editor.data[end:edit.start])
+		b.WriteString(edit.text)
+		end = edit.end
+	}
+	b.Write(editor.data[end:])
+
+	return b.Bytes()
+}
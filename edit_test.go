@@ -0,0 +1,232 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveAs(t *testing.T) {
+	reader := openTestEpub(t)
+	metadata := &reader.Rootfiles[0].Metadata
+	metadata.Title[0].Text = "The Edited Book"
+	metadata.Creator = append(metadata.Creator, Creator{DCElement: DCElement{Text: "John Roe"}, Role: "ill"})
+	metadata.Identifier = append(metadata.Identifier, DCIdentifier{DCElement: DCElement{Text: "urn:uuid:0f5d1e2c-3b4a-4c5d-8e6f-7a8b9c0d1e2f"}})
+	metadata.Subject = []DCElement{{Text: "Fiction"}}
+	if err := reader.SetCover("image/gif", strings.NewReader("not a jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.SetCover("image/bmp", strings.NewReader("")); err == nil {
+		t.Error("SetCover(image/bmp) succeeded")
+	}
+
+	filename := filepath.Join(t.TempDir(), "edited.epub")
+	if err := reader.SaveAs(filename); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+
+	archive, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	if first := archive.File[0]; first.Name != mimetypePath || first.Method != zip.Store {
+		t.Errorf("first entry = %s, method %d", first.Name, first.Method)
+	}
+	got := saved.Rootfiles[0].Metadata
+	if firstTitle(got.Title) != "The Edited Book" || firstText(got.Publisher) != "Test Press" || len(got.Subject) != 1 {
+		t.Errorf("metadata = %+v", got)
+	}
+	if len(got.Creator) != 2 || got.Creator[0].FileAs != "Doe, Jane" || got.Creator[1].Text != "John Roe" || got.Creator[1].Role != "ill" {
+		t.Errorf("creators = %+v", got.Creator)
+	}
+	if identifiers := saved.Identifiers(); len(identifiers) != 2 || !identifiers[0].Unique || identifiers[1].Type != IdentifierUUID {
+		t.Errorf("identifiers = %+v", identifiers)
+	}
+	if item, ok := saved.Cover(); !ok || item.MediaType != "image/gif" {
+		t.Errorf("Cover() = %+v, %v", item, ok)
+	}
+	if cover, _ := saved.readFile("OEBPS/images/cover.jpg"); cover.String() != "not a jpeg" {
+		t.Errorf("cover = %q", cover)
+	}
+	for _, name := range []string{"OEBPS/chapter1.xhtml", "OEBPS/toc.ncx"} {
+		before, _ := reader.readFile(name)
+		after, _ := saved.readFile(name)
+		if !bytes.Equal(before.Bytes(), after.Bytes()) {
+			t.Errorf("%s changed", name)
+		}
+	}
+}
+
+func TestWriteEdited3(t *testing.T) {
+	opf := strings.Replace(testOPF, `<dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>`, `<dc:creator id="creator">Jane Doe</dc:creator>
+    <meta refines="#creator" property="role" scheme="marc:relators">aut</meta>
+    <meta refines="#creator" property="display-seq">1</meta>`, 1)
+	opf = strings.Replace(opf, `<meta name="cover" content="cover-image"/>`, "", 1)
+	opf = strings.Replace(opf, `<item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>`, "", 1)
+	opf = strings.Replace(opf, `version="2.0"`, `version="3.0"`, 1)
+	opf = strings.Replace(opf, `<item id="ncx"`, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx"`, 1)
+	book := buildTestEpub3(t, testFile{"OEBPS/content.opf", opf})
+	reader, err := OpenBuffer(book, int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := &reader.Rootfiles[0].Metadata
+	metadata.Identifier = nil
+	if err := reader.WriteEdited(&bytes.Buffer{}); !errors.Is(err, ErrNoUniqueIdentifier) {
+		t.Errorf("WriteEdited() without identifier = %v, want ErrNoUniqueIdentifier", err)
+	}
+	metadata.Identifier = []DCIdentifier{{DCElement: DCElement{Text: "9780306406157", ID: "bookid"}, Scheme: "15"}}
+	metadata.Creator[0].Role = "edt"
+	metadata.Creator = append(metadata.Creator, Creator{DCElement: DCElement{Text: "John Roe"}, FileAs: "Roe, John"})
+	if err := reader.SetCover("image/png", strings.NewReader("png")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, _ := written.readFile("OEBPS/content.opf")
+	for _, want := range []string{
+		`<meta refines="#creator" property="role" scheme="marc:relators">edt</meta>`,
+		`<meta refines="#creator" property="display-seq">1</meta>`,
+		`<dc:creator id="creator-2">John Roe</dc:creator>`,
+		`<meta refines="#creator-2" property="file-as">Roe, John</meta>`,
+		`<meta refines="#bookid" property="identifier-type">15</meta>`,
+		`<item id="cover-image" href="images/cover.png" media-type="image/png" properties="cover-image"/>`,
+	} {
+		if !strings.Contains(document.String(), want) {
+			t.Errorf("content.opf lacks %s:\n%s", want, document)
+		}
+	}
+	if strings.Contains(document.String(), ">aut<") {
+		t.Errorf("content.opf keeps the old role:\n%s", document)
+	}
+	if item, ok := written.Cover(); !ok || item.Href != "images/cover.png" {
+		t.Errorf("Cover() = %+v, %v", item, ok)
+	}
+}
+
+// TestWriteEditedCopies checks that the files of the book are copied with
+// their compression method, whatever MaxItemSize.
+func TestWriteEditedCopies(t *testing.T) {
+	original := buildTestEpub(t, testFile{"OEBPS/images/cover.jpg", strings.Repeat("\xff", 1<<16)})
+	zipped, err := zip.NewReader(bytes.NewReader(original), int64(len(original)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var book bytes.Buffer
+	writer := zip.NewWriter(&book)
+	for _, file := range zipped.File {
+		header := file.FileHeader
+		if file.Name == "OEBPS/images/cover.jpg" {
+			header.Method = zip.Store
+		}
+		w, err := writer.CreateHeader(&header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := OpenBuffer(book.Bytes(), int64(book.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.MaxItemSize = 4096
+	reader.Rootfiles[0].Metadata.Title[0].Text = "The Edited Book"
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := make(map[string]uint16)
+	for _, file := range written.File {
+		methods[file.Name] = file.Method
+	}
+	if methods["OEBPS/images/cover.jpg"] != zip.Store || methods["OEBPS/chapter1.xhtml"] != zip.Deflate {
+		t.Errorf("methods = %v", methods)
+	}
+}
+
+func TestWriteEditedEmptyMetadata(t *testing.T) {
+	opf := testOPF[:strings.Index(testOPF, "<metadata")] + "<metadata/>" + testOPF[strings.Index(testOPF, "</metadata>")+len("</metadata>"):]
+	opf = strings.Replace(opf, `<item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>`, "", 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", strings.Replace(opf, `unique-identifier="bookid" `, "", 1)})
+	reader.Rootfiles[0].Metadata.Title = []DCTitle{{DCElement: DCElement{Text: "The Edited Book"}}}
+	if err := reader.SetCover("image/png", strings.NewReader("png")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := firstTitle(written.Rootfiles[0].Metadata.Title); title != "The Edited Book" {
+		t.Errorf("title = %q", title)
+	}
+	if _, ok := written.Cover(); !ok {
+		document, _ := written.readFile("OEBPS/content.opf")
+		t.Errorf("no cover in\n%s", document)
+	}
+}
+
+// TestWriteEditedElements checks that the attributes of the edited Dublin
+// Core elements are written.
+func TestWriteEditedElements(t *testing.T) {
+	opf := strings.Replace(testOPF, `<dc:title>The Test Book</dc:title>`, `<dc:title xml:lang="en" dir="ltr">The Test Book</dc:title>
+    <dc:date opf:event="publication">2019</dc:date>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	metadata := &reader.Rootfiles[0].Metadata
+	metadata.Title[0].Text = "The Edited Book"
+	metadata.Date = append(metadata.Date, DCDate{DCElement{Text: "2020-01-02"}, "modification"})
+	metadata.Subject = []DCElement{{Text: "Fiction", Lang: "en"}, {Text: "Fiction", Lang: "fr", ID: "s2"}}
+	metadata.Rights = []DCElement{{Text: "Public domain"}}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := written.Metadata(), reader.Metadata(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metadata() = %+v\nwant %+v", got, want)
+	}
+}
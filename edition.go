@@ -0,0 +1,150 @@
+package epub
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Edition describes the edition of a book, for deduplicating a catalog
+// across editions.
+type Edition struct {
+	// Publisher is the publisher without the imprint, Imprint the imprint
+	// when the publisher names one ("Vintage, an imprint of ...").
+	Publisher string
+	Imprint   string
+	// Statement is the edition statement, e.g. "2nd Edition" or "Revised
+	// and Expanded Edition".
+	Statement string
+	// Number is the edition number, 0 when the statement has none.
+	Number int
+	// BaseTitle is the title without the edition statement.
+	BaseTitle string
+}
+
+var imprintPattern = regexp.MustCompile(`(?i)^(.+?)[\s,(]+an?\s+(?:imprint|division)\s+of\s+(.+?)\)?$`)
+
+// editionPattern matches title segments such as "2nd Edition", "Third
+// edition" or "Revised and Expanded Ed.".
+var editionPattern = regexp.MustCompile(`(?i)^(?:[\w'&]+\s+){0,5}?(?:edition|ed\.)$`)
+
+var titleSeparators = regexp.MustCompile(`\s*[,:;()\[\]]\s*|\s+[-–—]\s+`)
+
+var editionOrdinals = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+var ordinalPattern = regexp.MustCompile(`(?i)\b(\d+)(?:st|nd|rd|th)\b`)
+
+// Edition returns the edition information found in the publisher, in
+// edition and imprint metadata, and in the title.
+func (epubReader *EpubReader) Edition() Edition {
+	metadata := epubReader.Rootfiles[0].Metadata
+	edition := Edition{Publisher: strings.TrimSpace(firstText(metadata.Publisher))}
+
+	if match := imprintPattern.FindStringSubmatch(edition.Publisher); match != nil {
+		edition.Imprint = strings.TrimSpace(match[1])
+		edition.Publisher = strings.TrimSpace(match[2])
+	}
+
+	edition.Statement = epubReader.metaValue("edition", "bookedition", "hasversion")
+	if edition.Imprint == "" {
+		edition.Imprint = epubReader.metaValue("imprint", "publisherimprint")
+	}
+
+	edition.BaseTitle = strings.TrimSpace(firstTitle(metadata.Title))
+	if base, statement := splitEditionStatement(edition.BaseTitle); statement != "" {
+		edition.BaseTitle = base
+		if edition.Statement == "" {
+			edition.Statement = statement
+		}
+	}
+	edition.Number = editionNumber(edition.Statement)
+
+	return edition
+}
+
+// metaValue returns the value of the first meta element whose property or
+// name, lowercased and without its prefix, is one of keys.
+func (epubReader *EpubReader) metaValue(keys ...string) string {
+	return findMeta(epubReader.Rootfiles[0].Metadata.Meta, keys...)
+}
+
+// findMeta returns the value of the first of metas whose key is one of
+// keys.
+func findMeta(metas []Meta, keys ...string) string {
+	for _, meta := range metas {
+		value := strings.TrimSpace(meta.Text)
+		if meta.Property == "" {
+			value = strings.TrimSpace(meta.Content)
+		}
+		if value != "" && containsString(keys, metaKey(meta.Property, meta.Name)) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// metaKey returns the property of a meta element, or else its name,
+// lowercased and without its prefix.
+func metaKey(property, name string) string {
+	key := strings.ToLower(property)
+	if key == "" {
+		key = strings.ToLower(name)
+	}
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		key = key[i+1:]
+	}
+
+	return key
+}
+
+// series returns the series of the book and the position of the book in
+// it, from calibre or EPUB 3 collection metadata.
+func (epubReader *EpubReader) series() (string, string) {
+	return epubReader.metaValue("series", "belongs-to-collection"), epubReader.metaValue("series_index", "group-position")
+}
+
+// splitEditionStatement returns title without its edition statement, and
+// the statement.
+func splitEditionStatement(title string) (string, string) {
+	locations := titleSeparators.FindAllStringIndex(title, -1)
+
+	start := 0
+	for i := 0; i <= len(locations); i++ {
+		end := len(title)
+		if i < len(locations) {
+			end = locations[i][0]
+		}
+		segment := strings.TrimSpace(title[start:end])
+		if segment != "" && editionPattern.MatchString(segment) {
+			base := strings.TrimSpace(title[:start] + title[end:])
+			base = strings.Trim(base, " ,:;-–—()[]")
+			return strings.Join(strings.Fields(strings.ReplaceAll(base, "()", "")), " "), segment
+		}
+		if i < len(locations) {
+			start = locations[i][1]
+		}
+	}
+
+	return title, ""
+}
+
+// editionNumber returns the number of an edition statement, 1 for "First
+// Edition" or "1st ed.", 0 when it has none.
+func editionNumber(statement string) int {
+	if match := ordinalPattern.FindStringSubmatch(statement); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		return n
+	}
+
+	for _, word := range strings.Fields(strings.ToLower(statement)) {
+		if n, ok := editionOrdinals[word]; ok {
+			return n
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,34 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEdition(t *testing.T) {
+	for _, test := range []struct {
+		title, publisher, meta string
+		want                   Edition
+	}{
+		{"The Test Book", "Test Press", "", Edition{Publisher: "Test Press", BaseTitle: "The Test Book"}},
+		{"The Go Programming Language, 2nd Edition", "Addison-Wesley", "",
+			Edition{Publisher: "Addison-Wesley", Statement: "2nd Edition", Number: 2, BaseTitle: "The Go Programming Language"}},
+		{"Cooking for Geeks (Third Edition)", "O'Reilly", "",
+			Edition{Publisher: "O'Reilly", Statement: "Third Edition", Number: 3, BaseTitle: "Cooking for Geeks"}},
+		{"Mindset: Revised and Expanded Edition", "Vintage, an imprint of Penguin Random House", "",
+			Edition{Publisher: "Penguin Random House", Imprint: "Vintage", Statement: "Revised and Expanded Edition", BaseTitle: "Mindset"}},
+		{"Dune", "Ace (a division of Penguin)", `<meta property="schema:bookEdition">40th anniversary edition</meta>`,
+			Edition{Publisher: "Penguin", Imprint: "Ace", Statement: "40th anniversary edition", Number: 40, BaseTitle: "Dune"}},
+		{"Dune", "Ace", `<meta name="edition" content="Fifth"/><meta property="imprint">Ace Books</meta>`,
+			Edition{Publisher: "Ace", Imprint: "Ace Books", Statement: "Fifth", Number: 5, BaseTitle: "Dune"}},
+		{"Editions of the Past", "Press", "", Edition{Publisher: "Press", BaseTitle: "Editions of the Past"}},
+	} {
+		opf := strings.Replace(testOPF, "<dc:title>The Test Book</dc:title>", "<dc:title>"+test.title+"</dc:title>", 1)
+		opf = strings.Replace(opf, "<dc:publisher>Test Press</dc:publisher>", "<dc:publisher>"+test.publisher+"</dc:publisher>"+test.meta, 1)
+		opf = strings.Replace(opf, "O'Reilly", "O&apos;Reilly", 1)
+
+		if got := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Edition(); got != test.want {
+			t.Errorf("%s: Edition() = %+v", test.title, got)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package epub
 import (
 	"archive/zip"
 	"bytes"
-	"encoding/base64"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/url"
 	"os"
+	"path"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -20,6 +23,7 @@ const containerPath = "META-INF/container.xml"
 var (
 	ErrFileNotFound      = errors.New("epub: no '%s' found in file")
 	ErrorNoISBN          = errors.New("no ISBN found in file")
+	ErrorNoCover         = errors.New("no cover found in file")
 	ErrorNoMimetype      = errors.New("no mimetype found in file")
 	ErrorInvalidMimetype = errors.New("invalid mimetype")
 	ErrorNoRootFile      = errors.New("no rootfile")
@@ -39,12 +43,29 @@ var (
 	// ErrBadManifest occurs when a manifest in content.opf references an item
 	// that does not exist in the zip.
 	ErrBadManifest = errors.New("epub: manifest references non-existent item")
+
+	// ErrItemTooLarge occurs when a file of the container is larger than
+	// the MaxItemSize of the reader.
+	ErrItemTooLarge = errors.New("epub: file too large")
 )
 
 type EpubReader struct {
-	Name  string
+	Name string
+	// Files holds the entries of zip containers; it is nil for other
+	// storages.
 	Files map[string]*zip.File
 	Container
+	// MaxItemSize is the largest file of the container the reader reads,
+	// in bytes; reading past it fails with ErrItemTooLarge. 0 means no
+	// limit. ReadItemHead permits inspecting the start of larger files.
+	MaxItemSize int64
+	storage     Storage
+	// renditions holds the rootfiles in container order, alternates the
+	// rootfiles which are not package documents.
+	renditions []*Rootfile
+	alternates []*Rootfile
+	// cover is the cover image set by SetCover.
+	cover *pendingCover
 }
 
 type EpubReaderCloser struct {
@@ -62,6 +83,13 @@ type Rootfile struct {
 	XMLName   xml.Name `xml:"rootfile"`
 	FullPath  string   `xml:"full-path,attr"`
 	MediaType string   `xml:"media-type,attr"`
+	// The rendition selection attributes of books shipping several
+	// renditions, such as a "fixed" Layout or an "auditory" AccessMode.
+	RenditionLabel      string `xml:"http://www.idpf.org/2013/rendition label,attr"`
+	RenditionLanguage   string `xml:"http://www.idpf.org/2013/rendition language,attr"`
+	RenditionLayout     string `xml:"http://www.idpf.org/2013/rendition layout,attr"`
+	RenditionMedia      string `xml:"http://www.idpf.org/2013/rendition media,attr"`
+	RenditionAccessMode string `xml:"http://www.idpf.org/2013/rendition accessMode,attr"`
 	Package
 }
 
@@ -71,53 +99,47 @@ type Package struct {
 	Xmlns            string   `xml:"xmlns,attr"`
 	UniqueIdentifier string   `xml:"unique-identifier,attr"`
 	Version          string   `xml:"version,attr"`
+	Prefix           string   `xml:"prefix,attr"`
 	Metadata         struct {
-		Text    string `xml:",chardata"`
-		Dc      string `xml:"dc,attr"`
-		Opf     string `xml:"opf,attr"`
-		Title   string `xml:"title"`
-		Creator struct {
-			Text   string `xml:",chardata"`
-			Role   string `xml:"role,attr"`
-			FileAs string `xml:"file-as,attr"`
-		} `xml:"creator"`
-		Identifier []struct {
-			Text   string `xml:",chardata"`
-			ID     string `xml:"id,attr"`
-			Scheme string `xml:"scheme,attr"`
-		} `xml:"identifier"`
-		Date        string `xml:"date"`
-		Publisher   string `xml:"publisher"`
-		Description string `xml:"description"`
-		Contributor struct {
-			Text string `xml:",chardata"`
-			Role string `xml:"role,attr"`
-		} `xml:"contributor"`
-		Subject  string `xml:"subject"`
-		Language string `xml:"language"`
-		Meta     []struct {
-			Text    string `xml:",chardata"`
-			Name    string `xml:"name,attr"`
-			Content string `xml:"content,attr"`
-		} `xml:"meta"`
+		Text string `xml:",chardata"`
+		Dc   string `xml:"dc,attr"`
+		Opf  string `xml:"opf,attr"`
+		// The Dublin Core elements, in document order. Metadata resolves
+		// their EPUB 3 refinements.
+		Title       []DCTitle      `xml:"title"`
+		Creator     []Creator      `xml:"creator"`
+		Identifier  []DCIdentifier `xml:"identifier"`
+		Date        []DCDate       `xml:"date"`
+		Publisher   []DCElement    `xml:"publisher"`
+		Description []DCElement    `xml:"description"`
+		Contributor []Creator      `xml:"contributor"`
+		Subject     []DCElement    `xml:"subject"`
+		Source      []DCElement    `xml:"source"`
+		Language    []DCElement    `xml:"language"`
+		Type        []DCElement    `xml:"type"`
+		Format      []DCElement    `xml:"format"`
+		Relation    []DCElement    `xml:"relation"`
+		Coverage    []DCElement    `xml:"coverage"`
+		Rights      []DCElement    `xml:"rights"`
+		Meta        []Meta         `xml:"meta"`
+		Link        []Link         `xml:"link"`
 	} `xml:"metadata"`
 	Manifest struct {
 		Text string `xml:",chardata"`
-		Item []struct {
-			Text      string `xml:",chardata"`
-			Href      string `xml:"href,attr"`
-			ID        string `xml:"id,attr"`
-			MediaType string `xml:"media-type,attr"`
-		} `xml:"item"`
+		Item []Item `xml:"item"`
 	} `xml:"manifest"`
 	Spine struct {
-		Text    string `xml:",chardata"`
-		Toc     string `xml:"toc,attr"`
-		Itemref []struct {
+		Text                     string `xml:",chardata"`
+		Toc                      string `xml:"toc,attr"`
+		PageProgressionDirection string `xml:"page-progression-direction,attr"`
+		Itemref                  []struct {
 			Text  string `xml:",chardata"`
 			Idref string `xml:"idref,attr"`
 		} `xml:"itemref"`
 	} `xml:"spine"`
+	Bindings struct {
+		MediaType []Binding `xml:"mediaType"`
+	} `xml:"bindings"`
 	Guide struct {
 		Text      string `xml:",chardata"`
 		Reference []struct {
@@ -129,51 +151,116 @@ type Package struct {
 	} `xml:"guide"`
 }
 
+// Item is a resource declared in the package manifest.
+type Item struct {
+	Text         string `xml:",chardata"`
+	Href         string `xml:"href,attr"`
+	ID           string `xml:"id,attr"`
+	MediaType    string `xml:"media-type,attr"`
+	Fallback     string `xml:"fallback,attr"`
+	Properties   string `xml:"properties,attr"`
+	MediaOverlay string `xml:"media-overlay,attr"`
+}
+
 func init() {
 	log.Logger = log.With().Caller().Logger()
 }
 
+// GetISBN returns the ISBN of the book, normalized by NormalizeISBN. It
+// prefers the identifiers declared as ISBNs, by an EPUB 2 opf:scheme or an
+// EPUB 3 identifier-type refinement, then urn:isbn: identifiers, then any
+// identifier holding a valid ISBN. An ISBN with a wrong check digit is only
+// returned when declared and no valid one is found.
 func (epubReader *EpubReader) GetISBN() (string, error) {
-	for _, id := range epubReader.Rootfiles[0].Metadata.Identifier {
-		if id.Scheme == "ISBN" {
-			if id.ID != "" {
-				return id.ID, nil
-			}
-
-			return id.Text, nil
-		}
+	if isbn, ok := epubReader.Rootfiles[0].isbn(); ok {
+		return isbn, nil
 	}
 
 	return "", fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorNoISBN)
 }
 
-func (epubReader *EpubReader) GetCover() (string, error) {
-	// keys := reflect.ValueOf(epubReader.Files).MapKeys()
-	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
-		if item.ID == "cover" && item.MediaType == "image/jpeg" {
-			buffer, err := epubReader.readFile(item.Href)
-			return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()), err
+// isbn returns the ISBN of the package, see GetISBN.
+func (rootfile *Rootfile) isbn() (string, bool) {
+	isbn, rank := "", 0
+	for _, id := range rootfile.Metadata.Identifier {
+		declared := isISBNScheme(id.Scheme)
+		for _, meta := range rootfile.refinements(id.ID) {
+			declared = declared || strings.TrimSpace(meta.Property) == "identifier-type" && isISBNType(meta)
+		}
+		normalized, valid := NormalizeISBN(id.Text)
+
+		r := 0
+		switch {
+		case valid && declared:
+			r = 4
+		case valid && strings.HasPrefix(strings.ToLower(strings.TrimSpace(id.Text)), "urn:isbn:"):
+			r = 3
+		case valid:
+			r = 2
+		case declared && normalized != "":
+			r = 1
+		}
+		if r > rank {
+			isbn, rank = normalized, r
 		}
 	}
-	if _, ok := epubReader.Files["cover.jpeg"]; ok {
-		fmt.Println("FOUND")
-		buffer, err := epubReader.readFile("cover.jpeg")
-		return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buffer.Bytes()), err
+
+	return isbn, rank > 0
+}
+
+// GetCover returns the manifest href, the media type and the bytes of the
+// cover image found by Cover, from the EPUB 3 cover-image property, the
+// EPUB 2 cover meta or the guide cover page. It returns ErrorNoCover when
+// the book declares no cover.
+func (epubReader *EpubReader) GetCover() (string, string, io.Reader, error) {
+	item, ok := epubReader.Cover()
+	if !ok {
+		return "", "", nil, fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorNoCover)
+	}
+
+	buffer, err := epubReader.readFile(epubReader.itemPath(item.Href))
+	if err != nil {
+		return item.Href, item.MediaType, nil, err
+	}
+
+	return item.Href, item.MediaType, bytes.NewReader(buffer.Bytes()), nil
+}
+
+// NewReader returns a reader of the EPUB of the given size read from r, as
+// zip.NewReader does, so that books stored in memory, in blob storage or
+// behind HTTP range requests can be read without temporary files. Files
+// are read from r on demand; r must stay usable while the book is read.
+// The reader is named "<reader>" in errors and logs until Name is set.
+func NewReader(r io.ReaderAt, size int64) (*EpubReader, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("epub: open zip: %w", err)
+	}
+
+	reader := &EpubReader{Name: "<reader>"}
+	storage := newZipStorage(zipReader, r)
+	reader.Files = storage.files
+
+	if err = reader.init(storage); err != nil {
+		return nil, err
 	}
 
-	return "", nil
+	return reader, nil
 }
 
 func OpenBuffer(buffer []byte, size int64) (*EpubReaderCloser, error) {
-	zipReader, err := zip.NewReader(bytes.NewReader(buffer), size)
+	raw := bytes.NewReader(buffer)
+	zipReader, err := zip.NewReader(raw, size)
 	if err != nil {
 		return nil, fmt.Errorf("epub: open zip: %w", err)
 	}
 
 	reader := new(EpubReaderCloser)
 	reader.Name = "filename"
+	storage := newZipStorage(zipReader, raw)
+	reader.Files = storage.files
 
-	if err = reader.init(zipReader); err != nil {
+	if err = reader.init(storage); err != nil {
 		return nil, err
 	}
 
@@ -181,7 +268,7 @@ func OpenBuffer(buffer []byte, size int64) (*EpubReaderCloser, error) {
 }
 
 func OpenReader(filename string) (*EpubReaderCloser, error) {
-	zipFile, err := os.Open(filename)
+	zipFile, err := os.Open(longPath(filename))
 	if err != nil {
 		return nil, err
 	}
@@ -201,35 +288,40 @@ func OpenReader(filename string) (*EpubReaderCloser, error) {
 	reader := new(EpubReaderCloser)
 	reader.Name = filename
 	reader.file = zipFile
+	storage := newZipStorage(zipReader, zipFile)
+	reader.Files = storage.files
 
-	if err = reader.init(zipReader); err != nil {
+	if err = reader.init(storage); err != nil {
+		zipFile.Close()
 		return nil, err
 	}
 
 	return reader, nil
 }
 
-func (epubReader *EpubReader) init(zipReader *zip.Reader) error {
-	epubReader.Files = make(map[string]*zip.File)
-	for _, f := range zipReader.File {
-		epubReader.Files[f.Name] = f
-	}
+func (epubReader *EpubReader) init(storage Storage) (err error) {
+	end := startSpan("open", epubReader.Name)
+	defer func() { end(err) }()
+	epubReader.storage = storage
 
-	if mimetype, err := epubReader.readFile(mimetypePath); err != nil {
+	if mimetype, err := epubReader.readPooled(mimetypePath); err != nil {
 		log.Trace().Str("file", epubReader.Name).Msg("not an epub (no mimetype)")
 		return fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorNoMimetype)
-	} else if mimetype.String() != epubMimetype {
+	} else if !bytes.Equal(mimetype.Bytes(), []byte(epubMimetype)) {
 		log.Trace().Str("file", epubReader.Name).Msg("not an epub (invalid mimetype)")
 		return fmt.Errorf("epub: %s: %w %s", epubReader.Name, ErrorInvalidMimetype, mimetype.String())
+	} else {
+		releaseBuffer(mimetype)
 	}
 
-	container, err := epubReader.readFile(containerPath)
+	container, err := epubReader.readPooled(containerPath)
 	if err != nil {
 		log.Trace().Str("file", epubReader.Name).Msg("not an epub (no container)")
 		return fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorNoRootFile)
 	}
 
-	err = xml.Unmarshal(container.Bytes(), &epubReader.Container)
+	err = decodeXML(containerPath, container.Bytes(), &epubReader.Container)
+	releaseBuffer(container)
 	if err != nil {
 		log.Trace().Str("file", epubReader.Name).Msg(fmt.Sprintf("unmarshall container: %s", err.Error()))
 		return fmt.Errorf("epub: %s: unmarshalling container: %w", epubReader.Name, err)
@@ -239,18 +331,42 @@ func (epubReader *EpubReader) init(zipReader *zip.Reader) error {
 		return fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorNoRootFile)
 	}
 
+	var packages []*Rootfile
+	for _, rootfile := range epubReader.Container.Rootfiles {
+		if isPackageRootfile(rootfile) {
+			packages = append(packages, rootfile)
+		} else {
+			log.Debug().Str("file", epubReader.Name).Str("rootfile", rootfile.FullPath).Str("media-type", rootfile.MediaType).Msg("alternate rendition")
+			epubReader.alternates = append(epubReader.alternates, rootfile)
+		}
+	}
+	if len(packages) == 0 {
+		return fmt.Errorf("epub: %s: %w: no package document among %d rootfiles", epubReader.Name, ErrorNoRootFile, len(epubReader.alternates))
+	}
+	epubReader.Container.Rootfiles = packages
+
 	for _, rootFile := range epubReader.Container.Rootfiles {
-		rootfile, err := epubReader.readFile(rootFile.FullPath)
+		rootfile, err := epubReader.readPooled(rootFile.FullPath)
 		if err != nil {
 			log.Trace().Str("file", epubReader.Name).Msg("not an epub (bad root file)")
 			return fmt.Errorf("epub: %s: %w", epubReader.Name, ErrorBadRootFile)
 		}
 
-		err = xml.Unmarshal(rootfile.Bytes(), &rootFile.Package)
+		err = decodeXML(rootFile.FullPath, rootfile.Bytes(), &rootFile.Package)
+		releaseBuffer(rootfile)
 		if err != nil {
 			log.Trace().Str("file", epubReader.Name).Msg("cannot parse (bad root file)")
 			return fmt.Errorf("epub: cannot parse %s: %w", epubReader.Name, err)
 		}
+		if isPooledDecoding() {
+			internPackage(&rootFile.Package)
+		}
+	}
+
+	epubReader.renditions = append([]*Rootfile(nil), epubReader.Container.Rootfiles...)
+
+	if isLenient() {
+		epubReader.ApplyQuirks()
 	}
 
 	// <Rootfile full-path="OEBPS/book.opf" media-type="application/oebps-package+xml">
@@ -266,20 +382,83 @@ func (epubReader *EpubReader) init(zipReader *zip.Reader) error {
 	return nil
 }
 
-func (epubReader *EpubReader) readFile(name string) (*bytes.Buffer, error) {
-	file, ok := epubReader.Files[name]
-	if !ok {
+// FileNames returns the names of the files of the container, in container
+// order. Unlike ranging over Files, the order is the same on every call.
+func (epubReader *EpubReader) FileNames() []string {
+	return append([]string(nil), epubReader.storage.Names()...)
+}
+
+func (epubReader *EpubReader) openFile(name string) (io.ReadCloser, error) {
+	reader, err := epubReader.storage.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
 		return nil, fmt.Errorf("epub: %s, file '%s' %w", epubReader.Name, name, ErrorFileMissing)
 	}
+	if err == nil && epubReader.MaxItemSize > 0 {
+		reader = &itemReader{ReadCloser: reader, remaining: epubReader.MaxItemSize, err: fmt.Errorf("epub: %s: '%s': %w: more than %d bytes", epubReader.Name, name, ErrItemTooLarge, epubReader.MaxItemSize)}
+	}
 
-	reader, err := file.Open()
+	return reader, err
+}
+
+// itemReader fails with err when more than remaining bytes are read.
+type itemReader struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (reader *itemReader) Read(p []byte) (int, error) {
+	if reader.remaining <= 0 {
+		var probe [1]byte
+		if n, err := reader.ReadCloser.Read(probe[:]); n > 0 {
+			return 0, reader.err
+		} else if err != nil {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if int64(len(p)) > reader.remaining {
+		p = p[:reader.remaining]
+	}
+	n, err := reader.ReadCloser.Read(p)
+	reader.remaining -= int64(n)
+
+	return n, err
+}
+
+// ReadItemHead returns the first n bytes of the file name of the container,
+// or the whole file when it is shorter, to sniff the head of content
+// documents without reading them. Files larger than MaxItemSize can be
+// inspected when n does not exceed it.
+func (epubReader *EpubReader) ReadItemHead(name string, n int) ([]byte, error) {
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	head, err := io.ReadAll(io.LimitReader(reader, int64(n)))
+	count("bytes-read", int64(len(head)))
+	if err != nil {
+		return nil, err
+	}
+
+	return head, nil
+}
+
+func (epubReader *EpubReader) readFile(name string) (_ *bytes.Buffer, err error) {
+	end := startSpan("read", name)
+	defer func() { end(err) }()
+
+	reader, err := epubReader.openFile(name)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
 
 	var buffer bytes.Buffer
-	_, err = io.Copy(&buffer, reader)
+	n, err := io.Copy(&buffer, reader)
+	count("bytes-read", n)
 	if err != nil {
 		return nil, err
 	}
@@ -287,6 +466,59 @@ func (epubReader *EpubReader) readFile(name string) (*bytes.Buffer, error) {
 	return &buffer, nil
 }
 
+// decodeXML unmarshals the document name.
+func decodeXML(name string, data []byte, v interface{}) error {
+	end := startSpan("decode", name)
+	err := newXMLDecoder(bytes.NewReader(data)).Decode(v)
+	end(err)
+
+	return err
+}
+
+// itemPath returns the zip path of href, a (possibly URL-encoded) reference
+// relative to the package document, without its fragment.
+func (epubReader *EpubReader) itemPath(href string) string {
+	return resolvePath(epubReader.Rootfiles[0].FullPath, href)
+}
+
+// OpenItem returns a reader of the file of href, a reference relative to the
+// package document as manifest and content hrefs are: it may be
+// URL-encoded, hold ../ segments and a fragment. Files whose zip name holds
+// the encoded href are found too. ErrBadHref is returned for remote hrefs
+// and hrefs outside the container.
+func (epubReader *EpubReader) OpenItem(href string) (io.ReadCloser, error) {
+	name := epubReader.itemPath(href)
+	if strings.Contains(href, ":") || strings.HasPrefix(href, "/") || name == ".." || strings.HasPrefix(name, "../") {
+		return nil, fmt.Errorf("epub: %s: %w: '%s'", epubReader.Name, ErrBadHref, href)
+	}
+
+	reader, err := epubReader.openFile(name)
+	if errors.Is(err, ErrorFileMissing) {
+		raw := href
+		if i := strings.IndexByte(raw, '#'); i >= 0 {
+			raw = raw[:i]
+		}
+		if raw = path.Join(path.Dir(epubReader.Rootfiles[0].FullPath), raw); raw != name && epubReader.hasFile(raw) {
+			return epubReader.openFile(raw)
+		}
+	}
+
+	return reader, err
+}
+
+// resolvePath returns the zip path of href, a reference relative to the zip
+// entry base, without its fragment.
+func resolvePath(base, href string) string {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href = href[:i]
+	}
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+
+	return path.Join(path.Dir(base), href)
+}
+
 func (epubReaderCloser *EpubReaderCloser) Close() {
 	epubReaderCloser.file.Close()
 }
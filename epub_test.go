@@ -1,11 +1,290 @@
 package epub
 
 import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
 	"testing"
 )
 
+type testFile struct {
+	name string
+	body string
+}
+
+const testContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>`
+
+const testOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="bookid" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:opf="http://www.idpf.org/2007/opf">
+    <dc:title>The Test Book</dc:title>
+    <dc:creator opf:role="aut" opf:file-as="Doe, Jane">Jane Doe</dc:creator>
+    <dc:identifier id="bookid" opf:scheme="ISBN">9780306406157</dc:identifier>
+    <dc:language>en</dc:language>
+    <dc:publisher>Test Press</dc:publisher>
+    <meta name="cover" content="cover-image"/>
+  </metadata>
+  <manifest>
+    <item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+    <item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="chapter2" href="chapter2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine toc="ncx">
+    <itemref idref="chapter1"/>
+    <itemref idref="chapter2"/>
+  </spine>
+</package>`
+
+const testNCX = `<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head><meta name="dtb:uid" content="9780306406157"/></head>
+  <docTitle><text>The Test Book</text></docTitle>
+  <navMap>
+    <navPoint id="np1" playOrder="1">
+      <navLabel><text>Chapter One</text></navLabel>
+      <content src="chapter1.xhtml"/>
+    </navPoint>
+    <navPoint id="np2" playOrder="2">
+      <navLabel><text>Chapter Two</text></navLabel>
+      <content src="chapter2.xhtml"/>
+    </navPoint>
+  </navMap>
+</ncx>`
+
+const testChapter1 = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter One</title><link rel="stylesheet" href="style.css"/></head>
+<body>
+<h1>Chapter One</h1>
+<p>It was a dark &amp; stormy night.</p>
+<img src="images/cover.jpg" alt="cover"/>
+</body>
+</html>`
+
+const testChapter2 = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter Two</title></head>
+<body>
+<h1>Chapter Two</h1>
+<p>The end.</p>
+</body>
+</html>`
+
+// buildTestEpub returns the bytes of a small but complete EPUB 2 book. The
+// given files replace the default entry with the same name, or are appended
+// to the archive; a file with an empty body removes the default entry.
+func buildTestEpub(t testing.TB, files ...testFile) []byte {
+	t.Helper()
+
+	entries := []testFile{
+		{mimetypePath, epubMimetype},
+		{containerPath, testContainer},
+		{"OEBPS/content.opf", testOPF},
+		{"OEBPS/toc.ncx", testNCX},
+		{"OEBPS/chapter1.xhtml", testChapter1},
+		{"OEBPS/chapter2.xhtml", testChapter2},
+		{"OEBPS/images/cover.jpg", "\xff\xd8\xff\xe0 not really a jpeg"},
+		{"OEBPS/style.css", "p { margin: 0; }"},
+	}
+
+	for _, file := range files {
+		replaced := false
+		for i := range entries {
+			if entries[i].name == file.name {
+				entries[i].body = file.body
+				replaced = true
+			}
+		}
+		if !replaced {
+			entries = append(entries, file)
+		}
+	}
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for _, entry := range entries {
+		if entry.body == "" {
+			continue
+		}
+		method := zip.Deflate
+		if entry.name == mimetypePath {
+			method = zip.Store
+		}
+		w, err := zipWriter.CreateHeader(&zip.FileHeader{Name: entry.name, Method: method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+// openTestEpub opens the book built by buildTestEpub.
+func openTestEpub(t testing.TB, files ...testFile) *EpubReaderCloser {
+	t.Helper()
+
+	buffer := buildTestEpub(t, files...)
+	reader, err := OpenBuffer(buffer, int64(len(buffer)))
+	if err != nil {
+		t.Fatalf("OpenBuffer() = %v", err)
+	}
+
+	return reader
+}
+
 func TestOpenReader(t *testing.T) {
 	if _, err := OpenReader("/etc/fstab"); err == nil {
 		t.Errorf("OpenReader() = no error")
 	}
 }
+
+func TestOpenBuffer(t *testing.T) {
+	reader := openTestEpub(t)
+
+	if got := firstTitle(reader.Rootfiles[0].Metadata.Title); got != "The Test Book" {
+		t.Errorf("Title = %q", got)
+	}
+}
+
+// countingReaderAt counts the bytes read from an io.ReaderAt.
+type countingReaderAt struct {
+	r io.ReaderAt
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n += n
+	return n, err
+}
+
+func TestNewReader(t *testing.T) {
+	data := buildTestEpub(t)
+	source := &countingReaderAt{r: bytes.NewReader(data)}
+
+	reader, err := NewReader(source, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Name != "<reader>" || firstTitle(reader.Rootfiles[0].Metadata.Title) != "The Test Book" {
+		t.Errorf("NewReader() = %q, %q", reader.Name, firstTitle(reader.Rootfiles[0].Metadata.Title))
+	}
+	before := source.n
+	if _, err := reader.readFile("OEBPS/chapter1.xhtml"); err != nil || source.n == before {
+		t.Errorf("readFile() read %d bytes from the source, %v", source.n-before, err)
+	}
+
+	if _, err := NewReader(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Errorf("NewReader(not a zip) = no error")
+	}
+}
+
+func TestFileNames(t *testing.T) {
+	reader := openTestEpub(t)
+
+	want := []string{
+		mimetypePath,
+		containerPath,
+		"OEBPS/content.opf",
+		"OEBPS/toc.ncx",
+		"OEBPS/chapter1.xhtml",
+		"OEBPS/chapter2.xhtml",
+		"OEBPS/images/cover.jpg",
+		"OEBPS/style.css",
+	}
+	for i := 0; i < 10; i++ {
+		if got := reader.FileNames(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("FileNames() = %v", got)
+		}
+	}
+}
+
+func TestGetCover(t *testing.T) {
+	reader := openTestEpub(t)
+	href, mediaType, r, err := reader.GetCover()
+	if err != nil || href != "images/cover.jpg" || mediaType != "image/jpeg" {
+		t.Fatalf("GetCover() = %q, %q, %v", href, mediaType, err)
+	}
+	if data, err := io.ReadAll(r); err != nil || string(data) != "\xff\xd8\xff\xe0 not really a jpeg" {
+		t.Errorf("GetCover() data = %q, %v", data, err)
+	}
+
+	opf := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, ``, 1)
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	if _, _, _, err := reader.GetCover(); !errors.Is(err, ErrorNoCover) {
+		t.Errorf("GetCover(no cover) = %v", err)
+	}
+}
+
+func TestOpenItem(t *testing.T) {
+	reader := openTestEpub(t,
+		testFile{"OEBPS/text/chapter 3.xhtml", "<html>3</html>"},
+		testFile{"OEBPS/text/chapter%204.xhtml", "<html>4</html>"},
+		testFile{"styles/book.css", "body {}"})
+
+	for href, want := range map[string]string{
+		"text/chapter%203.xhtml#p1":  "<html>3</html>",
+		"text/chapter 3.xhtml":       "<html>3</html>",
+		"text/chapter%204.xhtml":     "<html>4</html>",
+		"text/../../styles/book.css": "body {}",
+	} {
+		file, err := reader.OpenItem(href)
+		if err != nil {
+			t.Errorf("OpenItem(%q): %v", href, err)
+			continue
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil || string(content) != want {
+			t.Errorf("OpenItem(%q) = %q, %v", href, content, err)
+		}
+	}
+
+	if _, err := reader.OpenItem("text/missing.xhtml"); !errors.Is(err, ErrorFileMissing) {
+		t.Errorf("OpenItem(missing) = %v, want ErrorFileMissing", err)
+	}
+	for _, href := range []string{"../../secret", "/etc/passwd", "http://example.com/a.css"} {
+		if _, err := reader.OpenItem(href); !errors.Is(err, ErrBadHref) {
+			t.Errorf("OpenItem(%q) = %v, want ErrBadHref", href, err)
+		}
+	}
+}
+
+func TestMaxItemSize(t *testing.T) {
+	chapter := "<html><head><title>Huge</title></head><body>" + strings.Repeat("<p>text</p>", 1000) + "</body></html>"
+	reader := openTestEpub(t, testFile{"OEBPS/text/huge.xhtml", chapter})
+	reader.MaxItemSize = 1024
+
+	head, err := reader.ReadItemHead("OEBPS/text/huge.xhtml", 36)
+	if err != nil || string(head) != "<html><head><title>Huge</title></hea" {
+		t.Errorf("ReadItemHead(36) = %q, %v", head, err)
+	}
+	if head, err := reader.ReadItemHead("mimetype", 100); err != nil || string(head) != "application/epub+zip" {
+		t.Errorf("ReadItemHead(mimetype) = %q, %v", head, err)
+	}
+	if _, err := reader.readFile("OEBPS/text/huge.xhtml"); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("readFile(huge) = %v, want ErrItemTooLarge", err)
+	}
+	if _, err := reader.ReadItemHead("OEBPS/text/huge.xhtml", 2048); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("ReadItemHead(2048) = %v, want ErrItemTooLarge", err)
+	}
+	if _, err := reader.readFile("OEBPS/chapter1.xhtml"); err != nil {
+		t.Errorf("readFile(chapter1): %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package epub_test
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jeanmarcboite/epub"
+)
+
+// sample3 is a small EPUB 3 book, generated by the tests with the EPUB 2
+// book of testdata/sample2.epub.
+//
+//go:embed testdata/sample3.epub
+var sample3 []byte
+
+func ExampleOpenReader() {
+	reader, err := epub.OpenReader("testdata/sample2.epub")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	metadata := reader.Rootfiles[0].Metadata
+	fmt.Println(metadata.Title[0].Text, "by", metadata.Creator[0].Text)
+	for _, item := range reader.SpineItems() {
+		fmt.Println(item.Href)
+	}
+	// Output:
+	// The Test Book by Jane Doe
+	// chapter1.xhtml
+	// chapter2.xhtml
+}
+
+func ExampleOpenBuffer() {
+	reader, err := epub.OpenBuffer(sample3, int64(len(sample3)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	fmt.Println(reader.Rootfiles[0].Version, len(reader.Validate()))
+	// Output: 3.0 0
+}
+
+func ExampleNewReader() {
+	// Any io.ReaderAt works, such as an HTTP range reader or a blob.
+	reader, err := epub.NewReader(bytes.NewReader(sample3), int64(len(sample3)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(reader.Rootfiles[0].Metadata.Title[0].Text)
+	// Output: The Test Book
+}
+
+func ExampleEpubReader_TOC() {
+	reader, err := epub.OpenBuffer(sample3, int64(len(sample3)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	toc, err := reader.TOC()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var print func(entries []epub.TOCEntry, depth int)
+	print = func(entries []epub.TOCEntry, depth int) {
+		for _, entry := range entries {
+			line := strings.Repeat("  ", depth) + entry.Title
+			if entry.HasLink() {
+				line += " -> " + entry.Href
+			}
+			fmt.Println(line)
+			print(entry.Children, depth+1)
+		}
+	}
+	print(toc, 0)
+	// Output:
+	// Chapter One -> chapter1.xhtml
+	//   Section 1 -> chapter1.xhtml#s1
+	// Part Two
+	//   Chapter Two -> chapter2.xhtml
+}
@@ -0,0 +1,280 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrUnsafePath occurs when a zip entry would be extracted outside of
+	// the target directory, or through a symbolic link.
+	ErrUnsafePath = errors.New("epub: unsafe entry path")
+
+	// ErrExtractLimit occurs when an extraction exceeds the limits of
+	// ExtractOptions.
+	ErrExtractLimit = errors.New("epub: extraction limit exceeded")
+)
+
+const maxComponentLength = 255
+
+// ExtractOptions tunes ExtractAllWith.
+type ExtractOptions struct {
+	// Hardened is meant for untrusted uploads: existing files are never
+	// overwritten, paths going through symbolic links in the target
+	// directory are refused, and the limits below are enforced.
+	Hardened bool
+	// MaxFiles and MaxBytes limit the number of files and the number of
+	// bytes written in hardened mode. They default to 10000 files and
+	// 1 GiB.
+	MaxFiles int
+	MaxBytes int64
+	// StripImageMetadata removes from the images written their EXIF, XMP
+	// and text metadata, as StripImageMetadata does.
+	StripImageMetadata bool
+}
+
+// ExtractRecord is the audit log entry of one zip entry.
+type ExtractRecord struct {
+	Name string
+	// Path is the file written, empty if the entry was skipped.
+	Path    string
+	Size    int64
+	Skipped bool
+	Reason  string
+}
+
+// ExtractAll writes the entries of the book below dir, creating it if
+// needed. See ExtractAllWith.
+func (epubReader *EpubReader) ExtractAll(dir string) error {
+	_, err := epubReader.ExtractAllWith(dir, ExtractOptions{})
+
+	return err
+}
+
+// ExtractAllWith writes the entries of the book below dir, creating it if
+// needed, and returns an audit log of what was written or skipped.
+//
+// Entries with absolute names, '..' components escaping dir, components
+// longer than 255 bytes or symbolic link modes are skipped. Entry names
+// are adapted to the platform: on Windows, reserved device names (CON,
+// NUL, ...) and forbidden characters are escaped and long paths are
+// supported. Entries whose names only differ by case, which would
+// overwrite each other on case-insensitive file systems, get a ~N suffix.
+func (epubReader *EpubReader) ExtractAllWith(dir string, options ExtractOptions) ([]ExtractRecord, error) {
+	if options.MaxFiles <= 0 {
+		options.MaxFiles = 10000
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = 1 << 30
+	}
+
+	var audit []ExtractRecord
+	var written int64
+	files := 0
+	used := make(map[string]bool)
+
+	for _, name := range epubReader.storage.Names() {
+		record := ExtractRecord{Name: name}
+
+		rel, err := entryPath(name)
+		if info, ok := epubReader.stat(name); err == nil && ok && info.Mode()&os.ModeSymlink != 0 {
+			err = fmt.Errorf("%w: symbolic link", ErrUnsafePath)
+		}
+		if err == nil && options.Hardened {
+			err = checkNoSymlink(dir, rel)
+		}
+		if err != nil {
+			record.Skipped = true
+			record.Reason = err.Error()
+			audit = append(audit, record)
+			log.Warn().Str("file", epubReader.Name).Str("entry", name).Msg(record.Reason)
+			continue
+		}
+
+		if files++; options.Hardened && files > options.MaxFiles {
+			return audit, fmt.Errorf("epub: %s: %w: more than %d files", epubReader.Name, ErrExtractLimit, options.MaxFiles)
+		}
+
+		rel = uniquePath(rel, used)
+		record.Path = filepath.Join(dir, filepath.FromSlash(rel))
+		limit := int64(-1)
+		if options.Hardened {
+			limit = options.MaxBytes - written
+		}
+		strip := false
+		if item, ok := epubReader.itemByPath(name); ok && options.StripImageMetadata {
+			strip = isImage(item)
+		}
+		record.Size, err = epubReader.extractFile(name, record.Path, limit, options.Hardened, strip)
+		written += record.Size
+		audit = append(audit, record)
+		if err != nil {
+			return audit, err
+		}
+	}
+
+	return audit, nil
+}
+
+// extractFile copies the entry name to target, writing at most limit bytes
+// unless limit is negative, and stripping the metadata of the image it
+// holds when strip is true.
+func (epubReader *EpubReader) extractFile(name, target string, limit int64, exclusive, strip bool) (int64, error) {
+	if err := os.MkdirAll(longPath(filepath.Dir(target)), 0o755); err != nil {
+		return 0, err
+	}
+
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	var source io.Reader = reader
+	if strip {
+		if limit >= 0 {
+			source = io.LimitReader(reader, limit+1)
+		}
+		data, err := io.ReadAll(source)
+		if err != nil {
+			return 0, fmt.Errorf("epub: %s: extract '%s': %w", epubReader.Name, name, err)
+		}
+		source = bytes.NewReader(StripImageMetadata(data))
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	file, err := os.OpenFile(longPath(target), flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	if limit < 0 {
+		n, err = io.Copy(file, source)
+	} else if n, err = io.CopyN(file, source, limit+1); err == io.EOF {
+		err = nil
+	} else if err == nil {
+		err = ErrExtractLimit
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(longPath(target))
+		return n, fmt.Errorf("epub: %s: extract '%s': %w", epubReader.Name, name, err)
+	}
+
+	return n, file.Close()
+}
+
+// checkNoSymlink returns an error if a component of rel below dir is an
+// existing symbolic link.
+func checkNoSymlink(dir, rel string) error {
+	current := dir
+	for _, component := range strings.Split(rel, "/") {
+		current = filepath.Join(current, component)
+		info, err := os.Lstat(longPath(current))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: '%s' is a symbolic link", ErrUnsafePath, current)
+		}
+	}
+
+	return nil
+}
+
+// entryPath returns the cleaned, slash separated path of a zip entry,
+// relative to the extraction directory, with each component adapted to the
+// platform.
+func entryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if path.IsAbs(name) || filepath.IsAbs(name) || hasDriveLetter(name) {
+		return "", ErrUnsafePath
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrUnsafePath
+	}
+
+	components := strings.Split(cleaned, "/")
+	for i, component := range components {
+		if len(component) > maxComponentLength {
+			return "", fmt.Errorf("%w: component longer than %d bytes", ErrUnsafePath, maxComponentLength)
+		}
+		components[i] = platformComponent(component)
+	}
+
+	return strings.Join(components, "/"), nil
+}
+
+func hasDriveLetter(name string) bool {
+	return len(name) >= 2 && name[1] == ':' &&
+		('a' <= name[0] && name[0] <= 'z' || 'A' <= name[0] && name[0] <= 'Z')
+}
+
+// uniquePath returns rel, or rel with a ~N suffix before its extension when
+// a path differing only by case was already used.
+func uniquePath(rel string, used map[string]bool) string {
+	unique := rel
+	for n := 1; used[strings.ToLower(unique)]; n++ {
+		ext := path.Ext(rel)
+		unique = strings.TrimSuffix(rel, ext) + "~" + strconv.Itoa(n) + ext
+	}
+	if unique != rel {
+		log.Warn().Str("entry", rel).Str("path", unique).Msg("case-insensitive collision")
+	}
+	used[strings.ToLower(unique)] = true
+
+	return unique
+}
+
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// windowsComponent returns a path component Windows can create: forbidden
+// characters are replaced with '_', trailing dots and spaces are dropped,
+// and reserved device names get a '_' suffix on their base name.
+func windowsComponent(component string) string {
+	component = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, component)
+
+	component = strings.TrimRight(component, ". ")
+	if component == "" {
+		return "_"
+	}
+
+	base := component
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		return base + "_" + component[len(base):]
+	}
+
+	return component
+}
@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package epub
+
+func longPath(p string) string {
+	return p
+}
+
+func platformComponent(component string) string {
+	return component
+}
@@ -0,0 +1,112 @@
+package epub
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractAll(t *testing.T) {
+	reader := openTestEpub(t,
+		testFile{"OEBPS/Chapter1.xhtml", "<html/>"},
+	)
+
+	dir := t.TempDir()
+	if err := reader.ExtractAll(dir); err != nil {
+		t.Fatalf("ExtractAll() = %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"mimetype":               epubMimetype,
+		"OEBPS/chapter1.xhtml":   testChapter1,
+		"OEBPS/Chapter1~1.xhtml": "<html/>",
+	} {
+		body, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || string(body) != want {
+			t.Errorf("%s = %q, %v", name, body, err)
+		}
+	}
+}
+
+func TestEntryPath(t *testing.T) {
+	for name, want := range map[string]string{
+		"OEBPS/text/../chapter.xhtml": "OEBPS/chapter.xhtml",
+		"OEBPS\\images\\cover.jpg":    "OEBPS/images/cover.jpg",
+	} {
+		if got, err := entryPath(name); err != nil || got != want {
+			t.Errorf("entryPath(%q) = %q, %v", name, got, err)
+		}
+	}
+
+	for _, name := range []string{"/etc/passwd", "../evil", "OEBPS/../../evil", "..", "C:/Windows/evil", "OEBPS/" + strings.Repeat("x", 256)} {
+		if _, err := entryPath(name); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("entryPath(%q) = %v", name, err)
+		}
+	}
+}
+
+func TestWindowsComponent(t *testing.T) {
+	for component, want := range map[string]string{
+		"chapter.xhtml": "chapter.xhtml",
+		"CON":           "CON_",
+		"nul.xhtml":     "nul_.xhtml",
+		"com1.tar.gz":   "com1_.tar.gz",
+		"console.xhtml": "console.xhtml",
+		"what?.xhtml":   "what_.xhtml",
+		"trailing. ":    "trailing",
+		"...":           "_",
+	} {
+		if got := windowsComponent(component); got != want {
+			t.Errorf("windowsComponent(%q) = %q", component, got)
+		}
+	}
+}
+
+func TestExtractAllWith(t *testing.T) {
+	reader := openTestEpub(t,
+		testFile{"../escape.txt", "evil"},
+		testFile{"/etc/evil", "evil"},
+		testFile{"OEBPS/link/target.txt", "through a link"},
+	)
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "OEBPS"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "OEBPS", "link")); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+
+	audit, err := reader.ExtractAllWith(dir, ExtractOptions{Hardened: true})
+	if err != nil {
+		t.Fatalf("ExtractAllWith() = %v", err)
+	}
+
+	skipped := map[string]bool{}
+	for _, record := range audit {
+		if record.Skipped {
+			skipped[record.Name] = true
+		}
+	}
+	if len(skipped) != 3 || !skipped["../escape.txt"] || !skipped["/etc/evil"] || !skipped["OEBPS/link/target.txt"] {
+		t.Errorf("ExtractAllWith() skipped %v", skipped)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "target.txt")); !os.IsNotExist(err) {
+		t.Errorf("wrote through symbolic link: %v", err)
+	}
+
+	// Hardened mode never overwrites.
+	if _, err := reader.ExtractAllWith(dir, ExtractOptions{Hardened: true}); err == nil {
+		t.Errorf("ExtractAllWith(again) = no error")
+	}
+
+	if _, err := reader.ExtractAllWith(t.TempDir(), ExtractOptions{Hardened: true, MaxBytes: 100}); !errors.Is(err, ErrExtractLimit) {
+		t.Errorf("ExtractAllWith(MaxBytes) = %v", err)
+	}
+	if _, err := reader.ExtractAllWith(t.TempDir(), ExtractOptions{Hardened: true, MaxFiles: 2}); !errors.Is(err, ErrExtractLimit) {
+		t.Errorf("ExtractAllWith(MaxFiles) = %v", err)
+	}
+}
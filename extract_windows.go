@@ -0,0 +1,31 @@
+//go:build windows
+// +build windows
+
+package epub
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPath returns p in the \\?\ form that lifts the MAX_PATH limit when it
+// is too long for the classic Win32 API.
+func longPath(p string) string {
+	if len(p) < 248 || strings.HasPrefix(p, `\\?\`) {
+		return p
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+
+	return `\\?\` + abs
+}
+
+func platformComponent(component string) string {
+	return windowsComponent(component)
+}
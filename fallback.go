@@ -0,0 +1,111 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+)
+
+var (
+	// ErrNoItem occurs when an id does not match any manifest item.
+	ErrNoItem = errors.New("epub: no such manifest item")
+
+	// ErrFallbackLoop occurs when the fallback chain of an item refers back
+	// to an item already in the chain.
+	ErrFallbackLoop = errors.New("epub: fallback chain loops")
+
+	// ErrNoFallback occurs when the fallback chain of an item ends without
+	// reaching a core media type.
+	ErrNoFallback = errors.New("epub: no fallback with a core media type")
+)
+
+// coreMediaTypes are the EPUB 3 core media types, which every reading system
+// supports without fallback.
+var coreMediaTypes = map[string]bool{
+	"image/gif":                     true,
+	"image/jpeg":                    true,
+	"image/png":                     true,
+	"image/svg+xml":                 true,
+	"image/webp":                    true,
+	"audio/mpeg":                    true,
+	"audio/mp4":                     true,
+	"audio/ogg":                     true,
+	"text/css":                      true,
+	"font/ttf":                      true,
+	"font/otf":                      true,
+	"font/woff":                     true,
+	"font/woff2":                    true,
+	"application/font-sfnt":         true,
+	"application/font-woff":         true,
+	"application/vnd.ms-opentype":   true,
+	"application/xhtml+xml":         true,
+	"application/javascript":        true,
+	"text/javascript":               true,
+	"application/ecmascript":        true,
+	"application/x-dtbncx+xml":      true,
+	"application/smil+xml":          true,
+	"application/pls+xml":           true,
+	"application/oebps-package+xml": true,
+}
+
+// IsCoreMediaType reports whether mediaType, with or without parameters, is
+// an EPUB core media type.
+func IsCoreMediaType(mediaType string) bool {
+	if base, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = base
+	}
+
+	return coreMediaTypes[mediaType]
+}
+
+// ItemByID returns the manifest item with the given id.
+func (epubReader *EpubReader) ItemByID(id string) (Item, bool) {
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.ID == id {
+			return item, true
+		}
+	}
+
+	return Item{}, false
+}
+
+// FallbackChain returns the item with the given id followed by the items of
+// its fallback chain.
+func (epubReader *EpubReader) FallbackChain(id string) ([]Item, error) {
+	var chain []Item
+	seen := make(map[string]bool)
+
+	for id != "" {
+		if seen[id] {
+			return chain, fmt.Errorf("epub: %s: item '%s': %w", epubReader.Name, id, ErrFallbackLoop)
+		}
+		seen[id] = true
+
+		item, ok := epubReader.ItemByID(id)
+		if !ok {
+			return chain, fmt.Errorf("epub: %s: item '%s': %w", epubReader.Name, id, ErrNoItem)
+		}
+		chain = append(chain, item)
+		id = item.Fallback
+	}
+
+	return chain, nil
+}
+
+// OpenWithFallback opens the first item of the fallback chain of id that has
+// a core media type, and returns it along with its content.
+func (epubReader *EpubReader) OpenWithFallback(id string) (Item, io.ReadCloser, error) {
+	chain, err := epubReader.FallbackChain(id)
+	for _, item := range chain {
+		if IsCoreMediaType(item.MediaType) {
+			reader, err := epubReader.openFile(epubReader.itemPath(item.Href))
+			return item, reader, err
+		}
+	}
+	if err != nil {
+		return Item{}, nil, err
+	}
+
+	return Item{}, nil, fmt.Errorf("epub: %s: item '%s': %w", epubReader.Name, id, ErrNoFallback)
+}
@@ -0,0 +1,56 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOpenWithFallback(t *testing.T) {
+	opf := strings.Replace(testOPF, `<manifest>`, `<manifest>
+    <item id="tiff" href="images/map.tiff" media-type="image/tiff" fallback="heic"/>
+    <item id="heic" href="images/map.heic" media-type="image/heic" fallback="png"/>
+    <item id="png" href="images/map.png" media-type="image/png"/>
+    <item id="loop1" href="a.foo" media-type="application/foo" fallback="loop2"/>
+    <item id="loop2" href="b.foo" media-type="application/foo" fallback="loop1"/>
+    <item id="dead" href="c.foo" media-type="application/foo"/>`, 1)
+
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/images/map.png", "PNG"},
+	)
+
+	item, rc, err := reader.OpenWithFallback("tiff")
+	if err != nil {
+		t.Fatalf("OpenWithFallback(tiff) = %v", err)
+	}
+	rc.Close()
+	if item.ID != "png" {
+		t.Errorf("OpenWithFallback(tiff) = %s", item.ID)
+	}
+
+	if _, _, err := reader.OpenWithFallback("loop1"); !errors.Is(err, ErrFallbackLoop) {
+		t.Errorf("OpenWithFallback(loop1) = %v", err)
+	}
+	if _, _, err := reader.OpenWithFallback("dead"); !errors.Is(err, ErrNoFallback) {
+		t.Errorf("OpenWithFallback(dead) = %v", err)
+	}
+	if _, _, err := reader.OpenWithFallback("nope"); !errors.Is(err, ErrNoItem) {
+		t.Errorf("OpenWithFallback(nope) = %v", err)
+	}
+}
+
+func TestIsCoreMediaType(t *testing.T) {
+	for mediaType, want := range map[string]bool{
+		"image/png":               true,
+		"audio/ogg; codecs=opus":  true,
+		"image/tiff":              false,
+		"application/pdf":         false,
+		"application/xhtml+xml":   true,
+		"application/x-shockwave": false,
+	} {
+		if got := IsCoreMediaType(mediaType); got != want {
+			t.Errorf("IsCoreMediaType(%q) = %v", mediaType, got)
+		}
+	}
+}
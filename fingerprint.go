@@ -0,0 +1,72 @@
+package epub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// FingerprintVersion is the version of the fingerprint algorithm. It is
+// part of every fingerprint and changes whenever the inputs change, so that
+// fingerprints of different versions never compare equal.
+const FingerprintVersion = 1
+
+// Fingerprint returns an identifier of the book for sync services, of the
+// form "epubfp1:" followed by a hex SHA-256.
+//
+// The hash covers, one "key=value" line each, normalized to lower case with
+// white space collapsed: the unique identifier of the package, the other
+// identifiers in document order, the title, the creator, the language,
+// the publisher, the edition statement (see Edition), and the hrefs of the
+// spine documents in reading order. Modification dates, file order,
+// compression, stylesheets and the content of documents, which vary between
+// downloads from the same store (watermarks, repackaging), are left out;
+// identifiers, edition statements and the spine differ between editions.
+func (epubReader *EpubReader) Fingerprint() string {
+	hash := sha256.New()
+	for _, line := range epubReader.fingerprintInputs() {
+		hash.Write([]byte(line))
+		hash.Write([]byte{'\n'})
+	}
+
+	return "epubfp" + strconv.Itoa(FingerprintVersion) + ":" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func (epubReader *EpubReader) fingerprintInputs() []string {
+	rootfile := epubReader.Rootfiles[0]
+	metadata := rootfile.Metadata
+	normalize := func(s string) string { return strings.ToLower(collapseSpace(s)) }
+
+	inputs := []string{"version=" + strconv.Itoa(FingerprintVersion)}
+	for _, identifier := range metadata.Identifier {
+		key := "identifier="
+		if identifier.ID != "" && identifier.ID == rootfile.UniqueIdentifier {
+			key = "unique-identifier="
+		}
+		inputs = append(inputs, key+normalizeIdentifier(identifier.Text))
+	}
+	inputs = append(inputs,
+		"title="+normalize(firstTitle(metadata.Title)),
+		"creator="+normalize(firstCreator(metadata.Creator).Text),
+		"language="+normalize(firstText(metadata.Language)),
+		"publisher="+normalize(firstText(metadata.Publisher)),
+		"edition="+normalize(epubReader.Edition().Statement),
+	)
+	for _, item := range epubReader.SpineItems() {
+		inputs = append(inputs, "spine="+epubReader.itemPath(item.Href))
+	}
+
+	return inputs
+}
+
+// normalizeIdentifier lowercases an identifier and drops the urn: prefixes
+// and the hyphens and spaces stores add or remove from ISBNs and UUIDs.
+func normalizeIdentifier(identifier string) string {
+	identifier = strings.ToLower(strings.TrimSpace(identifier))
+	for _, prefix := range []string{"urn:uuid:", "urn:isbn:", "isbn:", "uuid:"} {
+		identifier = strings.TrimPrefix(identifier, prefix)
+	}
+
+	return strings.NewReplacer("-", "", " ", "").Replace(identifier)
+}
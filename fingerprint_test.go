@@ -0,0 +1,76 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	fingerprint := openTestEpub(t).Fingerprint()
+	if !strings.HasPrefix(fingerprint, "epubfp1:") || len(fingerprint) != len("epubfp1:")+64 {
+		t.Fatalf("Fingerprint() = %q", fingerprint)
+	}
+
+	// A re-download: different identifier formatting, white space, content
+	// and modification date, and added files.
+	opf := strings.Replace(testOPF, "9780306406157", "urn:isbn:978-0-306-40615-7", 1)
+	opf = strings.Replace(opf, "<dc:title>The Test Book</dc:title>", "<dc:title>The  Test\n Book</dc:title>", 1)
+	opf = strings.Replace(opf, "</metadata>", `<meta property="dcterms:modified">2026-01-01T00:00:00Z</meta></metadata>`, 1)
+	same := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/chapter2.xhtml", strings.Replace(testChapter2, "The end.", "The end. Licensed to jane@example.com", 1)},
+		testFile{"META-INF/watermark.txt", "jane"},
+	)
+	if got := same.Fingerprint(); got != fingerprint {
+		t.Errorf("re-download Fingerprint() = %q, want %q", got, fingerprint)
+	}
+
+	for name, opf := range map[string]string{
+		"isbn":    strings.Replace(testOPF, "9780306406157", "9780306406164", 1),
+		"edition": strings.Replace(testOPF, "<dc:title>The Test Book</dc:title>", "<dc:title>The Test Book, 2nd Edition</dc:title>", 1),
+		"spine":   strings.Replace(testOPF, `<itemref idref="chapter2"/>`, "", 1),
+	} {
+		if got := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Fingerprint(); got == fingerprint {
+			t.Errorf("%s: Fingerprint() did not change", name)
+		}
+	}
+}
+
+func TestFingerprintIgnoresCompression(t *testing.T) {
+	data := buildTestEpub(t)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for i := len(zipReader.File) - 1; i >= 0; i-- {
+		file := zipReader.File[i]
+		w, err := writer.CreateHeader(&zip.FileHeader{Name: file.Name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content bytes.Buffer
+		content.ReadFrom(r)
+		r.Close()
+		w.Write(content.Bytes())
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repacked, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repacked.Fingerprint() != openTestEpub(t).Fingerprint() {
+		t.Error("repackaging changed the fingerprint")
+	}
+}
@@ -0,0 +1,17 @@
+package epub
+
+import (
+	"io/fs"
+)
+
+// FS returns a read-only view of the book as a file system. Paths mirror the
+// entries of the container, e.g. "META-INF/container.xml" or
+// "OEBPS/content.opf", so the book can be handed to anything that works on an
+// fs.FS (http.FS, template.ParseFS, fs.WalkDir, ...).
+func (epubReader *EpubReader) FS() fs.FS {
+	if storage, ok := epubReader.storage.(interface{ FS() fs.FS }); ok {
+		return storage.FS()
+	}
+
+	return storageFS{epubReader.storage}
+}
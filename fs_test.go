@@ -0,0 +1,40 @@
+package epub
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestFS(t *testing.T) {
+	reader := openTestEpub(t)
+	fsys := reader.FS()
+
+	mimetype, err := fs.ReadFile(fsys, "mimetype")
+	if err != nil {
+		t.Fatalf("ReadFile(mimetype) = %v", err)
+	}
+	if string(mimetype) != epubMimetype {
+		t.Errorf("mimetype = %q", mimetype)
+	}
+
+	var names []string
+	err = fs.WalkDir(fsys, "OEBPS", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDir() = %v", err)
+	}
+	if len(names) != 6 {
+		t.Errorf("WalkDir(OEBPS) = %v", names)
+	}
+
+	if _, err := fsys.Open("OEBPS/missing.xhtml"); err == nil {
+		t.Errorf("Open(missing) = no error")
+	}
+}
@@ -0,0 +1,204 @@
+package epub
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// CoverStyle describes a generated cover.
+type CoverStyle struct {
+	Width      int
+	Height     int
+	Background color.Color
+	Foreground color.Color
+	// Format is "png" or "jpeg".
+	Format string
+}
+
+// DefaultCoverStyle is a 600x900 PNG with cream letters on dark blue.
+var DefaultCoverStyle = CoverStyle{
+	Width:      600,
+	Height:     900,
+	Background: color.RGBA{0x1f, 0x3a, 0x5f, 0xff},
+	Foreground: color.RGBA{0xf5, 0xec, 0xd7, 0xff},
+	Format:     "png",
+}
+
+// MediaType returns the media type of the covers generated with the style.
+func (style CoverStyle) MediaType() string {
+	if style.Format == "jpeg" {
+		return "image/jpeg"
+	}
+
+	return "image/png"
+}
+
+// GenerateCover draws a typographic cover: the title in large letters in
+// the upper half, a rule, and the author below it. Zero fields of style are
+// taken from DefaultCoverStyle.
+func GenerateCover(title, author string, style CoverStyle) ([]byte, error) {
+	if style.Width <= 0 || style.Height <= 0 {
+		style.Width, style.Height = DefaultCoverStyle.Width, DefaultCoverStyle.Height
+	}
+	if style.Background == nil {
+		style.Background = DefaultCoverStyle.Background
+	}
+	if style.Foreground == nil {
+		style.Foreground = DefaultCoverStyle.Foreground
+	}
+	if style.Format == "" {
+		style.Format = DefaultCoverStyle.Format
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, style.Width, style.Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(style.Background), image.Point{}, draw.Src)
+	fg := image.NewUniform(style.Foreground)
+
+	margin := style.Width / 10
+	textWidth := style.Width - 2*margin
+
+	// Largest scale at which the title fits above the rule without
+	// breaking words.
+	top := margin + style.Height/10
+	ruleY := style.Height / 2
+	scale := 1
+	var lines []string
+	for s := maxInt(1, style.Width/40); s >= 1; s-- {
+		width := textWidth / ((glyphWidth + 1) * s)
+		lines = wrapText(glyphText(title), width)
+		if top+len(lines)*(glyphHeight+3)*s <= ruleY-2*s && longestWord(title) <= width {
+			scale = s
+			break
+		}
+	}
+	y := top
+	for _, line := range lines {
+		drawLine(img, fg, line, style.Width, y, scale)
+		y += (glyphHeight + 3) * scale
+	}
+
+	draw.Draw(img, image.Rect(margin, ruleY, style.Width-margin, ruleY+maxInt(1, scale/2)), fg, image.Point{}, draw.Src)
+
+	authorScale := maxInt(1, scale/2)
+	y = ruleY + 3*authorScale*glyphHeight
+	for _, line := range wrapText(glyphText(author), textWidth/((glyphWidth+1)*authorScale)) {
+		drawLine(img, fg, line, style.Width, y, authorScale)
+		y += (glyphHeight + 3) * authorScale
+	}
+
+	var buffer bytes.Buffer
+	var err error
+	switch style.Format {
+	case "png":
+		err = png.Encode(&buffer, img)
+	case "jpeg":
+		err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 90})
+	default:
+		err = fmt.Errorf("epub: unknown cover format %s", style.Format)
+	}
+
+	return buffer.Bytes(), err
+}
+
+// SetGeneratedCover sets, as SetCover does, a cover generated with style
+// from the title and first creator of the book, when it has no cover. It
+// reports whether a cover was set.
+func (epubReader *EpubReader) SetGeneratedCover(style CoverStyle) (bool, error) {
+	if _, ok := epubReader.Cover(); ok || epubReader.cover != nil {
+		return false, nil
+	}
+
+	metadata := epubReader.Rootfiles[0].Metadata
+	var author string
+	if len(metadata.Creator) > 0 {
+		author = metadata.Creator[0].Text
+	}
+	data, err := GenerateCover(firstTitle(metadata.Title), author, style)
+	if err != nil {
+		return false, fmt.Errorf("epub: %s: cover: %w", epubReader.Name, err)
+	}
+
+	return true, epubReader.SetCover(style.MediaType(), bytes.NewReader(data))
+}
+
+// wrapText splits text into lines of at most width runes, breaking at
+// spaces when possible.
+func wrapText(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > width {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+
+	return lines
+}
+
+func longestWord(text string) int {
+	longest := 0
+	for _, word := range strings.Fields(glyphText(text)) {
+		if n := len([]rune(word)); n > longest {
+			longest = n
+		}
+	}
+
+	return longest
+}
+
+// drawLine draws text horizontally centered, its top at y.
+func drawLine(img draw.Image, fg image.Image, text string, width, y, scale int) {
+	x := (width - (len([]rune(text))*(glyphWidth+1)-1)*scale) / 2
+	drawText(img, fg, text, x, y, scale)
+}
+
+// drawText draws text, its top left corner at x, y.
+func drawText(img draw.Image, fg image.Image, text string, x, y, scale int) {
+	for _, r := range text {
+		rows := strings.Fields(glyphs[r])
+		for row, bits := range rows {
+			for col, bit := range bits {
+				if bit == '#' {
+					px, py := x+col*scale, y+row*scale
+					draw.Draw(img, image.Rect(px, py, px+scale, py+scale), fg, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,119 @@
+package epub
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateCover(t *testing.T) {
+	data, err := GenerateCover("Le Comte de Monte-Cristo", "Alexandre Dumas", CoverStyle{})
+	if err != nil {
+		t.Fatalf("GenerateCover() = %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("png.Decode() = %v", err)
+	}
+	if img.Bounds() != image.Rect(0, 0, 600, 900) {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+
+	// The title is drawn in the upper half, the author in the lower half.
+	bg := DefaultCoverStyle.Background
+	count := func(r image.Rectangle) int {
+		n := 0
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				if img.At(x, y) != bg {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if count(image.Rect(0, 0, 600, 440)) == 0 || count(image.Rect(0, 470, 600, 900)) == 0 {
+		t.Errorf("cover has no text")
+	}
+
+	style := CoverStyle{Width: 300, Height: 450, Format: "jpeg"}
+	data, err = GenerateCover("A Title", "", style)
+	if err != nil {
+		t.Fatalf("GenerateCover(jpeg) = %v", err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil || style.MediaType() != "image/jpeg" {
+		t.Errorf("jpeg.Decode() = %v", err)
+	}
+
+	// A thumbnail, too narrow for the title to fit at scale 1, still has
+	// text.
+	data, err = GenerateCover("A Title", "An Author", CoverStyle{Width: 32, Height: 48})
+	if err != nil {
+		t.Fatalf("GenerateCover(32x48) = %v", err)
+	}
+	if img, err = png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("png.Decode() = %v", err)
+	}
+	if count(image.Rect(0, 0, 32, 24)) == 0 || count(image.Rect(0, 25, 32, 48)) == 0 {
+		t.Errorf("thumbnail has no text")
+	}
+
+	if _, err := GenerateCover("A Title", "", CoverStyle{Format: "gif"}); err == nil {
+		t.Errorf("GenerateCover(gif) = no error")
+	}
+}
+
+func TestSetGeneratedCover(t *testing.T) {
+	if ok, err := openTestEpub(t).SetGeneratedCover(CoverStyle{}); ok || err != nil {
+		t.Errorf("SetGeneratedCover() with a cover = %v, %v", ok, err)
+	}
+
+	opf := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, "", 1)
+	opf = strings.Replace(opf, `<item id="cover-image" href="images/cover.jpg" media-type="image/jpeg"/>`, "", 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	if ok, err := reader.SetGeneratedCover(CoverStyle{}); !ok || err != nil {
+		t.Fatalf("SetGeneratedCover() = %v, %v", ok, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, ok := written.Cover()
+	if !ok || item.MediaType != "image/png" {
+		t.Fatalf("Cover() = %+v, %v", item, ok)
+	}
+	data, _ := written.readFile(written.itemPath(item.Href))
+	if _, err := png.Decode(data); err != nil {
+		t.Errorf("png.Decode() = %v", err)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"THE GREAT GATSBY", 10, []string{"THE GREAT", "GATSBY"}},
+		{"SUPERCALIFRAGILISTIC", 8, []string{"SUPERCAL", "IFRAGILI", "STIC"}},
+		{"", 10, nil},
+	} {
+		if got := wrapText(test.text, test.width); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("wrapText(%q, %d) = %q", test.text, test.width, got)
+		}
+	}
+
+	if got := glyphText("Zoë’s café"); got != "ZOE'S CAFE" {
+		t.Errorf("glyphText() = %q", got)
+	}
+}
@@ -1,5 +1,8 @@
 module github.com/jeanmarcboite/epub
 
-go 1.15
+go 1.16
 
-require github.com/rs/zerolog v1.20.0
+require (
+	github.com/rs/zerolog v1.20.0
+	golang.org/x/text v0.3.0
+)
@@ -0,0 +1,92 @@
+package epub
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Sources of guessed metadata.
+const (
+	GuessedFromFilename = "filename"
+)
+
+// MetadataGuess is a title and author guessed for a book whose package has
+// none, so that catalogs do not show empty rows. It is never as reliable
+// as metadata, and should be shown as such.
+type MetadataGuess struct {
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Year is 0 when no year was found.
+	Year int `json:"year,omitempty"`
+	// Source is where the guess was made from, such as
+	// GuessedFromFilename.
+	Source string `json:"source"`
+	// Confidence ranges from 0 to 1.
+	Confidence float64 `json:"confidence"`
+}
+
+var (
+	filenameYear    = regexp.MustCompile(`\s*[(\[]((?:1[5-9]|20)\d\d)[)\]]`)
+	filenameBracket = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]\s*$`)
+	filenameDash    = regexp.MustCompile(`\s+[-–—]\s+`)
+)
+
+// GuessMetadataFromPath guesses the title and author of a book from its
+// file name, in the "Author - Title (Year)" form, underscores standing for
+// spaces. The directories of the file decide which side of the dash is
+// the author when one of them is named after it, as in the
+// "Author/Title (id)/Title - Author.epub" layout of calibre, and give the
+// author of files named after their title only.
+func GuessMetadataFromPath(filename string) (MetadataGuess, bool) {
+	guess := MetadataGuess{Source: GuessedFromFilename}
+
+	name := cleanFilename(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)))
+	if match := filenameYear.FindStringSubmatch(name); match != nil {
+		guess.Year, _ = strconv.Atoi(match[1])
+		name = strings.Replace(name, match[0], "", 1)
+	}
+	name = strings.TrimSpace(filenameBracket.ReplaceAllString(name, ""))
+	if name == "" {
+		return guess, false
+	}
+
+	dir := filepath.Dir(filename)
+	parent := cleanFilename(filepath.Base(dir))
+	grandparent := cleanFilename(filepath.Base(filepath.Dir(dir)))
+	isDir := func(s string) bool {
+		return s != "" && (strings.EqualFold(s, parent) || strings.EqualFold(s, grandparent))
+	}
+
+	if parts := filenameDash.Split(name, 2); len(parts) == 2 {
+		guess.Author, guess.Title = parts[0], parts[1]
+		guess.Confidence = 0.5
+		if isDir(guess.Title) && !isDir(guess.Author) {
+			guess.Author, guess.Title = guess.Title, guess.Author
+		}
+		if isDir(guess.Author) {
+			guess.Confidence = 0.7
+		}
+		return guess, true
+	}
+
+	guess.Title = name
+	guess.Confidence = 0.3
+	if strings.EqualFold(strings.TrimSpace(filenameBracket.ReplaceAllString(parent, "")), name) && grandparent != "." && grandparent != "" {
+		guess.Author = grandparent
+		guess.Confidence = 0.5
+	}
+
+	return guess, true
+}
+
+// cleanFilename returns the words of a file name, with underscores taken
+// for spaces.
+func cleanFilename(name string) string {
+	if name == "." || name == string(filepath.Separator) {
+		return ""
+	}
+
+	return collapseSpace(strings.ReplaceAll(name, "_", " "))
+}
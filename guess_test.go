@@ -0,0 +1,44 @@
+package epub
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGuessMetadataFromPath(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		want     MetadataGuess
+		ok       bool
+	}{
+		{"books/Ursula K. Le Guin - The Dispossessed (1974).epub", MetadataGuess{"The Dispossessed", "Ursula K. Le Guin", 1974, GuessedFromFilename, 0.5}, true},
+		{"books/Alain_Damasio_-_La_Horde_du_Contrevent.epub", MetadataGuess{"La Horde du Contrevent", "Alain Damasio", 0, GuessedFromFilename, 0.5}, true},
+		{"Alain Damasio/Les Furtifs (12)/Les Furtifs - Alain Damasio.epub", MetadataGuess{"Les Furtifs", "Alain Damasio", 0, GuessedFromFilename, 0.7}, true},
+		{"Alain Damasio/Les Furtifs (12)/Les Furtifs.epub", MetadataGuess{"Les Furtifs", "Alain Damasio", 0, GuessedFromFilename, 0.5}, true},
+		{"Dune [1965].epub", MetadataGuess{"Dune", "", 1965, GuessedFromFilename, 0.3}, true},
+		{"(1984).epub", MetadataGuess{Year: 1984, Source: GuessedFromFilename}, false},
+	} {
+		got, ok := GuessMetadataFromPath(filepath.FromSlash(test.filename))
+		if ok != test.ok || ok && got != test.want {
+			t.Errorf("GuessMetadataFromPath(%q) = %+v, %v, want %+v", test.filename, got, ok, test.want)
+		}
+	}
+}
+
+func TestLibraryBookGuess(t *testing.T) {
+	opf := strings.Replace(testOPF, "<dc:title>The Test Book</dc:title>", "<dc:title></dc:title>", 1)
+	filename := filepath.Join(t.TempDir(), "Jane Roe - Untitled Notes (2001).epub")
+	if err := os.WriteFile(filename, buildTestEpub(t, testFile{"OEBPS/content.opf", opf}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	book := readLibraryBook(filename)
+	if book.Title != "Untitled Notes" || book.Creator != "Jane Doe" || book.Guess == nil || book.Guess.Author != "Jane Roe" {
+		t.Errorf("readLibraryBook() = %+v, guess %+v", book, book.Guess)
+	}
+	if book = readLibraryBook(filepath.Join("testdata", "sample2.epub")); book.Guess != nil {
+		t.Errorf("readLibraryBook(sample2) guess = %+v, want none", book.Guess)
+	}
+}
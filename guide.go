@@ -0,0 +1,95 @@
+package epub
+
+import "strings"
+
+// GuideReference is a reference of the EPUB 2 guide.
+type GuideReference struct {
+	// Type is the type attribute, verbatim: "toc", "text", "start",
+	// "other.ms-coverimage-standard", ...
+	Type string
+	// Landmark is the EPUB 3 landmark semantic the type maps to, e.g.
+	// "bodymatter" for "text", or empty when the type is unknown.
+	Landmark string
+	Title    string
+	Href     string
+}
+
+// Custom reports whether the type is an "other." extension type.
+func (reference GuideReference) Custom() bool {
+	return strings.HasPrefix(strings.ToLower(reference.Type), "other.")
+}
+
+// guideLandmarks maps the guide types of OPF 2.0, and common non-standard
+// ones, to the EPUB 3 structural semantics vocabulary.
+var guideLandmarks = map[string]string{
+	"acknowledgements":       "acknowledgments",
+	"acknowledgments":        "acknowledgments",
+	"afterword":              "afterword",
+	"appendix":               "appendix",
+	"backmatter":             "backmatter",
+	"bibliography":           "bibliography",
+	"bodymatter":             "bodymatter",
+	"colophon":               "colophon",
+	"contents":               "toc",
+	"copyright":              "copyright-page",
+	"copyright-page":         "copyright-page",
+	"cover":                  "cover",
+	"dedication":             "dedication",
+	"epigraph":               "epigraph",
+	"epilogue":               "epilogue",
+	"errata":                 "errata",
+	"foreword":               "foreword",
+	"frontmatter":            "frontmatter",
+	"glossary":               "glossary",
+	"index":                  "index",
+	"introduction":           "introduction",
+	"loa":                    "loa",
+	"loi":                    "loi",
+	"lot":                    "lot",
+	"lov":                    "lov",
+	"ms-coverimage":          "cover",
+	"ms-coverimage-standard": "cover",
+	"ms-thumbimage":          "cover",
+	"ms-thumbimage-standard": "cover",
+	"ms-titlepage":           "titlepage",
+	"ms-titlepage-standard":  "titlepage",
+	"notes":                  "endnotes",
+	"preface":                "preface",
+	"prologue":               "prologue",
+	"reader-start-page":      "bodymatter",
+	"start":                  "bodymatter",
+	"table-of-contents":      "toc",
+	"text":                   "bodymatter",
+	"title-page":             "titlepage",
+	"titlepage":              "titlepage",
+	"toc":                    "toc",
+}
+
+// GuideLandmark returns the EPUB 3 landmark semantic of a guide type, or
+// the empty string when the type is unknown. Types are matched without
+// regard to case, and "other." types are mapped like the type following
+// the prefix.
+func GuideLandmark(guideType string) string {
+	key := strings.ToLower(strings.TrimSpace(guideType))
+	if landmark, ok := guideLandmarks[key]; ok {
+		return landmark
+	}
+
+	return guideLandmarks[strings.TrimPrefix(key, "other.")]
+}
+
+// Guide returns the references of the EPUB 2 guide, in document order.
+func (epubReader *EpubReader) Guide() []GuideReference {
+	var references []GuideReference
+
+	for _, reference := range epubReader.Rootfiles[0].Guide.Reference {
+		references = append(references, GuideReference{
+			Type:     reference.Type,
+			Landmark: GuideLandmark(reference.Type),
+			Title:    reference.Title,
+			Href:     reference.Href,
+		})
+	}
+
+	return references
+}
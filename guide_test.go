@@ -0,0 +1,35 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGuide(t *testing.T) {
+	opf := strings.Replace(testOPF, "</package>", `<guide>
+    <reference type="toc" title="Contents" href="toc.xhtml"/>
+    <reference type="text" title="Start" href="chapter1.xhtml"/>
+    <reference type="Acknowledgements" href="thanks.xhtml"/>
+    <reference type="other.ms-coverimage-standard" href="images/cover.jpg"/>
+    <reference type="other.reader-start-page" href="chapter1.xhtml#start"/>
+    <reference type="other.sponsor" href="ads.xhtml"/>
+  </guide>
+</package>`, 1)
+
+	guide := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Guide()
+	want := []GuideReference{
+		{Type: "toc", Landmark: "toc", Title: "Contents", Href: "toc.xhtml"},
+		{Type: "text", Landmark: "bodymatter", Title: "Start", Href: "chapter1.xhtml"},
+		{Type: "Acknowledgements", Landmark: "acknowledgments", Href: "thanks.xhtml"},
+		{Type: "other.ms-coverimage-standard", Landmark: "cover", Href: "images/cover.jpg"},
+		{Type: "other.reader-start-page", Landmark: "bodymatter", Href: "chapter1.xhtml#start"},
+		{Type: "other.sponsor", Href: "ads.xhtml"},
+	}
+	if !reflect.DeepEqual(guide, want) {
+		t.Errorf("Guide() = %+v", guide)
+	}
+	if guide[0].Custom() || !guide[5].Custom() {
+		t.Error("Custom() mismatch")
+	}
+}
@@ -0,0 +1,240 @@
+package epub
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// HealthReport is what WriteHTMLReport renders: the validation reports of
+// books and, optionally, the statistics of the corpus they belong to.
+type HealthReport struct {
+	Title     string
+	Generated time.Time
+	Reports   []FileReport
+	Stats     *CorpusStats
+}
+
+type htmlReportBook struct {
+	Anchor   string
+	Path     string
+	Name     string
+	Errors   int
+	Warnings int
+	Infos    int
+	Issues   []Issue
+}
+
+type htmlReportRule struct {
+	ID          string
+	Description string
+	Books       int
+	Issues      int
+	Errors      int
+	Warnings    int
+	Infos       int
+}
+
+type htmlReportCount struct {
+	Key   string
+	Count int
+}
+
+type htmlReportData struct {
+	Title     string
+	Generated string
+	Failing   int
+	Issues    int
+	Books     []htmlReportBook
+	Rules     []htmlReportRule
+	Stats     *CorpusStats
+	Counts    []htmlReportCount
+	Versions  []htmlReportCount
+	Producers []htmlReportCount
+}
+
+// WriteHTMLReport writes the report as a standalone HTML page, for editors
+// and other readers who do not use the command line: a table of the books
+// and one of the rules, both sortable by clicking their headers, the
+// statistics of the corpus, and the issues of each book, linked from the
+// book table.
+func WriteHTMLReport(w io.Writer, report HealthReport) error {
+	data := htmlReportData{Title: report.Title, Stats: report.Stats}
+	if data.Title == "" {
+		data.Title = "EPUB report"
+	}
+	if !report.Generated.IsZero() {
+		data.Generated = report.Generated.Format(time.RFC1123)
+	}
+
+	descriptions := make(map[string]string)
+	for _, rule := range Rules() {
+		descriptions[rule.ID] = rule.Description
+	}
+	rules := make(map[string]*htmlReportRule)
+
+	for i, fileReport := range report.Reports {
+		book := htmlReportBook{Anchor: "book-" + strconv.Itoa(i+1), Path: fileReport.Path, Name: filepath.Base(fileReport.Path), Issues: fileReport.Issues}
+		seen := make(map[string]bool)
+		for _, issue := range fileReport.Issues {
+			rule, ok := rules[issue.RuleID]
+			if !ok {
+				rule = &htmlReportRule{ID: issue.RuleID, Description: descriptions[issue.RuleID]}
+				rules[issue.RuleID] = rule
+			}
+			rule.Issues++
+			if !seen[issue.RuleID] {
+				seen[issue.RuleID] = true
+				rule.Books++
+			}
+			switch {
+			case issue.Severity >= SeverityError:
+				book.Errors++
+				rule.Errors++
+			case issue.Severity == SeverityWarning:
+				book.Warnings++
+				rule.Warnings++
+			default:
+				book.Infos++
+				rule.Infos++
+			}
+		}
+		if fileReport.HasErrors() {
+			data.Failing++
+		}
+		data.Issues += len(fileReport.Issues)
+		data.Books = append(data.Books, book)
+	}
+
+	for _, rule := range rules {
+		data.Rules = append(data.Rules, *rule)
+	}
+	sort.Slice(data.Rules, func(i, j int) bool {
+		a, b := data.Rules[i], data.Rules[j]
+		return a.Issues > b.Issues || a.Issues == b.Issues && a.ID < b.ID
+	})
+
+	if stats := report.Stats; stats != nil {
+		data.Counts = []htmlReportCount{
+			{"Books", stats.Books},
+			{"Unreadable", stats.Unreadable},
+			{"Without ISBN", stats.NoISBN},
+			{"Without cover", stats.NoCover},
+			{"Without table of contents", stats.NoTOC},
+			{"Broken spine", stats.BrokenSpine},
+			{"Missing files", stats.MissingFiles},
+		}
+		data.Versions = sortedCounts(stats.Versions)
+		data.Producers = sortedCounts(stats.Producers)
+	}
+
+	return htmlReport.Execute(w, data)
+}
+
+// sortedCounts returns the counts, largest first.
+func sortedCounts(counts map[string]int) []htmlReportCount {
+	sorted := make([]htmlReportCount, 0, len(counts))
+	for key, count := range counts {
+		sorted = append(sorted, htmlReportCount{key, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Key < b.Key
+	})
+
+	return sorted
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+table.sortable th { cursor: pointer; }
+th[aria-sort=ascending]::after { content: " \25B2"; }
+th[aria-sort=descending]::after { content: " \25BC"; }
+td.number { text-align: right; }
+.error { color: #b00020; }
+.warning { color: #a05a00; }
+.info { color: #555; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Generated}}<p>Generated {{.Generated}}</p>
+{{end}}{{if .Books}}<p>{{len .Books}} books, {{.Failing}} with errors, {{.Issues}} issues.</p>
+
+<h2>Books</h2>
+<table class="sortable">
+<thead><tr><th>Book</th><th>Errors</th><th>Warnings</th><th>Infos</th></tr></thead>
+<tbody>
+{{range .Books}}<tr><td><a href="#{{.Anchor}}" title="{{.Path}}">{{.Name}}</a></td><td class="number">{{.Errors}}</td><td class="number">{{.Warnings}}</td><td class="number">{{.Infos}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{if .Rules}}
+<h2>Rules</h2>
+<table class="sortable">
+<thead><tr><th>Rule</th><th>Description</th><th>Books</th><th>Errors</th><th>Warnings</th><th>Infos</th></tr></thead>
+<tbody>
+{{range .Rules}}<tr><td>{{.ID}}</td><td>{{.Description}}</td><td class="number">{{.Books}}</td><td class="number">{{.Errors}}</td><td class="number">{{.Warnings}}</td><td class="number">{{.Infos}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{if .Stats}}
+<h2>Library</h2>
+<table>
+<tbody>
+{{range .Counts}}<tr><th>{{.Key}}</th><td class="number">{{.Count}}</td></tr>
+{{end}}</tbody>
+</table>
+<table class="sortable">
+<thead><tr><th>EPUB version</th><th>Books</th></tr></thead>
+<tbody>
+{{range .Versions}}<tr><td>{{.Key}}</td><td class="number">{{.Count}}</td></tr>
+{{end}}</tbody>
+</table>
+<table class="sortable">
+<thead><tr><th>Producer</th><th>Books</th></tr></thead>
+<tbody>
+{{range .Producers}}<tr><td>{{.Key}}</td><td class="number">{{.Count}}</td></tr>
+{{end}}</tbody>
+</table>
+{{end}}{{range .Books}}
+<h3 id="{{.Anchor}}">{{.Path}}</h3>
+{{if .Issues}}<table>
+<thead><tr><th>Severity</th><th>Rule</th><th>File</th><th>Message</th></tr></thead>
+<tbody>
+{{range .Issues}}<tr class="{{.Severity}}"><td>{{.Severity}}</td><td>{{.RuleID}}</td><td>{{.Path}}</td><td>{{.Message}}</td></tr>
+{{end}}</tbody>
+</table>
+{{else}}<p>No issues.</p>
+{{end}}{{end}}
+<script>
+document.querySelectorAll("table.sortable").forEach(function (table) {
+  table.querySelectorAll("thead th").forEach(function (th, column) {
+    th.addEventListener("click", function () {
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      var ascending = th.getAttribute("aria-sort") !== "ascending";
+      rows.sort(function (a, b) {
+        var x = a.cells[column].textContent, y = b.cells[column].textContent;
+        var c = isNaN(x) || isNaN(y) ? x.localeCompare(y) : x - y;
+        return ascending ? c : -c;
+      });
+      table.querySelectorAll("thead th").forEach(function (h) { h.removeAttribute("aria-sort"); });
+      th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+      rows.forEach(function (row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,46 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	reports := []FileReport{
+		{Path: "library/a.epub", Issues: []Issue{
+			{RuleID: RuleContainer, Severity: SeverityError, Message: "not a <zip>"},
+			{RuleID: "spine-empty", Severity: SeverityWarning, Path: "OEBPS/content.opf", Message: "spine is empty"},
+		}},
+		{Path: "library/b.epub"},
+	}
+	stats := &CorpusStats{Books: 2, NoCover: 1, Versions: map[string]int{"2.0": 1, "3.0": 1}, Producers: map[string]int{"calibre": 2}}
+
+	var b bytes.Buffer
+	err := WriteHTMLReport(&b, HealthReport{Title: "Spring list", Generated: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Reports: reports, Stats: stats})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := b.String()
+	for _, want := range []string{
+		"<title>Spring list</title>",
+		"<p>2 books, 1 with errors, 2 issues.</p>",
+		`<a href="#book-1" title="library/a.epub">a.epub</a>`,
+		`<h3 id="book-2">library/b.epub</h3>`,
+		"<p>No issues.</p>",
+		"not a &lt;zip&gt;",
+		"<tr><th>Without cover</th><td class=\"number\">1</td></tr>",
+		"<tr><td>calibre</td><td class=\"number\">2</td></tr>",
+		`<table class="sortable">`,
+		"<script>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report lacks %s", want)
+		}
+	}
+	if !strings.Contains(page, "<h2>Books</h2>") || !strings.Contains(page, "<td>container</td>") {
+		t.Errorf("report lacks the book or rule table")
+	}
+}
@@ -0,0 +1,135 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ReadingOrderPath is the path HTTPHandlerWith serves the reading order of the
+// book at, when HTTPHandlerOptions.ReadingOrder is set.
+const ReadingOrderPath = "/reading-order.json"
+
+// HTTPHandlerOptions tunes HTTPHandlerWith.
+type HTTPHandlerOptions struct {
+	// ReadingOrder serves the reading order of the book as JSON at
+	// ReadingOrderPath.
+	ReadingOrder bool
+}
+
+// ReadingOrder is the JSON document of the reading order endpoint of
+// HTTPHandlerWith.
+type ReadingOrder struct {
+	Title    string `json:"title,omitempty"`
+	Language string `json:"language,omitempty"`
+	// ReadingOrder lists the spine documents, with hrefs relative to the
+	// root of the handler.
+	ReadingOrder []ReadingOrderLink `json:"readingOrder"`
+}
+
+// ReadingOrderLink is a document of the reading order.
+type ReadingOrderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type"`
+}
+
+// HTTPHandler returns a handler serving the resources of the book over HTTP,
+// as HTTPHandlerWith with no options does.
+func (epubReader *EpubReader) HTTPHandler() http.Handler {
+	return epubReader.HTTPHandlerWith(HTTPHandlerOptions{})
+}
+
+// HTTPHandlerWith returns a handler serving the resources of the book over
+// HTTP, to render it in a browser. Paths are relative to the package
+// document, as manifest hrefs are, so that /chapter1.xhtml serves
+// OEBPS/chapter1.xhtml; paths not found there are taken as container paths.
+// The Content-Type is the media type of the manifest item, or else guessed
+// from the extension. / redirects to the first spine document. Files are
+// read whole, within MaxItemSize, and served with support for ranges and
+// conditional requests.
+func (epubReader *EpubReader) HTTPHandlerWith(options HTTPHandlerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		urlPath := path.Clean("/" + r.URL.Path)
+		switch {
+		case urlPath == "/":
+			items := epubReader.SpineItems()
+			if len(items) == 0 {
+				http.NotFound(w, r)
+				return
+			}
+			// A relative location, which http.Redirect would make absolute,
+			// keeps the prefix the handler may be mounted under, as with
+			// http.StripPrefix.
+			w.Header().Set("Location", items[0].Href)
+			w.WriteHeader(http.StatusFound)
+			return
+		case options.ReadingOrder && urlPath == ReadingOrderPath:
+			epubReader.serveReadingOrder(w, r)
+			return
+		}
+
+		name := path.Join(path.Dir(epubReader.Rootfiles[0].FullPath), urlPath[1:])
+		if !epubReader.hasFile(name) {
+			name = urlPath[1:]
+		}
+		buffer, err := epubReader.readFile(name)
+		switch {
+		case errors.Is(err, ErrorFileMissing):
+			http.NotFound(w, r)
+			return
+		case errors.Is(err, ErrItemTooLarge):
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		mediaType := mime.TypeByExtension(path.Ext(name))
+		if item, ok := epubReader.itemByPath(name); ok && item.MediaType != "" {
+			mediaType = item.MediaType
+		}
+		if mediaType == "" {
+			mediaType = http.DetectContentType(buffer.Bytes())
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+
+		var modTime time.Time
+		if info, ok := epubReader.stat(name); ok {
+			modTime = info.ModTime()
+		}
+		http.ServeContent(w, r, name, modTime, bytes.NewReader(buffer.Bytes()))
+	})
+}
+
+func (epubReader *EpubReader) serveReadingOrder(w http.ResponseWriter, r *http.Request) {
+	metadata := epubReader.Rootfiles[0].Metadata
+	order := ReadingOrder{
+		Title:        strings.TrimSpace(firstTitle(metadata.Title)),
+		Language:     strings.TrimSpace(firstText(metadata.Language)),
+		ReadingOrder: []ReadingOrderLink{},
+	}
+	for _, item := range epubReader.SpineItems() {
+		order.ReadingOrder = append(order.ReadingOrder, ReadingOrderLink{Href: item.Href, Type: item.MediaType})
+	}
+
+	data, err := json.Marshal(order)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,80 @@
+package epub
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	reader := openTestEpub(t, testFile{"fonts/serif.otf", "OTTO"})
+	handler := reader.HTTPHandlerWith(HTTPHandlerOptions{ReadingOrder: true})
+
+	get := func(method, target string, header ...string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for _, test := range []struct {
+		method, target string
+		status         int
+		contentType    string
+		body           string
+	}{
+		{"GET", "/chapter1.xhtml", http.StatusOK, "application/xhtml+xml", testChapter1},
+		{"GET", "/OEBPS/style.css", http.StatusOK, "text/css", "p { margin: 0; }"},
+		{"GET", "/text/../images/cover.jpg", http.StatusOK, "image/jpeg", ""},
+		{"GET", "/fonts/serif.otf", http.StatusOK, "", "OTTO"},
+		{"GET", "/../../etc/passwd", http.StatusNotFound, "", ""},
+		{"GET", "/missing.xhtml", http.StatusNotFound, "", ""},
+		{"POST", "/chapter1.xhtml", http.StatusMethodNotAllowed, "", ""},
+		{"GET", "/", http.StatusFound, "", ""},
+	} {
+		response := get(test.method, test.target)
+		if response.Code != test.status {
+			t.Errorf("%s %s = %d, want %d", test.method, test.target, response.Code, test.status)
+			continue
+		}
+		if test.contentType != "" && !strings.HasPrefix(response.Header().Get("Content-Type"), test.contentType) {
+			t.Errorf("%s %s: Content-Type = %s", test.method, test.target, response.Header().Get("Content-Type"))
+		}
+		if test.body != "" && response.Body.String() != test.body {
+			t.Errorf("%s %s = %q", test.method, test.target, response.Body)
+		}
+	}
+	if location := get("GET", "/").Header().Get("Location"); location != "chapter1.xhtml" {
+		t.Errorf("GET / redirects to %s", location)
+	}
+	server := httptest.NewServer(http.StripPrefix("/book/", handler))
+	defer server.Close()
+	mounted, err := server.Client().Get(server.URL + "/book/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mounted.Body.Close()
+	if mounted.StatusCode != http.StatusOK || mounted.Request.URL.Path != "/book/chapter1.xhtml" {
+		t.Errorf("GET /book/ = %d from %s", mounted.StatusCode, mounted.Request.URL)
+	}
+	if response := get("GET", "/style.css", "Range", "bytes=0-0"); response.Code != http.StatusPartialContent || response.Body.String() != "p" {
+		t.Errorf("GET /style.css bytes=0-0 = %d %q", response.Code, response.Body)
+	}
+
+	var order ReadingOrder
+	response := get("GET", ReadingOrderPath)
+	if err := json.Unmarshal(response.Body.Bytes(), &order); err != nil {
+		t.Fatal(err)
+	}
+	if order.Title != "The Test Book" || len(order.ReadingOrder) != 2 || order.ReadingOrder[1] != (ReadingOrderLink{Href: "chapter2.xhtml", Type: "application/xhtml+xml"}) {
+		t.Errorf("GET %s = %s", ReadingOrderPath, response.Body)
+	}
+	if response := reader.HTTPHandler().(http.HandlerFunc); response == nil {
+		t.Error("Handler() = nil")
+	}
+}
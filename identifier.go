@@ -0,0 +1,143 @@
+package epub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// IdentifierType is the kind of a dc:identifier.
+type IdentifierType string
+
+const (
+	IdentifierISBN10  IdentifierType = "isbn10"
+	IdentifierISBN13  IdentifierType = "isbn13"
+	IdentifierUUID    IdentifierType = "uuid"
+	IdentifierDOI     IdentifierType = "doi"
+	IdentifierASIN    IdentifierType = "asin"
+	IdentifierCalibre IdentifierType = "calibre"
+	IdentifierOther   IdentifierType = "other"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	// asinPattern matches the ASINs of Kindle books; the ASIN of a
+	// printed book is its ISBN-10.
+	asinPattern = regexp.MustCompile(`^B0[0-9A-Z]{8}$`)
+)
+
+// Identifier is a dc:identifier of the package, typed and normalized.
+type Identifier struct {
+	Type IdentifierType
+	// Value is the normalized identifier: the digits of an ISBN, a
+	// lowercase UUID, a DOI without its doi: or resolver prefix, an
+	// uppercase ASIN, or else the text of the element, whitespace
+	// collapsed.
+	Value string
+	// Text is the text of the element.
+	Text string
+	// Scheme is the declared scheme of the identifier: its EPUB 2
+	// opf:scheme attribute or its EPUB 3 identifier-type refinement.
+	Scheme string
+	ID     string
+	// Unique is set for the identifier the unique-identifier attribute of
+	// the package names.
+	Unique bool
+	// Valid is false for ISBNs with a wrong check digit.
+	Valid bool
+}
+
+// Identifiers returns the identifiers of the book, in document order,
+// typed from their declared scheme or else from their form, such as a
+// urn:isbn: or urn:uuid: prefix.
+func (epubReader *EpubReader) Identifiers() []Identifier {
+	rootfile := epubReader.Rootfiles[0]
+
+	var identifiers []Identifier
+	for _, id := range rootfile.Metadata.Identifier {
+		identifier := Identifier{
+			Text:   id.Text,
+			Scheme: strings.TrimSpace(id.Scheme),
+			ID:     id.ID,
+			Unique: id.ID != "" && id.ID == rootfile.UniqueIdentifier,
+		}
+		declaredISBN := isISBNScheme(identifier.Scheme)
+		for _, meta := range rootfile.refinements(id.ID) {
+			if strings.TrimSpace(meta.Property) != "identifier-type" {
+				continue
+			}
+			if identifier.Scheme == "" {
+				identifier.Scheme = collapseSpace(meta.Text)
+				if strings.HasPrefix(meta.Scheme, "onix:codelist5") && identifier.Scheme == "06" {
+					identifier.Scheme = "DOI"
+				}
+			}
+			declaredISBN = declaredISBN || isISBNType(meta)
+		}
+		identifiers = append(identifiers, classifyIdentifier(identifier, declaredISBN))
+	}
+
+	return identifiers
+}
+
+// UniqueIdentifier returns the identifier the unique-identifier attribute
+// of the package names, and whether it exists.
+func (epubReader *EpubReader) UniqueIdentifier() (Identifier, bool) {
+	for _, identifier := range epubReader.Identifiers() {
+		if identifier.Unique {
+			return identifier, true
+		}
+	}
+
+	return Identifier{}, false
+}
+
+// classifyIdentifier sets the type, value and validity of identifier.
+func classifyIdentifier(identifier Identifier, declaredISBN bool) Identifier {
+	text := collapseSpace(identifier.Text)
+	lower := strings.ToLower(text)
+	scheme := strings.ToLower(identifier.Scheme)
+	identifier.Value, identifier.Valid = text, true
+
+	// Undeclared numbers are taken for ISBNs when their check digit is
+	// right, unless another scheme is declared.
+	declaredISBN = declaredISBN || strings.HasPrefix(lower, "urn:isbn:")
+	if isbn, valid := NormalizeISBN(text); isbn != "" && (declaredISBN || valid && scheme == "") {
+		identifier.Type, identifier.Value, identifier.Valid = IdentifierISBN13, isbn, valid
+		if len(isbn) == 10 {
+			identifier.Type = IdentifierISBN10
+		}
+		return identifier
+	}
+
+	doi := doiPattern.FindStringSubmatch(text)
+	switch {
+	case scheme == "uuid" || uuidPattern.MatchString(trimPrefixFold(trimPrefixFold(text, "urn:uuid:"), "uuid:")):
+		identifier.Type, identifier.Value = IdentifierUUID, strings.ToLower(trimPrefixFold(trimPrefixFold(text, "urn:uuid:"), "uuid:"))
+	case doi != nil:
+		identifier.Type, identifier.Value = IdentifierDOI, doi[1]
+	case scheme == "doi":
+		identifier.Type = IdentifierDOI
+	case scheme == "asin" || scheme == "mobi-asin" || scheme == "amazon" || asinPattern.MatchString(strings.ToUpper(trimASIN(text))):
+		identifier.Type, identifier.Value = IdentifierASIN, strings.ToUpper(trimASIN(text))
+	case scheme == "calibre":
+		identifier.Type = IdentifierCalibre
+	default:
+		identifier.Type = IdentifierOther
+	}
+
+	return identifier
+}
+
+// trimASIN returns the ASIN s without its urn:asin: or asin: prefix.
+func trimASIN(s string) string {
+	return trimPrefixFold(trimPrefixFold(s, "urn:asin:"), "asin:")
+}
+
+// trimPrefixFold returns s without prefix, ignoring case.
+func trimPrefixFold(s, prefix string) string {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):]
+	}
+
+	return s
+}
@@ -0,0 +1,48 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testIdentifiers = `<dc:identifier id="bookid">urn:uuid:5B4BBF4B-0C7C-4BD5-8BDB-1C5C0B7F3A41</dc:identifier>
+    <dc:identifier id="isbn">978-0-306-40615-7</dc:identifier>
+    <meta refines="#isbn" property="identifier-type" scheme="onix:codelist5">15</meta>
+    <dc:identifier opf:scheme="ISBN">0-306-40615-2</dc:identifier>
+    <dc:identifier>urn:isbn:9780306406158</dc:identifier>
+    <dc:identifier>https://doi.org/10.1000/182</dc:identifier>
+    <dc:identifier opf:scheme="MOBI-ASIN">b00abcdefg</dc:identifier>
+    <dc:identifier opf:scheme="calibre" id="calibre_id">0306406152</dc:identifier>
+    <dc:identifier>Internal 42</dc:identifier>`
+
+func TestIdentifiers(t *testing.T) {
+	opf := strings.Replace(testOPF, `<dc:identifier id="bookid" opf:scheme="ISBN">9780306406157</dc:identifier>`, testIdentifiers, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+
+	var got []Identifier
+	for _, identifier := range reader.Identifiers() {
+		identifier.Text = ""
+		got = append(got, identifier)
+	}
+	want := []Identifier{
+		{Type: IdentifierUUID, Value: "5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41", ID: "bookid", Unique: true, Valid: true},
+		{Type: IdentifierISBN13, Value: "9780306406157", Scheme: "15", ID: "isbn", Valid: true},
+		{Type: IdentifierISBN10, Value: "0306406152", Scheme: "ISBN", Valid: true},
+		{Type: IdentifierISBN13, Value: "9780306406158", Valid: false},
+		{Type: IdentifierDOI, Value: "10.1000/182", Valid: true},
+		{Type: IdentifierASIN, Value: "B00ABCDEFG", Scheme: "MOBI-ASIN", Valid: true},
+		{Type: IdentifierCalibre, Value: "0306406152", Scheme: "calibre", ID: "calibre_id", Valid: true},
+		{Type: IdentifierOther, Value: "Internal 42", Valid: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Identifiers() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if unique, ok := reader.UniqueIdentifier(); !ok || unique.Type != IdentifierUUID {
+		t.Errorf("UniqueIdentifier() = %+v, %v", unique, ok)
+	}
+	if unique, ok := openTestEpub(t).UniqueIdentifier(); !ok || unique.Type != IdentifierISBN13 || unique.Value != "9780306406157" {
+		t.Errorf("UniqueIdentifier() of the ISBN book = %+v, %v", unique, ok)
+	}
+}
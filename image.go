@@ -0,0 +1,357 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+)
+
+// BookImage is an image of the book, read and decoded on demand.
+type BookImage struct {
+	// Path is the zip path of the image, MediaType its declared media
+	// type, empty for images outside the manifest.
+	Path       string
+	MediaType  string
+	epubReader *EpubReader
+}
+
+// Image returns the image stored as the manifest item.
+func (epubReader *EpubReader) Image(item Item) *BookImage {
+	return &BookImage{Path: epubReader.itemPath(item.Href), MediaType: item.MediaType, epubReader: epubReader}
+}
+
+// CoverImage returns the cover image of the book, as found by Cover.
+func (epubReader *EpubReader) CoverImage() (*BookImage, bool) {
+	item, ok := epubReader.Cover()
+	if !ok {
+		return nil, false
+	}
+
+	return epubReader.Image(item), true
+}
+
+// ItemImages returns the images the content document item shows, in
+// document order, without reading them.
+func (epubReader *EpubReader) ItemImages(item Item) ([]*BookImage, error) {
+	paths, err := epubReader.documentImages(epubReader.itemPath(item.Href))
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*BookImage, len(paths))
+	for i, name := range paths {
+		images[i] = &BookImage{Path: name, epubReader: epubReader}
+		if image, ok := epubReader.itemByPath(name); ok {
+			images[i].MediaType = image.MediaType
+		}
+	}
+
+	return images, nil
+}
+
+// Data returns the bytes of the image, without its EXIF, XMP, IPTC and
+// text metadata when strip is true. See StripImageMetadata.
+func (img *BookImage) Data(strip bool) ([]byte, error) {
+	buffer, err := img.epubReader.readFile(img.Path)
+	if err != nil {
+		return nil, err
+	}
+	if strip {
+		return StripImageMetadata(buffer.Bytes()), nil
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Orientation returns the EXIF orientation of the image, from 1 (upright)
+// to 8, 1 when it has none.
+func (img *BookImage) Orientation() (int, error) {
+	data, err := img.Data(false)
+	if err != nil {
+		return 1, err
+	}
+
+	return imageOrientation(data), nil
+}
+
+// Config returns the color model and the dimensions of the image, as
+// displayed once its EXIF orientation is applied, without decoding it.
+func (img *BookImage) Config() (image.Config, error) {
+	data, err := img.Data(false)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return config, fmt.Errorf("epub: %s: decode '%s': %w", img.epubReader.Name, img.Path, err)
+	}
+	if imageOrientation(data) >= 5 {
+		config.Width, config.Height = config.Height, config.Width
+	}
+
+	return config, nil
+}
+
+// Decode decodes the image, rotated and flipped upright according to its
+// EXIF orientation. The image is decoded again on each call.
+func (img *BookImage) Decode() (image.Image, error) {
+	data, err := img.Data(false)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("epub: %s: decode '%s': %w", img.epubReader.Name, img.Path, err)
+	}
+
+	return orientImage(decoded, imageOrientation(data)), nil
+}
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader   = []byte("Exif\x00\x00")
+)
+
+// imageOrientation returns the EXIF orientation of a JPEG or PNG image, 1
+// when it has none.
+func imageOrientation(data []byte) int {
+	if bytes.HasPrefix(data, pngSignature) {
+		for _, chunk := range pngChunks(data) {
+			if chunk.kind == "eXIf" {
+				return tiffOrientation(chunk.data)
+			}
+		}
+		return 1
+	}
+
+	for _, segment := range jpegSegments(data) {
+		if segment.marker == 0xE1 && bytes.HasPrefix(segment.data, exifHeader) {
+			return tiffOrientation(segment.data[len(exifHeader):])
+		}
+	}
+
+	return 1
+}
+
+// tiffOrientation returns the Orientation tag of the first IFD of the TIFF
+// structure of EXIF data, 1 when it has none or is invalid.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+		}
+	}
+
+	return 1
+}
+
+// orientationTIFF returns a big-endian TIFF structure holding only the
+// Orientation tag.
+func orientationTIFF(orientation int) []byte {
+	return []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8,
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0,
+		0, 0, 0, 0, // no next IFD
+	}
+}
+
+// orientImage returns img transformed to display upright according to the
+// EXIF orientation.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	size := image.Rect(0, 0, w, h)
+	if orientation >= 5 {
+		size = image.Rect(0, 0, h, w)
+	}
+	oriented := image.NewRGBA(size)
+	draw.Draw(oriented, oriented.Bounds(), image.Transparent, image.Point{}, draw.Src)
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			oriented.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+
+	return oriented
+}
+
+// StripImageMetadata returns a JPEG or PNG image without the metadata that
+// may identify its author or where it was taken: EXIF, including GPS
+// positions and camera serial numbers, XMP, IPTC, comments and text
+// chunks. The EXIF orientation is kept, so that the image still displays
+// upright; color profiles are kept too. Other images are returned as is.
+func StripImageMetadata(data []byte) []byte {
+	if bytes.HasPrefix(data, pngSignature) {
+		return stripPNGMetadata(data)
+	}
+	segments := jpegSegments(data)
+	if len(segments) == 0 {
+		return data
+	}
+
+	orientation := imageOrientation(data)
+	stripped := bytes.NewBuffer(make([]byte, 0, len(data)))
+	stripped.Write(data[:2])
+
+	end := 2
+	for _, segment := range segments {
+		end = segment.end
+		if orientation != 1 && segment.marker != 0xE0 {
+			// The EXIF segment follows the JFIF one, if any.
+			payload := append(append([]byte{}, exifHeader...), orientationTIFF(orientation)...)
+			stripped.Write([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)})
+			stripped.Write(payload)
+			orientation = 1
+		}
+		switch segment.marker {
+		case 0xE1, 0xED, 0xFE: // EXIF and XMP, IPTC, comments
+			continue
+		}
+		stripped.Write(data[segment.start:segment.end])
+	}
+	stripped.Write(data[end:])
+
+	return stripped.Bytes()
+}
+
+// pngMetadataChunks are the PNG chunks StripImageMetadata removes.
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNGMetadata(data []byte) []byte {
+	orientation := imageOrientation(data)
+	stripped := bytes.NewBuffer(make([]byte, 0, len(data)))
+	stripped.Write(pngSignature)
+
+	end := len(pngSignature)
+	for _, chunk := range pngChunks(data) {
+		end = chunk.end
+		if pngMetadataChunks[chunk.kind] {
+			continue
+		}
+		if chunk.kind == "IDAT" && orientation != 1 {
+			// eXIf must precede the image data.
+			writePNGChunk(stripped, "eXIf", orientationTIFF(orientation))
+			orientation = 1
+		}
+		stripped.Write(data[chunk.start:chunk.end])
+	}
+	stripped.Write(data[end:])
+
+	return stripped.Bytes()
+}
+
+func writePNGChunk(b *bytes.Buffer, kind string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	b.Write(length[:])
+	b.WriteString(kind)
+	b.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	binary.Write(b, binary.BigEndian, crc.Sum32())
+}
+
+// imageSegment is a JPEG segment or a PNG chunk: data[start:end] holds it
+// whole.
+type imageSegment struct {
+	marker     byte
+	kind       string
+	start, end int
+	data       []byte
+}
+
+// jpegSegments returns the segments of a JPEG image preceding its scan
+// data, none if data is not a JPEG image.
+func jpegSegments(data []byte) []imageSegment {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+
+	var segments []imageSegment
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xFF {
+			i++ // fill byte
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segments = append(segments, imageSegment{marker: marker, start: i, end: i + 2 + length, data: data[i+4 : i+2+length]})
+		i += 2 + length
+	}
+
+	return segments
+}
+
+// pngChunks returns the chunks of a PNG image.
+func pngChunks(data []byte) []imageSegment {
+	var chunks []imageSegment
+	for i := len(pngSignature); i+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		if length < 0 || i+12+length > len(data) {
+			break
+		}
+		chunks = append(chunks, imageSegment{kind: string(data[i+4 : i+8]), start: i, end: i + 12 + length, data: data[i+8 : i+8+length]})
+		i += 12 + length
+	}
+
+	return chunks
+}
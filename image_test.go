@@ -0,0 +1,171 @@
+package epub
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPicture returns a 3x2 image whose pixels all differ.
+func testPicture() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 100), uint8(y * 100), 0, 255})
+		}
+	}
+
+	return img
+}
+
+// testOrientedJPEG returns a JPEG image with an EXIF orientation, a GPS-like XMP
+// packet and a comment.
+func testOrientedJPEG(t *testing.T, orientation int) []byte {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, testPicture(), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := encoded.Bytes()
+
+	segment := func(marker byte, payload []byte) []byte {
+		return append([]byte{0xFF, marker, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	}
+	var b bytes.Buffer
+	b.Write(data[:2])
+	b.Write(segment(0xE1, append(append([]byte{}, exifHeader...), orientationTIFF(orientation)...)))
+	b.Write(segment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<exif:GPSLatitude>48,51.4N</exif:GPSLatitude>")))
+	b.Write(segment(0xFE, []byte("Taken by Jane Doe")))
+	b.Write(data[2:])
+
+	return b.Bytes()
+}
+
+// testOrientedPNG returns a PNG image with an EXIF orientation and a text chunk.
+func testOrientedPNG(t *testing.T, orientation int) []byte {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, testPicture()); err != nil {
+		t.Fatal(err)
+	}
+	data := encoded.Bytes()
+	chunks := pngChunks(data)
+
+	var b bytes.Buffer
+	b.Write(data[:chunks[0].end])
+	writePNGChunk(&b, "tEXt", []byte("Author\x00Jane Doe"))
+	writePNGChunk(&b, "eXIf", orientationTIFF(orientation))
+	b.Write(data[chunks[0].end:])
+
+	return b.Bytes()
+}
+
+func TestOrientImage(t *testing.T) {
+	// The displayed position of the stored top left pixel, and the
+	// displayed size, for each orientation.
+	for orientation, want := range map[int][2]image.Point{
+		1: {{0, 0}, {3, 2}},
+		2: {{2, 0}, {3, 2}},
+		3: {{2, 1}, {3, 2}},
+		4: {{0, 1}, {3, 2}},
+		5: {{0, 0}, {2, 3}},
+		6: {{1, 0}, {2, 3}},
+		7: {{1, 2}, {2, 3}},
+		8: {{0, 2}, {2, 3}},
+	} {
+		oriented := orientImage(testPicture(), orientation)
+		if size := oriented.Bounds().Size(); size != want[1] {
+			t.Errorf("orientImage(%d) size = %v, want %v", orientation, size, want[1])
+		}
+		if r, g, _, _ := oriented.At(want[0].X, want[0].Y).RGBA(); r != 0 || g != 0 {
+			t.Errorf("orientImage(%d) top left pixel not at %v", orientation, want[0])
+		}
+	}
+}
+
+func TestBookImage(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/images/cover.jpg", string(testOrientedPNG(t, 6))})
+
+	cover, ok := reader.CoverImage()
+	if !ok || cover.Path != "OEBPS/images/cover.jpg" || cover.MediaType != "image/jpeg" {
+		t.Fatalf("CoverImage() = %+v, %v", cover, ok)
+	}
+	if orientation, err := cover.Orientation(); err != nil || orientation != 6 {
+		t.Errorf("Orientation() = %d, %v", orientation, err)
+	}
+	if config, err := cover.Config(); err != nil || config.Width != 2 || config.Height != 3 {
+		t.Errorf("Config() = %+v, %v", config, err)
+	}
+	decoded, err := cover.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, _, _ := decoded.At(1, 0).RGBA(); decoded.Bounds().Size() != image.Pt(2, 3) || r != 0 || g != 0 {
+		t.Errorf("Decode() not upright")
+	}
+
+	item, _ := reader.ItemByID("chapter1")
+	images, err := reader.ItemImages(item)
+	if err != nil || len(images) != 1 || images[0].Path != cover.Path {
+		t.Errorf("ItemImages() = %+v, %v", images, err)
+	}
+
+	if _, err := reader.Image(Item{Href: "style.css"}).Decode(); err == nil {
+		t.Errorf("Decode(style.css) = no error")
+	}
+}
+
+func TestStripImageMetadata(t *testing.T) {
+	for name, data := range map[string][]byte{"jpeg": testOrientedJPEG(t, 8), "png": testOrientedPNG(t, 8)} {
+		stripped := StripImageMetadata(data)
+		for _, personal := range []string{"GPS", "Jane Doe"} {
+			if bytes.Contains(stripped, []byte(personal)) {
+				t.Errorf("%s: StripImageMetadata() kept %q", name, personal)
+			}
+		}
+		if imageOrientation(stripped) != 8 {
+			t.Errorf("%s: StripImageMetadata() orientation = %d", name, imageOrientation(stripped))
+		}
+		if _, _, err := image.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("%s: decode stripped image: %v", name, err)
+		}
+	}
+
+	if data := []byte("GIF89a"); !bytes.Equal(StripImageMetadata(data), data) {
+		t.Errorf("StripImageMetadata(gif) changed")
+	}
+}
+
+func TestStripImageMetadataOnExport(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/images/cover.jpg", string(testOrientedJPEG(t, 1))})
+
+	dir := t.TempDir()
+	if _, err := reader.ExtractAllWith(dir, ExtractOptions{StripImageMetadata: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "OEBPS", "images", "cover.jpg"))
+	if err != nil || bytes.Contains(data, []byte("Jane Doe")) || imageOrientation(data) != 1 {
+		t.Errorf("extracted cover = %d bytes, %v", len(data), err)
+	}
+
+	var b bytes.Buffer
+	result, err := reader.WriteScrubbed(&b, ScrubOptions{StripImageMetadata: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Removed) != 1 || result.Removed[0].Path != "OEBPS/images/cover.jpg" {
+		t.Errorf("WriteScrubbed() = %+v", result.Removed)
+	}
+	scrubbed, err := OpenBuffer(b.Bytes(), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scrubbed.Close()
+	cover, _ := scrubbed.CoverImage()
+	if data, err := cover.Data(false); err != nil || bytes.Contains(data, []byte("Jane Doe")) {
+		t.Errorf("scrubbed cover = %d bytes, %v", len(data), err)
+	}
+}
@@ -0,0 +1,173 @@
+package epub
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxSniffedTitles bounds the content documents Inspect reads for titles.
+const maxSniffedTitles = 20
+
+// Inspection is what Inspect could salvage from a file.
+type Inspection struct {
+	Path string
+	// Entries lists the zip entries, empty when the file is not a zip.
+	Entries []string
+	// Opened is set when the file opened as a valid book.
+	Opened bool
+	// PackagePath is the package document the metadata was read from,
+	// found through the container or, when it is broken, by name.
+	PackagePath string
+	Title       string
+	Creator     string
+	Language    string
+	Identifier  string
+	Publisher   string
+	// HTMLTitles are the title elements of the first content documents,
+	// in archive order.
+	HTMLTitles []string
+	Errors     []error
+}
+
+// Inspect returns whatever can be learned about the book at filename,
+// without failing: the zip listing, the metadata of a package document
+// parsed leniently even when the container is missing or the package
+// invalid, and the titles of the content documents, with the errors met
+// along the way. The title falls back to the first content document title.
+func Inspect(filename string) Inspection {
+	inspection := Inspection{Path: filename}
+
+	file, err := os.Open(longPath(filename))
+	if err != nil {
+		inspection.Errors = append(inspection.Errors, err)
+		return inspection
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		inspection.Errors = append(inspection.Errors, err)
+		return inspection
+	}
+	zipReader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		inspection.Errors = append(inspection.Errors, fmt.Errorf("epub: open zip %s: %w", filename, err))
+		return inspection
+	}
+
+	storage := newZipStorage(zipReader, file)
+	inspection.Entries = append(inspection.Entries, storage.Names()...)
+	reader := &EpubReader{Name: filename, Files: storage.files}
+
+	if err := reader.init(storage); err == nil {
+		inspection.Opened = true
+		inspection.PackagePath = reader.Rootfiles[0].FullPath
+		inspection.setMetadata(&reader.Rootfiles[0].Package)
+	} else {
+		inspection.Errors = append(inspection.Errors, err)
+		inspection.salvagePackage(reader)
+	}
+
+	inspection.sniffTitles(reader)
+	if inspection.Title == "" && len(inspection.HTMLTitles) > 0 {
+		inspection.Title = inspection.HTMLTitles[0]
+	}
+
+	return inspection
+}
+
+func (inspection *Inspection) setMetadata(pkg *Package) {
+	metadata := pkg.Metadata
+	inspection.Title = collapseSpace(firstTitle(metadata.Title))
+	inspection.Creator = collapseSpace(firstCreator(metadata.Creator).Text)
+	inspection.Language = collapseSpace(firstText(metadata.Language))
+	inspection.Publisher = collapseSpace(firstText(metadata.Publisher))
+	for _, identifier := range metadata.Identifier {
+		if inspection.Identifier == "" || identifier.ID != "" && identifier.ID == pkg.UniqueIdentifier {
+			inspection.Identifier = collapseSpace(identifier.Text)
+		}
+	}
+}
+
+// salvagePackage parses leniently the package documents listed by the
+// container, if it can be read, or else the .opf entries, and keeps the
+// metadata of the first one that has any.
+func (inspection *Inspection) salvagePackage(reader *EpubReader) {
+	var candidates []string
+	if container, err := reader.readFile(containerPath); err == nil {
+		var c Container
+		if err := lenientDecode(container, &c); err == nil {
+			for _, rootfile := range c.Rootfiles {
+				candidates = append(candidates, rootfile.FullPath)
+			}
+		}
+	}
+	for _, name := range inspection.Entries {
+		if strings.EqualFold(path.Ext(name), ".opf") {
+			candidates = append(candidates, name)
+		}
+	}
+
+	for _, name := range candidates {
+		buffer, err := reader.readFile(name)
+		if err != nil {
+			inspection.Errors = append(inspection.Errors, err)
+			continue
+		}
+		var pkg Package
+		if err := lenientDecode(buffer, &pkg); err != nil {
+			inspection.Errors = append(inspection.Errors, fmt.Errorf("epub: %s: parse '%s': %w", reader.Name, name, err))
+		}
+		inspection.setMetadata(&pkg)
+		if inspection.Title != "" || inspection.Identifier != "" || inspection.Creator != "" {
+			inspection.PackagePath = name
+			return
+		}
+	}
+}
+
+// lenientDecode decodes an XML document that may not be well-formed,
+// keeping what was decoded before an error.
+func lenientDecode(r io.Reader, v interface{}) error {
+	decoder := xml.NewDecoder(newEntityGuard(r))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+
+	return decoder.Decode(v)
+}
+
+func (inspection *Inspection) sniffTitles(reader *EpubReader) {
+	for _, name := range inspection.Entries {
+		if len(inspection.HTMLTitles) >= maxSniffedTitles {
+			return
+		}
+		switch strings.ToLower(path.Ext(name)) {
+		case ".xhtml", ".html", ".htm":
+		default:
+			continue
+		}
+
+		file, err := reader.openFile(name)
+		if err != nil {
+			inspection.Errors = append(inspection.Errors, err)
+			continue
+		}
+		document, err := parseDOM(file)
+		file.Close()
+		if err != nil {
+			inspection.Errors = append(inspection.Errors, fmt.Errorf("epub: %s: parse '%s': %w", reader.Name, name, err))
+		}
+		if title := document.find(func(n *domNode) bool { return n.name == "title" }); title != nil {
+			if text := collapseSpace(title.textContent()); text != "" {
+				inspection.HTMLTitles = append(inspection.HTMLTitles, text)
+			}
+		}
+	}
+}
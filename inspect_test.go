@@ -0,0 +1,51 @@
+package epub
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+
+	inspection := Inspect(write("valid.epub", buildTestEpub(t)))
+	if !inspection.Opened || len(inspection.Errors) != 0 || inspection.Title != "The Test Book" ||
+		inspection.Identifier != "9780306406157" || len(inspection.Entries) != 8 || len(inspection.HTMLTitles) != 2 {
+		t.Errorf("valid: %+v", inspection)
+	}
+
+	inspection = Inspect(write("nocontainer.epub", buildTestEpub(t, testFile{containerPath, ""})))
+	if inspection.Opened || len(inspection.Errors) == 0 || inspection.Title != "The Test Book" ||
+		inspection.PackagePath != "OEBPS/content.opf" || inspection.Creator != "Jane Doe" {
+		t.Errorf("no container: %+v", inspection)
+	}
+
+	broken := strings.Replace(testOPF, "</metadata>", "<dc:subject>unclosed</metadata>", 1)
+	broken = strings.Replace(broken, `<dc:title>The Test Book</dc:title>`, `<dc:title>The Test Book & Co</dc:title>`, 1)
+	inspection = Inspect(write("broken.epub", buildTestEpub(t, testFile{"OEBPS/content.opf", broken})))
+	if inspection.Opened || inspection.Title != "The Test Book & Co" || inspection.Language != "en" {
+		t.Errorf("broken package: %+v", inspection)
+	}
+
+	inspection = Inspect(write("noopf.epub", buildTestEpub(t, testFile{"OEBPS/content.opf", ""})))
+	if inspection.Opened || inspection.Title != "Chapter One" || inspection.PackagePath != "" {
+		t.Errorf("no package: %+v", inspection)
+	}
+
+	inspection = Inspect(write("text.epub", []byte("not a zip")))
+	if len(inspection.Errors) != 1 || len(inspection.Entries) != 0 {
+		t.Errorf("not a zip: %+v", inspection)
+	}
+	if inspection := Inspect(filepath.Join(dir, "missing.epub")); len(inspection.Errors) != 1 {
+		t.Errorf("missing: %+v", inspection)
+	}
+}
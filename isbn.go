@@ -0,0 +1,85 @@
+package epub
+
+import (
+	"strings"
+)
+
+// isbnPrefixes are the prefixes of ISBN identifiers found in the wild,
+// lowercased, longest first.
+var isbnPrefixes = []string{"urn:isbn:", "isbn-13:", "isbn-10:", "isbn13:", "isbn10:", "isbn:", "isbn"}
+
+// NormalizeISBN returns the ISBN-10 or ISBN-13 written in s without its
+// urn:isbn: or ISBN prefix, hyphens and spaces, such as 9780306406157 for
+// "urn:isbn:978-0-306-40615-7". ok is false when the check digit is wrong;
+// the ISBN is empty when s is not shaped as an ISBN.
+func NormalizeISBN(s string) (isbn string, ok bool) {
+	s = strings.TrimSpace(s)
+	lower := strings.ToLower(s)
+	for _, prefix := range isbnPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			s = s[len(prefix):]
+			break
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case '0' <= r && r <= '9':
+			b.WriteRune(r)
+		case r == 'x' || r == 'X':
+			b.WriteByte('X')
+		case r == '-' || r == ' ' || r == '\u00a0':
+		default:
+			return "", false
+		}
+	}
+	isbn = b.String()
+	if x := strings.IndexByte(isbn, 'X'); x >= 0 && (x != len(isbn)-1 || len(isbn) != 10) {
+		return "", false
+	}
+
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, c := range isbn {
+			digit := int(c - '0')
+			if c == 'X' {
+				digit = 10
+			}
+			sum += (10 - i) * digit
+		}
+		return isbn, sum%11 == 0
+	case 13:
+		sum := 0
+		for i, c := range isbn {
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += weight * int(c-'0')
+		}
+		return isbn, sum%10 == 0
+	}
+
+	return "", false
+}
+
+// isISBNScheme reports whether the EPUB 2 opf:scheme of an identifier
+// declares an ISBN, as "ISBN", "isbn" or "ISBN-13" do.
+func isISBNScheme(scheme string) bool {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+
+	return scheme == "isbn" || scheme == "isbn-10" || scheme == "isbn-13" || scheme == "isbn10" || scheme == "isbn13"
+}
+
+// isISBNType reports whether an EPUB 3 identifier-type refinement declares
+// an ISBN: 02 and 15 are the ISBN-10 and ISBN-13 codes of ONIX code list 5.
+func isISBNType(meta Meta) bool {
+	value := strings.TrimSpace(meta.Text)
+	if strings.HasPrefix(meta.Scheme, "onix:codelist5") {
+		return value == "02" || value == "15"
+	}
+
+	return isISBNScheme(value)
+}
@@ -0,0 +1,82 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		isbn string
+		ok   bool
+	}{
+		{"9780306406157", "9780306406157", true},
+		{"urn:isbn:978-0-306-40615-7", "9780306406157", true},
+		{"URN:ISBN:9780306406157", "9780306406157", true},
+		{"ISBN 0-306-40615-2", "0306406152", true},
+		{"isbn:080442957x", "080442957X", true},
+		{"ISBN-13: 978 0 306 40615 7", "9780306406157", true},
+		{"9780306406158", "9780306406158", false},
+		{"0306406153", "0306406153", false},
+		{"urn:uuid:5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41", "", false},
+		{"97803064X6157", "", false},
+		{"123", "", false},
+	} {
+		if isbn, ok := NormalizeISBN(test.in); isbn != test.isbn || ok != test.ok {
+			t.Errorf("NormalizeISBN(%q) = %q, %v, want %q, %v", test.in, isbn, ok, test.isbn, test.ok)
+		}
+	}
+}
+
+func TestGetISBN(t *testing.T) {
+	const defaultIdentifier = `<dc:identifier id="bookid" opf:scheme="ISBN">9780306406157</dc:identifier>`
+
+	for _, test := range []struct {
+		name        string
+		identifiers string
+		want        string
+	}{
+		{"EPUB 2 opf:scheme", defaultIdentifier, "9780306406157"},
+		{"lowercase scheme, hyphens", `<dc:identifier id="bookid" opf:scheme="isbn">978-0-306-40615-7</dc:identifier>`, "9780306406157"},
+		{"unprefixed scheme", `<dc:identifier id="bookid" scheme="ISBN">0306406152</dc:identifier>`, "0306406152"},
+		{"EPUB 3 urn:isbn", `<dc:identifier id="bookid">urn:isbn:9780306406157</dc:identifier>`, "9780306406157"},
+		{"EPUB 3 identifier-type", `<dc:identifier id="bookid">978-0-306-40615-7</dc:identifier>
+    <meta refines="#bookid" property="identifier-type" scheme="onix:codelist5">15</meta>`, "9780306406157"},
+		{"uuid then bare ISBN", `<dc:identifier id="bookid">urn:uuid:5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41</dc:identifier>
+    <dc:identifier>9780306406157</dc:identifier>`, "9780306406157"},
+		{"calibre and ISBN schemes", `<dc:identifier opf:scheme="calibre" id="calibre_id">42</dc:identifier>
+    <dc:identifier opf:scheme="uuid" id="bookid">5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41</dc:identifier>
+    <dc:identifier opf:scheme="ISBN">9780306406157</dc:identifier>`, "9780306406157"},
+		{"declared beats bare", `<dc:identifier id="bookid">9780804429573</dc:identifier>
+    <dc:identifier opf:scheme="ISBN">0306406152</dc:identifier>`, "0306406152"},
+		{"valid beats wrong check digit", `<dc:identifier id="bookid" opf:scheme="ISBN">9780306406158</dc:identifier>
+    <dc:identifier>urn:isbn:0306406152</dc:identifier>`, "0306406152"},
+		{"declared wrong check digit", `<dc:identifier id="bookid" opf:scheme="ISBN">9780306406158</dc:identifier>`, "9780306406158"},
+		{"no ISBN", `<dc:identifier id="bookid" opf:scheme="uuid">5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41</dc:identifier>`, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			opf := strings.Replace(testOPF, defaultIdentifier, test.identifiers, 1)
+			isbn, err := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).GetISBN()
+			if test.want == "" {
+				if !errors.Is(err, ErrorNoISBN) {
+					t.Errorf("GetISBN() = %q, %v, want ErrorNoISBN", isbn, err)
+				}
+			} else if isbn != test.want || err != nil {
+				t.Errorf("GetISBN() = %q, %v, want %q", isbn, err, test.want)
+			}
+		})
+	}
+
+	for _, sample := range []string{"testdata/sample2.epub", "testdata/sample3.epub"} {
+		reader, err := OpenReader(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isbn, err := reader.GetISBN(); isbn != "9780306406157" || err != nil {
+			t.Errorf("%s: GetISBN() = %q, %v", sample, isbn, err)
+		}
+		reader.Close()
+	}
+}
@@ -0,0 +1,247 @@
+package epub
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// Issue summary formats, besides FormatHTML.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// RuleStepFailed is the rule of the issues recording the failure of a
+// pipeline step.
+const RuleStepFailed = "step-failed"
+
+// CollectedIssue is an issue reported for a book by a pipeline step.
+type CollectedIssue struct {
+	Book string
+	Step string
+	Issue
+}
+
+// IssueCollector aggregates the issues of pipelines run over many books,
+// to be summarized by rule, by book and by severity. It is safe for
+// concurrent use; its zero value is ready to use.
+type IssueCollector struct {
+	mu     sync.Mutex
+	issues []CollectedIssue
+}
+
+// Add records the issues a step found in book.
+func (collector *IssueCollector) Add(book, step string, issues ...Issue) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	for _, issue := range issues {
+		collector.issues = append(collector.issues, CollectedIssue{Book: book, Step: step, Issue: issue})
+	}
+}
+
+// AddError records the failure of a step on book as a RuleStepFailed
+// error; a nil err records nothing.
+func (collector *IssueCollector) AddError(book, step string, err error) {
+	if err != nil {
+		collector.Add(book, step, Issue{RuleID: RuleStepFailed, Severity: SeverityError, Message: err.Error()})
+	}
+}
+
+// AddReports records the issues of validation reports.
+func (collector *IssueCollector) AddReports(step string, reports ...FileReport) {
+	for _, report := range reports {
+		collector.Add(report.Path, step, report.Issues...)
+	}
+}
+
+// Issues returns the issues collected, sorted by book, then by decreasing
+// severity; issues of equal rank stay in the order they were added.
+func (collector *IssueCollector) Issues() []CollectedIssue {
+	collector.mu.Lock()
+	issues := append([]CollectedIssue(nil), collector.issues...)
+	collector.mu.Unlock()
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Book != issues[j].Book {
+			return issues[i].Book < issues[j].Book
+		}
+		return issues[i].Severity > issues[j].Severity
+	})
+
+	return issues
+}
+
+// IssueGroup is the issues sharing a rule, a book or a severity.
+type IssueGroup struct {
+	Key      string           `json:"key"`
+	Errors   int              `json:"errors"`
+	Warnings int              `json:"warnings"`
+	Infos    int              `json:"infos"`
+	Issues   []CollectedIssue `json:"-"`
+}
+
+// Total returns the number of issues of the group.
+func (group IssueGroup) Total() int {
+	return group.Errors + group.Warnings + group.Infos
+}
+
+// IssueSummary groups collected issues. Groups are sorted by decreasing
+// errors, then warnings, then by key.
+type IssueSummary struct {
+	Books      int          `json:"books"`
+	Errors     int          `json:"errors"`
+	Warnings   int          `json:"warnings"`
+	Infos      int          `json:"infos"`
+	BySeverity []IssueGroup `json:"bySeverity"`
+	ByRule     []IssueGroup `json:"byRule"`
+	ByBook     []IssueGroup `json:"byBook"`
+}
+
+// Summary groups the issues collected by severity, by rule and by book.
+func (collector *IssueCollector) Summary() IssueSummary {
+	issues := collector.Issues()
+	var summary IssueSummary
+
+	group := func(key func(CollectedIssue) string) []IssueGroup {
+		index := make(map[string]int)
+		var groups []IssueGroup
+		for _, issue := range issues {
+			k := key(issue)
+			i, ok := index[k]
+			if !ok {
+				i = len(groups)
+				index[k] = i
+				groups = append(groups, IssueGroup{Key: k})
+			}
+			groups[i].count(issue)
+		}
+		sort.SliceStable(groups, func(i, j int) bool {
+			a, b := groups[i], groups[j]
+			if a.Errors != b.Errors {
+				return a.Errors > b.Errors
+			}
+			if a.Warnings != b.Warnings {
+				return a.Warnings > b.Warnings
+			}
+			return a.Key < b.Key
+		})
+		return groups
+	}
+
+	summary.BySeverity = group(func(issue CollectedIssue) string { return issue.Severity.String() })
+	summary.ByRule = group(func(issue CollectedIssue) string { return issue.RuleID })
+	summary.ByBook = group(func(issue CollectedIssue) string { return issue.Book })
+	summary.Books = len(summary.ByBook)
+	for _, group := range summary.BySeverity {
+		summary.Errors += group.Errors
+		summary.Warnings += group.Warnings
+		summary.Infos += group.Infos
+	}
+
+	return summary
+}
+
+func (group *IssueGroup) count(issue CollectedIssue) {
+	switch {
+	case issue.Severity >= SeverityError:
+		group.Errors++
+	case issue.Severity == SeverityWarning:
+		group.Warnings++
+	default:
+		group.Infos++
+	}
+	group.Issues = append(group.Issues, issue)
+}
+
+// WriteSummary writes the summary of the issues collected in the given
+// format: FormatText, FormatJSON or FormatHTML, a standalone page.
+func (collector *IssueCollector) WriteSummary(w io.Writer, format string) error {
+	summary := collector.Summary()
+
+	switch format {
+	case FormatText:
+		return summary.writeText(w)
+	case FormatJSON:
+		type jsonIssue struct {
+			Book     string `json:"book"`
+			Step     string `json:"step,omitempty"`
+			Rule     string `json:"rule"`
+			Severity string `json:"severity"`
+			Path     string `json:"path,omitempty"`
+			Message  string `json:"message"`
+		}
+		var document struct {
+			IssueSummary
+			Issues []jsonIssue `json:"issues"`
+		}
+		document.IssueSummary = summary
+		document.Issues = []jsonIssue{}
+		for _, issue := range collector.Issues() {
+			document.Issues = append(document.Issues, jsonIssue{issue.Book, issue.Step, issue.RuleID, issue.Severity.String(), issue.Path, issue.Message})
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(document)
+	case FormatHTML:
+		return htmlIssueSummary.Execute(w, summary)
+	}
+
+	return fmt.Errorf("epub: unknown summary format %s", format)
+}
+
+func (summary IssueSummary) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "%d books: %d errors, %d warnings, %d infos\n", summary.Books, summary.Errors, summary.Warnings, summary.Infos)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, section := range []struct {
+		title  string
+		groups []IssueGroup
+	}{
+		{"SEVERITY", summary.BySeverity},
+		{"RULE", summary.ByRule},
+		{"BOOK", summary.ByBook},
+	} {
+		fmt.Fprintf(tw, "\n%s\tERRORS\tWARNINGS\tINFOS\n", section.title)
+		for _, group := range section.groups {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", group.Key, group.Errors, group.Warnings, group.Infos)
+		}
+	}
+
+	return tw.Flush()
+}
+
+var htmlIssueSummary = template.Must(template.New("issues").Funcs(template.FuncMap{
+	"section": func(title string, groups []IssueGroup) interface{} {
+		return struct {
+			Title  string
+			Groups []IssueGroup
+		}{title, groups}
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Issues</title></head>
+<body>
+<h1>Issues</h1>
+<p>{{.Books}} books: {{.Errors}} errors, {{.Warnings}} warnings, {{.Infos}} infos</p>
+{{define "groups"}}<table>
+<tr><th>{{.Title}}</th><th>Errors</th><th>Warnings</th><th>Infos</th></tr>
+{{range .Groups}}<tr><td>{{.Key}}</td><td>{{.Errors}}</td><td>{{.Warnings}}</td><td>{{.Infos}}</td></tr>
+{{end}}</table>
+{{end}}<h2>By severity</h2>
+{{template "groups" (section "Severity" .BySeverity)}}<h2>By rule</h2>
+{{template "groups" (section "Rule" .ByRule)}}<h2>By book</h2>
+{{range .ByBook}}<details>
+<summary>{{.Key}}: {{.Errors}} errors, {{.Warnings}} warnings, {{.Infos}} infos</summary>
+<ul>
+{{range .Issues}}<li>{{.Severity}} {{.RuleID}}{{if .Step}} ({{.Step}}){{end}}: {{if .Path}}{{.Path}}: {{end}}{{.Message}}</li>
+{{end}}</ul>
+</details>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,81 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIssueCollector(t *testing.T) {
+	var collector IssueCollector
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			book := fmt.Sprintf("book%d.epub", i%2)
+			collector.Add(book, "validate", Issue{RuleID: "spine-empty", Severity: SeverityWarning, Message: "empty"})
+			if i == 3 {
+				collector.AddError(book, "optimize", errors.New("disk full"))
+			}
+			collector.AddError(book, "optimize", nil)
+		}(i)
+	}
+	wg.Wait()
+	collector.AddReports("validate", FileReport{Path: "book2.epub", Issues: []Issue{{RuleID: "nav-required", Severity: SeverityInfo, Message: "<no nav>"}}})
+
+	issues := collector.Issues()
+	if len(issues) != 12 || issues[0].Book != "book0.epub" || issues[5].Book != "book1.epub" ||
+		issues[5].RuleID != RuleStepFailed || issues[5].Step != "optimize" {
+		t.Fatalf("Issues() = %+v", issues)
+	}
+
+	summary := collector.Summary()
+	if summary.Books != 3 || summary.Errors != 1 || summary.Warnings != 10 || summary.Infos != 1 {
+		t.Errorf("Summary() = %+v", summary)
+	}
+	if len(summary.ByBook) != 3 || summary.ByBook[0].Key != "book1.epub" || summary.ByBook[0].Total() != 6 {
+		t.Errorf("ByBook = %+v", summary.ByBook)
+	}
+	if len(summary.ByRule) != 3 || summary.ByRule[0].Key != RuleStepFailed || summary.ByRule[1].Key != "spine-empty" {
+		t.Errorf("ByRule = %+v", summary.ByRule)
+	}
+	if len(summary.BySeverity) != 3 || summary.BySeverity[0].Key != "error" || summary.BySeverity[2].Key != "info" {
+		t.Errorf("BySeverity = %+v", summary.BySeverity)
+	}
+
+	var b bytes.Buffer
+	if err := collector.WriteSummary(&b, FormatText); err != nil || !strings.HasPrefix(b.String(), "3 books: 1 errors, 10 warnings, 1 infos\n") ||
+		!strings.Contains(b.String(), "step-failed   1       0         0\n") {
+		t.Errorf("text summary = %s, %v", b.String(), err)
+	}
+
+	b.Reset()
+	var document struct {
+		Books  int `json:"books"`
+		Issues []struct {
+			Book, Rule, Severity string
+		} `json:"issues"`
+	}
+	if err := collector.WriteSummary(&b, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b.Bytes(), &document); err != nil || document.Books != 3 || len(document.Issues) != 12 || document.Issues[5].Severity != "error" {
+		t.Errorf("JSON summary = %s, %v", b.String(), err)
+	}
+
+	b.Reset()
+	if err := collector.WriteSummary(&b, FormatHTML); err != nil || !strings.Contains(b.String(), "<td>spine-empty</td><td>0</td><td>10</td>") ||
+		!strings.Contains(b.String(), "&lt;no nav&gt;") {
+		t.Errorf("HTML summary = %s, %v", b.String(), err)
+	}
+
+	if err := collector.WriteSummary(&b, "xml"); err == nil {
+		t.Errorf("WriteSummary(xml) = no error")
+	}
+}
@@ -0,0 +1,307 @@
+package epub
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageBlock is the number of letters from which a block of a
+// chapter is large enough for its language to be detected.
+const minLanguageBlock = 80
+
+// ChapterLanguage is the language and script detected in a spine document,
+// for text-to-speech pipelines to choose voices and search engines to pick
+// analyzers.
+type ChapterLanguage struct {
+	ID   string
+	Href string
+	// Declared is the lang or xml:lang of the root element of the document,
+	// or else the language of the package.
+	Declared string
+	LanguageDetection
+	// Blocks are the large blocks whose language or script differ from
+	// those of the document, such as quotations in another language.
+	Blocks []BlockLanguage
+}
+
+// BlockLanguage is the language and script detected in a block of a
+// document.
+type BlockLanguage struct {
+	// ID is the id of the block element, if any, and Offset the offset of
+	// the block in the text of the document, in runes.
+	ID     string
+	Offset int
+	// Declared is the language declared by the block or its ancestors.
+	Declared string
+	LanguageDetection
+}
+
+// LanguageDetection is the language and script of a text, as detected by
+// DetectLanguage.
+type LanguageDetection struct {
+	// Language is a BCP 47 primary language subtag, empty when unknown.
+	Language string
+	// Script is an ISO 15924 code, such as "Latn", "Cyrl" or "Jpan".
+	Script string
+	// Confidence ranges from 0 to 1.
+	Confidence float64
+}
+
+// detectedScripts are the scripts DetectLanguage tells apart, and the
+// language written in each script, when there is mostly one.
+var detectedScripts = []struct {
+	code     string
+	table    *unicode.RangeTable
+	language string
+}{
+	{"Latn", unicode.Latin, ""},
+	{"Cyrl", unicode.Cyrillic, ""},
+	{"Grek", unicode.Greek, "el"},
+	{"Arab", unicode.Arabic, "ar"},
+	{"Hebr", unicode.Hebrew, "he"},
+	{"Deva", unicode.Devanagari, "hi"},
+	{"Beng", unicode.Bengali, "bn"},
+	{"Taml", unicode.Tamil, "ta"},
+	{"Thai", unicode.Thai, "th"},
+	{"Geor", unicode.Georgian, "ka"},
+	{"Armn", unicode.Armenian, "hy"},
+	{"Ethi", unicode.Ethiopic, "am"},
+	{"Hang", unicode.Hangul, "ko"},
+	{"Hira", unicode.Hiragana, "ja"},
+	{"Kana", unicode.Katakana, "ja"},
+	{"Hani", unicode.Han, "zh"},
+}
+
+// stopWords are the most frequent words of the languages written in
+// scripts shared by several languages.
+var stopWords = map[string]map[string][]string{
+	"Latn": {
+		"en": strings.Fields("the and of to in is that it was for on with as his he i you not be at by this had but"),
+		"fr": strings.Fields("le la les de des et est un une du que qui dans pour pas sur il elle au ne se en avec"),
+		"de": strings.Fields("der die das und ist nicht ein eine zu den von mit sich des auf für im dem ich er sie es"),
+		"es": strings.Fields("el la los las de y que en un una es no por con para se su al lo del como"),
+		"it": strings.Fields("il la le di e che è un una per non con del della si sono gli nel ma come"),
+		"pt": strings.Fields("o a os as de e que em um uma não do da para com se por mais é dos"),
+		"nl": strings.Fields("de het een en van is dat niet in op te zijn met voor er maar ook die"),
+		"sv": strings.Fields("och att det som en är på av för med den inte till har jag de var"),
+		"pl": strings.Fields("i w na nie się z że do to jest jak o co ale od po tak za"),
+		"la": strings.Fields("et in est non ad cum quod ut sed qui quae esse sunt enim autem"),
+	},
+	"Cyrl": {
+		"ru": strings.Fields("и в не на что я с он как это по но его она к из то же так был"),
+		"uk": strings.Fields("і в не на що я з він як це та але його вона до є від так бути"),
+		"bg": strings.Fields("и в не на да се че от за с е това как той но са по"),
+		"sr": strings.Fields("и у не на да се је од за са то што као али"),
+	},
+}
+
+var stopWordSets = make(map[string]map[string]map[string]bool)
+
+func init() {
+	for script, languages := range stopWords {
+		stopWordSets[script] = make(map[string]map[string]bool)
+		for language, words := range languages {
+			set := make(map[string]bool)
+			for _, word := range words {
+				set[word] = true
+			}
+			stopWordSets[script][language] = set
+		}
+	}
+}
+
+// DetectLanguage detects the dominant script of text and its language:
+// from the script alone for the scripts mostly used by one language, from
+// the frequency of common words for the Latin and Cyrillic scripts. Han
+// characters mixed with kana are Japanese ("Jpan"), with hangul Korean
+// ("Kore").
+func DetectLanguage(text string) LanguageDetection {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, script := range detectedScripts {
+			if unicode.Is(script.table, r) {
+				counts[script.code]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return LanguageDetection{}
+	}
+
+	kana := counts["Hira"] + counts["Kana"]
+	switch {
+	case kana > 0 && kana*10 >= counts["Hani"]:
+		counts["Jpan"] = kana + counts["Hani"]
+	case counts["Hang"] > 0:
+		counts["Kore"] = counts["Hang"] + counts["Hani"]
+	}
+
+	detection := LanguageDetection{}
+	best := 0
+	for _, code := range []string{"Jpan", "Kore"} {
+		if counts[code] > best {
+			detection.Script, best = code, counts[code]
+		}
+	}
+	for _, script := range detectedScripts {
+		if counts[script.code] > best {
+			detection.Script, detection.Language, best = script.code, script.language, counts[script.code]
+		}
+	}
+	switch detection.Script {
+	case "":
+		return detection
+	case "Jpan":
+		detection.Language = "ja"
+	case "Kore":
+		detection.Language = "ko"
+	case "Arab":
+		detection.Language = arabicScriptLanguage(text)
+	}
+
+	// Confidence grows with the share of the letters in the script and
+	// with the length of the text.
+	detection.Confidence = float64(best) / float64(letters) * lengthConfidence(letters, 40)
+	if sets, ok := stopWordSets[detection.Script]; ok {
+		language, confidence := detectByStopWords(text, sets)
+		detection.Language = language
+		detection.Confidence *= confidence
+	}
+
+	return detection
+}
+
+// detectByStopWords returns the language of sets whose words are the most
+// frequent in text, and the confidence in it.
+func detectByStopWords(text string, sets map[string]map[string]bool) (string, float64) {
+	hits := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		for language, set := range sets {
+			if set[word] {
+				hits[language]++
+			}
+		}
+	}
+
+	best, first, second := "", 0, 0
+	for language, n := range hits {
+		switch {
+		case n > first || n == first && language < best:
+			best, first, second = language, n, first
+		case n > second:
+			second = n
+		}
+	}
+	if first == 0 {
+		return "", 0
+	}
+
+	return best, float64(first-second) / float64(first) * lengthConfidence(first, 10)
+}
+
+// arabicScriptLanguage tells Persian and Urdu, which have letters of their
+// own, from Arabic.
+func arabicScriptLanguage(text string) string {
+	switch {
+	case strings.ContainsAny(text, "ٹڈڑںھے"):
+		return "ur"
+	case strings.ContainsAny(text, "پچژگ"):
+		return "fa"
+	}
+
+	return "ar"
+}
+
+// lengthConfidence returns the confidence in a detection made on n
+// observations, full from enough of them.
+func lengthConfidence(n, enough int) float64 {
+	if n >= enough {
+		return 1
+	}
+
+	return float64(n) / float64(enough)
+}
+
+// ChapterLanguages returns the language and script detected in each spine
+// document, in reading order, and in their large blocks in another
+// language or script.
+func (epubReader *EpubReader) ChapterLanguages() ([]ChapterLanguage, error) {
+	var chapters []ChapterLanguage
+
+	for _, item := range epubReader.SpineItems() {
+		document, err := epubReader.parseDocument(epubReader.itemPath(item.Href))
+		if err != nil {
+			return chapters, err
+		}
+
+		chapter := ChapterLanguage{ID: item.ID, Href: item.Href, Declared: strings.TrimSpace(firstText(epubReader.Rootfiles[0].Metadata.Language))}
+		if root := document.find(func(n *domNode) bool { return n.name == "html" }); root != nil && root.attr("lang") != "" {
+			chapter.Declared = root.attr("lang")
+		}
+
+		body := document.find(func(n *domNode) bool { return n.name == "body" })
+		if body == nil {
+			chapters = append(chapters, chapter)
+			continue
+		}
+		chapter.LanguageDetection = DetectLanguage(body.textContent())
+
+		body.walk(func(n *domNode) bool {
+			if n.name == "" || !blockElements[n.name] || n == body || hasBlockChild(n) {
+				return n.name != "" && !skippedElements[n.name]
+			}
+			text := n.textContent()
+			if countLetters(text) < minLanguageBlock {
+				return false
+			}
+			detection := DetectLanguage(text)
+			if detection.Language != "" && detection.Language != chapter.Language || detection.Script != chapter.Script {
+				chapter.Blocks = append(chapter.Blocks, BlockLanguage{
+					ID:                n.attr("id"),
+					Offset:            n.start,
+					Declared:          declaredLanguage(n, chapter.Declared),
+					LanguageDetection: detection,
+				})
+			}
+			return false
+		})
+
+		chapters = append(chapters, chapter)
+	}
+
+	return chapters, nil
+}
+
+// hasBlockChild reports whether a descendant of node is a block element.
+func hasBlockChild(node *domNode) bool {
+	return node.find(func(n *domNode) bool { return n != node && blockElements[n.name] }) != nil
+}
+
+// declaredLanguage returns the lang or xml:lang of node or of its nearest
+// ancestor declaring one, or else fallback.
+func declaredLanguage(node *domNode, fallback string) string {
+	for n := node; n != nil; n = n.parent {
+		if lang := n.attr("lang"); lang != "" {
+			return lang
+		}
+	}
+
+	return fallback
+}
+
+func countLetters(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+
+	return n
+}
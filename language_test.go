@@ -0,0 +1,68 @@
+package epub
+
+import (
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for _, test := range []struct {
+		text             string
+		language, script string
+	}{
+		{"It was the best of times, it was the worst of times, it was the age of wisdom, it was the age of foolishness.", "en", "Latn"},
+		{"Longtemps, je me suis couché de bonne heure. Parfois, à peine ma bougie éteinte, mes yeux se fermaient si vite que je n'avais pas le temps de me dire : je m'endors.", "fr", "Latn"},
+		{"Als Gregor Samsa eines Morgens aus unruhigen Träumen erwachte, fand er sich in seinem Bett zu einem ungeheueren Ungeziefer verwandelt, und er sah die Decke.", "de", "Latn"},
+		{"En un lugar de la Mancha, de cuyo nombre no quiero acordarme, no ha mucho tiempo que vivía un hidalgo de los de lanza en astillero.", "es", "Latn"},
+		{"Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива по-своему. Всё смешалось в доме Облонских, и он не знал, что делать.", "ru", "Cyrl"},
+		{"吾輩は猫である。名前はまだ無い。どこで生れたかとんと見当がつかぬ。", "ja", "Jpan"},
+		{"나는 고양이로소이다. 이름은 아직 없다.", "ko", "Kore"},
+		{"子曰：學而時習之，不亦說乎？有朋自遠方來，不亦樂乎？", "zh", "Hani"},
+		{"Μῆνιν ἄειδε θεὰ Πηληϊάδεω Ἀχιλῆος", "el", "Grek"},
+		{"در یک شب تاریک و طوفانی، پدرم گفت که باید برگردیم.", "fa", "Arab"},
+		{"1234 — !?", "", ""},
+	} {
+		detection := DetectLanguage(test.text)
+		if detection.Language != test.language || detection.Script != test.script {
+			t.Errorf("DetectLanguage(%.20q) = %+v, want %s, %s", test.text, detection, test.language, test.script)
+		}
+		if test.language != "" && (detection.Confidence <= 0 || detection.Confidence > 1) {
+			t.Errorf("DetectLanguage(%.20q) confidence = %v", test.text, detection.Confidence)
+		}
+	}
+}
+
+func TestChapterLanguages(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/chapter2.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en">
+<head><title>Chapter Two</title></head>
+<body>
+<p>It was the best of times, it was the worst of times, it was the age of wisdom, it was the age of foolishness, it was the epoch of belief.</p>
+<blockquote lang="fr"><p id="quote">Longtemps, je me suis couché de bonne heure. Parfois, à peine ma bougie éteinte, mes yeux se fermaient si vite que je n'avais pas le temps de me dire : je m'endors.</p></blockquote>
+<p>And it was the season of Light, it was the season of Darkness, it was the spring of hope, it was the winter of despair, and all that.</p>
+</body>
+</html>`})
+
+	chapters, err := reader.ChapterLanguages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chapters) != 2 {
+		t.Fatalf("ChapterLanguages() = %+v", chapters)
+	}
+	if chapters[0].Declared != "en" || chapters[0].Language != "en" || len(chapters[0].Blocks) != 0 {
+		t.Errorf("ChapterLanguages()[0] = %+v", chapters[0])
+	}
+
+	chapter := chapters[1]
+	if chapter.ID != "chapter2" || chapter.Language != "en" || chapter.Script != "Latn" || len(chapter.Blocks) != 1 {
+		t.Fatalf("ChapterLanguages()[1] = %+v", chapter)
+	}
+	if block := chapter.Blocks[0]; block.ID != "quote" || block.Declared != "fr" || block.Language != "fr" || block.Offset == 0 {
+		t.Errorf("Blocks = %+v", chapter.Blocks)
+	}
+
+	stats, err := reader.ChapterStats()
+	if err != nil || stats[1].Language != "en" || stats[1].Script != "Latn" {
+		t.Errorf("ChapterStats() = %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,308 @@
+package epub
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Writing modes.
+const (
+	HorizontalTB = "horizontal-tb"
+	VerticalRL   = "vertical-rl"
+	VerticalLR   = "vertical-lr"
+)
+
+// LayoutProfile summarizes the writing modes and rendition properties of a
+// book, for reading systems to configure their renderer before loading its
+// content.
+type LayoutProfile struct {
+	// PageProgression is the page-progression-direction of the spine:
+	// "ltr", "rtl" or "default".
+	PageProgression string
+	// WritingMode is the writing mode of most spine documents: HorizontalTB,
+	// VerticalRL or VerticalLR.
+	WritingMode string
+	// Documents counts the spine documents; VerticalDocuments are the paths
+	// of those in a vertical writing mode.
+	Documents         int
+	VerticalDocuments []string
+	// TextOrientations are the text-orientation values the styles use,
+	// such as "upright" or "sideways", sorted.
+	TextOrientations []string
+	// TateChuYoko counts the elements whose text is set horizontally
+	// within vertical lines, such as numbers.
+	TateChuYoko int
+	// Layout is "reflowable" or "pre-paginated"; Spread and Orientation
+	// are the rendition properties of the book, "auto" by default.
+	Layout      string
+	Spread      string
+	Orientation string
+	Language    string
+}
+
+// writingModeProperties are the properties setting the writing mode, with
+// their legacy and prefixed forms.
+var writingModeProperties = []string{"-epub-writing-mode", "-webkit-writing-mode", "writing-mode"}
+
+var (
+	textCombineProperties     = []string{"-epub-text-combine", "-webkit-text-combine", "text-combine-upright"}
+	textOrientationProperties = []string{"-epub-text-orientation", "-webkit-text-orientation", "text-orientation"}
+)
+
+// tateChuYokoClasses are the class names publishers commonly give to
+// tate-chu-yoko, counted even when the stylesheet setting them is missing.
+var tateChuYokoClasses = []string{"tcy", "tate-chu-yoko", "tatechuyoko"}
+
+// LayoutProfile returns the layout profile of the book. The writing mode of
+// a document is the one its styles set on the html or body element; rule
+// specificity is ignored, later rules win.
+func (epubReader *EpubReader) LayoutProfile() (LayoutProfile, error) {
+	rootfile := epubReader.Rootfiles[0]
+	profile := LayoutProfile{
+		PageProgression: rootfile.Spine.PageProgressionDirection,
+		Layout:          epubReader.metaValue("layout"),
+		Spread:          epubReader.metaValue("spread"),
+		Orientation:     epubReader.metaValue("orientation"),
+		Language:        strings.TrimSpace(firstText(rootfile.Metadata.Language)),
+	}
+	if profile.PageProgression == "" {
+		profile.PageProgression = "default"
+	}
+	if profile.Layout == "" {
+		profile.Layout = "reflowable"
+		if epubReader.isFixedLayout() {
+			profile.Layout = "pre-paginated"
+		}
+	}
+	if profile.Spread == "" {
+		profile.Spread = "auto"
+	}
+	if profile.Orientation == "" {
+		profile.Orientation = "auto"
+		if options, ok, _ := epubReader.AppleDisplayOptions(); ok {
+			switch options.OrientationLock("*") {
+			case "portrait-only":
+				profile.Orientation = "portrait"
+			case "landscape-only":
+				profile.Orientation = "landscape"
+			}
+		}
+	}
+
+	modes := make(map[string]int)
+	orientations := make(map[string]bool)
+	for _, item := range epubReader.SpineItems() {
+		name := epubReader.itemPath(item.Href)
+		document, err := epubReader.parseDocument(name)
+		if err != nil {
+			return profile, err
+		}
+		layout, err := epubReader.documentLayout(name, document)
+		if err != nil {
+			return profile, err
+		}
+
+		profile.Documents++
+		modes[layout.writingMode]++
+		if layout.writingMode != HorizontalTB {
+			profile.VerticalDocuments = append(profile.VerticalDocuments, name)
+		}
+		profile.TateChuYoko += layout.tateChuYoko
+		for _, orientation := range layout.textOrientations {
+			orientations[orientation] = true
+		}
+	}
+
+	profile.WritingMode = HorizontalTB
+	for _, mode := range []string{VerticalRL, VerticalLR} {
+		if modes[mode] > modes[profile.WritingMode] {
+			profile.WritingMode = mode
+		}
+	}
+	for orientation := range orientations {
+		profile.TextOrientations = append(profile.TextOrientations, orientation)
+	}
+	sort.Strings(profile.TextOrientations)
+
+	return profile, nil
+}
+
+// documentLayout is the layout of a content document.
+type documentLayout struct {
+	writingMode      string
+	tateChuYoko      int
+	textOrientations []string
+}
+
+// documentLayout applies the styles of the document name to its html and
+// body elements, and counts its tate-chu-yoko elements.
+func (epubReader *EpubReader) documentLayout(name string, document *domNode) (documentLayout, error) {
+	layout := documentLayout{writingMode: HorizontalTB}
+	html := document.find(func(n *domNode) bool { return n.name == "html" })
+	body := document.find(func(n *domNode) bool { return n.name == "body" })
+	if html == nil {
+		return layout, nil
+	}
+
+	styles, err := epubReader.documentStyles(name, document)
+	if err != nil {
+		return layout, err
+	}
+
+	var htmlMode, bodyMode string
+	combined := make(map[*domNode]bool)
+	apply := func(declarations map[string]string, matches func(*domNode) bool) {
+		if mode := declaredValue(declarations, writingModeProperties); mode != "" {
+			if matches(html) {
+				htmlMode = mode
+			}
+			if body != nil && matches(body) {
+				bodyMode = mode
+			}
+		}
+		if orientation := declaredValue(declarations, textOrientationProperties); orientation != "" {
+			layout.textOrientations = append(layout.textOrientations, strings.ToLower(orientation))
+		}
+		if combine := declaredValue(declarations, textCombineProperties); combine != "" && !strings.EqualFold(combine, "none") && body != nil {
+			body.walk(func(n *domNode) bool {
+				if n.name != "" && matches(n) {
+					combined[n] = true
+				}
+				return n.name != ""
+			})
+		}
+	}
+
+	for _, css := range styles {
+		walkCSSRules(css, func(selectors []string, block string) {
+			var parsed []cssSelector
+			for _, selector := range selectors {
+				if s, ok := parseSelector(selector); ok {
+					parsed = append(parsed, s)
+				}
+			}
+			apply(cssDeclarations(block), func(n *domNode) bool {
+				for _, selector := range parsed {
+					if selector.matches(n) {
+						return true
+					}
+				}
+				return false
+			})
+		})
+	}
+	html.walk(func(n *domNode) bool {
+		if style := n.attr("style"); style != "" {
+			element := n
+			apply(cssDeclarations(style), func(m *domNode) bool { return m == element })
+		}
+		return n.name != ""
+	})
+
+	switch {
+	case bodyMode != "":
+		layout.writingMode = normalizeWritingMode(bodyMode)
+	case htmlMode != "":
+		layout.writingMode = normalizeWritingMode(htmlMode)
+	}
+
+	if body != nil {
+		body.walk(func(n *domNode) bool {
+			if n.name == "" {
+				return false
+			}
+			if !combined[n] {
+				for _, class := range strings.Fields(n.attr("class")) {
+					if containsString(tateChuYokoClasses, strings.ToLower(class)) {
+						combined[n] = true
+						break
+					}
+				}
+			}
+			return true
+		})
+	}
+	layout.tateChuYoko = len(combined)
+
+	return layout, nil
+}
+
+// documentStyles returns the style sheets of the document name, linked or
+// embedded, in document order, each preceded by the style sheets it
+// imports. Missing style sheets are ignored.
+func (epubReader *EpubReader) documentStyles(name string, document *domNode) ([]string, error) {
+	var styles []string
+	seen := make(map[string]bool)
+
+	var addStylesheet func(path string) error
+	addStylesheet = func(path string) error {
+		if seen[path] {
+			return nil
+		}
+		seen[path] = true
+		buffer, err := epubReader.readFile(path)
+		if errors.Is(err, ErrorFileMissing) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		css := buffer.String()
+		for _, match := range cssImportPattern.FindAllStringSubmatch(css, -1) {
+			if imported, ok := localReference(path, strings.TrimSpace(match[1])); ok {
+				if err := addStylesheet(imported); err != nil {
+					return err
+				}
+			}
+		}
+		styles = append(styles, css)
+		return nil
+	}
+
+	var err error
+	document.walk(func(n *domNode) bool {
+		switch {
+		case err != nil:
+			return false
+		case n.name == "style":
+			styles = append(styles, n.textContent())
+			return false
+		case n.name == "link":
+			rel := strings.Fields(strings.ToLower(n.attr("rel")))
+			if containsString(rel, "stylesheet") && !containsString(rel, "alternate") {
+				if href, ok := localReference(name, n.attr("href")); ok {
+					err = addStylesheet(href)
+				}
+			}
+		}
+		return n.name != ""
+	})
+
+	return styles, err
+}
+
+// declaredValue returns the value of the last of properties declared.
+func declaredValue(declarations map[string]string, properties []string) string {
+	value := ""
+	for _, property := range properties {
+		if v, ok := declarations[property]; ok {
+			value = v
+		}
+	}
+
+	return value
+}
+
+// normalizeWritingMode maps the legacy values of writing-mode, such as
+// "tb-rl", to the current ones.
+func normalizeWritingMode(mode string) string {
+	switch strings.ToLower(mode) {
+	case "vertical-rl", "tb-rl", "tb", "sideways-rl":
+		return VerticalRL
+	case "vertical-lr", "tb-lr", "sideways-lr":
+		return VerticalLR
+	}
+
+	return HorizontalTB
+}
@@ -0,0 +1,62 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLayoutProfile(t *testing.T) {
+	reader := openTestEpub(t)
+	profile, err := reader.LayoutProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (LayoutProfile{
+		PageProgression: "default",
+		WritingMode:     HorizontalTB,
+		Documents:       2,
+		Layout:          "reflowable",
+		Spread:          "auto",
+		Orientation:     "auto",
+		Language:        "en",
+	}); !reflect.DeepEqual(profile, want) {
+		t.Errorf("LayoutProfile() = %+v", profile)
+	}
+
+	opf := strings.Replace(testOPF, `<spine`, `<spine page-progression-direction="rtl"`, 1)
+	opf = strings.Replace(opf, `<meta name="cover"`, `<meta property="rendition:spread">landscape</meta>
+    <meta name="cover"`, 1)
+	reader = openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/style.css", `@import "vertical.css";
+@media amzn-kf8 { .tcy { -webkit-text-combine: horizontal; } }
+p.number { text-combine-upright: all !important; }`},
+		testFile{"OEBPS/vertical.css", `html { -epub-writing-mode: vertical-rl; writing-mode: vertical-rl; }
+.upright { text-orientation: upright; }`},
+		testFile{"OEBPS/chapter1.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter One</title><link rel="stylesheet" href="style.css"/></head>
+<body>
+<p>第<span class="tcy">12</span>章</p>
+<p class="number">2019</p>
+<p style="-epub-text-combine: horizontal">25</p>
+</body>
+</html>`},
+		testFile{"OEBPS/chapter2.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter Two</title><style>body { writing-mode: tb-rl; }</style></head>
+<body><p>Text.</p></body>
+</html>`},
+	)
+
+	profile, err = reader.LayoutProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.PageProgression != "rtl" || profile.WritingMode != VerticalRL || profile.Spread != "landscape" ||
+		!reflect.DeepEqual(profile.VerticalDocuments, []string{"OEBPS/chapter1.xhtml", "OEBPS/chapter2.xhtml"}) ||
+		!reflect.DeepEqual(profile.TextOrientations, []string{"upright"}) || profile.TateChuYoko != 3 {
+		t.Errorf("LayoutProfile() = %+v", profile)
+	}
+}
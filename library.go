@@ -0,0 +1,507 @@
+package epub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LibraryBook is a book of a library, or the tombstone of a deleted one.
+type LibraryBook struct {
+	Path string `json:"path"`
+	// Root is the scanned directory the book was found in.
+	Root        string    `json:"root"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"modTime"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Creator     string    `json:"creator,omitempty"`
+	// AuthorSort is the sort key of the creator, see AuthorSort.
+	AuthorSort  string   `json:"authorSort,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Identifier  string   `json:"identifier,omitempty"`
+	Publisher   string   `json:"publisher,omitempty"`
+	Series      string   `json:"series,omitempty"`
+	SeriesIndex string   `json:"seriesIndex,omitempty"`
+	Subjects    []string `json:"subjects,omitempty"`
+	// Year is the year of the publication date, 0 if unknown.
+	Year     int  `json:"year,omitempty"`
+	HasCover bool `json:"hasCover,omitempty"`
+	// MetadataScore is the completeness of the metadata, out of 100, see
+	// EpubReader.MetadataScore.
+	MetadataScore int `json:"metadataScore,omitempty"`
+	// Read is set by the application with SetRead, and kept by scans.
+	Read bool `json:"read,omitempty"`
+	// Guess is set when the package has no title or creator: the blank
+	// ones are then guessed.
+	Guess *MetadataGuess `json:"guess,omitempty"`
+	// Error is set when the file does not open as a valid book; the
+	// metadata is then what Inspect could salvage.
+	Error string `json:"error,omitempty"`
+	// DeletedAt is set on tombstones: books found by a previous scan
+	// whose file is gone.
+	DeletedAt time.Time `json:"deletedAt,omitempty"`
+	// DuplicateOf is the path of the preferred copy of the book, when
+	// another file has the same fingerprint.
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+}
+
+// Deleted reports whether the book is a tombstone.
+func (book LibraryBook) Deleted() bool {
+	return !book.DeletedAt.IsZero()
+}
+
+// LibraryChangeKind is the kind of a LibraryChange.
+type LibraryChangeKind string
+
+const (
+	LibraryAdded    LibraryChangeKind = "added"
+	LibraryModified LibraryChangeKind = "modified"
+	LibraryDeleted  LibraryChangeKind = "deleted"
+)
+
+// LibraryChange is a change found by a scan.
+type LibraryChange struct {
+	Kind LibraryChangeKind `json:"kind"`
+	Book LibraryBook       `json:"book"`
+}
+
+// LibraryRoot is a directory scanned into a library.
+type LibraryRoot struct {
+	Path string `json:"path"`
+	// Priority ranks the copies of a book found in several roots: the
+	// copy from the root with the highest priority is preferred.
+	Priority int `json:"priority"`
+}
+
+// Library is a catalog of EPUB files, kept up to date by incremental scans.
+type Library struct {
+	// Roots are the directories scanned into the library.
+	Roots []LibraryRoot `json:"roots,omitempty"`
+	// Language is the BCP 47 tag of the language of the catalog, whose
+	// collation rules sort the listings of the library.
+	Language string `json:"language,omitempty"`
+	// Collections are the smart collections of the library.
+	Collections []Collection `json:"collections,omitempty"`
+	// Books holds the books and tombstones, sorted by path.
+	Books []LibraryBook `json:"books"`
+	// Progress is the state of an interrupted scan.
+	Progress *ScanProgress `json:"progress,omitempty"`
+
+	filename string
+}
+
+// ScanProgress is the state of a scan, persisted so that an interrupted
+// scan resumes where it stopped.
+type ScanProgress struct {
+	// Roots are the roots of the scan, Remaining the ones not listed yet
+	// and Root the one being scanned.
+	Roots     []string  `json:"roots"`
+	Remaining []string  `json:"remaining"`
+	Root      string    `json:"root"`
+	Started   time.Time `json:"started"`
+	// Pending are the files left to scan.
+	Pending []string `json:"pending"`
+	// Changes are the changes found so far.
+	Changes []LibraryChange `json:"changes"`
+}
+
+// ScanOptions tunes Scan.
+type ScanOptions struct {
+	// Checkpoint is the number of files scanned between two saves of the
+	// library, 100 if zero. Libraries without a file are not saved.
+	Checkpoint int
+}
+
+// LoadLibrary reads the library saved in filename, or returns an empty
+// library if the file does not exist. The library is saved back to
+// filename during and after scans.
+func LoadLibrary(filename string) (*Library, error) {
+	library := &Library{filename: filename}
+
+	data, err := os.ReadFile(longPath(filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return library, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("epub: library %s: %w", filename, err)
+	}
+	if err := json.Unmarshal(data, library); err != nil {
+		return nil, fmt.Errorf("epub: library %s: %w", filename, err)
+	}
+
+	return library, nil
+}
+
+// Save writes the library to the file it was loaded from.
+func (library *Library) Save() error {
+	if library.filename == "" {
+		return nil
+	}
+
+	return SafeWriteFile(library.filename, SaveOptions{}, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(library)
+	})
+}
+
+// Book returns the book or tombstone with the given path.
+func (library *Library) Book(path string) (LibraryBook, bool) {
+	i := sort.Search(len(library.Books), func(i int) bool { return library.Books[i].Path >= path })
+	if i < len(library.Books) && library.Books[i].Path == path {
+		return library.Books[i], true
+	}
+
+	return LibraryBook{}, false
+}
+
+// put adds or replaces a book, keeping Books sorted.
+func (library *Library) put(book LibraryBook) {
+	i := sort.Search(len(library.Books), func(i int) bool { return library.Books[i].Path >= book.Path })
+	if i < len(library.Books) && library.Books[i].Path == book.Path {
+		library.Books[i] = book
+		return
+	}
+	library.Books = append(library.Books, LibraryBook{})
+	copy(library.Books[i+1:], library.Books[i:])
+	library.Books[i] = book
+}
+
+// Scan updates the library with the .epub files below root. It is
+// ScanRoots with a single root, of unchanged priority.
+func (library *Library) Scan(ctx context.Context, root string, options ScanOptions) ([]LibraryChange, error) {
+	for _, known := range library.Roots {
+		if known.Path == root {
+			return library.ScanRoots(ctx, []LibraryRoot{known}, options)
+		}
+	}
+
+	return library.ScanRoots(ctx, []LibraryRoot{{Path: root}}, options)
+}
+
+// ScanRoots updates the library with the .epub files below the roots, one
+// root after the other, and returns the changes since the previous scan:
+// new books, and books whose size or modification time changed, are read
+// again, and books that disappeared become tombstones. A root that does
+// not exist, such as an unmounted network share, is skipped and its books
+// are kept as they are.
+//
+// Books found in several roots are matched by fingerprint: the copy of the
+// root with the highest priority is preferred, then the most recently
+// modified one, and the others are marked as its duplicates.
+//
+// The library is saved regularly. When ctx is canceled, the scan stops
+// with the changes found so far and ctx.Err(); the next scan of the same
+// roots resumes it, and returns all the changes of the scan once complete.
+func (library *Library) ScanRoots(ctx context.Context, roots []LibraryRoot, options ScanOptions) ([]LibraryChange, error) {
+	if options.Checkpoint <= 0 {
+		options.Checkpoint = 100
+	}
+
+	var paths []string
+	for _, root := range roots {
+		paths = append(paths, root.Path)
+		library.setRoot(root)
+	}
+	if library.Progress == nil || !equalStrings(library.Progress.Roots, paths) {
+		library.Progress = &ScanProgress{Roots: paths, Remaining: paths, Started: time.Now().UTC(), Pending: []string{}, Changes: []LibraryChange{}}
+	}
+	progress := library.Progress
+
+	for scanned := 0; ; {
+		if len(progress.Pending) == 0 {
+			if len(progress.Remaining) == 0 {
+				break
+			}
+			progress.Root, progress.Remaining = progress.Remaining[0], progress.Remaining[1:]
+			if err := library.listRoot(progress); err != nil {
+				return progress.Changes, err
+			}
+			if err := library.Save(); err != nil {
+				return progress.Changes, err
+			}
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return progress.Changes, firstError(library.Save(), err)
+		}
+		if scanned > 0 && scanned%options.Checkpoint == 0 {
+			if err := library.Save(); err != nil {
+				return progress.Changes, err
+			}
+		}
+
+		path := progress.Pending[0]
+		progress.Pending = progress.Pending[1:]
+		if change, ok := library.scanFile(progress.Root, path); ok {
+			progress.Changes = append(progress.Changes, change)
+		}
+		scanned++
+	}
+
+	changes := progress.Changes
+	library.Progress = nil
+	library.resolveDuplicates()
+
+	return changes, library.Save()
+}
+
+// SetRead sets the read state of the book with the given path, and
+// reports whether the library has it.
+func (library *Library) SetRead(path string, read bool) bool {
+	i := sort.Search(len(library.Books), func(i int) bool { return library.Books[i].Path >= path })
+	if i == len(library.Books) || library.Books[i].Path != path {
+		return false
+	}
+	library.Books[i].Read = read
+
+	return true
+}
+
+// setRoot adds root to the roots of the library, or updates its priority.
+func (library *Library) setRoot(root LibraryRoot) {
+	for i, known := range library.Roots {
+		if known.Path == root.Path {
+			library.Roots[i] = root
+			return
+		}
+	}
+	library.Roots = append(library.Roots, root)
+}
+
+// listRoot adds the files below the root of progress to its pending files
+// and turns the books of the root that disappeared into tombstones.
+func (library *Library) listRoot(progress *ScanProgress) error {
+	root := progress.Root
+	if _, err := os.Stat(longPath(root)); err != nil {
+		return nil
+	}
+
+	found := make(map[string]bool)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".epub") {
+			progress.Pending = append(progress.Pending, path)
+			found[path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("epub: scan %s: %w", root, err)
+	}
+
+	for i, book := range library.Books {
+		if book.Root == root && !book.Deleted() && !found[book.Path] {
+			library.Books[i].DeletedAt = progress.Started
+			library.Books[i].DuplicateOf = ""
+			progress.Changes = append(progress.Changes, LibraryChange{Kind: LibraryDeleted, Book: library.Books[i]})
+		}
+	}
+
+	return nil
+}
+
+// resolveDuplicates marks the copies of each book but the preferred one as
+// duplicates.
+func (library *Library) resolveDuplicates() {
+	priorities := make(map[string]int)
+	for _, root := range library.Roots {
+		priorities[root.Path] = root.Priority
+	}
+
+	preferred := make(map[string]int)
+	for i, book := range library.Books {
+		library.Books[i].DuplicateOf = ""
+		if book.Deleted() || book.Fingerprint == "" {
+			continue
+		}
+		j, ok := preferred[book.Fingerprint]
+		if !ok {
+			preferred[book.Fingerprint] = i
+			continue
+		}
+		other := library.Books[j]
+		if priorities[book.Root] > priorities[other.Root] ||
+			priorities[book.Root] == priorities[other.Root] && book.ModTime.After(other.ModTime) {
+			preferred[book.Fingerprint] = i
+		}
+	}
+
+	for i, book := range library.Books {
+		if j, ok := preferred[book.Fingerprint]; ok && !book.Deleted() && j != i {
+			library.Books[i].DuplicateOf = library.Books[j].Path
+		}
+	}
+}
+
+// Catalog returns the books of the library, without tombstones and
+// duplicates, sorted by path.
+func (library *Library) Catalog() []LibraryBook {
+	var books []LibraryBook
+	for _, book := range library.Books {
+		if !book.Deleted() && book.DuplicateOf == "" {
+			books = append(books, book)
+		}
+	}
+
+	return books
+}
+
+// scanFile reads the file path again if it changed since the last scan.
+func (library *Library) scanFile(root, path string) (LibraryChange, bool) {
+	info, err := os.Stat(longPath(path))
+	if err != nil {
+		// Deleted since the scan started: the next scan makes it a
+		// tombstone.
+		return LibraryChange{}, false
+	}
+
+	kind := LibraryAdded
+	previous, known := library.Book(path)
+	if known && !previous.Deleted() {
+		if previous.Size == info.Size() && previous.ModTime.Equal(info.ModTime()) {
+			return LibraryChange{}, false
+		}
+		kind = LibraryModified
+	}
+
+	book := readLibraryBook(path)
+	book.Read = known && previous.Read
+	book.Root = root
+	book.Size = info.Size()
+	book.ModTime = info.ModTime().UTC()
+	library.put(book)
+
+	return LibraryChange{Kind: kind, Book: book}, true
+}
+
+// readLibraryBook returns the metadata of the book at path.
+func readLibraryBook(path string) LibraryBook {
+	var inspection Inspection
+	var guesses []MetadataGuess
+	book := LibraryBook{Path: path}
+
+	if reader, err := OpenReader(path); err == nil {
+		inspection.setMetadata(&reader.Rootfiles[0].Package)
+		book.Fingerprint = reader.Fingerprint()
+		book.Series, book.SeriesIndex = reader.series()
+		book.Subjects = reader.subjects()
+		book.AuthorSort = reader.AuthorSort()
+		if inspection.Title == "" || inspection.Creator == "" {
+			if guess, ok := reader.GuessMetadataFromTitlePage(); ok {
+				guesses = append(guesses, guess)
+			}
+		}
+		_, book.HasCover = reader.Cover()
+		book.MetadataScore = reader.MetadataScore().Score
+		if match := datePattern.FindStringSubmatch(strings.TrimSpace(firstDate(reader.Rootfiles[0].Metadata.Date))); match != nil {
+			book.Year, _ = strconv.Atoi(match[1])
+		}
+		reader.Close()
+	} else {
+		inspection = Inspect(path)
+		book.Error = err.Error()
+	}
+
+	book.Title = inspection.Title
+	book.Creator = inspection.Creator
+	if book.Title == "" || book.Creator == "" {
+		if guess, ok := GuessMetadataFromPath(path); ok {
+			guesses = append([]MetadataGuess{guess}, guesses...)
+		}
+		book.guess(guesses)
+	}
+	if book.AuthorSort == "" {
+		book.AuthorSort = AuthorSort(book.Creator)
+	}
+	book.Language = inspection.Language
+	book.Identifier = inspection.Identifier
+	book.Publisher = inspection.Publisher
+
+	return book
+}
+
+// guess fills the blank title, creator and year of the book with the most
+// confident of the guesses, the first one on ties, and keeps it.
+func (book *LibraryBook) guess(guesses []MetadataGuess) {
+	if len(guesses) == 0 {
+		return
+	}
+	best := guesses[0]
+	for _, guess := range guesses[1:] {
+		if guess.Confidence > best.Confidence {
+			best = guess
+		}
+	}
+
+	book.Guess = &best
+	if book.Title == "" {
+		book.Title = best.Title
+	}
+	if book.Creator == "" {
+		book.Creator = best.Author
+	}
+	if book.Year == 0 {
+		book.Year = best.Year
+	}
+}
+
+// subjects returns the non-empty dc:subject elements of the package
+// document, whitespace collapsed.
+func (epubReader *EpubReader) subjects() []string {
+	var subjects []string
+	for _, subject := range epubReader.Rootfiles[0].Metadata.Subject {
+		if text := collapseSpace(subject.Text); text != "" {
+			subjects = append(subjects, text)
+		}
+	}
+
+	return subjects
+}
+
+// PurgeTombstones drops the tombstones of books deleted before the given
+// time.
+func (library *Library) PurgeTombstones(before time.Time) {
+	books := library.Books[:0]
+	for _, book := range library.Books {
+		if !book.Deleted() || !book.DeletedAt.Before(before) {
+			books = append(books, book)
+		}
+	}
+	library.Books = books
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package epub
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func changeKinds(changes []LibraryChange) string {
+	var kinds []string
+	for _, change := range changes {
+		kinds = append(kinds, string(change.Kind)+" "+filepath.Base(change.Book.Path))
+	}
+
+	return strings.Join(kinds, ", ")
+}
+
+func TestLibraryScan(t *testing.T) {
+	root := t.TempDir()
+	state := filepath.Join(t.TempDir(), "library.json")
+	write := func(name string, data []byte) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.epub", buildTestEpub(t))
+	write("b.epub", buildTestEpub(t))
+	write("broken.epub", []byte("not a zip"))
+	write("notes.txt", []byte("ignored"))
+
+	// A canceled scan persists its progress, and the next one resumes it.
+	library, err := LoadLibrary(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := library.Scan(ctx, root, ScanOptions{}); err != context.Canceled {
+		t.Fatalf("Scan() error = %v, want context.Canceled", err)
+	}
+	if library, err = LoadLibrary(state); err != nil || library.Progress == nil || len(library.Progress.Pending) != 3 {
+		t.Fatalf("LoadLibrary() = %+v, %v", library, err)
+	}
+
+	changes, err := library.Scan(context.Background(), root, ScanOptions{Checkpoint: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := changeKinds(changes); got != "added a.epub, added b.epub, added broken.epub" {
+		t.Errorf("first scan = %s", got)
+	}
+	if book, _ := library.Book(filepath.Join(root, "a.epub")); book.Title != "The Test Book" || book.Identifier != "9780306406157" || book.Fingerprint == "" {
+		t.Errorf("a.epub = %+v", book)
+	}
+	if book, _ := library.Book(filepath.Join(root, "broken.epub")); book.Error == "" {
+		t.Errorf("broken.epub = %+v", book)
+	}
+
+	// Unchanged files are not read again.
+	if changes, err := library.Scan(context.Background(), root, ScanOptions{}); err != nil || len(changes) != 0 {
+		t.Errorf("second scan = %s, %v", changeKinds(changes), err)
+	}
+
+	write("a.epub", buildTestEpub(t, testFile{"OEBPS/style.css", "p { margin: 1em; }"}))
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(root, "a.epub"), later, later)
+	os.Remove(filepath.Join(root, "b.epub"))
+	if changes, err = library.Scan(context.Background(), root, ScanOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := changeKinds(changes); got != "deleted b.epub, modified a.epub" {
+		t.Errorf("third scan = %s", got)
+	}
+
+	library, err = LoadLibrary(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if book, ok := library.Book(filepath.Join(root, "b.epub")); !ok || !book.Deleted() || library.Progress != nil {
+		t.Errorf("b.epub = %+v, %v", book, ok)
+	}
+	library.PurgeTombstones(time.Now().Add(time.Minute))
+	if _, ok := library.Book(filepath.Join(root, "b.epub")); ok || len(library.Books) != 2 {
+		t.Errorf("PurgeTombstones() left %+v", library.Books)
+	}
+}
+
+func TestLibraryScanRoots(t *testing.T) {
+	local, nas := t.TempDir(), t.TempDir()
+	book := buildTestEpub(t)
+	other := buildTestEpub(t, testFile{"OEBPS/content.opf", strings.Replace(testOPF, "9780306406157", "9780000000002", 1)})
+	for path, data := range map[string][]byte{
+		filepath.Join(local, "book.epub"):       book,
+		filepath.Join(nas, "copy.epub"):         book,
+		filepath.Join(nas, "other.epub"):        other,
+		filepath.Join(nas, "shelf", "old.epub"): other,
+	} {
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(nas, "shelf", "old.epub"), old, old)
+
+	var library Library
+	roots := []LibraryRoot{{Path: local, Priority: 10}, {Path: nas}}
+	changes, err := library.ScanRoots(context.Background(), roots, ScanOptions{})
+	if err != nil || len(changes) != 4 {
+		t.Fatalf("ScanRoots() = %s, %v", changeKinds(changes), err)
+	}
+
+	var catalog []string
+	for _, book := range library.Catalog() {
+		catalog = append(catalog, book.Path)
+	}
+	if want := []string{filepath.Join(local, "book.epub"), filepath.Join(nas, "other.epub")}; strings.Join(catalog, ",") != strings.Join(want, ",") {
+		t.Errorf("Catalog() = %v", catalog)
+	}
+	if duplicate, _ := library.Book(filepath.Join(nas, "copy.epub")); duplicate.DuplicateOf != filepath.Join(local, "book.epub") {
+		t.Errorf("copy.epub = %+v", duplicate)
+	}
+
+	// An unmounted root keeps its books.
+	if err := os.RemoveAll(nas); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := library.ScanRoots(context.Background(), roots, ScanOptions{}); err != nil || len(changes) != 0 || len(library.Catalog()) != 2 {
+		t.Errorf("ScanRoots() without NAS = %s, %v", changeKinds(changes), err)
+	}
+}
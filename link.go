@@ -0,0 +1,79 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ErrRemoteLink occurs when dereferencing a link whose target is not
+// inside the book.
+var ErrRemoteLink = errors.New("epub: link target is not in the book")
+
+// Link is an OPF metadata link, pointing at a resource that describes the
+// publication: a metadata record (rel="record"), an ONIX or XMP file, ...
+type Link struct {
+	Href       string `xml:"href,attr"`
+	Rel        string `xml:"rel,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	ID         string `xml:"id,attr"`
+	Refines    string `xml:"refines,attr"`
+	Properties string `xml:"properties,attr"`
+	Hreflang   string `xml:"hreflang,attr"`
+}
+
+// HasRel reports whether rel is one of the space separated relationships of
+// the link.
+func (link Link) HasRel(rel string) bool {
+	for _, r := range strings.Fields(link.Rel) {
+		if r == rel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLocal reports whether the link target is a resource in the book.
+func (link Link) IsLocal() bool {
+	u, err := url.Parse(link.Href)
+
+	return err == nil && u.Scheme == "" && u.Host == "" && !strings.HasPrefix(u.Path, "/")
+}
+
+// markup returns the link element of the package metadata.
+func (link Link) markup() string {
+	var b strings.Builder
+	b.WriteString(`<link href="` + escapeXML(link.Href) + `"`)
+	for _, attribute := range []struct{ name, value string }{
+		{"rel", link.Rel},
+		{"media-type", link.MediaType},
+		{"id", link.ID},
+		{"refines", link.Refines},
+		{"properties", link.Properties},
+		{"hreflang", link.Hreflang},
+	} {
+		if attribute.value != "" {
+			b.WriteString(" " + attribute.name + `="` + escapeXML(attribute.value) + `"`)
+		}
+	}
+	b.WriteString("/>")
+
+	return b.String()
+}
+
+// Links returns the links of the package metadata.
+func (epubReader *EpubReader) Links() []Link {
+	return epubReader.Rootfiles[0].Metadata.Link
+}
+
+// OpenLink opens the in-book target of link.
+func (epubReader *EpubReader) OpenLink(link Link) (io.ReadCloser, error) {
+	if !link.IsLocal() {
+		return nil, fmt.Errorf("epub: %s: '%s': %w", epubReader.Name, link.Href, ErrRemoteLink)
+	}
+
+	return epubReader.openFile(epubReader.itemPath(link.Href))
+}
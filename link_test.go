@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	opf := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, `<meta name="cover" content="cover-image"/>
+    <link rel="record" href="meta/record.jsonld" media-type="application/ld+json"/>
+    <link rel="record" href="https://example.com/onix.xml" media-type="application/xml" properties="onix"/>`, 1)
+
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/meta/record.jsonld", `{"@type": "Book"}`},
+	)
+
+	links := reader.Links()
+	if len(links) != 2 {
+		t.Fatalf("Links() = %v", links)
+	}
+	if !links[0].HasRel("record") || !links[0].IsLocal() || links[1].IsLocal() {
+		t.Errorf("Links() = %v", links)
+	}
+
+	rc, err := reader.OpenLink(links[0])
+	if err != nil {
+		t.Fatalf("OpenLink() = %v", err)
+	}
+	defer rc.Close()
+	if body, _ := ioutil.ReadAll(rc); string(body) != `{"@type": "Book"}` {
+		t.Errorf("OpenLink() = %q", body)
+	}
+
+	if _, err := reader.OpenLink(links[1]); !errors.Is(err, ErrRemoteLink) {
+		t.Errorf("OpenLink(remote) = %v", err)
+	}
+
+	records, err := reader.MetadataRecords()
+	if err != nil || len(records) != 1 || records[0].Type() != "Book" {
+		t.Errorf("MetadataRecords() = %v, %v", records, err)
+	}
+}
+
+func TestWriteEditedLinks(t *testing.T) {
+	reader := openTestEpub(t)
+	link := Link{Href: "meta/a&b.xml", Rel: "record", MediaType: "application/xml", Properties: "onix"}
+	reader.Rootfiles[0].Metadata.Link = []Link{link}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := written.Links(); len(links) != 1 || links[0] != link {
+		t.Fatalf("Links() = %+v", links)
+	}
+
+	written.Rootfiles[0].Metadata.Link = nil
+	buffer.Reset()
+	if err := written.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if written, err = OpenBuffer(buffer.Bytes(), int64(buffer.Len())); err != nil || len(written.Links()) != 0 {
+		t.Errorf("Links() = %+v, %v", written.Links(), err)
+	}
+}
@@ -0,0 +1,90 @@
+package epub
+
+import (
+	"mime"
+	"sort"
+)
+
+// MediaTypeStat summarizes the manifest items of one media type.
+type MediaTypeStat struct {
+	MediaType string
+	Count     int
+	// Bytes is the uncompressed size of the items found in the zip.
+	Bytes uint64
+	// Core is set for EPUB core media types.
+	Core bool
+	// Unsupported lists the ids of the items of a foreign media type that
+	// have neither a fallback to a core media type nor another way to be
+	// rendered (a handler or a metadata link).
+	Unsupported []string
+}
+
+// MediaTypeStats groups the manifest items by media type, sorted by media
+// type.
+func (epubReader *EpubReader) MediaTypeStats() []MediaTypeStat {
+	stats := make(map[string]*MediaTypeStat)
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		mediaType := item.MediaType
+		if base, _, err := mime.ParseMediaType(mediaType); err == nil {
+			mediaType = base
+		}
+
+		stat, ok := stats[mediaType]
+		if !ok {
+			stat = &MediaTypeStat{MediaType: mediaType, Core: IsCoreMediaType(mediaType)}
+			stats[mediaType] = stat
+		}
+		stat.Count++
+		stat.Bytes += epubReader.fileSize(epubReader.itemPath(item.Href))
+		if !stat.Core && !epubReader.isSupported(item) {
+			stat.Unsupported = append(stat.Unsupported, item.ID)
+		}
+	}
+
+	sorted := make([]MediaTypeStat, 0, len(stats))
+	for _, stat := range stats {
+		sorted = append(sorted, *stat)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MediaType < sorted[j].MediaType })
+
+	return sorted
+}
+
+// isSupported reports whether a reading system has a way to render item.
+func (epubReader *EpubReader) isSupported(item Item) bool {
+	chain, _ := epubReader.FallbackChain(item.ID)
+	for _, fallback := range chain {
+		if IsCoreMediaType(fallback.MediaType) {
+			return true
+		}
+	}
+
+	if _, ok := epubReader.Handler(item.MediaType); ok {
+		return true
+	}
+
+	for _, link := range epubReader.Links() {
+		if link.IsLocal() && epubReader.itemPath(link.Href) == epubReader.itemPath(item.Href) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkForeignResources(epubReader *EpubReader) []Issue {
+	var issues []Issue
+
+	for _, stat := range epubReader.MediaTypeStats() {
+		for _, id := range stat.Unsupported {
+			item, _ := epubReader.ItemByID(id)
+			issues = append(issues, Issue{
+				Path:    epubReader.itemPath(item.Href),
+				Message: "foreign media type " + stat.MediaType + " without a core media type fallback",
+			})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,44 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediaTypeStats(t *testing.T) {
+	opf := strings.Replace(testOPF, `<manifest>`, `<manifest>
+    <item id="tiff" href="images/map.tiff" media-type="image/tiff" fallback="cover-image"/>
+    <item id="pdf" href="extra.pdf" media-type="application/pdf"/>`, 1)
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/extra.pdf", "%PDF"},
+		testFile{"OEBPS/images/map.tiff", "II*\x00"},
+	)
+
+	stats := reader.MediaTypeStats()
+	byType := make(map[string]MediaTypeStat)
+	var order []string
+	for _, stat := range stats {
+		byType[stat.MediaType] = stat
+		order = append(order, stat.MediaType)
+	}
+
+	if strings.Join(order, " ") != "application/pdf application/x-dtbncx+xml application/xhtml+xml image/jpeg image/tiff text/css" {
+		t.Errorf("MediaTypeStats() order = %v", order)
+	}
+	xhtml := byType["application/xhtml+xml"]
+	if xhtml.Count != 2 || !xhtml.Core || xhtml.Bytes != uint64(len(testChapter1)+len(testChapter2)) {
+		t.Errorf("xhtml = %+v", xhtml)
+	}
+	if tiff := byType["image/tiff"]; tiff.Core || len(tiff.Unsupported) != 0 {
+		t.Errorf("tiff = %+v", tiff)
+	}
+	if pdf := byType["application/pdf"]; len(pdf.Unsupported) != 1 || pdf.Bytes != 4 {
+		t.Errorf("pdf = %+v", pdf)
+	}
+
+	issues := reader.Validate()
+	if len(issues) != 1 || issues[0].RuleID != "foreign-resource" || issues[0].Path != "OEBPS/extra.pdf" {
+		t.Errorf("Validate() = %v", issues)
+	}
+}
@@ -0,0 +1,218 @@
+package epub
+
+import "strings"
+
+// DCElement is a Dublin Core element of the package metadata.
+type DCElement struct {
+	Text string `xml:",chardata"`
+	ID   string `xml:"id,attr"`
+	// Lang is the xml:lang attribute of the element.
+	Lang string `xml:"lang,attr"`
+	Dir  string `xml:"dir,attr"`
+}
+
+// Creator is a dc:creator or dc:contributor element. Role and FileAs are
+// the EPUB 2 opf:role and opf:file-as attributes.
+type Creator struct {
+	DCElement
+	Role   string `xml:"role,attr"`
+	FileAs string `xml:"file-as,attr"`
+}
+
+// DCDate is a dc:date element. Event is the EPUB 2 opf:event attribute,
+// such as "publication" or "modification".
+type DCDate struct {
+	DCElement
+	Event string `xml:"event,attr"`
+}
+
+// DCIdentifier is a dc:identifier element. Scheme is the EPUB 2
+// opf:scheme attribute.
+type DCIdentifier struct {
+	DCElement
+	Scheme string `xml:"scheme,attr"`
+}
+
+// DCTitle is a dc:title element. Type is the EPUB 3 title-type refining
+// it, such as "main" or "subtitle", resolved by Metadata.
+type DCTitle struct {
+	DCElement
+	Type string `xml:"-"`
+}
+
+// Meta is a meta element of the package metadata: an EPUB 2 name and
+// content pair, or an EPUB 3 property, whose value is its text. A property
+// refining another element names it in Refines, as "#id".
+type Meta struct {
+	Text     string `xml:",chardata"`
+	ID       string `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Content  string `xml:"content,attr"`
+	Property string `xml:"property,attr"`
+	Refines  string `xml:"refines,attr"`
+	Scheme   string `xml:"scheme,attr"`
+}
+
+// DublinCore holds every Dublin Core element of the package metadata, in
+// document order.
+type DublinCore struct {
+	Titles       []DCTitle
+	Creators     []Creator
+	Contributors []Creator
+	Subjects     []DCElement
+	Descriptions []DCElement
+	Publishers   []DCElement
+	Dates        []DCDate
+	Types        []DCElement
+	Formats      []DCElement
+	Identifiers  []DCIdentifier
+	Sources      []DCElement
+	Languages    []DCElement
+	Relations    []DCElement
+	Coverages    []DCElement
+	Rights       []DCElement
+}
+
+// Metadata returns the Dublin Core elements of Package.Metadata, with the
+// roles and sort names of creators, the types of titles and the schemes of
+// identifiers declared by EPUB 3 refining meta elements resolved. Its
+// slices may be changed without changing the package metadata.
+func (epubReader *EpubReader) Metadata() DublinCore {
+	source := epubReader.Rootfiles[0].Metadata
+	metadata := DublinCore{
+		Titles:       append([]DCTitle(nil), source.Title...),
+		Creators:     epubReader.Creators(),
+		Contributors: epubReader.Contributors(),
+		Dates:        append([]DCDate(nil), source.Date...),
+		Identifiers:  append([]DCIdentifier(nil), source.Identifier...),
+	}
+	for _, elements := range []struct {
+		to   *[]DCElement
+		from []DCElement
+	}{
+		{&metadata.Subjects, source.Subject}, {&metadata.Descriptions, source.Description},
+		{&metadata.Publishers, source.Publisher}, {&metadata.Types, source.Type},
+		{&metadata.Formats, source.Format}, {&metadata.Sources, source.Source},
+		{&metadata.Languages, source.Language}, {&metadata.Relations, source.Relation},
+		{&metadata.Coverages, source.Coverage}, {&metadata.Rights, source.Rights},
+	} {
+		*elements.to = append([]DCElement(nil), elements.from...)
+	}
+
+	for i := range metadata.Titles {
+		metadata.Titles[i].Type = epubReader.refinement(metadata.Titles[i].ID, "title-type")
+	}
+	for i, identifier := range metadata.Identifiers {
+		if identifier.Scheme == "" {
+			metadata.Identifiers[i].Scheme = epubReader.refinement(identifier.ID, "identifier-type")
+		}
+	}
+
+	return metadata
+}
+
+// Creators returns the creators of the book, with the roles and sort names
+// of EPUB 3 refining meta elements.
+func (epubReader *EpubReader) Creators() []Creator {
+	creators := make([]Creator, len(epubReader.Rootfiles[0].Metadata.Creator))
+	for i, creator := range epubReader.Rootfiles[0].Metadata.Creator {
+		creators[i] = epubReader.resolveCreator(creator)
+	}
+
+	return creators
+}
+
+// Contributors returns the contributors of the book, like Creators.
+func (epubReader *EpubReader) Contributors() []Creator {
+	contributors := make([]Creator, len(epubReader.Rootfiles[0].Metadata.Contributor))
+	for i, contributor := range epubReader.Rootfiles[0].Metadata.Contributor {
+		contributors[i] = epubReader.resolveCreator(contributor)
+	}
+
+	return contributors
+}
+
+// Refinements returns the meta elements refining the element with the
+// given id, in document order.
+func (epubReader *EpubReader) Refinements(id string) []Meta {
+	return epubReader.Rootfiles[0].refinements(id)
+}
+
+func (rootfile *Rootfile) refinements(id string) []Meta {
+	if id == "" {
+		return nil
+	}
+
+	var refinements []Meta
+	for _, meta := range rootfile.Metadata.Meta {
+		if strings.TrimSpace(meta.Refines) == "#"+id {
+			refinements = append(refinements, meta)
+		}
+	}
+
+	return refinements
+}
+
+// refinement returns the value of the first property refining the element
+// with the given id.
+func (epubReader *EpubReader) refinement(id, property string) string {
+	for _, meta := range epubReader.Refinements(id) {
+		if strings.TrimSpace(meta.Property) == property {
+			if value := collapseSpace(meta.Text); value != "" {
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+// resolveCreator fills the role and sort name of creator missing EPUB 2
+// attributes from the EPUB 3 meta elements refining it.
+func (epubReader *EpubReader) resolveCreator(creator Creator) Creator {
+	if creator.Role == "" {
+		creator.Role = epubReader.refinement(creator.ID, "role")
+	}
+	if creator.FileAs == "" {
+		creator.FileAs = epubReader.refinement(creator.ID, "file-as")
+	}
+
+	return creator
+}
+
+// firstCreator returns the first of creators, or a zero creator.
+func firstCreator(creators []Creator) Creator {
+	if len(creators) == 0 {
+		return Creator{}
+	}
+
+	return creators[0]
+}
+
+// firstText returns the text of the first of elements, "" when there is
+// none.
+func firstText(elements []DCElement) string {
+	if len(elements) == 0 {
+		return ""
+	}
+
+	return elements[0].Text
+}
+
+// firstTitle returns the text of the first of titles.
+func firstTitle(titles []DCTitle) string {
+	if len(titles) == 0 {
+		return ""
+	}
+
+	return titles[0].Text
+}
+
+// firstDate returns the text of the first of dates.
+func firstDate(dates []DCDate) string {
+	if len(dates) == 0 {
+		return ""
+	}
+
+	return dates[0].Text
+}
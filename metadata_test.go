@@ -0,0 +1,141 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	opf := strings.Replace(testOPF, `<dc:language>en</dc:language>`, `<dc:language>en</dc:language>
+    <dc:title xml:lang="fr" dir="ltr">Le Livre de Test</dc:title>
+    <dc:creator id="c2" opf:role="ill">John Roe</dc:creator>
+    <dc:contributor opf:role="trl">Ann Poe</dc:contributor>
+    <dc:subject>Fiction</dc:subject>
+    <dc:subject>Testing</dc:subject>
+    <dc:date opf:event="publication">2019-05-01</dc:date>
+    <dc:date opf:event="modification">2020-01-02</dc:date>
+    <dc:rights>Public domain</dc:rights>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+
+	metadata := reader.Metadata()
+	want := DublinCore{
+		Titles: []DCTitle{{DCElement: DCElement{Text: "The Test Book"}}, {DCElement: DCElement{Text: "Le Livre de Test", Lang: "fr", Dir: "ltr"}}},
+		Creators: []Creator{
+			{DCElement: DCElement{Text: "Jane Doe"}, Role: "aut", FileAs: "Doe, Jane"},
+			{DCElement: DCElement{Text: "John Roe", ID: "c2"}, Role: "ill"},
+		},
+		Contributors: []Creator{{DCElement: DCElement{Text: "Ann Poe"}, Role: "trl"}},
+		Subjects:     []DCElement{{Text: "Fiction"}, {Text: "Testing"}},
+		Publishers:   []DCElement{{Text: "Test Press"}},
+		Dates:        []DCDate{{DCElement{Text: "2019-05-01"}, "publication"}, {DCElement{Text: "2020-01-02"}, "modification"}},
+		Identifiers:  []DCIdentifier{{DCElement{Text: "9780306406157", ID: "bookid"}, "ISBN"}},
+		Languages:    []DCElement{{Text: "en"}},
+		Rights:       []DCElement{{Text: "Public domain"}},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("Metadata() = %+v\nwant %+v", metadata, want)
+	}
+
+	if creators := reader.Rootfiles[0].Metadata.Creator; len(creators) != 2 || creators[1].Role != "ill" {
+		t.Errorf("Package creators = %+v", creators)
+	}
+	if titles := reader.Rootfiles[0].Metadata.Title; len(titles) != 2 || titles[1].Lang != "fr" || titles[1].Dir != "ltr" {
+		t.Errorf("Package titles = %+v", titles)
+	}
+	if subjects := reader.subjects(); !reflect.DeepEqual(subjects, []string{"Fiction", "Testing"}) {
+		t.Errorf("subjects() = %q", subjects)
+	}
+	if translation, ok := reader.Translation(); !ok || translation.Translator != "Ann Poe" {
+		t.Errorf("Translation() = %+v, %v", translation, ok)
+	}
+}
+
+const testRefinesOPF = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" unique-identifier="bookid" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title id="t1">The Test Book</dc:title>
+    <meta refines="#t1" property="title-type">main</meta>
+    <dc:title id="t2">A Subtitle</dc:title>
+    <meta refines="#t2" property="title-type">subtitle</meta>
+    <dc:creator id="c1">Jane Doe</dc:creator>
+    <meta refines="#c1" property="role" scheme="marc:relators" id="role1">aut</meta>
+    <meta refines="#c1" property="file-as">Doe, Jane</meta>
+    <dc:creator id="c2">John Roe</dc:creator>
+    <meta refines="#c2" property="role" scheme="marc:relators">edt</meta>
+    <dc:contributor id="c3">Ann Poe</dc:contributor>
+    <meta refines="#c3" property="role" scheme="marc:relators">trl</meta>
+    <dc:identifier id="bookid">9780306406157</dc:identifier>
+    <meta refines="#bookid" property="identifier-type" scheme="onix:codelist5">15</meta>
+    <dc:language>en</dc:language>
+    <meta property="dcterms:modified">2020-01-02T00:00:00Z</meta>
+  </metadata>
+  <manifest>
+    <item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chapter1"/>
+  </spine>
+</package>`
+
+func TestRefines(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", testRefinesOPF})
+
+	want := []Creator{
+		{DCElement: DCElement{Text: "Jane Doe", ID: "c1"}, Role: "aut", FileAs: "Doe, Jane"},
+		{DCElement: DCElement{Text: "John Roe", ID: "c2"}, Role: "edt"},
+	}
+	if creators := reader.Creators(); !reflect.DeepEqual(creators, want) {
+		t.Errorf("Creators() = %+v", creators)
+	}
+	if refinements := reader.Refinements("c1"); len(refinements) != 2 || refinements[0].Scheme != "marc:relators" || refinements[0].ID != "role1" {
+		t.Errorf("Refinements() = %+v", refinements)
+	}
+	if sort := reader.AuthorSort(); sort != "Doe, Jane" {
+		t.Errorf("AuthorSort() = %q", sort)
+	}
+	if citation := reader.Citation(); !reflect.DeepEqual(citation.Editors, []string{"Roe, John"}) {
+		t.Errorf("Citation() = %+v", citation)
+	}
+	if translation, ok := reader.Translation(); !ok || translation.Translator != "Ann Poe" {
+		t.Errorf("Translation() = %+v, %v", translation, ok)
+	}
+
+	metadata := reader.Metadata()
+	if len(metadata.Titles) != 2 || metadata.Titles[0].Type != "main" || metadata.Titles[1].Type != "subtitle" {
+		t.Errorf("Titles = %+v", metadata.Titles)
+	}
+	if len(metadata.Identifiers) != 1 || metadata.Identifiers[0].Scheme != "15" {
+		t.Errorf("Identifiers = %+v", metadata.Identifiers)
+	}
+	if len(metadata.Contributors) != 1 || metadata.Contributors[0].Role != "trl" {
+		t.Errorf("Contributors = %+v", metadata.Contributors)
+	}
+}
+
+// TestMetadataPackage checks that Metadata follows the package metadata
+// of the active rendition, without sharing its slices.
+func TestMetadataPackage(t *testing.T) {
+	container := strings.Replace(testContainer, "</rootfiles>", `  <rootfile full-path="OEBPS/content3.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>`, 1)
+	opf3 := strings.Replace(testOPF, `version="2.0"`, `version="3.0"`, 1)
+	opf3 = strings.Replace(opf3, "The Test Book", "The Third Book", 1)
+	reader := openTestEpub(t, testFile{containerPath, container}, testFile{"OEBPS/content3.opf", opf3})
+
+	metadata := reader.Metadata()
+	metadata.Titles[0].Text = "Changed"
+	metadata.Languages[0].Text = "fr"
+	if metadata := reader.Metadata(); metadata.Titles[0].Text != "The Test Book" || metadata.Languages[0].Text != "en" {
+		t.Errorf("Metadata() title = %q, language = %q", metadata.Titles[0].Text, metadata.Languages[0].Text)
+	}
+	reader.Rootfiles[0].Metadata.Subject = []DCElement{{Text: "Fiction", Lang: "en"}}
+	if metadata := reader.Metadata(); !reflect.DeepEqual(metadata.Subjects, reader.Rootfiles[0].Metadata.Subject) {
+		t.Errorf("Metadata() subjects = %+v", metadata.Subjects)
+	}
+	if err := reader.SetActiveRendition(1); err != nil {
+		t.Fatal(err)
+	}
+	if metadata := reader.Metadata(); metadata.Titles[0].Text != "The Third Book" {
+		t.Errorf("Metadata() of the active rendition title = %q", metadata.Titles[0].Text)
+	}
+}
@@ -0,0 +1,97 @@
+package epub
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NavList is a nav element of the navigation document: the table of
+// contents ("toc"), the landmarks, the page list, or another list such as
+// the list of tables ("lot"), illustrations ("loi"), audio ("loa") or video
+// ("lov") clips.
+type NavList struct {
+	// Type is the epub:type attribute of the nav element, verbatim.
+	Type string
+	// Title is the text of the heading of the list, if any.
+	Title   string
+	Hidden  bool
+	Entries []TOCEntry
+}
+
+// Is reports whether the epub:type of the list includes navType.
+func (list NavList) Is(navType string) bool {
+	for _, field := range strings.Fields(list.Type) {
+		if field == navType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NavLists returns the nav elements of the navigation document, in document
+// order. A book without navigation document has none.
+func (epubReader *EpubReader) NavLists(options TOCOptions) ([]NavList, error) {
+	item, ok := epubReader.navItem()
+	if !ok {
+		return nil, nil
+	}
+
+	name := epubReader.itemPath(item.Href)
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	document, err := parseDOM(reader)
+	if err != nil {
+		return nil, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+	}
+
+	builder := epubReader.newTOCBuilder(name, options)
+	var lists []NavList
+	document.walk(func(n *domNode) bool {
+		if n.name != "nav" {
+			return true
+		}
+
+		list := NavList{Hidden: isHidden(n)}
+		for _, a := range n.attrs {
+			if a.Name.Local == "type" && a.Name.Space != "" {
+				list.Type = a.Value
+			}
+		}
+		for _, child := range n.elements() {
+			switch child.name {
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if list.Title == "" {
+					list.Title = collapseSpace(child.textContent())
+				}
+			}
+		}
+		list.Entries = builder.navList(firstChild(n, "ol"), 1, list.Hidden)
+		lists = append(lists, list)
+
+		return false
+	})
+
+	return lists, nil
+}
+
+// NavList returns the first list of the navigation document of the given
+// type, e.g. "lot" or "loi".
+func (epubReader *EpubReader) NavList(navType string) (NavList, bool, error) {
+	lists, err := epubReader.NavLists(TOCOptions{})
+	if err != nil {
+		return NavList{}, false, err
+	}
+
+	for _, list := range lists {
+		if list.Is(navType) {
+			return list, true, nil
+		}
+	}
+
+	return NavList{}, false, nil
+}
@@ -0,0 +1,52 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNavLists = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body>
+<nav epub:type="toc"><h1>Contents</h1><ol><li><a href="chapter1.xhtml">Chapter One</a></li></ol></nav>
+<nav epub:type="lot"><h2>Tables</h2><ol>
+  <li><a href="chapter1.xhtml#t1">Prices</a></li>
+  <li><a href="chapter2.xhtml#t2">Stock</a></li>
+</ol></nav>
+<nav epub:type="loi"><ol><li><a href="chapter1.xhtml#f1">A figure</a></li></ol></nav>
+<nav epub:type="page-list" hidden=""><ol><li><a href="chapter1.xhtml#p1">1</a></li></ol></nav>
+</body>
+</html>`
+
+func TestNavLists(t *testing.T) {
+	opf := strings.Replace(testOPF, `<item id="ncx"`, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx"`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/nav.xhtml", testNavLists})
+
+	lists, err := reader.NavLists(TOCOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, list := range lists {
+		types = append(types, list.Type)
+	}
+	if strings.Join(types, ",") != "toc,lot,loi,page-list" {
+		t.Errorf("NavLists() types = %v", types)
+	}
+
+	lot, ok, err := reader.NavList("lot")
+	if err != nil || !ok || lot.Title != "Tables" || len(lot.Entries) != 2 || lot.Entries[1].Href != "chapter2.xhtml#t2" {
+		t.Errorf("NavList(lot) = %+v, %v, %v", lot, ok, err)
+	}
+	if pages, ok, _ := reader.NavList("page-list"); !ok || !pages.Hidden || !pages.Entries[0].Hidden {
+		t.Errorf("NavList(page-list) = %+v", pages)
+	}
+	if _, ok, _ := reader.NavList("lov"); ok {
+		t.Error("NavList(lov) found")
+	}
+
+	if lists, err := openTestEpub(t).NavLists(TOCOptions{}); err != nil || len(lists) != 0 {
+		t.Errorf("EPUB 2 NavLists() = %v, %v", lists, err)
+	}
+}
@@ -0,0 +1,78 @@
+package epub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// NetworkOptions configures the features of the package that access the
+// network. The zero value uses a client with a 30 second timeout and the
+// proxy settings of the environment.
+type NetworkOptions struct {
+	// Client sends the requests; it carries proxies, transports and
+	// timeouts.
+	Client *http.Client
+	// Headers are added to every request.
+	Headers http.Header
+	// UserAgent replaces the default User-Agent header.
+	UserAgent string
+}
+
+// DefaultNetworkOptions are used by the network features when no options
+// are given. Set it once, before using them.
+var DefaultNetworkOptions = &NetworkOptions{}
+
+const defaultUserAgent = "github.com/jeanmarcboite/epub"
+
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+func (options *NetworkOptions) client() *http.Client {
+	if options != nil && options.Client != nil {
+		return options.Client
+	}
+
+	return defaultClient
+}
+
+// Do sends req with the configured client and headers.
+func (options *NetworkOptions) Do(req *http.Request) (*http.Response, error) {
+	if options == nil {
+		options = DefaultNetworkOptions
+	}
+
+	userAgent := defaultUserAgent
+	if options.UserAgent != "" {
+		userAgent = options.UserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	for key, values := range options.Headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	return options.client().Do(req)
+}
+
+// Get fetches url and returns the response body when the status is 200.
+// The caller closes the body.
+func (options *NetworkOptions) Get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := options.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("epub: get %s: %s", url, resp.Status)
+	}
+
+	return resp.Body, nil
+}
@@ -0,0 +1,49 @@
+package epub
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNetworkOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.Header.Get("User-Agent") + " " + r.Header.Get("X-Api-Key")))
+	}))
+	defer server.Close()
+
+	options := &NetworkOptions{
+		Client:    server.Client(),
+		Headers:   http.Header{"X-Api-Key": {"secret"}},
+		UserAgent: "test/1.0",
+	}
+
+	body, err := options.Get(context.Background(), server.URL+"/ok")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	defer body.Close()
+	if b, _ := ioutil.ReadAll(body); string(b) != "test/1.0 secret" {
+		t.Errorf("Get() = %q", b)
+	}
+
+	if _, err := options.Get(context.Background(), server.URL+"/missing"); err == nil {
+		t.Errorf("Get(missing) = no error")
+	}
+
+	var none *NetworkOptions
+	body, err = none.Get(context.Background(), server.URL+"/ok")
+	if err != nil {
+		t.Fatalf("nil Get() = %v", err)
+	}
+	defer body.Close()
+	if b, _ := ioutil.ReadAll(body); string(b) != defaultUserAgent+" " {
+		t.Errorf("nil Get() = %q", b)
+	}
+}
@@ -0,0 +1,92 @@
+package epub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// OpenLibrary is a metadata provider backed by the Open Library books API.
+type OpenLibrary struct {
+	// BaseURL defaults to https://openlibrary.org.
+	BaseURL string
+	// Network defaults to DefaultNetworkOptions.
+	Network *NetworkOptions
+}
+
+// Name implements MetadataProvider.
+func (openLibrary *OpenLibrary) Name() string {
+	return "openlibrary"
+}
+
+type openLibraryNamed struct {
+	Name string `json:"name"`
+}
+
+type openLibraryBook struct {
+	Title       string             `json:"title"`
+	Authors     []openLibraryNamed `json:"authors"`
+	Publishers  []openLibraryNamed `json:"publishers"`
+	PublishDate string             `json:"publish_date"`
+	Subjects    []openLibraryNamed `json:"subjects"`
+	Notes       interface{}        `json:"notes"`
+	Cover       struct {
+		Large string `json:"large"`
+	} `json:"cover"`
+}
+
+// LookupISBN implements MetadataProvider.
+func (openLibrary *OpenLibrary) LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error) {
+	base := openLibrary.BaseURL
+	if base == "" {
+		base = "https://openlibrary.org"
+	}
+	key := "ISBN:" + isbn
+	query := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+
+	network := openLibrary.Network
+	if network == nil {
+		network = DefaultNetworkOptions
+	}
+	body, err := network.Get(ctx, strings.TrimSuffix(base, "/")+"/api/books?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var books map[string]openLibraryBook
+	if err := json.NewDecoder(body).Decode(&books); err != nil {
+		return nil, fmt.Errorf("epub: openlibrary: %w", err)
+	}
+	book, ok := books[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: isbn %s", ErrMetadataNotFound, isbn)
+	}
+
+	metadata := &BookMetadata{
+		Title:    book.Title,
+		Date:     book.PublishDate,
+		ISBN:     isbn,
+		CoverURL: book.Cover.Large,
+		Source:   openLibrary.Name(),
+	}
+	for _, author := range book.Authors {
+		metadata.Authors = append(metadata.Authors, author.Name)
+	}
+	if len(book.Publishers) > 0 {
+		metadata.Publisher = book.Publishers[0].Name
+	}
+	for _, subject := range book.Subjects {
+		metadata.Subjects = append(metadata.Subjects, subject.Name)
+	}
+	switch notes := book.Notes.(type) {
+	case string:
+		metadata.Description = notes
+	case map[string]interface{}:
+		metadata.Description, _ = notes["value"].(string)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,273 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OptimizeOptions tunes the optimizer.
+type OptimizeOptions struct {
+	// CSSSafelist lists simple selectors, such as ".js-open", "#top" or
+	// "aside", whose rules are kept even when they match nothing, for
+	// elements created by scripts or reading systems.
+	CSSSafelist []string
+	// EmbedAudit appends the audit record of the optimization to the audit
+	// log of the book at AuditPath.
+	EmbedAudit bool
+}
+
+// OptimizeResult sums up what the optimizer changed.
+type OptimizeResult struct {
+	// RemovedSelectors maps the stylesheets that changed to the selectors
+	// removed from them.
+	RemovedSelectors map[string][]string
+	// SavedBytes is the size difference of the rewritten entries,
+	// before compression.
+	SavedBytes int64
+	// Audit records the changes, stylesheet by stylesheet.
+	Audit AuditRecord
+}
+
+// WriteOptimized writes an optimized copy of the book to w.
+//
+// Stylesheet rules whose selectors match no element of the content
+// documents using the stylesheet, directly or through @import, are
+// removed. Selectors the optimizer cannot evaluate, and stylesheets no
+// document uses, are kept. Style elements and attributes are not changed.
+func (epubReader *EpubReader) WriteOptimized(w io.Writer, options OptimizeOptions) (OptimizeResult, error) {
+	result := OptimizeResult{
+		RemovedSelectors: make(map[string][]string),
+		Audit:            AuditRecord{Operation: "optimize", Time: time.Now().UTC()},
+	}
+
+	replacements, err := epubReader.pruneStylesheets(options, &result)
+	if err != nil {
+		return result, err
+	}
+	result.Audit.SavedBytes = result.SavedBytes
+
+	if options.EmbedAudit {
+		if replacements[AuditPath], err = epubReader.appendAuditLog(result.Audit); err != nil {
+			return result, err
+		}
+	}
+
+	return result, epubReader.writeZip(w, replacements)
+}
+
+// OptimizeFile optimizes the EPUB at filename in place, with the guarantees
+// of SafeWriteFile. Its signature fits a BatchStep once options are bound.
+func OptimizeFile(filename string, options OptimizeOptions) (OptimizeResult, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return OptimizeResult{}, err
+	}
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteOptimized(&buffer, options)
+	reader.Close()
+	if err != nil {
+		return result, err
+	}
+
+	return result, SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// pruneStylesheets returns the pruned content of the stylesheets that
+// changed, by zip path.
+func (epubReader *EpubReader) pruneStylesheets(options OptimizeOptions, result *OptimizeResult) (map[string][]byte, error) {
+	resources, err := epubReader.ResourceMap()
+	if err != nil {
+		return nil, err
+	}
+
+	// Elements of the documents using each stylesheet.
+	elements := make(map[string][]*domNode)
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		switch item.MediaType {
+		case "application/xhtml+xml", "text/html", "image/svg+xml":
+		default:
+			continue
+		}
+		name := epubReader.itemPath(item.Href)
+		var documentElements []*domNode
+		for _, resource := range resources.Closure(name) {
+			if item, ok := epubReader.itemByPath(resource); !ok || item.MediaType != "text/css" {
+				continue
+			}
+			if documentElements == nil {
+				if documentElements, err = epubReader.documentElements(name); err != nil {
+					return nil, err
+				}
+			}
+			elements[resource] = append(elements[resource], documentElements...)
+		}
+	}
+
+	safelist := cssSafelistPattern(options.CSSSafelist)
+	replacements := make(map[string][]byte)
+	for _, name := range stylesheetNames(elements) {
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		css, removed := pruneCSS(buffer.String(), func(selector string) bool {
+			if safelist != nil && safelist.MatchString(selector) {
+				return true
+			}
+			parsed, ok := parseSelector(selector)
+			if !ok {
+				return true
+			}
+			for _, element := range elements[name] {
+				if parsed.matches(element) {
+					return true
+				}
+			}
+			return false
+		})
+		if len(removed) == 0 {
+			continue
+		}
+
+		replacements[name] = []byte(css)
+		result.RemovedSelectors[name] = removed
+		result.SavedBytes += int64(buffer.Len() - len(css))
+		result.Audit.Files = append(result.Audit.Files, AuditFile{
+			Path:    name,
+			Change:  AuditModified,
+			Before:  int64(buffer.Len()),
+			After:   int64(len(css)),
+			Details: removed,
+		})
+	}
+
+	return replacements, nil
+}
+
+// documentElements returns the elements of the content document name.
+func (epubReader *EpubReader) documentElements(name string) ([]*domNode, error) {
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	document, err := parseDOM(reader)
+	if err != nil {
+		return nil, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+	}
+
+	var elements []*domNode
+	document.walk(func(node *domNode) bool {
+		if node.name != "" && node != document {
+			elements = append(elements, node)
+		}
+		return true
+	})
+
+	return elements, nil
+}
+
+// cssSafelistPattern returns a pattern matching the selectors using one of
+// the simple selectors of safelist, nil if it is empty.
+func cssSafelistPattern(safelist []string) *regexp.Regexp {
+	var alternatives []string
+	for _, simple := range safelist {
+		if simple = strings.TrimSpace(simple); simple != "" {
+			alternatives = append(alternatives, regexp.QuoteMeta(simple))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+
+	return regexp.MustCompile(`(^|[^\w-])(` + strings.Join(alternatives, "|") + `)($|[^\w-])`)
+}
+
+func stylesheetNames(m map[string][]*domNode) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// writeZip writes the files of the book to w as a zip container, in
+// container order, with the mimetype entry first and stored. Files in
+// replacements are written with the new content, those the book does not
+// have yet last, and files mapped to nil are left out.
+func (epubReader *EpubReader) writeZip(w io.Writer, replacements map[string][]byte) error {
+	writer := zip.NewWriter(w)
+
+	names := epubReader.FileNames()
+	sort.SliceStable(names, func(i, j int) bool { return names[i] == mimetypePath && names[j] != mimetypePath })
+	var added []string
+	for name, content := range replacements {
+		if content != nil && !epubReader.hasFile(name) {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	names = append(names, added...)
+
+	for _, name := range names {
+		if content, ok := replacements[name]; ok && content == nil {
+			continue
+		}
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate}
+		if name == mimetypePath {
+			// No modification time either, which would add an extra
+			// field.
+			header.Method = zip.Store
+		} else if info, ok := epubReader.stat(name); ok {
+			header.Modified = info.ModTime()
+			// Entries stored, such as images which do not compress,
+			// stay stored.
+			if original, ok := info.Sys().(*zip.FileHeader); ok && original.Method == zip.Store {
+				header.Method = zip.Store
+			}
+		}
+
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("epub: %s: write '%s': %w", epubReader.Name, name, err)
+		}
+		if content, ok := replacements[name]; ok {
+			_, err = entry.Write(content)
+		} else {
+			err = epubReader.copyFile(entry, name)
+		}
+		if err != nil {
+			return fmt.Errorf("epub: %s: write '%s': %w", epubReader.Name, name, err)
+		}
+	}
+
+	return writer.Close()
+}
+
+// copyFile copies the content of the file name to w. Files are copied
+// whatever their size: MaxItemSize only limits the files read.
+func (epubReader *EpubReader) copyFile(w io.Writer, name string) error {
+	reader, err := epubReader.storage.Open(name)
+	if err != nil {
+		return fmt.Errorf("epub: %s: open '%s': %w", epubReader.Name, name, err)
+	}
+	defer reader.Close()
+
+	_, err = io.Copy(w, reader)
+
+	return err
+}
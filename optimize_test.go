@@ -0,0 +1,77 @@
+package epub
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteOptimized(t *testing.T) {
+	opf := strings.Replace(testOPF, `<item id="css"`, `<item id="base" href="base.css" media-type="text/css"/>
+    <item id="css"`, 1)
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/style.css", `@import "base.css";
+h1, .sidebar { color: red; }
+.js-open { display: block; }
+p img { width: 100%; }
+`},
+		testFile{"OEBPS/base.css", `body { margin: 0; } table { border: 0; }`},
+	)
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteOptimized(&buffer, OptimizeOptions{CSSSafelist: []string{".js-open"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"OEBPS/base.css":  {"table"},
+		"OEBPS/style.css": {".sidebar", "p img"},
+	}
+	if !reflect.DeepEqual(result.RemovedSelectors, want) || result.SavedBytes <= 0 {
+		t.Errorf("result = %+v", result)
+	}
+
+	optimized, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := optimized.FileNames(); names[0] != mimetypePath || len(names) != len(reader.FileNames()) {
+		t.Errorf("FileNames() = %v", names)
+	}
+	if issues := optimized.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+	css, err := optimized.readFile("OEBPS/style.css")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "@import \"base.css\";\nh1 { color: red; }\n.js-open { display: block; }\n"; css.String() != want {
+		t.Errorf("style.css = %q", css.String())
+	}
+}
+
+func TestOptimizeFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "book.epub")
+	data := buildTestEpub(t, testFile{"OEBPS/style.css", "p { margin: 0; } .dead { color: red; }"})
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := OptimizeFile(filename, OptimizeOptions{})
+	if err != nil || len(result.RemovedSelectors["OEBPS/style.css"]) != 1 {
+		t.Fatalf("OptimizeFile() = %+v, %v", result, err)
+	}
+
+	reader, err := OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if css, err := reader.readFile("OEBPS/style.css"); err != nil || css.String() != "p { margin: 0; }" {
+		t.Errorf("style.css = %q, %v", css, err)
+	}
+}
@@ -0,0 +1,216 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OverlayCue is a par element of a media overlay: a fragment of a content
+// document and the audio clip narrating it.
+type OverlayCue struct {
+	// TextSrc is the zip path and fragment of the text, Text its content.
+	TextSrc string
+	Text    string
+	// Audio is the zip path of the audio file.
+	Audio string
+	Begin time.Duration
+	End   time.Duration
+}
+
+// MediaOverlay returns the cues of the media overlay of the spine item with
+// the given id, in document order.
+func (epubReader *EpubReader) MediaOverlay(id string) ([]OverlayCue, error) {
+	item, ok := epubReader.ItemByID(id)
+	if !ok {
+		return nil, fmt.Errorf("epub: %s: item '%s': %w", epubReader.Name, id, ErrNoItem)
+	}
+	smil, ok := epubReader.ItemByID(item.MediaOverlay)
+	if !ok {
+		return nil, fmt.Errorf("epub: %s: media overlay of '%s': %w", epubReader.Name, id, ErrNoItem)
+	}
+
+	name := epubReader.itemPath(smil.Href)
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	cues, err := parseSMIL(reader, name)
+	if err != nil {
+		return cues, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+	}
+
+	documents := make(map[string]*domNode)
+	for i, cue := range cues {
+		path, fragment := cue.TextSrc, ""
+		if j := strings.IndexByte(path, '#'); j >= 0 {
+			path, fragment = path[:j], path[j+1:]
+		}
+
+		document, ok := documents[path]
+		if !ok {
+			if reader, err := epubReader.openFile(path); err == nil {
+				document, _ = parseDOM(reader)
+				reader.Close()
+			}
+			documents[path] = document
+		}
+		if document == nil {
+			continue
+		}
+		if element := document.byID(fragment); element != nil {
+			cues[i].Text = collapseSpace(element.textContent())
+		}
+	}
+
+	return cues, nil
+}
+
+// parseSMIL returns the par elements of a SMIL document stored at name.
+func parseSMIL(r io.Reader, name string) ([]OverlayCue, error) {
+	var cues []OverlayCue
+	var cue *OverlayCue
+
+	decoder := newXMLDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return cues, nil
+		}
+		if err != nil {
+			return cues, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "par":
+				cue = &OverlayCue{}
+			case "text":
+				if cue != nil {
+					src := attr(t, "src")
+					cue.TextSrc = resolvePath(name, src)
+					if i := strings.IndexByte(src, '#'); i >= 0 {
+						cue.TextSrc += src[i:]
+					}
+				}
+			case "audio":
+				if cue != nil {
+					cue.Audio = resolvePath(name, attr(t, "src"))
+					if cue.Begin, err = parseClock(attr(t, "clipBegin")); err == nil {
+						cue.End, err = parseClock(attr(t, "clipEnd"))
+					}
+					if err != nil {
+						return cues, err
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "par" && cue != nil {
+				cues = append(cues, *cue)
+				cue = nil
+			}
+		}
+	}
+}
+
+// parseClock parses a SMIL clock value: full (1:02:03.5) or partial (02:03.5)
+// clock values, or timecounts (3.5s, 200ms, 2min, 1h, 3.5).
+func parseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	if strings.Contains(s, ":") {
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid clock value %s", s)
+		}
+		var d time.Duration
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(part, 64)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid clock value %s", s)
+			}
+			unit := time.Second
+			switch len(parts) - 1 - i {
+			case 1:
+				unit = time.Minute
+			case 2:
+				unit = time.Hour
+			}
+			d += time.Duration(v * float64(unit))
+		}
+		return d, nil
+	}
+
+	unit := time.Second
+	for _, suffix := range []struct {
+		suffix string
+		unit   time.Duration
+	}{{"ms", time.Millisecond}, {"min", time.Minute}, {"h", time.Hour}, {"s", time.Second}} {
+		if strings.HasSuffix(s, suffix.suffix) {
+			s, unit = strings.TrimSuffix(s, suffix.suffix), suffix.unit
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid clock value %s", s)
+	}
+
+	return time.Duration(v * float64(unit)), nil
+}
+
+// WriteWebVTT writes cues as a WebVTT file, using the text fragment ids as
+// cue identifiers.
+func WriteWebVTT(w io.Writer, cues []OverlayCue) error {
+	var b strings.Builder
+
+	b.WriteString("WEBVTT\n")
+	for _, cue := range cues {
+		b.WriteString("\n")
+		if i := strings.IndexByte(cue.TextSrc, '#'); i >= 0 {
+			b.WriteString(cue.TextSrc[i+1:] + "\n")
+		}
+		fmt.Fprintf(&b, "%s --> %s\n%s\n", formatTimestamp(cue.Begin, '.'), formatTimestamp(cue.End, '.'), cueText(cue.Text))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// WriteSRT writes cues as a SubRip file.
+func WriteSRT(w io.Writer, cues []OverlayCue) error {
+	var b strings.Builder
+
+	for i, cue := range cues {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n", i+1, formatTimestamp(cue.Begin, ','), formatTimestamp(cue.End, ','), cueText(cue.Text))
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// cueText returns text without the blank lines and arrows that would end
+// or confuse a cue.
+func cueText(text string) string {
+	return strings.ReplaceAll(collapseSpace(text), "-->", "->")
+}
+
+func formatTimestamp(d time.Duration, separator byte) string {
+	ms := d.Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, separator, ms%1000)
+}
@@ -0,0 +1,90 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSMIL = `<?xml version="1.0" encoding="UTF-8"?>
+<smil xmlns="http://www.w3.org/ns/SMIL" xmlns:epub="http://www.idpf.org/2007/ops" version="3.0">
+  <body>
+    <seq epub:textref="../chapter1.xhtml">
+      <par id="par1">
+        <text src="../chapter1.xhtml#title"/>
+        <audio src="../audio/chapter1.mp3" clipBegin="0:00:00.500" clipEnd="2.25s"/>
+      </par>
+      <seq>
+        <par id="par2">
+          <text src="../chapter1.xhtml#p1"/>
+          <audio src="../audio/chapter1.mp3" clipBegin="2250ms" clipEnd="01:02:03.004"/>
+        </par>
+      </seq>
+    </seq>
+  </body>
+</smil>`
+
+func TestMediaOverlay(t *testing.T) {
+	opf := strings.Replace(testOPF, `<item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>`,
+		`<item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml" media-overlay="smil1"/>
+    <item id="smil1" href="smil/chapter1.smil" media-type="application/smil+xml"/>`, 1)
+	chapter := strings.Replace(strings.Replace(testChapter1, "<h1>", `<h1 id="title">`, 1), "<p>", `<p id="p1">`, 1)
+
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/chapter1.xhtml", chapter},
+		testFile{"OEBPS/smil/chapter1.smil", testSMIL},
+	)
+
+	cues, err := reader.MediaOverlay("chapter1")
+	if err != nil {
+		t.Fatalf("MediaOverlay() = %v", err)
+	}
+	want := []OverlayCue{
+		{"OEBPS/chapter1.xhtml#title", "Chapter One", "OEBPS/audio/chapter1.mp3", 500 * time.Millisecond, 2250 * time.Millisecond},
+		{"OEBPS/chapter1.xhtml#p1", "It was a dark & stormy night.", "OEBPS/audio/chapter1.mp3", 2250 * time.Millisecond, time.Hour + 2*time.Minute + 3004*time.Millisecond},
+	}
+	if len(cues) != 2 || cues[0] != want[0] || cues[1] != want[1] {
+		t.Fatalf("MediaOverlay() = %+v", cues)
+	}
+
+	var vtt bytes.Buffer
+	if err := WriteWebVTT(&vtt, cues); err != nil {
+		t.Fatal(err)
+	}
+	if want := "WEBVTT\n\ntitle\n00:00:00.500 --> 00:00:02.250\nChapter One\n\np1\n00:00:02.250 --> 01:02:03.004\nIt was a dark & stormy night.\n"; vtt.String() != want {
+		t.Errorf("WriteWebVTT() = %q", vtt.String())
+	}
+
+	var srt bytes.Buffer
+	if err := WriteSRT(&srt, cues); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n00:00:00,500 --> 00:00:02,250\nChapter One\n\n2\n00:00:02,250 --> 01:02:03,004\nIt was a dark & stormy night.\n"; srt.String() != want {
+		t.Errorf("WriteSRT() = %q", srt.String())
+	}
+
+	if _, err := reader.MediaOverlay("chapter2"); err == nil {
+		t.Errorf("MediaOverlay(chapter2) = no error")
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"":         0,
+		"12.5":     12500 * time.Millisecond,
+		"1.5min":   90 * time.Second,
+		"2h":       2 * time.Hour,
+		"05:00.25": 5*time.Minute + 250*time.Millisecond,
+		"1:00:00":  time.Hour,
+		"100ms":    100 * time.Millisecond,
+	} {
+		if got, err := parseClock(s); err != nil || got != want {
+			t.Errorf("parseClock(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := parseClock("soon"); err == nil {
+		t.Errorf("parseClock(soon) = no error")
+	}
+}
@@ -0,0 +1,109 @@
+package epub
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+)
+
+var pooledDecoding int32
+
+// SetPooledDecoding enables or disables pooled decoding, meant for services
+// opening many books: the buffers holding the container and package
+// documents are reused between opens instead of being allocated for each
+// book, and the media types, properties and common hrefs of the manifests
+// are interned, so that books kept open share one copy of each. It is
+// disabled by default. BenchmarkOpenBuffer measures its effect.
+func SetPooledDecoding(enabled bool) {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&pooledDecoding, value)
+}
+
+func isPooledDecoding() bool {
+	return atomic.LoadInt32(&pooledDecoding) == 1
+}
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity above which buffers are dropped rather
+// than pooled, so that one huge package does not pin memory.
+const maxPooledBuffer = 1 << 20
+
+// readPooled is like readFile, with a buffer from the pool when pooled
+// decoding is enabled. The buffer is handed back with releaseBuffer.
+func (epubReader *EpubReader) readPooled(name string) (*bytes.Buffer, error) {
+	if !isPooledDecoding() {
+		return epubReader.readFile(name)
+	}
+
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	if _, err := buffer.ReadFrom(reader); err != nil {
+		releaseBuffer(buffer)
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+// releaseBuffer returns a buffer of readPooled to the pool.
+func releaseBuffer(buffer *bytes.Buffer) {
+	if isPooledDecoding() && buffer.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buffer)
+	}
+}
+
+// maxInterned bounds the interned strings, which are never released.
+const maxInterned = 4096
+
+var (
+	internedMu sync.RWMutex
+	interned   = make(map[string]string)
+)
+
+// intern returns the shared copy of s, registering s if there is room.
+func intern(s string) string {
+	internedMu.RLock()
+	shared, ok := interned[s]
+	internedMu.RUnlock()
+	if ok {
+		return shared
+	}
+
+	internedMu.Lock()
+	defer internedMu.Unlock()
+	if shared, ok := interned[s]; ok {
+		return shared
+	}
+	if len(interned) < maxInterned {
+		interned[s] = s
+	}
+
+	return s
+}
+
+// internPackage interns the strings repeated across the manifests of many
+// books.
+func internPackage(pkg *Package) {
+	pkg.Version = intern(pkg.Version)
+	for i := range pkg.Metadata.Language {
+		pkg.Metadata.Language[i].Text = intern(pkg.Metadata.Language[i].Text)
+	}
+	for i := range pkg.Manifest.Item {
+		item := &pkg.Manifest.Item[i]
+		item.MediaType = intern(item.MediaType)
+		item.Properties = intern(item.Properties)
+		if len(item.Href) <= 32 {
+			item.Href = intern(item.Href)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package epub
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestPooledDecoding(t *testing.T) {
+	plain := openTestEpub(t)
+
+	SetPooledDecoding(true)
+	defer SetPooledDecoding(false)
+	first, second := openTestEpub(t), openTestEpub(t)
+
+	if !reflect.DeepEqual(first.Rootfiles[0].Package, plain.Rootfiles[0].Package) {
+		t.Error("pooled decoding changed the package")
+	}
+
+	data := func(s string) uintptr { return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data }
+	a, b := first.Rootfiles[0].Manifest.Item[0], second.Rootfiles[0].Manifest.Item[0]
+	if data(a.MediaType) != data(b.MediaType) || data(a.Href) != data(b.Href) {
+		t.Error("manifest strings are not interned")
+	}
+}
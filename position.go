@@ -0,0 +1,197 @@
+package epub
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Position is the place where a reader left a book.
+type Position struct {
+	// Book identifies the book, usually by its unique identifier.
+	Book string `json:"book,omitempty"`
+	// CFI is the EPUB canonical fragment identifier of the position.
+	CFI string `json:"cfi,omitempty"`
+	// Href is the content document of the position, relative to the root
+	// of the container, possibly with a fragment.
+	Href string `json:"href,omitempty"`
+	// Percentage is the progression in the book, from 0 to 1.
+	Percentage float64   `json:"percentage"`
+	Updated    time.Time `json:"updated"`
+	Device     string    `json:"device,omitempty"`
+}
+
+// positionsVersion is the version of the neutral JSON format.
+const positionsVersion = 1
+
+type positionsDocument struct {
+	Version   int        `json:"version"`
+	Positions []Position `json:"positions"`
+}
+
+// ReadPositions reads positions in the neutral JSON format written by
+// WritePositions.
+func ReadPositions(r io.Reader) ([]Position, error) {
+	var document positionsDocument
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("epub: read positions: %w", err)
+	}
+	if document.Version != positionsVersion {
+		return nil, fmt.Errorf("epub: read positions: unsupported version %d", document.Version)
+	}
+
+	return document.Positions, nil
+}
+
+// WritePositions writes positions in a neutral JSON format:
+//
+//	{"version": 1, "positions": [{"book": "urn:isbn:...", "cfi": "epubcfi(...)",
+//	  "href": "OEBPS/chapter1.xhtml", "percentage": 0.42, "updated": "...",
+//	  "device": "..."}]}
+func WritePositions(w io.Writer, positions []Position) error {
+	if positions == nil {
+		positions = []Position{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(positionsDocument{Version: positionsVersion, Positions: positions})
+}
+
+// calibreAnnotation is an entry of the annotations calibre keeps for a
+// book; "last-read" entries hold reading positions.
+type calibreAnnotation struct {
+	Type      string    `json:"type"`
+	Pos       string    `json:"pos,omitempty"`
+	PosType   string    `json:"pos_type,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Device    string    `json:"device,omitempty"`
+}
+
+// ReadCalibrePositions reads the last-read positions of a calibre
+// annotations JSON array. Other annotations are ignored.
+func ReadCalibrePositions(r io.Reader, book string) ([]Position, error) {
+	var annotations []calibreAnnotation
+	if err := json.NewDecoder(r).Decode(&annotations); err != nil {
+		return nil, fmt.Errorf("epub: read calibre positions: %w", err)
+	}
+
+	var positions []Position
+	for _, annotation := range annotations {
+		if annotation.Type != "last-read" || annotation.PosType != "epubcfi" {
+			continue
+		}
+		positions = append(positions, Position{
+			Book:    book,
+			CFI:     annotation.Pos,
+			Updated: annotation.Timestamp,
+			Device:  annotation.Device,
+		})
+	}
+
+	return positions, nil
+}
+
+// WriteCalibrePositions writes the positions having a CFI as calibre
+// last-read annotations.
+func WriteCalibrePositions(w io.Writer, positions []Position) error {
+	annotations := []calibreAnnotation{}
+	for _, position := range positions {
+		if position.CFI == "" {
+			continue
+		}
+		annotations = append(annotations, calibreAnnotation{
+			Type:      "last-read",
+			Pos:       position.CFI,
+			PosType:   "epubcfi",
+			Timestamp: position.Updated.UTC(),
+			Device:    position.Device,
+		})
+	}
+
+	return json.NewEncoder(w).Encode(annotations)
+}
+
+// ErrKoboBookMissing occurs when writing the position of a book that is
+// not in a Kobo database.
+var ErrKoboBookMissing = errors.New("epub: book not in the kobo database")
+
+// Kobo devices keep reading state in the content table of
+// .kobo/KoboReader.sqlite, one row with ContentType 6 per book.
+const (
+	koboSelect = `SELECT ContentID, COALESCE(ChapterIDBookmarked, ''), COALESCE(___PercentRead, 0), COALESCE(DateLastRead, '')
+		FROM content WHERE ContentType = 6 AND ContentID LIKE ?`
+	koboUpdate = `UPDATE content SET ChapterIDBookmarked = ?, ___PercentRead = ?, DateLastRead = ?
+		WHERE ContentType = 6 AND ContentID = ?`
+	koboTime = "2006-01-02T15:04:05Z"
+)
+
+// ReadKoboPositions reads the positions of the books of a Kobo database
+// whose ContentID (file:///mnt/onboard/... path) matches the SQL LIKE
+// pattern. The caller opens db with the SQLite driver of their choice. The
+// ContentID is returned as Book.
+func ReadKoboPositions(db *sql.DB, pattern string) ([]Position, error) {
+	rows, err := db.Query(koboSelect, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("epub: read kobo positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []Position
+	for rows.Next() {
+		var position Position
+		var percent float64
+		var lastRead string
+		if err := rows.Scan(&position.Book, &position.Href, &percent, &lastRead); err != nil {
+			return positions, fmt.Errorf("epub: read kobo positions: %w", err)
+		}
+		position.Percentage = percent / 100
+		position.Device = "kobo"
+		if updated, err := time.Parse(koboTime, strings.TrimSuffix(lastRead, ".000")); err == nil {
+			position.Updated = updated
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, rows.Err()
+}
+
+// WriteKoboPositions stores positions in a Kobo database, in a single
+// transaction; each position Book must be the ContentID of the book on the
+// device, or no position is written and ErrKoboBookMissing is returned.
+func WriteKoboPositions(db *sql.DB, positions []Position) error {
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("epub: write kobo positions: %w", err)
+	}
+	for _, position := range positions {
+		result, err := tx.Exec(koboUpdate,
+			position.Href,
+			int(position.Percentage*100+0.5),
+			position.Updated.UTC().Format(koboTime),
+			position.Book,
+		)
+		var n int64
+		if err == nil {
+			n, err = result.RowsAffected()
+		}
+		if err == nil && n == 0 {
+			err = fmt.Errorf("'%s': %w", position.Book, ErrKoboBookMissing)
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("epub: write kobo positions: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("epub: write kobo positions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package epub
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPositions(t *testing.T) {
+	positions := []Position{{
+		Book:       "urn:isbn:9780306406157",
+		CFI:        "epubcfi(/6/4!/4/2/1:10)",
+		Href:       "OEBPS/chapter1.xhtml",
+		Percentage: 0.42,
+		Updated:    time.Date(2020, 5, 4, 3, 2, 1, 0, time.UTC),
+		Device:     "phone",
+	}}
+
+	var buffer bytes.Buffer
+	if err := WritePositions(&buffer, positions); err != nil {
+		t.Fatalf("WritePositions() = %v", err)
+	}
+	got, err := ReadPositions(&buffer)
+	if err != nil || !reflect.DeepEqual(got, positions) {
+		t.Errorf("ReadPositions() = %v, %v", got, err)
+	}
+
+	if _, err := ReadPositions(strings.NewReader(`{"version": 2}`)); err == nil {
+		t.Errorf("ReadPositions(version 2) = no error")
+	}
+}
+
+func TestCalibrePositions(t *testing.T) {
+	annotations := `[
+  {"type": "highlight", "pos": "epubcfi(/6/2)", "pos_type": "epubcfi", "timestamp": "2020-01-01T00:00:00Z"},
+  {"type": "last-read", "pos": "epubcfi(/6/4!/4/2)", "pos_type": "epubcfi", "timestamp": "2020-05-04T03:02:01.5Z", "device": "calibre"}
+]`
+
+	positions, err := ReadCalibrePositions(strings.NewReader(annotations), "book")
+	if err != nil || len(positions) != 1 || positions[0].CFI != "epubcfi(/6/4!/4/2)" || positions[0].Device != "calibre" {
+		t.Fatalf("ReadCalibrePositions() = %v, %v", positions, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteCalibrePositions(&buffer, positions); err != nil {
+		t.Fatalf("WriteCalibrePositions() = %v", err)
+	}
+	again, err := ReadCalibrePositions(&buffer, "book")
+	if err != nil || !reflect.DeepEqual(again, positions) {
+		t.Errorf("round trip = %v, %v", again, err)
+	}
+}
+
+func TestKoboPositions(t *testing.T) {
+	koboTestBooks["kobo"] = map[string][]driver.Value{
+		"file:///mnt/onboard/book.epub":  {"", int64(0), ""},
+		"file:///mnt/onboard/other.epub": {"", int64(0), ""},
+	}
+	db, err := sql.Open("kobotest", "kobo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	position := Position{
+		Book:       "file:///mnt/onboard/book.epub",
+		Href:       "OEBPS/chapter1.xhtml",
+		Percentage: 0.42,
+		Updated:    time.Date(2020, 5, 4, 3, 2, 1, 0, time.UTC),
+		Device:     "kobo",
+	}
+	if err := WriteKoboPositions(db, []Position{position}); err != nil {
+		t.Fatalf("WriteKoboPositions() = %v", err)
+	}
+	positions, err := ReadKoboPositions(db, "%/book.epub")
+	if err != nil || !reflect.DeepEqual(positions, []Position{position}) {
+		t.Errorf("ReadKoboPositions() = %+v, %v", positions, err)
+	}
+
+	// A missing book rolls back the positions written before it.
+	other := position
+	other.Book = "file:///mnt/onboard/other.epub"
+	missing := position
+	missing.Book = "file:///mnt/onboard/missing.epub"
+	if err := WriteKoboPositions(db, []Position{other, missing}); !errors.Is(err, ErrKoboBookMissing) {
+		t.Errorf("WriteKoboPositions(missing) = %v, want ErrKoboBookMissing", err)
+	}
+	if positions, err := ReadKoboPositions(db, "%/other.epub"); err != nil || len(positions) != 1 || positions[0].Href != "" {
+		t.Errorf("ReadKoboPositions() after a rollback = %+v, %v", positions, err)
+	}
+}
+
+// koboTestBooks are the books of the fake Kobo databases of the kobotest
+// driver, by database name: the ChapterIDBookmarked, ___PercentRead and
+// DateLastRead columns by ContentID.
+var koboTestBooks = make(map[string]map[string][]driver.Value)
+
+func init() {
+	sql.Register("kobotest", koboTestDriver{})
+}
+
+// koboTestDriver runs the statements of ReadKoboPositions and
+// WriteKoboPositions on koboTestBooks.
+type koboTestDriver struct{}
+
+func (koboTestDriver) Open(name string) (driver.Conn, error) {
+	return &koboTestConn{books: koboTestBooks[name]}, nil
+}
+
+// koboTestConn runs the statements on books, whose rows are saved when a
+// transaction begins, for a rollback to restore them.
+type koboTestConn struct {
+	books map[string][]driver.Value
+	saved map[string][]driver.Value
+}
+
+func (conn *koboTestConn) Prepare(query string) (driver.Stmt, error) {
+	return koboTestStmt{conn.books, query}, nil
+}
+
+func (*koboTestConn) Close() error { return nil }
+
+func (conn *koboTestConn) Begin() (driver.Tx, error) {
+	conn.saved = make(map[string][]driver.Value)
+	for id, book := range conn.books {
+		conn.saved[id] = append([]driver.Value(nil), book...)
+	}
+
+	return conn, nil
+}
+
+func (conn *koboTestConn) Commit() error {
+	conn.saved = nil
+	return nil
+}
+
+func (conn *koboTestConn) Rollback() error {
+	for id, book := range conn.saved {
+		copy(conn.books[id], book)
+	}
+	conn.saved = nil
+
+	return nil
+}
+
+type koboTestStmt struct {
+	books map[string][]driver.Value
+	query string
+}
+
+func (koboTestStmt) Close() error  { return nil }
+func (koboTestStmt) NumInput() int { return -1 }
+
+func (stmt koboTestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if stmt.query != koboUpdate {
+		return nil, errors.New("kobotest: unknown statement")
+	}
+	book, ok := stmt.books[args[3].(string)]
+	if !ok {
+		return driver.RowsAffected(0), nil
+	}
+	copy(book, args[:3])
+
+	return driver.RowsAffected(1), nil
+}
+
+func (stmt koboTestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if stmt.query != koboSelect {
+		return nil, errors.New("kobotest: unknown query")
+	}
+	like := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(args[0].(string)), "%", ".*") + "$")
+	rows := &koboTestRows{}
+	for id, book := range stmt.books {
+		if like.MatchString(id) {
+			rows.rows = append(rows.rows, append([]driver.Value{id}, book...))
+		}
+	}
+	sort.Slice(rows.rows, func(i, j int) bool { return rows.rows[i][0].(string) < rows.rows[j][0].(string) })
+
+	return rows, nil
+}
+
+type koboTestRows struct {
+	rows [][]driver.Value
+}
+
+func (*koboTestRows) Columns() []string {
+	return []string{"ContentID", "ChapterIDBookmarked", "___PercentRead", "DateLastRead"}
+}
+
+func (*koboTestRows) Close() error { return nil }
+
+func (rows *koboTestRows) Next(dest []driver.Value) error {
+	if len(rows.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, rows.rows[0])
+	rows.rows = rows.rows[1:]
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package epub
+
+import (
+	"strings"
+)
+
+// Default vocabularies of unprefixed property values.
+const (
+	MetaVocabulary    = "http://idpf.org/epub/vocab/package/meta/#"
+	ItemVocabulary    = "http://idpf.org/epub/vocab/package/item/#"
+	ItemrefVocabulary = "http://idpf.org/epub/vocab/package/itemref/#"
+)
+
+// reservedPrefixes may be used in a package document without being declared.
+var reservedPrefixes = map[string]string{
+	"a11y":      "http://www.idpf.org/epub/vocab/package/a11y/#",
+	"dcterms":   "http://purl.org/dc/terms/",
+	"marc":      "http://id.loc.gov/vocabulary/",
+	"media":     "http://www.idpf.org/epub/vocab/overlays/#",
+	"msv":       "http://www.idpf.org/epub/vocab/structure/magazine/#",
+	"onix":      "http://www.editeur.org/ONIX/book/codelists/current.html#",
+	"prism":     "http://www.prismstandard.org/specifications/3.0/PRISM_CV_Spec_3.0.htm#",
+	"rendition": "http://www.idpf.org/vocab/rendition/#",
+	"schema":    "http://schema.org/",
+	"xsd":       "http://www.w3.org/2001/XMLSchema#",
+}
+
+// Property is a property value both as written in the package document and
+// expanded to an IRI.
+type Property struct {
+	Name string
+	IRI  string
+}
+
+// Prefixes returns the prefix mappings in effect for the package: the
+// reserved prefixes, overridden by those declared in the prefix attribute.
+func (epubReader *EpubReader) Prefixes() map[string]string {
+	prefixes := make(map[string]string, len(reservedPrefixes))
+	for prefix, iri := range reservedPrefixes {
+		prefixes[prefix] = iri
+	}
+
+	fields := strings.Fields(epubReader.Rootfiles[0].Prefix)
+	for i := 0; i+1 < len(fields); i++ {
+		if strings.HasSuffix(fields[i], ":") && len(fields[i]) > 1 {
+			prefixes[strings.TrimSuffix(fields[i], ":")] = fields[i+1]
+			i++
+		}
+	}
+
+	return prefixes
+}
+
+// ExpandProperty expands a property value to an IRI. Unprefixed values are
+// resolved against vocabulary, values with an unknown prefix are returned
+// unchanged.
+func (epubReader *EpubReader) ExpandProperty(property, vocabulary string) string {
+	return expandProperty(epubReader.Prefixes(), property, vocabulary)
+}
+
+// ParseProperties splits a space separated properties attribute and expands
+// each value.
+func (epubReader *EpubReader) ParseProperties(properties, vocabulary string) []Property {
+	prefixes := epubReader.Prefixes()
+
+	var parsed []Property
+	for _, name := range strings.Fields(properties) {
+		parsed = append(parsed, Property{Name: name, IRI: expandProperty(prefixes, name, vocabulary)})
+	}
+
+	return parsed
+}
+
+// ItemHasProperty reports whether the properties of item include iri,
+// whatever prefix the package uses for it.
+func (epubReader *EpubReader) ItemHasProperty(item Item, iri string) bool {
+	for _, property := range epubReader.ParseProperties(item.Properties, ItemVocabulary) {
+		if property.IRI == iri {
+			return true
+		}
+	}
+
+	return false
+}
+
+func expandProperty(prefixes map[string]string, property, vocabulary string) string {
+	i := strings.IndexByte(property, ':')
+	if i < 0 {
+		return vocabulary + property
+	}
+
+	if iri, ok := prefixes[property[:i]]; ok {
+		return iri + property[i+1:]
+	}
+
+	return property
+}
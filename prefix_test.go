@@ -0,0 +1,32 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandProperty(t *testing.T) {
+	opf := strings.Replace(testOPF, `version="2.0"`, `version="3.0"
+  prefix="foo: http://example.com/foo#  rendition: http://example.com/rendition#"`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+
+	for property, want := range map[string]string{
+		"cover-image":       ItemVocabulary + "cover-image",
+		"foo:bar":           "http://example.com/foo#bar",
+		"rendition:layout":  "http://example.com/rendition#layout",
+		"schema:accessMode": "http://schema.org/accessMode",
+		"unknown:property":  "unknown:property",
+	} {
+		if got := reader.ExpandProperty(property, ItemVocabulary); got != want {
+			t.Errorf("ExpandProperty(%q) = %q", property, got)
+		}
+	}
+
+	item := Item{Properties: "nav foo:special"}
+	if !reader.ItemHasProperty(item, "http://example.com/foo#special") || !reader.ItemHasProperty(item, ItemVocabulary+"nav") {
+		t.Errorf("ItemHasProperty() = false")
+	}
+	if properties := reader.ParseProperties(item.Properties, ItemVocabulary); len(properties) != 2 || properties[1].Name != "foo:special" {
+		t.Errorf("ParseProperties() = %v", properties)
+	}
+}
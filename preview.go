@@ -0,0 +1,172 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+)
+
+// PreviewPage is a content document to render.
+type PreviewPage struct {
+	Book *EpubReader
+	Item Item
+	// Path is the zip path of the document, against which its references
+	// resolve.
+	Path string
+}
+
+// Rasterizer renders a content document to an image of the given size. It
+// is the integration point for layout engines; TextRasterizer is a minimal
+// built-in implementation.
+type Rasterizer interface {
+	Rasterize(ctx context.Context, page PreviewPage, size image.Point) (image.Image, error)
+}
+
+// Preview renders the cover page, when the guide declares one, and the
+// first spine documents, up to pages images, as PNG.
+func (epubReader *EpubReader) Preview(ctx context.Context, rasterizer Rasterizer, pages int, size image.Point) ([][]byte, error) {
+	var images [][]byte
+
+	for _, page := range epubReader.previewPages(pages) {
+		if err := ctx.Err(); err != nil {
+			return images, err
+		}
+
+		img, err := rasterizer.Rasterize(ctx, page, size)
+		if err != nil {
+			return images, fmt.Errorf("epub: %s: render '%s': %w", epubReader.Name, page.Path, err)
+		}
+
+		var buffer bytes.Buffer
+		if err := png.Encode(&buffer, img); err != nil {
+			return images, err
+		}
+		images = append(images, buffer.Bytes())
+	}
+
+	return images, nil
+}
+
+func (epubReader *EpubReader) previewPages(pages int) []PreviewPage {
+	var candidates []Item
+	for _, reference := range epubReader.Rootfiles[0].Guide.Reference {
+		if GuideLandmark(reference.Type) == "cover" {
+			if item, ok := epubReader.itemByPath(epubReader.itemPath(reference.Href)); ok && !isImage(item) {
+				candidates = append(candidates, item)
+			}
+		}
+	}
+	candidates = append(candidates, epubReader.SpineItems()...)
+
+	var previews []PreviewPage
+	seen := make(map[string]bool)
+	for _, item := range candidates {
+		if len(previews) >= pages {
+			break
+		}
+		if name := epubReader.itemPath(item.Href); !seen[name] {
+			seen[name] = true
+			previews = append(previews, PreviewPage{Book: epubReader, Item: item, Path: name})
+		}
+	}
+
+	return previews
+}
+
+// TextRasterizer renders a document without a layout engine: a document
+// made of an image, such as a cover page, is rendered as that image scaled
+// to fit, and other documents as their text, wrapped with a bitmap font.
+// Styles are ignored.
+type TextRasterizer struct {
+	Background color.Color
+	Foreground color.Color
+	// Scale is the size of the font pixels, 2 if zero.
+	Scale int
+}
+
+// Rasterize implements Rasterizer.
+func (rasterizer TextRasterizer) Rasterize(ctx context.Context, page PreviewPage, size image.Point) (image.Image, error) {
+	background, foreground := rasterizer.Background, rasterizer.Foreground
+	if background == nil {
+		background = color.White
+	}
+	if foreground == nil {
+		foreground = color.Black
+	}
+	scale := rasterizer.Scale
+	if scale <= 0 {
+		scale = 2
+	}
+
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	text, err := page.Book.ItemText(page.Item, TextOptions{PreserveVerse: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(strings.Fields(text)) < 20 {
+		if picture, ok := page.Book.documentPicture(page.Path); ok {
+			drawScaled(img, picture)
+			return img, nil
+		}
+	}
+
+	fg := image.NewUniform(foreground)
+	margin := size.X / 12
+	columns := (size.X - 2*margin) / ((glyphWidth + 1) * scale)
+	lineHeight := (glyphHeight + 3) * scale
+	y := margin
+	for _, paragraph := range strings.Split(text, "\n") {
+		for _, line := range wrapText(glyphText(paragraph), columns) {
+			if y+lineHeight > size.Y-margin {
+				return img, nil
+			}
+			drawText(img, fg, line, margin, y, scale)
+			y += lineHeight
+		}
+		if paragraph == "" {
+			y += lineHeight / 2
+		}
+	}
+
+	return img, nil
+}
+
+// documentPicture decodes the first image of the document name, upright.
+func (epubReader *EpubReader) documentPicture(name string) (image.Image, bool) {
+	images, err := epubReader.documentImages(name)
+	if err != nil || len(images) == 0 {
+		return nil, false
+	}
+
+	picture, err := (&BookImage{Path: images[0], epubReader: epubReader}).Decode()
+
+	return picture, err == nil
+}
+
+// drawScaled draws src centered on dst, scaled with nearest neighbour
+// sampling to fit while keeping its aspect ratio.
+func drawScaled(dst draw.Image, src image.Image) {
+	db, sb := dst.Bounds(), src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return
+	}
+
+	width, height := db.Dx(), sb.Dy()*db.Dx()/sb.Dx()
+	if height > db.Dy() {
+		width, height = sb.Dx()*db.Dy()/sb.Dy(), db.Dy()
+	}
+	left, top := db.Min.X+(db.Dx()-width)/2, db.Min.Y+(db.Dy()-height)/2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dst.Set(left+x, top+y, src.At(sb.Min.X+x*sb.Dx()/width, sb.Min.Y+y*sb.Dy()/height))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package epub
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+const testCoverPage = `<html xmlns="http://www.w3.org/1999/xhtml"><body><img src="images/cover.png" alt="cover"/></body></html>`
+
+func TestPreview(t *testing.T) {
+	// A 2x1 image, red then blue.
+	picture := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	picture.Set(0, 0, color.RGBA{0xff, 0, 0, 0xff})
+	picture.Set(1, 0, color.RGBA{0, 0, 0xff, 0xff})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, picture); err != nil {
+		t.Fatal(err)
+	}
+
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <manifest>
+    <item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+    <item id="cover-image" href="images/cover.png" media-type="image/png"/>
+    <item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="chapter2" href="chapter2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="chapter1"/><itemref idref="chapter2"/></spine>
+  <guide><reference type="cover" href="cover.xhtml"/></guide>
+</package>`
+	book := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/cover.xhtml", testCoverPage},
+		testFile{"OEBPS/images/cover.png", encoded.String()},
+	)
+
+	pages, err := book.Preview(context.Background(), TextRasterizer{}, 2, image.Pt(100, 150))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Preview() returned %d pages, want 2", len(pages))
+	}
+
+	cover, err := png.Decode(bytes.NewReader(pages[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cover.Bounds().Size() != image.Pt(100, 150) {
+		t.Errorf("cover size = %v", cover.Bounds().Size())
+	}
+	// The picture is scaled to 100x50, centered vertically.
+	for _, test := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{10, 75, color.RGBA{0xff, 0, 0, 0xff}},
+		{90, 75, color.RGBA{0, 0, 0xff, 0xff}},
+		{50, 10, color.RGBA{0xff, 0xff, 0xff, 0xff}},
+	} {
+		if got := color.RGBAModel.Convert(cover.At(test.x, test.y)); got != test.want {
+			t.Errorf("cover at %d,%d = %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+
+	text, err := png.Decode(bytes.NewReader(pages[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark := 0
+	for y := 0; y < 150; y++ {
+		for x := 0; x < 100; x++ {
+			if r, _, _, _ := text.At(x, y).RGBA(); r == 0 {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.Error("chapter page has no text drawn")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := book.Preview(ctx, TextRasterizer{}, 2, image.Pt(100, 150)); err != context.Canceled {
+		t.Errorf("Preview() with canceled context = %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package epub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Producers detected by Producer.
+const (
+	ProducerCalibre  = "calibre"
+	ProducerSigil    = "Sigil"
+	ProducerInDesign = "InDesign"
+	ProducerVellum   = "Vellum"
+	ProducerPages    = "Pages"
+	ProducerKepubify = "kepubify"
+)
+
+// Producer is the tool that produced a book.
+type Producer struct {
+	// Name is one of the Producer constants, or empty when the tool is
+	// not known.
+	Name    string
+	Version string
+	// Generator is the generator meta of the package, if any.
+	Generator string
+}
+
+// producerGenerators maps words of generator metas to producers.
+var producerGenerators = []struct {
+	word, name string
+}{
+	{"calibre", ProducerCalibre},
+	{"sigil", ProducerSigil},
+	{"indesign", ProducerInDesign},
+	{"vellum", ProducerVellum},
+	{"kepubify", ProducerKepubify},
+	{"pages", ProducerPages},
+}
+
+// maxProducerDocuments bounds the spine documents Producer reads.
+const maxProducerDocuments = 3
+
+var producerVersion = regexp.MustCompile(`\d+(?:\.\d+)+|\d+`)
+
+// Producer returns the tool that produced the book, from the generator
+// meta or, when it is missing or unknown, from the traces tools leave:
+// the metas of calibre and Sigil, the generated stylesheet of InDesign and
+// the koboSpan elements kepubify adds to documents.
+func (epubReader *EpubReader) Producer() Producer {
+	producer := Producer{Generator: epubReader.metaValue("generator")}
+
+	lower := strings.ToLower(producer.Generator)
+	for _, generator := range producerGenerators {
+		if strings.Contains(lower, generator.word) {
+			producer.Name = generator.name
+			producer.Version = producerVersion.FindString(producer.Generator)
+			return producer
+		}
+	}
+
+	metadata := epubReader.Rootfiles[0].Metadata
+	for _, meta := range metadata.Meta {
+		name := strings.ToLower(meta.Name)
+		switch {
+		case name == "sigil version":
+			producer.Name, producer.Version = ProducerSigil, strings.TrimSpace(meta.Content)
+			return producer
+		case strings.HasPrefix(name, "calibre:"):
+			producer.Name = ProducerCalibre
+			return producer
+		}
+	}
+	for _, identifier := range metadata.Identifier {
+		if strings.EqualFold(identifier.Scheme, "calibre") {
+			producer.Name = ProducerCalibre
+			return producer
+		}
+	}
+
+	for _, name := range epubReader.FileNames() {
+		if strings.HasSuffix(name, "/idGeneratedStyles.css") || name == "idGeneratedStyles.css" {
+			producer.Name = ProducerInDesign
+			return producer
+		}
+	}
+	items := epubReader.SpineItems()
+	if len(items) > maxProducerDocuments {
+		items = items[:maxProducerDocuments]
+	}
+	for _, item := range items {
+		if buffer, err := epubReader.readFile(epubReader.itemPath(item.Href)); err == nil && strings.Contains(buffer.String(), "koboSpan") {
+			producer.Name = ProducerKepubify
+			break
+		}
+	}
+
+	return producer
+}
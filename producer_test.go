@@ -0,0 +1,38 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProducer(t *testing.T) {
+	withMeta := func(meta string) testFile {
+		return testFile{"OEBPS/content.opf", strings.Replace(testOPF, `<meta name="cover"`, meta+`
+    <meta name="cover"`, 1)}
+	}
+
+	for _, test := range []struct {
+		name  string
+		files []testFile
+		want  Producer
+	}{
+		{"unknown", nil, Producer{}},
+		{"calibre generator", []testFile{withMeta(`<meta name="generator" content="calibre (5.10.1) [https://calibre-ebook.com]"/>`)},
+			Producer{ProducerCalibre, "5.10.1", "calibre (5.10.1) [https://calibre-ebook.com]"}},
+		{"InDesign generator", []testFile{withMeta(`<meta name="generator" content="Adobe InDesign 15.1"/>`)},
+			Producer{ProducerInDesign, "15.1", "Adobe InDesign 15.1"}},
+		{"unknown generator", []testFile{withMeta(`<meta name="generator" content="Acme Books 2"/>`)},
+			Producer{Generator: "Acme Books 2"}},
+		{"Sigil meta", []testFile{withMeta(`<meta name="Sigil version" content="0.9.18"/>`)}, Producer{Name: ProducerSigil, Version: "0.9.18"}},
+		{"calibre meta", []testFile{withMeta(`<meta name="calibre:series" content="Earthsea"/>`)}, Producer{Name: ProducerCalibre}},
+		{"InDesign stylesheet", []testFile{{"OEBPS/css/idGeneratedStyles.css", "p {}"}}, Producer{Name: ProducerInDesign}},
+		{"kepub", []testFile{{"OEBPS/chapter1.xhtml", strings.Replace(testChapter1, "<p>", `<p><span class="koboSpan" id="kobo.1.1">`, 1)}},
+			Producer{Name: ProducerKepubify}},
+	} {
+		reader := openTestEpub(t, test.files...)
+		if got := reader.Producer(); got != test.want {
+			t.Errorf("%s: Producer() = %+v, want %+v", test.name, got, test.want)
+		}
+		reader.Close()
+	}
+}
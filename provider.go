@@ -0,0 +1,159 @@
+package epub
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrMetadataNotFound occurs when a metadata provider knows nothing about
+// a book.
+var ErrMetadataNotFound = errors.New("epub: no metadata found")
+
+// BookMetadata is bibliographic metadata returned by a provider.
+type BookMetadata struct {
+	Title       string   `json:"title,omitempty"`
+	Authors     []string `json:"authors,omitempty"`
+	Publisher   string   `json:"publisher,omitempty"`
+	Date        string   `json:"date,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Subjects    []string `json:"subjects,omitempty"`
+	ISBN        string   `json:"isbn,omitempty"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	// Source is the name of the provider.
+	Source string `json:"source,omitempty"`
+}
+
+// MetadataProvider looks up book metadata, typically from a web service.
+type MetadataProvider interface {
+	Name() string
+	// LookupISBN returns ErrMetadataNotFound for unknown books.
+	LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error)
+}
+
+// LookupMetadata looks up the book ISBN with provider.
+func (epubReader *EpubReader) LookupMetadata(ctx context.Context, provider MetadataProvider) (*BookMetadata, error) {
+	isbn, err := epubReader.GetISBN()
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.LookupISBN(ctx, isbn)
+}
+
+// RateLimited returns a provider waiting for bucket before each lookup, so
+// that batches do not exceed the rate the service allows.
+func RateLimited(provider MetadataProvider, bucket *TokenBucket) MetadataProvider {
+	return &rateLimitedProvider{provider, bucket}
+}
+
+type rateLimitedProvider struct {
+	MetadataProvider
+	bucket *TokenBucket
+}
+
+func (p *rateLimitedProvider) LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error) {
+	if err := p.bucket.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return p.MetadataProvider.LookupISBN(ctx, isbn)
+}
+
+// Fallback returns a provider trying each provider in order until one
+// finds the book. Errors other than ErrMetadataNotFound are returned when
+// no provider finds the book.
+func Fallback(providers ...MetadataProvider) MetadataProvider {
+	return fallbackProvider(providers)
+}
+
+type fallbackProvider []MetadataProvider
+
+func (providers fallbackProvider) Name() string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.Name()
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (providers fallbackProvider) LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error) {
+	var failure error
+	for _, provider := range providers {
+		metadata, err := provider.LookupISBN(ctx, isbn)
+		if err == nil {
+			return metadata, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !errors.Is(err, ErrMetadataNotFound) && failure == nil {
+			failure = err
+		}
+	}
+	if failure != nil {
+		return nil, failure
+	}
+
+	return nil, fmt.Errorf("%w: isbn %s", ErrMetadataNotFound, isbn)
+}
+
+// Cached returns a provider keeping the results of provider, including
+// unknown books, in files below dir for ttl. Lookups found in the cache
+// do not reach provider, nor its rate limiter.
+func Cached(provider MetadataProvider, dir string, ttl time.Duration) MetadataProvider {
+	return &cachedProvider{provider, dir, ttl}
+}
+
+type cachedProvider struct {
+	MetadataProvider
+	dir string
+	ttl time.Duration
+}
+
+type cacheEntry struct {
+	Found    bool          `json:"found"`
+	Metadata *BookMetadata `json:"metadata,omitempty"`
+}
+
+func (p *cachedProvider) path(isbn string) string {
+	sum := sha256.Sum256([]byte(p.Name() + "\x00" + isbn))
+
+	return filepath.Join(p.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (p *cachedProvider) LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error) {
+	path := p.path(isbn)
+
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < p.ttl {
+		var entry cacheEntry
+		if data, err := ioutil.ReadFile(path); err == nil && json.Unmarshal(data, &entry) == nil {
+			if !entry.Found {
+				return nil, fmt.Errorf("%w: isbn %s", ErrMetadataNotFound, isbn)
+			}
+			return entry.Metadata, nil
+		}
+	}
+
+	metadata, err := p.MetadataProvider.LookupISBN(ctx, isbn)
+	if err != nil && !errors.Is(err, ErrMetadataNotFound) {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(cacheEntry{Found: err == nil, Metadata: metadata})
+	if mkErr := os.MkdirAll(p.dir, 0o755); mkErr == nil {
+		ioutil.WriteFile(path, data, 0o644)
+	}
+
+	return metadata, err
+}
@@ -0,0 +1,133 @@
+package epub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name  string
+	books map[string]*BookMetadata
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) LookupISBN(ctx context.Context, isbn string) (*BookMetadata, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if metadata, ok := p.books[isbn]; ok {
+		return metadata, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrMetadataNotFound, isbn)
+}
+
+func TestFallback(t *testing.T) {
+	broken := &fakeProvider{name: "broken", err: errors.New("unavailable")}
+	first := &fakeProvider{name: "first", books: map[string]*BookMetadata{"1": {Title: "One", Source: "first"}}}
+	second := &fakeProvider{name: "second", books: map[string]*BookMetadata{"1": {Title: "Uno"}, "2": {Title: "Two", Source: "second"}}}
+	provider := Fallback(broken, first, second)
+
+	if metadata, err := provider.LookupISBN(context.Background(), "1"); err != nil || metadata.Source != "first" {
+		t.Errorf("LookupISBN(1) = %v, %v", metadata, err)
+	}
+	if metadata, err := provider.LookupISBN(context.Background(), "2"); err != nil || metadata.Source != "second" {
+		t.Errorf("LookupISBN(2) = %v, %v", metadata, err)
+	}
+	if _, err := provider.LookupISBN(context.Background(), "3"); err == nil || errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupISBN(3) = %v, want the broken provider error", err)
+	}
+	if _, err := Fallback(first).LookupISBN(context.Background(), "3"); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupISBN(3) = %v", err)
+	}
+	if provider.Name() != "broken,first,second" {
+		t.Errorf("Name() = %s", provider.Name())
+	}
+}
+
+func TestCached(t *testing.T) {
+	fake := &fakeProvider{name: "fake", books: map[string]*BookMetadata{"1": {Title: "One"}}}
+	dir := t.TempDir()
+	provider := Cached(fake, dir, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if metadata, err := provider.LookupISBN(context.Background(), "1"); err != nil || metadata.Title != "One" {
+			t.Fatalf("LookupISBN(1) = %v, %v", metadata, err)
+		}
+		if _, err := provider.LookupISBN(context.Background(), "2"); !errors.Is(err, ErrMetadataNotFound) {
+			t.Fatalf("LookupISBN(2) = %v", err)
+		}
+	}
+	if fake.calls != 2 {
+		t.Errorf("provider called %d times", fake.calls)
+	}
+
+	expired := Cached(fake, dir, 0)
+	expired.LookupISBN(context.Background(), "1")
+	if fake.calls != 3 {
+		t.Errorf("expired entry not refreshed")
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	fake := &fakeProvider{name: "fake"}
+	provider := RateLimited(fake, NewTokenBucket(20, 2))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		provider.LookupISBN(context.Background(), "1")
+	}
+	// Two lookups use the burst, the next two wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 lookups took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	slow := RateLimited(fake, NewTokenBucket(0.001, 1))
+	slow.LookupISBN(ctx, "1")
+	if _, err := slow.LookupISBN(ctx, "1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("LookupISBN(canceled) = %v", err)
+	}
+}
+
+func TestOpenLibrary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("bibkeys") != "ISBN:9780306406157" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"ISBN:9780306406157": {
+  "title": "The Test Book",
+  "authors": [{"name": "Jane Doe"}],
+  "publishers": [{"name": "Test Press"}],
+  "publish_date": "2020",
+  "subjects": [{"name": "Testing"}],
+  "notes": {"type": "/type/text", "value": "A book."},
+  "cover": {"large": "https://covers.example.com/1-L.jpg"}
+}}`))
+	}))
+	defer server.Close()
+
+	provider := &OpenLibrary{BaseURL: server.URL, Network: &NetworkOptions{Client: server.Client()}}
+	metadata, err := provider.LookupISBN(context.Background(), "9780306406157")
+	if err != nil {
+		t.Fatalf("LookupISBN() = %v", err)
+	}
+	if metadata.Title != "The Test Book" || metadata.Authors[0] != "Jane Doe" || metadata.Publisher != "Test Press" ||
+		metadata.Description != "A book." || metadata.Subjects[0] != "Testing" || metadata.Source != "openlibrary" {
+		t.Errorf("LookupISBN() = %+v", metadata)
+	}
+
+	if _, err := provider.LookupISBN(context.Background(), "0000000000"); !errors.Is(err, ErrMetadataNotFound) {
+		t.Errorf("LookupISBN(unknown) = %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package epub
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// LibrarySortKey is a field books can be sorted on.
+type LibrarySortKey int
+
+const (
+	SortByTitle LibrarySortKey = iota
+	SortByAuthor
+	// SortBySeries sorts on the series name, then on the position in the
+	// series.
+	SortBySeries
+	SortByYear
+	SortByModTime
+	SortByPath
+	// SortByMetadataScore sorts on the completeness of the metadata, the
+	// least complete first, for cleanups.
+	SortByMetadataScore
+)
+
+type librarySort struct {
+	key        LibrarySortKey
+	descending bool
+}
+
+// LibraryQuery selects, sorts and paginates the books of a library
+// catalog. Its methods add criteria and return the query, so that they can
+// be chained:
+//
+//	page := library.Query().Author("le guin").Language("en").SortBy(SortByYear, true).Limit(20).Run()
+type LibraryQuery struct {
+	library *Library
+	filters []func(LibraryBook) bool
+	sorts   []librarySort
+	offset  int
+	limit   int
+	// transliterator, if set, transliterates the text sort keys.
+	transliterator Transliterator
+	language       string
+	collator       *collate.Collator
+}
+
+// LibraryPage is the result of a query.
+type LibraryPage struct {
+	Books []LibraryBook
+	// Total is the number of matching books, before pagination.
+	Total int
+}
+
+// Query returns a query over the catalog of the library, which matches all
+// books until criteria are added.
+func (library *Library) Query() *LibraryQuery {
+	return &LibraryQuery{library: library, language: library.Language}
+}
+
+// Where keeps the books for which match returns true.
+func (query *LibraryQuery) Where(match func(LibraryBook) bool) *LibraryQuery {
+	query.filters = append(query.filters, match)
+
+	return query
+}
+
+// Author keeps the books whose creator contains name, ignoring case.
+func (query *LibraryQuery) Author(name string) *LibraryQuery {
+	name = strings.ToLower(name)
+
+	return query.Where(func(book LibraryBook) bool {
+		return strings.Contains(strings.ToLower(book.Creator), name)
+	})
+}
+
+// Series keeps the books of the series, ignoring case.
+func (query *LibraryQuery) Series(series string) *LibraryQuery {
+	return query.Where(func(book LibraryBook) bool {
+		return strings.EqualFold(book.Series, series)
+	})
+}
+
+// Tag keeps the books with the subject, ignoring case.
+func (query *LibraryQuery) Tag(tag string) *LibraryQuery {
+	return query.Where(func(book LibraryBook) bool {
+		for _, subject := range book.Subjects {
+			if strings.EqualFold(subject, tag) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Language keeps the books in the language: "fr" matches "fr" and "fr-CA",
+// "fr-CA" only matches "fr-CA".
+func (query *LibraryQuery) Language(language string) *LibraryQuery {
+	return query.Where(func(book LibraryBook) bool {
+		return languageMatches(book.Language, language)
+	})
+}
+
+// Years keeps the books published between the years from and to,
+// inclusive. A zero bound is open; books of unknown year never match.
+func (query *LibraryQuery) Years(from, to int) *LibraryQuery {
+	return query.Where(func(book LibraryBook) bool {
+		return book.Year != 0 && (from == 0 || book.Year >= from) && (to == 0 || book.Year <= to)
+	})
+}
+
+// SortBy sorts the books on key. Further calls add keys used to sort the
+// books that compare equal; books are finally sorted by path.
+func (query *LibraryQuery) SortBy(key LibrarySortKey, descending bool) *LibraryQuery {
+	query.sorts = append(query.sorts, librarySort{key, descending})
+
+	return query
+}
+
+// Transliterate sorts titles, authors and series on their transliteration,
+// so that books in other scripts sort among Latin ones.
+func (query *LibraryQuery) Transliterate(transliterator Transliterator) *LibraryQuery {
+	query.transliterator = transliterator
+
+	return query
+}
+
+// Collate sorts titles, authors and series with the collation rules of
+// the language, instead of the language of the library.
+func (query *LibraryQuery) Collate(language string) *LibraryQuery {
+	query.language = language
+
+	return query
+}
+
+// Offset skips the first n matching books.
+func (query *LibraryQuery) Offset(n int) *LibraryQuery {
+	query.offset = n
+
+	return query
+}
+
+// Limit returns at most n books; 0 means no limit.
+func (query *LibraryQuery) Limit(n int) *LibraryQuery {
+	query.limit = n
+
+	return query
+}
+
+// Page selects the page of the given size, numbered from 1.
+func (query *LibraryQuery) Page(page, size int) *LibraryQuery {
+	if page < 1 {
+		page = 1
+	}
+
+	return query.Offset((page - 1) * size).Limit(size)
+}
+
+// Run returns the books matching the query.
+func (query *LibraryQuery) Run() LibraryPage {
+	var books []LibraryBook
+	for _, book := range query.library.Catalog() {
+		if query.matches(book) {
+			books = append(books, book)
+		}
+	}
+
+	query.collator = newCollator(query.language)
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, s := range query.sorts {
+			if c := query.compareBooks(books[i], books[j], s.key); c != 0 {
+				return c < 0 != s.descending
+			}
+		}
+		return books[i].Path < books[j].Path
+	})
+
+	page := LibraryPage{Total: len(books)}
+	if query.offset >= len(books) {
+		return page
+	}
+	books = books[query.offset:]
+	if query.limit > 0 && query.limit < len(books) {
+		books = books[:query.limit]
+	}
+	page.Books = books
+
+	return page
+}
+
+func (query *LibraryQuery) matches(book LibraryBook) bool {
+	for _, filter := range query.filters {
+		if !filter(book) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareBooks compares two books on key, returning -1, 0 or 1.
+func (query *LibraryQuery) compareBooks(a, b LibraryBook, key LibrarySortKey) int {
+	switch key {
+	case SortByTitle:
+		return query.compareText(a.Title, b.Title)
+	case SortByAuthor:
+		return query.compareText(authorSortKey(a), authorSortKey(b))
+	case SortBySeries:
+		if c := query.compareText(a.Series, b.Series); c != 0 {
+			return c
+		}
+		x, _ := strconv.ParseFloat(a.SeriesIndex, 64)
+		y, _ := strconv.ParseFloat(b.SeriesIndex, 64)
+		return compareNumbers(x, y)
+	case SortByYear:
+		return compareNumbers(float64(a.Year), float64(b.Year))
+	case SortByModTime:
+		switch {
+		case a.ModTime.Before(b.ModTime):
+			return -1
+		case a.ModTime.After(b.ModTime):
+			return 1
+		}
+	case SortByPath:
+		return strings.Compare(a.Path, b.Path)
+	case SortByMetadataScore:
+		return compareNumbers(float64(a.MetadataScore), float64(b.MetadataScore))
+	}
+
+	return 0
+}
+
+// authorSortKey returns the author sort key of a book, computing it for
+// books of libraries saved before it was stored.
+func authorSortKey(book LibraryBook) string {
+	if book.AuthorSort != "" {
+		return book.AuthorSort
+	}
+
+	return AuthorSort(book.Creator)
+}
+
+func (query *LibraryQuery) compareText(a, b string) int {
+	return query.collator.CompareString(SortKey(a, query.transliterator), SortKey(b, query.transliterator))
+}
+
+// newCollator returns a collator for the BCP 47 language tag, or for the
+// root collation of Unicode when the tag is empty or invalid.
+func newCollator(tag string) *collate.Collator {
+	t, err := language.Parse(tag)
+	if err != nil {
+		t = language.Und
+	}
+
+	return collate.New(t, collate.IgnoreCase)
+}
+
+func compareNumbers(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package epub
+
+import (
+	"testing"
+	"time"
+)
+
+func testLibrary() *Library {
+	return &Library{Books: []LibraryBook{
+		{Path: "a", Title: "The Dispossessed", Creator: "Ursula K. Le Guin", Language: "en", Year: 1974, Subjects: []string{"Science Fiction"}},
+		{Path: "b", Title: "Les Furtifs", Creator: "Alain Damasio", Language: "fr-FR", Year: 2019, Subjects: []string{"Science-fiction"}},
+		{Path: "c", Title: "La Horde du Contrevent", Creator: "Alain Damasio", Language: "fr", Year: 2004},
+		{Path: "d", Title: "A Wizard of Earthsea", Creator: "Ursula K. Le Guin", Language: "en", Year: 1968, Series: "Earthsea", SeriesIndex: "1"},
+		{Path: "e", Title: "The Tombs of Atuan", Creator: "Ursula K. Le Guin", Language: "en", Year: 1971, Series: "earthsea", SeriesIndex: "2"},
+		{Path: "f", Title: "Gone", DeletedAt: time.Now()},
+		{Path: "g", Title: "Copy", DuplicateOf: "a"},
+	}}
+}
+
+func pagePaths(page LibraryPage) string {
+	var paths string
+	for _, book := range page.Books {
+		paths += book.Path
+	}
+
+	return paths
+}
+
+func TestLibraryQuery(t *testing.T) {
+	library := testLibrary()
+
+	for _, test := range []struct {
+		name  string
+		query *LibraryQuery
+		want  string
+		total int
+	}{
+		{"all", library.Query(), "abcde", 5},
+		{"author", library.Query().Author("le guin").SortBy(SortByYear, false), "dea", 3},
+		{"series", library.Query().Series("Earthsea").SortBy(SortBySeries, true), "ed", 2},
+		{"tag", library.Query().Tag("science fiction"), "a", 1},
+		{"language", library.Query().Language("fr").SortBy(SortByTitle, false), "cb", 2},
+		{"years", library.Query().Years(1970, 2010), "ace", 3},
+		{"open years", library.Query().Years(2000, 0).SortBy(SortByYear, true), "bc", 2},
+		{"sort keys", library.Query().SortBy(SortByAuthor, false).SortBy(SortByTitle, true), "bcead", 5},
+		{"page", library.Query().SortBy(SortByTitle, false).Page(2, 2), "ba", 5},
+		{"past the end", library.Query().Offset(10), "", 5},
+	} {
+		page := test.query.Run()
+		if got := pagePaths(page); got != test.want || page.Total != test.total {
+			t.Errorf("%s: got %q (total %d), want %q (total %d)", test.name, got, page.Total, test.want, test.total)
+		}
+	}
+}
+
+func TestQueryCollate(t *testing.T) {
+	library := &Library{Books: []LibraryBook{
+		{Path: "a", Title: "Zoë"},
+		{Path: "b", Title: "Édouard"},
+		{Path: "c", Title: "zèbre"},
+		{Path: "d", Title: "Öl"},
+		{Path: "e", Title: "Ozean"},
+	}}
+
+	if got := pagePaths(library.Query().SortBy(SortByTitle, false).Run()); got != "bdeca" {
+		t.Errorf("root collation: got %q", got)
+	}
+	library.Language = "sv"
+	if got := pagePaths(library.Query().SortBy(SortByTitle, false).Run()); got != "becad" {
+		t.Errorf("Swedish collation: got %q", got)
+	}
+	if got := pagePaths(library.Query().Collate("de").SortBy(SortByTitle, false).Run()); got != "bdeca" {
+		t.Errorf("German collation: got %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+package epub
+
+import (
+	"path"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+)
+
+var lenient int32
+
+// SetLenient enables or disables lenient mode, in which the books opened
+// get the workarounds of ApplyQuirks. It is disabled by default.
+func SetLenient(enabled bool) {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&lenient, value)
+}
+
+func isLenient() bool {
+	return atomic.LoadInt32(&lenient) == 1
+}
+
+// Quirk is a known defect of the books of a producer, and its workaround.
+type Quirk struct {
+	ID string
+	// Producer is the producer whose books have the defect, or empty for
+	// defects found whatever the producer.
+	Producer    string
+	Description string
+	// fix works around the defect if the book has it, changing the
+	// package in memory, and reports whether it did.
+	fix func(epubReader *EpubReader) bool
+}
+
+// Quirks are the defects ApplyQuirks works around.
+var Quirks = []Quirk{
+	{
+		ID:          "encoded-hrefs",
+		Producer:    ProducerInDesign,
+		Description: "manifest hrefs are percent-encoded file names",
+		fix:         fixEncodedHrefs,
+	},
+	{
+		ID:          "cover-page-meta",
+		Producer:    ProducerCalibre,
+		Description: "cover meta refers to the cover page instead of its image",
+		fix:         fixCoverPageMeta,
+	},
+	{
+		ID:          "stripped-ncx",
+		Description: "spine refers to an NCX that was stripped from the book",
+		fix:         fixStrippedNCX,
+	},
+}
+
+// ApplyQuirks works around the known defects of the books of the producer
+// of the book that it has, changing the package in memory, logs a warning
+// for each and returns their IDs.
+func (epubReader *EpubReader) ApplyQuirks() []string {
+	producer := epubReader.Producer().Name
+
+	var applied []string
+	for _, quirk := range Quirks {
+		if quirk.Producer != "" && quirk.Producer != producer || !quirk.fix(epubReader) {
+			continue
+		}
+		log.Warn().Str("file", epubReader.Name).Str("quirk", quirk.ID).Msg(quirk.Description)
+		applied = append(applied, quirk.ID)
+	}
+
+	return applied
+}
+
+// fixEncodedHrefs escapes the percent signs of the hrefs whose target
+// only exists with its name left encoded.
+func fixEncodedHrefs(epubReader *EpubReader) bool {
+	fixed := false
+	items := epubReader.Rootfiles[0].Manifest.Item
+	for i, item := range items {
+		if !strings.Contains(item.Href, "%") || epubReader.hasItemFile(item) {
+			continue
+		}
+		if epubReader.hasFile(path.Join(path.Dir(epubReader.Rootfiles[0].FullPath), item.Href)) {
+			items[i].Href = strings.ReplaceAll(item.Href, "%", "%25")
+			fixed = true
+		}
+	}
+
+	return fixed
+}
+
+// fixCoverPageMeta points the cover meta to the first image of the
+// document it refers to.
+func fixCoverPageMeta(epubReader *EpubReader) bool {
+	metas := epubReader.Rootfiles[0].Metadata.Meta
+	for i, meta := range metas {
+		if meta.Name != "cover" {
+			continue
+		}
+		item, ok := epubReader.ItemByID(meta.Content)
+		if !ok || isImage(item) {
+			return false
+		}
+		images, err := epubReader.documentImages(epubReader.itemPath(item.Href))
+		if err != nil || len(images) == 0 {
+			return false
+		}
+		image, ok := epubReader.itemByPath(images[0])
+		if !ok {
+			return false
+		}
+		metas[i].Content = image.ID
+		return true
+	}
+
+	return false
+}
+
+// fixStrippedNCX removes the spine reference to a missing NCX, and the
+// manifest item of the NCX if its file is missing.
+func fixStrippedNCX(epubReader *EpubReader) bool {
+	pkg := &epubReader.Rootfiles[0].Package
+	if pkg.Spine.Toc == "" {
+		return false
+	}
+
+	item, ok := epubReader.ItemByID(pkg.Spine.Toc)
+	if ok && epubReader.hasItemFile(item) {
+		return false
+	}
+	pkg.Spine.Toc = ""
+	if ok {
+		items := pkg.Manifest.Item[:0]
+		for _, other := range pkg.Manifest.Item {
+			if other.ID != item.ID {
+				items = append(items, other)
+			}
+		}
+		pkg.Manifest.Item = items
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyQuirks(t *testing.T) {
+	generator := func(opf, name string) string {
+		return strings.Replace(opf, `<meta name="cover"`, `<meta name="generator" content="`+name+`"/>
+    <meta name="cover"`, 1)
+	}
+
+	encoded := generator(strings.Replace(testOPF, `href="chapter2.xhtml"`, `href="chapter%202.xhtml"`, 1), "Adobe InDesign 15.1")
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", encoded}, testFile{"OEBPS/chapter2.xhtml", ""}, testFile{"OEBPS/chapter%202.xhtml", testChapter2})
+	if got := reader.ApplyQuirks(); !reflect.DeepEqual(got, []string{"encoded-hrefs"}) {
+		t.Errorf("encoded hrefs: ApplyQuirks() = %v", got)
+	}
+	if item, _ := reader.ItemByID("chapter2"); !reader.hasItemFile(item) {
+		t.Errorf("encoded hrefs: %s still missing", item.Href)
+	}
+
+	coverPage := generator(strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, `<meta name="cover" content="chapter1"/>`, 1), "calibre 5.10")
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", coverPage})
+	if got := reader.ApplyQuirks(); !reflect.DeepEqual(got, []string{"cover-page-meta"}) {
+		t.Errorf("cover page: ApplyQuirks() = %v", got)
+	}
+	if item, ok := reader.Cover(); !ok || item.ID != "cover-image" {
+		t.Errorf("cover page: Cover() = %v, %v", item, ok)
+	}
+
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", coverPage}, testFile{"OEBPS/toc.ncx", ""})
+	if got := reader.ApplyQuirks(); !reflect.DeepEqual(got, []string{"cover-page-meta", "stripped-ncx"}) {
+		t.Errorf("stripped NCX: ApplyQuirks() = %v", got)
+	}
+	if _, ok := reader.ItemByID("ncx"); ok || reader.Rootfiles[0].Spine.Toc != "" {
+		t.Errorf("stripped NCX: NCX still referenced")
+	}
+
+	SetLenient(true)
+	defer SetLenient(false)
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", coverPage})
+	if got := reader.ApplyQuirks(); got != nil {
+		t.Errorf("lenient open: quirks left to apply %v", got)
+	}
+	SetLenient(false)
+	if got := openTestEpub(t).ApplyQuirks(); got != nil {
+		t.Errorf("ApplyQuirks() = %v, want none", got)
+	}
+}
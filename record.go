@@ -0,0 +1,91 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+const jsonLDMediaType = "application/ld+json"
+
+// MetadataRecord is a linked data record embedded in the book, typically a
+// schema.org Book description in JSON-LD.
+type MetadataRecord struct {
+	Href      string
+	MediaType string
+	Data      map[string]interface{}
+}
+
+// Type returns the @type of the record, e.g. "Book".
+func (record MetadataRecord) Type() string {
+	if t, ok := record.Data["@type"].(string); ok {
+		return t
+	}
+
+	return ""
+}
+
+// MetadataRecords returns the JSON-LD records of the book: those linked from
+// the metadata with rel="record", and those only declared in the manifest.
+func (epubReader *EpubReader) MetadataRecords() ([]MetadataRecord, error) {
+	var records []MetadataRecord
+	seen := make(map[string]bool)
+
+	for _, link := range epubReader.Links() {
+		if !link.HasRel("record") || link.MediaType != jsonLDMediaType || !link.IsLocal() {
+			continue
+		}
+
+		record, err := epubReader.readRecord(link.Href, link.MediaType)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+		seen[epubReader.itemPath(link.Href)] = true
+	}
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.MediaType != jsonLDMediaType || seen[epubReader.itemPath(item.Href)] {
+			continue
+		}
+
+		record, err := epubReader.readRecord(item.Href, item.MediaType)
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (epubReader *EpubReader) readRecord(href, mediaType string) (MetadataRecord, error) {
+	record := MetadataRecord{Href: href, MediaType: mediaType}
+
+	buffer, err := epubReader.readFile(epubReader.itemPath(href))
+	if err != nil {
+		return record, err
+	}
+
+	if err := json.Unmarshal(buffer.Bytes(), &record.Data); err != nil {
+		return record, fmt.Errorf("epub: %s: record '%s': %w", epubReader.Name, href, err)
+	}
+
+	return record, nil
+}
+
+// AddRecord adds to the book the JSON-LD record data as the file href,
+// relative to the package document, linked from the metadata with
+// rel="record".
+func (epubWriter *EpubWriter) AddRecord(href string, data map[string]interface{}) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("epub: record '%s': %w", href, err)
+	}
+	if err := epubWriter.AddItem(href, jsonLDMediaType, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	epubWriter.Metadata.Links = append(epubWriter.Metadata.Links, Link{Href: escapeHref(href), Rel: "record", MediaType: jsonLDMediaType})
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetadataRecords(t *testing.T) {
+	opf := strings.Replace(testOPF, `<manifest>`, `<manifest>
+    <item id="record" href="meta/book%20record.jsonld" media-type="application/ld+json"/>`, 1)
+
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/meta/book record.jsonld", `{"@context": "https://schema.org", "@type": "Book", "name": "The Test Book"}`},
+	)
+
+	records, err := reader.MetadataRecords()
+	if err != nil {
+		t.Fatalf("MetadataRecords() = %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("MetadataRecords() = %d records", len(records))
+	}
+	if records[0].Type() != "Book" || records[0].Data["name"] != "The Test Book" {
+		t.Errorf("MetadataRecords()[0] = %v", records[0].Data)
+	}
+}
+
+func TestAddRecord(t *testing.T) {
+	writer := NewWriter(WriterMetadata{Title: "The Test Book"})
+	if err := writer.AddItem("chapter1.xhtml", "application/xhtml+xml", strings.NewReader(testChapter1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.AddRecord("meta/book record.jsonld", map[string]interface{}{"@context": "https://schema.org", "@type": "Book", "name": "The Test Book"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := writer.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := reader.Links(); len(links) != 1 || !links[0].HasRel("record") {
+		t.Errorf("Links() = %+v", links)
+	}
+	records, err := reader.MetadataRecords()
+	if err != nil || len(records) != 1 || records[0].Type() != "Book" || records[0].Data["name"] != "The Test Book" {
+		t.Errorf("MetadataRecords() = %+v, %v", records, err)
+	}
+	if issues := reader.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %+v", issues)
+	}
+}
@@ -0,0 +1,197 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoRendition occurs when SetActiveRendition is given the index of a
+// rendition that does not exist.
+var ErrNoRendition = errors.New("epub: no such rendition")
+
+// packageMediaType is the media type of package documents.
+const packageMediaType = "application/oebps-package+xml"
+
+// MergedMetadata is the metadata of a book merged across its renditions.
+type MergedMetadata struct {
+	Title       string
+	Creators    []string
+	Language    string
+	Publisher   string
+	Description string
+	Date        string
+	ISBN        string
+	// Conflicts lists the fields the renditions give different values.
+	Conflicts []MetadataConflict
+}
+
+// MetadataConflict is a field the renditions give different values.
+type MetadataConflict struct {
+	Field string
+	// Values maps the FullPath of the renditions declaring the field to
+	// their value.
+	Values map[string]string
+}
+
+// Rendition returns the rootfile the accessors of the reader read, such as
+// Cover, TOC or GetISBN: its FullPath and Version tell which rendition
+// their results come from. It is the first rootfile of the container,
+// unless another one was selected with SetActiveRendition or
+// SelectVersion.
+func (epubReader *EpubReader) Rendition() *Rootfile {
+	return epubReader.Rootfiles[0]
+}
+
+// Renditions returns the rootfiles of the container, in container order
+// whichever rendition is active.
+func (epubReader *EpubReader) Renditions() []*Rootfile {
+	if epubReader.renditions == nil {
+		return append([]*Rootfile(nil), epubReader.Rootfiles...)
+	}
+
+	return append([]*Rootfile(nil), epubReader.renditions...)
+}
+
+// AlternateRenditions returns the rootfiles of the container which are not
+// package documents, such as the PDF rendition of a hybrid container, in
+// container order. The reader skips them: Rootfiles and Renditions only
+// hold package documents.
+func (epubReader *EpubReader) AlternateRenditions() []*Rootfile {
+	return append([]*Rootfile(nil), epubReader.alternates...)
+}
+
+// isPackageRootfile reports whether rootfile is a package document: by its
+// media type, or by its extension when the media type is wrong, as it is
+// in some books.
+func isPackageRootfile(rootfile *Rootfile) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(rootfile.MediaType))
+
+	return mediaType == packageMediaType || strings.HasSuffix(strings.ToLower(rootfile.FullPath), ".opf")
+}
+
+// DefaultRendition returns the first rootfile of the container, the
+// rendition reading systems pick unless they select another one.
+func (epubReader *EpubReader) DefaultRendition() *Rootfile {
+	return epubReader.Renditions()[0]
+}
+
+// SetActiveRendition makes the rendition i of Renditions the one the
+// accessors read, moving it first in Rootfiles.
+func (epubReader *EpubReader) SetActiveRendition(i int) error {
+	renditions := epubReader.Renditions()
+	if i < 0 || i >= len(renditions) {
+		return fmt.Errorf("epub: %s: %w: %d of %d", epubReader.Name, ErrNoRendition, i, len(renditions))
+	}
+	epubReader.activate(renditions[i])
+
+	return nil
+}
+
+// activate moves rootfile first in Rootfiles.
+func (epubReader *EpubReader) activate(rootfile *Rootfile) {
+	for i := range epubReader.Rootfiles {
+		if epubReader.Rootfiles[i] == rootfile {
+			copy(epubReader.Rootfiles[1:i+1], epubReader.Rootfiles[:i])
+			epubReader.Rootfiles[0] = rootfile
+			return
+		}
+	}
+}
+
+// SelectVersion makes the first rootfile whose package has the given
+// version the rendition the accessors read, moving it first in
+// Rootfiles, and reports whether there is one. A major version matches
+// its minor versions, so that "3" selects a "3.0" or "3.1" package over a
+// "2.0" one, to prefer the richer EPUB 3 rendition of books shipping both.
+func (epubReader *EpubReader) SelectVersion(version string) bool {
+	for _, rootfile := range epubReader.Rootfiles {
+		if rootfile.Version == version || strings.HasPrefix(rootfile.Version, version+".") {
+			epubReader.activate(rootfile)
+			return true
+		}
+	}
+
+	return false
+}
+
+// MergeMetadata merges the metadata of the renditions of the book, for
+// books shipping several: each field takes the first non-empty value, in
+// the order of Rootfiles, so that the selected rendition comes first.
+// Values differing other than by case and whitespace are reported as
+// conflicts. Other accessors, such as GetISBN, only read the selected
+// rendition.
+func (epubReader *EpubReader) MergeMetadata() MergedMetadata {
+	var merged MergedMetadata
+
+	merge := func(field string, value func(rootfile *Rootfile) string) string {
+		result := ""
+		values := make(map[string]string)
+		distinct := make(map[string]bool)
+		for _, rootfile := range epubReader.Rootfiles {
+			v := collapseSpace(value(rootfile))
+			if v == "" {
+				continue
+			}
+			if result == "" {
+				result = v
+			}
+			values[rootfile.FullPath] = v
+			distinct[strings.ToLower(v)] = true
+		}
+		if len(distinct) > 1 {
+			merged.Conflicts = append(merged.Conflicts, MetadataConflict{Field: field, Values: values})
+		}
+		return result
+	}
+
+	merged.Title = merge("title", func(rootfile *Rootfile) string { return firstTitle(rootfile.Metadata.Title) })
+	// Creators are merged as lists, which conflict when their names differ
+	// or come in another order; conflicts show them joined.
+	values := make(map[string]string)
+	conflict := false
+	for _, rootfile := range epubReader.Rootfiles {
+		var names []string
+		for _, creator := range rootfile.Metadata.Creator {
+			if name := collapseSpace(creator.Text); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		if merged.Creators == nil {
+			merged.Creators = names
+		}
+		conflict = conflict || !equalFoldStrings(names, merged.Creators)
+		values[rootfile.FullPath] = strings.Join(names, "; ")
+	}
+	if conflict {
+		merged.Conflicts = append(merged.Conflicts, MetadataConflict{Field: "creators", Values: values})
+	}
+	merged.Language = merge("language", func(rootfile *Rootfile) string { return firstText(rootfile.Metadata.Language) })
+	merged.Publisher = merge("publisher", func(rootfile *Rootfile) string { return firstText(rootfile.Metadata.Publisher) })
+	merged.Description = merge("description", func(rootfile *Rootfile) string { return firstText(rootfile.Metadata.Description) })
+	merged.Date = merge("date", func(rootfile *Rootfile) string { return firstDate(rootfile.Metadata.Date) })
+	merged.ISBN = merge("isbn", func(rootfile *Rootfile) string {
+		isbn, _ := rootfile.isbn()
+		return isbn
+	})
+
+	return merged
+}
+
+// equalFoldStrings reports whether a and b hold the same strings, ignoring
+// case, in the same order.
+func equalFoldStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+
+	return true
+}
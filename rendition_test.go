@@ -0,0 +1,134 @@
+package epub
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectVersion(t *testing.T) {
+	container := strings.Replace(testContainer, "</rootfiles>", `  <rootfile full-path="OEBPS/content3.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>`, 1)
+	opf3 := strings.Replace(testOPF, `version="2.0"`, `version="3.0"`, 1)
+	opf3 = strings.Replace(opf3, `<item id="ncx"`, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx"`, 1)
+	nav := strings.ReplaceAll(testNav, `href="text/`, `href="`)
+	reader := openTestEpub(t, testFile{containerPath, container}, testFile{"OEBPS/content3.opf", opf3}, testFile{"OEBPS/nav.xhtml", nav})
+
+	if rendition := reader.Rendition(); rendition.FullPath != "OEBPS/content.opf" || rendition.Version != "2.0" {
+		t.Errorf("Rendition() = %s %s", rendition.FullPath, rendition.Version)
+	}
+	if toc, _ := reader.TOC(); len(toc[0].Children) != 0 {
+		t.Errorf("EPUB 2 TOC() = %+v", toc)
+	}
+
+	if reader.SelectVersion("4") {
+		t.Errorf("SelectVersion(4) = true")
+	}
+	if !reader.SelectVersion("3") || reader.Rendition().FullPath != "OEBPS/content3.opf" || len(reader.Rootfiles) != 2 {
+		t.Fatalf("SelectVersion(3): Rendition() = %s", reader.Rendition().FullPath)
+	}
+	if toc, _ := reader.TOC(); len(toc[0].Children) != 1 {
+		t.Errorf("EPUB 3 TOC() = %+v", toc)
+	}
+	if !reader.SelectVersion("2.0") || reader.Rendition().FullPath != "OEBPS/content.opf" || reader.Rootfiles[1].FullPath != "OEBPS/content3.opf" {
+		t.Errorf("SelectVersion(2.0): Rendition() = %s", reader.Rendition().FullPath)
+	}
+}
+
+func TestRenditions(t *testing.T) {
+	container := strings.Replace(testContainer, "</rootfiles>", `  <rootfile full-path="OEBPS/fixed.opf" media-type="application/oebps-package+xml" rendition:layout="pre-paginated" rendition:label="Fixed"/>
+  </rootfiles>`, 1)
+	container = strings.Replace(container, `<container `, `<container xmlns:rendition="http://www.idpf.org/2013/rendition" `, 1)
+	fixed := strings.Replace(testOPF, `<dc:title>The Test Book</dc:title>`, `<dc:title>The Test Book (Fixed)</dc:title>`, 1)
+	reader := openTestEpub(t, testFile{containerPath, container}, testFile{"OEBPS/fixed.opf", fixed})
+
+	renditions := reader.Renditions()
+	if len(renditions) != 2 || renditions[1].RenditionLayout != "pre-paginated" || renditions[1].RenditionLabel != "Fixed" || renditions[0].MediaType != "application/oebps-package+xml" {
+		t.Fatalf("Renditions() = %+v", renditions)
+	}
+
+	if err := reader.SetActiveRendition(2); !errors.Is(err, ErrNoRendition) {
+		t.Errorf("SetActiveRendition(2) = %v, want ErrNoRendition", err)
+	}
+	if err := reader.SetActiveRendition(1); err != nil || reader.Rendition().FullPath != "OEBPS/fixed.opf" {
+		t.Fatalf("SetActiveRendition(1) = %v: Rendition() = %s", err, reader.Rendition().FullPath)
+	}
+	if metadata := reader.Metadata(); metadata.Titles[0].Text != "The Test Book (Fixed)" {
+		t.Errorf("Metadata() of the fixed rendition = %+v", metadata.Titles)
+	}
+	if reader.DefaultRendition().FullPath != "OEBPS/content.opf" || reader.Renditions()[0].FullPath != "OEBPS/content.opf" {
+		t.Errorf("DefaultRendition() = %s", reader.DefaultRendition().FullPath)
+	}
+	if err := reader.SetActiveRendition(0); err != nil || reader.Rendition().FullPath != "OEBPS/content.opf" {
+		t.Errorf("SetActiveRendition(0) = %v: Rendition() = %s", err, reader.Rendition().FullPath)
+	}
+}
+
+func TestAlternateRenditions(t *testing.T) {
+	pdf := `<rootfile full-path="book.pdf" media-type="application/pdf"/>`
+	container := strings.Replace(testContainer, "<rootfiles>", "<rootfiles>\n    "+pdf, 1)
+	reader := openTestEpub(t, testFile{containerPath, container}, testFile{"book.pdf", "%PDF-1.4"})
+
+	if reader.Rendition().FullPath != "OEBPS/content.opf" || len(reader.Renditions()) != 1 {
+		t.Errorf("Rendition() = %s", reader.Rendition().FullPath)
+	}
+	if alternates := reader.AlternateRenditions(); len(alternates) != 1 || alternates[0].FullPath != "book.pdf" || alternates[0].MediaType != "application/pdf" {
+		t.Errorf("AlternateRenditions() = %+v", alternates)
+	}
+
+	container = strings.Replace(testContainer, `<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>`, pdf, 1)
+	book := buildTestEpub(t, testFile{containerPath, container}, testFile{"book.pdf", "%PDF-1.4"})
+	if _, err := OpenBuffer(book, int64(len(book))); !errors.Is(err, ErrorNoRootFile) {
+		t.Errorf("OpenBuffer(PDF only) = %v, want ErrorNoRootFile", err)
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	container := strings.Replace(testContainer, "</rootfiles>", `  <rootfile full-path="OEBPS/content3.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>`, 1)
+	opf2 := strings.Replace(testOPF, `<dc:identifier id="bookid" opf:scheme="ISBN">9780306406157</dc:identifier>`, `<dc:identifier id="bookid">urn:uuid:5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41</dc:identifier>`, 1)
+	opf2 = strings.Replace(opf2, `<dc:publisher>Test Press</dc:publisher>`, "", 1)
+	opf3 := strings.Replace(testOPF, `version="2.0"`, `version="3.0"`, 1)
+	opf3 = strings.Replace(opf3, `<dc:title>The Test Book</dc:title>`, `<dc:title>The Test Book: Revised</dc:title>`, 1)
+	opf3 = strings.Replace(opf3, `<dc:language>en</dc:language>`, `<dc:language>EN</dc:language>
+    <dc:date>2019</dc:date>`, 1)
+	reader := openTestEpub(t, testFile{containerPath, container}, testFile{"OEBPS/content.opf", opf2}, testFile{"OEBPS/content3.opf", opf3})
+
+	merged := reader.MergeMetadata()
+	want := MergedMetadata{
+		Title:     "The Test Book",
+		Creators:  []string{"Jane Doe"},
+		Language:  "en",
+		Publisher: "Test Press",
+		Date:      "2019",
+		ISBN:      "9780306406157",
+		Conflicts: []MetadataConflict{{Field: "title", Values: map[string]string{
+			"OEBPS/content.opf":  "The Test Book",
+			"OEBPS/content3.opf": "The Test Book: Revised",
+		}}},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("MergeMetadata() = %+v", merged)
+	}
+	if _, err := reader.GetISBN(); err == nil {
+		t.Error("GetISBN() of the EPUB 2 rendition found an ISBN")
+	}
+
+	reader.SelectVersion("3")
+	if merged := reader.MergeMetadata(); merged.Title != "The Test Book: Revised" || merged.Language != "EN" {
+		t.Errorf("MergeMetadata() of the EPUB 3 rendition first = %+v", merged)
+	}
+
+	opf3 = strings.Replace(opf3, `>Jane Doe<`, `>Smith; John<`, 1)
+	reader = openTestEpub(t, testFile{containerPath, container}, testFile{"OEBPS/content.opf", opf2}, testFile{"OEBPS/content3.opf", opf3})
+	reader.SelectVersion("3")
+	merged = reader.MergeMetadata()
+	if !reflect.DeepEqual(merged.Creators, []string{"Smith; John"}) {
+		t.Errorf("MergeMetadata() creators = %q", merged.Creators)
+	}
+	if len(merged.Conflicts) != 2 || merged.Conflicts[1].Field != "creators" {
+		t.Errorf("MergeMetadata() conflicts = %+v", merged.Conflicts)
+	}
+}
@@ -0,0 +1,178 @@
+package epub
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes reports as JUnit XML: one test suite per file and one
+// test case per rule. Errors are failures, other issues are written to the
+// test case output.
+func WriteJUnit(w io.Writer, reports []FileReport) error {
+	var suites junitTestSuites
+
+	for _, report := range reports {
+		suite := junitTestSuite{Name: report.Path}
+		for _, rule := range Rules() {
+			testCase := junitTestCase{ClassName: report.Path, Name: rule.ID}
+			var failures, output []string
+			for _, issue := range report.Issues {
+				if issue.RuleID != rule.ID {
+					continue
+				}
+				if issue.Severity >= SeverityError {
+					failures = append(failures, issue.String())
+				} else {
+					output = append(output, issue.String())
+				}
+			}
+			if len(failures) > 0 {
+				testCase.Failure = &junitFailure{
+					Message: rule.Description,
+					Type:    rule.ID,
+					Text:    strings.Join(failures, "\n"),
+				}
+				suite.Failures++
+			}
+			testCase.SystemOut = strings.Join(output, "\n")
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		suite.Tests = len(suite.Cases)
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("epub: write junit: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	} `json:"driver"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// WriteSARIF writes reports as a SARIF 2.1.0 log.
+func WriteSARIF(w io.Writer, reports []FileReport) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "epub"
+	for _, rule := range Rules() {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               rule.ID,
+			ShortDescription: sarifMessage{rule.Description},
+		})
+	}
+
+	for _, report := range reports {
+		for _, issue := range report.Issues {
+			var location sarifLocation
+			location.PhysicalLocation.ArtifactLocation.URI = report.Path
+			if issue.Path != "" {
+				location.LogicalLocations = []sarifLogicalLocation{{issue.Path}}
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    issue.RuleID,
+				Level:     sarifLevel(issue.Severity),
+				Message:   sarifMessage{issue.Message},
+				Locations: []sarifLocation{location},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func sarifLevel(severity Severity) string {
+	switch {
+	case severity >= SeverityError:
+		return "error"
+	case severity == SeverityWarning:
+		return "warning"
+	}
+
+	return "note"
+}
@@ -0,0 +1,243 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ResourceMap cross-references the resources of a book. Keys and values are
+// zip paths.
+type ResourceMap struct {
+	// Uses maps each content document and stylesheet to the resources it
+	// references directly: images, stylesheets, fonts, scripts, audio,
+	// video and linked documents, sorted.
+	Uses map[string][]string
+	// UsedBy is the reverse of Uses.
+	UsedBy map[string][]string
+}
+
+var (
+	cssURLPattern    = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+	cssImportPattern = regexp.MustCompile(`@import\s+['"]([^'"]+)['"]`)
+)
+
+// referenceAttributes are the attributes of content documents holding
+// references to other resources.
+var referenceAttributes = map[string]bool{"src": true, "href": true, "data": true, "poster": true}
+
+// ResourceMap returns the references between the content documents and
+// stylesheets of the manifest and the resources of the book. Remote
+// references and data: URLs are left out.
+func (epubReader *EpubReader) ResourceMap() (ResourceMap, error) {
+	resources := ResourceMap{Uses: make(map[string][]string), UsedBy: make(map[string][]string)}
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		name := epubReader.itemPath(item.Href)
+		var references []string
+		var err error
+
+		switch item.MediaType {
+		case "application/xhtml+xml", "text/html", "image/svg+xml":
+			references, err = epubReader.documentReferences(name)
+		case "text/css":
+			var css string
+			if buffer, e := epubReader.readFile(name); e == nil {
+				css = buffer.String()
+			} else {
+				err = e
+			}
+			references = cssReferences(name, css)
+		default:
+			continue
+		}
+		if err != nil {
+			return resources, err
+		}
+
+		for _, reference := range references {
+			if reference != name {
+				resources.add(name, reference)
+			}
+		}
+	}
+
+	for _, m := range []map[string][]string{resources.Uses, resources.UsedBy} {
+		for key, values := range m {
+			sort.Strings(values)
+			m[key] = values
+		}
+	}
+
+	return resources, nil
+}
+
+func (resources ResourceMap) add(user, resource string) {
+	for _, existing := range resources.Uses[user] {
+		if existing == resource {
+			return
+		}
+	}
+	resources.Uses[user] = append(resources.Uses[user], resource)
+	resources.UsedBy[resource] = append(resources.UsedBy[resource], user)
+}
+
+// Closure returns the resources name uses directly or through other
+// resources, such as the fonts of its stylesheets, sorted.
+func (resources ResourceMap) Closure(name string) []string {
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	var closure []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, resource := range resources.Uses[current] {
+			if !seen[resource] {
+				seen[resource] = true
+				closure = append(closure, resource)
+				queue = append(queue, resource)
+			}
+		}
+	}
+	sort.Strings(closure)
+
+	return closure
+}
+
+// UnusedItems returns the manifest items that nothing uses: they are not in
+// the spine, not the navigation document, NCX or cover image, not a
+// fallback nor a media overlay, and not referenced, directly or not, from
+// any of these.
+func (epubReader *EpubReader) UnusedItems() ([]Item, error) {
+	resources, err := epubReader.ResourceMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []Item
+	roots = append(roots, epubReader.SpineItems()...)
+	if item, ok := epubReader.navItem(); ok {
+		roots = append(roots, item)
+	}
+	if item, ok := epubReader.ncxItem(); ok {
+		roots = append(roots, item)
+	}
+	if item, ok := epubReader.Cover(); ok {
+		roots = append(roots, item)
+	}
+	for _, reference := range epubReader.Rootfiles[0].Guide.Reference {
+		if item, ok := epubReader.itemByPath(epubReader.itemPath(reference.Href)); ok {
+			roots = append(roots, item)
+		}
+	}
+
+	used := make(map[string]bool)
+	var mark func(item Item)
+	mark = func(item Item) {
+		name := epubReader.itemPath(item.Href)
+		if used[name] {
+			return
+		}
+		used[name] = true
+		for _, resource := range resources.Closure(name) {
+			if used[resource] {
+				continue
+			}
+			if resourceItem, ok := epubReader.itemByPath(resource); ok {
+				mark(resourceItem)
+			} else {
+				used[resource] = true
+			}
+		}
+		for _, id := range []string{item.Fallback, item.MediaOverlay} {
+			if related, ok := epubReader.ItemByID(id); ok {
+				mark(related)
+			}
+		}
+	}
+	for _, item := range roots {
+		mark(item)
+	}
+
+	var unused []Item
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if !used[epubReader.itemPath(item.Href)] {
+			unused = append(unused, item)
+		}
+	}
+
+	return unused, nil
+}
+
+// documentReferences returns the zip paths a content document references
+// through attributes, style elements and style attributes.
+func (epubReader *EpubReader) documentReferences(name string) ([]string, error) {
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var references []string
+	inStyle := false
+	decoder := newXHTMLDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return references, nil
+		}
+		if err != nil {
+			return references, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			inStyle = strings.EqualFold(t.Name.Local, "style")
+			for _, a := range t.Attr {
+				if referenceAttributes[a.Name.Local] {
+					if reference, ok := localReference(name, a.Value); ok {
+						references = append(references, reference)
+					}
+				} else if a.Name.Local == "style" {
+					references = append(references, cssReferences(name, a.Value)...)
+				}
+			}
+		case xml.CharData:
+			if inStyle {
+				references = append(references, cssReferences(name, string(t))...)
+			}
+		case xml.EndElement:
+			inStyle = false
+		}
+	}
+}
+
+// cssReferences returns the zip paths referenced by the url() and @import
+// of css, relative to the entry name.
+func cssReferences(name, css string) []string {
+	var references []string
+
+	for _, pattern := range []*regexp.Regexp{cssURLPattern, cssImportPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(css, -1) {
+			if reference, ok := localReference(name, strings.TrimSpace(match[1])); ok {
+				references = append(references, reference)
+			}
+		}
+	}
+
+	return references
+}
+
+// localReference resolves href against the entry name, unless it is a
+// remote reference, a data: URL or a fragment of the same document.
+func localReference(name, href string) (string, bool) {
+	if href == "" || strings.HasPrefix(href, "#") || strings.Contains(href, ":") {
+		return "", false
+	}
+
+	return resolvePath(name, href), true
+}
@@ -0,0 +1,49 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResourceMap(t *testing.T) {
+	opf := strings.Replace(testOPF, `<item id="css"`, `<item id="font" href="fonts/serif.otf" media-type="font/otf"/>
+    <item id="unused" href="images/unused.png" media-type="image/png"/>
+    <item id="bg" href="images/bg.png" media-type="image/png"/>
+    <item id="css"`, 1)
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/style.css", `@font-face { src: url("fonts/serif.otf"); } body { background: url(data:image/png;base64,AAAA); }`},
+		testFile{"OEBPS/chapter2.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><head><style>p { background: url('images/bg.png') }</style></head>
+<body><p><a href="chapter1.xhtml#top">back</a> <a href="http://example.com/">web</a> <a href="#self">self</a></p></body></html>`},
+		testFile{"OEBPS/fonts/serif.otf", "font"},
+		testFile{"OEBPS/images/unused.png", "png"},
+		testFile{"OEBPS/images/bg.png", "png"},
+	)
+
+	resources, err := reader.ResourceMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uses := map[string][]string{
+		"OEBPS/chapter1.xhtml": {"OEBPS/images/cover.jpg", "OEBPS/style.css"},
+		"OEBPS/chapter2.xhtml": {"OEBPS/chapter1.xhtml", "OEBPS/images/bg.png"},
+		"OEBPS/style.css":      {"OEBPS/fonts/serif.otf"},
+	}
+	if !reflect.DeepEqual(resources.Uses, uses) {
+		t.Errorf("Uses = %v", resources.Uses)
+	}
+	if usedBy := resources.UsedBy["OEBPS/style.css"]; !reflect.DeepEqual(usedBy, []string{"OEBPS/chapter1.xhtml"}) {
+		t.Errorf("UsedBy[style.css] = %v", usedBy)
+	}
+
+	closure := resources.Closure("OEBPS/chapter1.xhtml")
+	if want := []string{"OEBPS/fonts/serif.otf", "OEBPS/images/cover.jpg", "OEBPS/style.css"}; !reflect.DeepEqual(closure, want) {
+		t.Errorf("Closure(chapter1) = %v", closure)
+	}
+
+	unused, err := reader.UnusedItems()
+	if err != nil || len(unused) != 1 || unused[0].ID != "unused" {
+		t.Errorf("UnusedItems() = %+v, %v", unused, err)
+	}
+}
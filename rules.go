@@ -0,0 +1,178 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrDuplicateRule occurs when registering a rule whose ID is already
+	// in use.
+	ErrDuplicateRule = errors.New("epub: duplicate rule id")
+
+	// ErrBadExpression occurs when an expression rule cannot be parsed.
+	ErrBadExpression = errors.New("epub: invalid rule expression")
+)
+
+var (
+	customRulesMu sync.RWMutex
+	customRules   []Rule
+)
+
+// RegisterRule adds a rule to those run by Validate. Check receives the
+// opened book, giving access to the parsed package and to its resources.
+func RegisterRule(rule Rule) error {
+	if rule.ID == "" || rule.Check == nil {
+		return fmt.Errorf("epub: rule '%s': missing id or check", rule.ID)
+	}
+
+	customRulesMu.Lock()
+	defer customRulesMu.Unlock()
+
+	for _, r := range builtinRules {
+		if r.ID == rule.ID {
+			return fmt.Errorf("%w: %s", ErrDuplicateRule, rule.ID)
+		}
+	}
+	for _, r := range customRules {
+		if r.ID == rule.ID {
+			return fmt.Errorf("%w: %s", ErrDuplicateRule, rule.ID)
+		}
+	}
+	customRules = append(customRules, rule)
+
+	return nil
+}
+
+// UnregisterRule removes a rule added with RegisterRule.
+func UnregisterRule(id string) {
+	customRulesMu.Lock()
+	defer customRulesMu.Unlock()
+
+	for i, r := range customRules {
+		if r.ID == id {
+			customRules = append(customRules[:i], customRules[i+1:]...)
+			return
+		}
+	}
+}
+
+var expressionPattern = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+must\s+(be\s+non-empty|equal|match)\s*("(?:[^"\\]|\\.)*")?\s*$`)
+
+// ExpressionRule builds a rule from a one line expression on a path of the
+// package document, such as
+//
+//	metadata.publisher must be non-empty
+//	metadata.language must equal "fr"
+//	metadata.identifier must match "^urn:isbn:"
+//
+// Path elements are matched case-insensitively against the fields of
+// Package; a path reaching several values (repeated elements) checks all
+// of them.
+func ExpressionRule(id string, severity Severity, expression string) (Rule, error) {
+	match := expressionPattern.FindStringSubmatch(expression)
+	if match == nil {
+		return Rule{}, fmt.Errorf("%w: %s", ErrBadExpression, expression)
+	}
+
+	path := strings.Split(match[1], ".")
+	operator := strings.Join(strings.Fields(match[2]), " ")
+	var operand string
+	if match[3] != "" {
+		var err error
+		if operand, err = strconv.Unquote(match[3]); err != nil {
+			return Rule{}, fmt.Errorf("%w: %s", ErrBadExpression, expression)
+		}
+	}
+
+	var check func(values []string) bool
+	switch operator {
+	case "be non-empty":
+		check = func(values []string) bool {
+			for _, value := range values {
+				if strings.TrimSpace(value) != "" {
+					return true
+				}
+			}
+			return false
+		}
+	case "equal":
+		check = func(values []string) bool {
+			for _, value := range values {
+				if strings.TrimSpace(value) != operand {
+					return false
+				}
+			}
+			return len(values) > 0
+		}
+	case "match":
+		re, err := regexp.Compile(operand)
+		if err != nil {
+			return Rule{}, fmt.Errorf("%w: %s: %v", ErrBadExpression, expression, err)
+		}
+		check = func(values []string) bool {
+			for _, value := range values {
+				if !re.MatchString(strings.TrimSpace(value)) {
+					return false
+				}
+			}
+			return len(values) > 0
+		}
+	}
+	if (operator == "be non-empty") != (match[3] == "") {
+		return Rule{}, fmt.Errorf("%w: %s", ErrBadExpression, expression)
+	}
+
+	return Rule{
+		ID:          id,
+		Severity:    severity,
+		Description: strings.TrimSpace(expression),
+		Check: func(epubReader *EpubReader) []Issue {
+			rootfile := epubReader.Rootfiles[0]
+			if !check(lookupPath(reflect.ValueOf(rootfile.Package), path)) {
+				return []Issue{{Path: rootfile.FullPath, Message: strings.TrimSpace(expression)}}
+			}
+			return nil
+		},
+	}, nil
+}
+
+// lookupPath returns the string values found at path below v.
+func lookupPath(v reflect.Value, path []string) []string {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return lookupPath(v.Elem(), path)
+	case reflect.Slice:
+		var values []string
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, lookupPath(v.Index(i), path)...)
+		}
+		return values
+	case reflect.String:
+		if len(path) == 0 {
+			return []string{v.String()}
+		}
+	case reflect.Struct:
+		if len(path) == 0 {
+			if text := v.FieldByName("Text"); text.IsValid() && text.Kind() == reflect.String {
+				return []string{text.String()}
+			}
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.PkgPath == "" && strings.EqualFold(field.Name, path[0]) {
+				return lookupPath(v.Field(i), path[1:])
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package epub
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpressionRule(t *testing.T) {
+	reader := openTestEpub(t)
+
+	for expression, pass := range map[string]bool{
+		`metadata.publisher must be non-empty`:    true,
+		`metadata.description must be non-empty`:  false,
+		`metadata.language must equal "en"`:       true,
+		`metadata.language must equal "fr"`:       false,
+		`metadata.identifier must match "^978"`:   true,
+		`metadata.creator.fileas must match ", "`: true,
+		`manifest.item.mediatype must match "^a"`: false,
+		`version must equal "2.0"`:                true,
+		`metadata.nothing must be non-empty`:      false,
+	} {
+		rule, err := ExpressionRule("house", SeverityWarning, expression)
+		if err != nil {
+			t.Errorf("ExpressionRule(%q) = %v", expression, err)
+			continue
+		}
+		if issues := rule.Check(&reader.EpubReader); (len(issues) == 0) != pass {
+			t.Errorf("%q: Check() = %v", expression, issues)
+		}
+	}
+
+	for _, expression := range []string{
+		`metadata.publisher`,
+		`metadata.publisher must be non-empty "x"`,
+		`metadata.language must equal`,
+		`metadata.language must match "("`,
+	} {
+		if _, err := ExpressionRule("bad", SeverityError, expression); !errors.Is(err, ErrBadExpression) {
+			t.Errorf("ExpressionRule(%q) = %v", expression, err)
+		}
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	rule, err := ExpressionRule("house-description", SeverityWarning, `metadata.description must be non-empty`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterRule(rule); err != nil {
+		t.Fatalf("RegisterRule() = %v", err)
+	}
+	defer UnregisterRule(rule.ID)
+
+	if err := RegisterRule(rule); !errors.Is(err, ErrDuplicateRule) {
+		t.Errorf("RegisterRule(again) = %v", err)
+	}
+
+	issues := openTestEpub(t).Validate()
+	if len(issues) != 1 || issues[0].RuleID != "house-description" || issues[0].Severity != SeverityWarning {
+		t.Errorf("Validate() = %v", issues)
+	}
+}
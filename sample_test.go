@@ -0,0 +1,62 @@
+package epub
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the sample books of testdata")
+
+// buildTestEpub3 returns the bytes of the book of buildTestEpub upgraded to
+// EPUB 3, with a navigation document.
+func buildTestEpub3(t testing.TB, files ...testFile) []byte {
+	t.Helper()
+
+	opf := strings.Replace(testOPF, `version="2.0"`, `version="3.0"`, 1)
+	opf = strings.Replace(opf, `<item id="ncx"`, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx"`, 1)
+	opf = strings.Replace(opf, `</metadata>`, `  <meta property="dcterms:modified">2020-01-01T00:00:00Z</meta>
+  </metadata>`, 1)
+	nav := strings.ReplaceAll(testNav, `href="text/`, `href="`)
+
+	return buildTestEpub(t, append([]testFile{{"OEBPS/content.opf", opf}, {"OEBPS/nav.xhtml", nav}}, files...)...)
+}
+
+// TestSamples checks that the sample books of testdata, used by the
+// examples, are the test books and are valid. Run go test -run TestSamples
+// -update to rewrite them.
+func TestSamples(t *testing.T) {
+	for _, sample := range []struct {
+		filename string
+		book     []byte
+	}{
+		{"testdata/sample2.epub", buildTestEpub(t)},
+		{"testdata/sample3.epub", buildTestEpub3(t)},
+	} {
+		if *update {
+			if err := ioutil.WriteFile(sample.filename, sample.book, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		book, err := ioutil.ReadFile(sample.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(book, sample.book) {
+			t.Errorf("%s is out of date: run go test -run TestSamples -update", sample.filename)
+		}
+
+		reader, err := OpenBuffer(book, int64(len(book)))
+		if err != nil {
+			t.Fatalf("%s: %v", sample.filename, err)
+		}
+		if issues := reader.Validate(); len(issues) != 0 {
+			t.Errorf("%s: Validate() = %v", sample.filename, issues)
+		}
+		reader.Close()
+	}
+}
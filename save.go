@@ -0,0 +1,94 @@
+package epub
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveOptions tunes SafeWriteFile.
+type SaveOptions struct {
+	// Backup keeps the previous content of the file as filename.bak.
+	Backup bool
+}
+
+// SafeWriteFile replaces filename with the output of write without ever
+// leaving a partial file behind: the output goes to a temporary file of the
+// same directory, which is synced and renamed over filename once write
+// succeeds. The mode of an existing file is preserved.
+func SafeWriteFile(filename string, options SaveOptions, write func(w io.Writer) error) error {
+	dir := filepath.Dir(filename)
+	temp, err := os.CreateTemp(longPath(dir), "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+	tempName := temp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			temp.Close()
+			os.Remove(tempName)
+		}
+	}()
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(longPath(filename)); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+
+	if err := write(temp); err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+	if err := temp.Chmod(mode); err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+	if err := temp.Sync(); err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+
+	if options.Backup {
+		if err := backupFile(filename); err != nil {
+			return fmt.Errorf("epub: save %s: backup: %w", filename, err)
+		}
+	}
+
+	if err := os.Rename(tempName, longPath(filename)); err != nil {
+		return fmt.Errorf("epub: save %s: %w", filename, err)
+	}
+	committed = true
+
+	// Sync the directory so that the rename survives a crash. Not all
+	// platforms support it, hence the ignored errors.
+	if d, err := os.Open(longPath(dir)); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+// backupFile copies filename, if it exists, to filename.bak.
+func backupFile(filename string) error {
+	backup := filename + ".bak"
+	os.Remove(longPath(backup))
+	if err := os.Link(longPath(filename), longPath(backup)); err == nil || os.IsNotExist(err) {
+		return nil
+	}
+
+	source, err := os.Open(longPath(filename))
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	return SafeWriteFile(backup, SaveOptions{}, func(w io.Writer) error {
+		_, err := io.Copy(w, source)
+		return err
+	})
+}
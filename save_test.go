@@ -0,0 +1,58 @@
+package epub
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSafeWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "book.epub")
+	write := func(content string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+
+	if err := SafeWriteFile(filename, SaveOptions{Backup: true}, write("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filename, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SafeWriteFile(filename, SaveOptions{Backup: true}, write("two")); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(filename); string(data) != "two" {
+		t.Errorf("content = %q", data)
+	}
+	if data, _ := os.ReadFile(filename + ".bak"); string(data) != "one" {
+		t.Errorf("backup = %q", data)
+	}
+	if info, err := os.Stat(filename); err != nil || runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, %v", info.Mode(), err)
+	}
+
+	failure := errors.New("failure")
+	err := SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("SafeWriteFile() = %v", err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "two" {
+		t.Errorf("content after failure = %q", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Errorf("directory holds %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,165 @@
+package epub
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// scanContext is the number of runes of text kept on each side of a match.
+const scanContext = 40
+
+// ScanMatch is a match of a Scanner in the text of a content document.
+type ScanMatch struct {
+	// Rule names the list or pattern that matched.
+	Rule string
+	Text string
+	// Document is the href of the content document, relative to the
+	// package document.
+	Document string
+	// Offset is the byte offset of the match in the text of the document,
+	// as ItemText returns it.
+	Offset int
+	// Context is the text around the match, whitespace collapsed.
+	Context string
+}
+
+// Scanner finds matches in the text of a content document. It is the
+// integration point for content screening: this package provides no list,
+// KeywordScanner and PatternScanner match those of the caller. Scanners
+// set the Rule, Text and Offset of their matches.
+type Scanner interface {
+	Scan(ctx context.Context, text string) ([]ScanMatch, error)
+}
+
+// ScannerFunc adapts a function to the Scanner interface.
+type ScannerFunc func(ctx context.Context, text string) ([]ScanMatch, error)
+
+// Scan calls f.
+func (f ScannerFunc) Scan(ctx context.Context, text string) ([]ScanMatch, error) {
+	return f(ctx, text)
+}
+
+// KeywordScanner returns a scanner matching the keywords as whole words,
+// ignoring case. Longer keywords are preferred when keywords overlap.
+func KeywordScanner(rule string, keywords []string) Scanner {
+	var quoted []string
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			quoted = append(quoted, regexp.QuoteMeta(keyword))
+		}
+	}
+	if len(quoted) == 0 {
+		return ScannerFunc(func(ctx context.Context, text string) ([]ScanMatch, error) { return nil, nil })
+	}
+	sort.SliceStable(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	pattern := regexp.MustCompile(`(?i)(?:` + strings.Join(quoted, "|") + `)`)
+
+	return ScannerFunc(func(ctx context.Context, text string) ([]ScanMatch, error) {
+		var matches []ScanMatch
+		for start := 0; start < len(text); {
+			location := pattern.FindStringIndex(text[start:])
+			if location == nil {
+				break
+			}
+			from, to := start+location[0], start+location[1]
+			if isWordBoundary(text, from) && isWordBoundary(text, to) {
+				matches = append(matches, ScanMatch{Rule: rule, Text: text[from:to], Offset: from})
+				start = to
+				continue
+			}
+			_, size := utf8.DecodeRuneInString(text[from:])
+			start = from + size
+		}
+		return matches, nil
+	})
+}
+
+// PatternScanner returns a scanner matching the regular expressions.
+func PatternScanner(rule string, patterns ...*regexp.Regexp) Scanner {
+	return ScannerFunc(func(ctx context.Context, text string) ([]ScanMatch, error) {
+		var matches []ScanMatch
+		for _, pattern := range patterns {
+			for _, location := range pattern.FindAllStringIndex(text, -1) {
+				if location[0] < location[1] {
+					matches = append(matches, ScanMatch{Rule: rule, Text: text[location[0]:location[1]], Offset: location[0]})
+				}
+			}
+		}
+		return matches, nil
+	})
+}
+
+// isWordBoundary reports whether the byte offset i of text is not between
+// two letters or digits.
+func isWordBoundary(text string, i int) bool {
+	if i == 0 || i == len(text) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i:])
+
+	return !isWordRune(before) || !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) || r == '_'
+}
+
+// ScanText runs the scanners over the text of the spine documents and
+// returns their matches, in reading order.
+func (epubReader *EpubReader) ScanText(ctx context.Context, scanners ...Scanner) ([]ScanMatch, error) {
+	var matches []ScanMatch
+	extractor := epubReader.newTextExtractor(TextOptions{})
+
+	for _, item := range epubReader.SpineItems() {
+		if err := ctx.Err(); err != nil {
+			return matches, err
+		}
+		text, err := extractor.itemText(item)
+		if err != nil {
+			return matches, err
+		}
+
+		var found []ScanMatch
+		for _, scanner := range scanners {
+			scanned, err := scanner.Scan(ctx, text)
+			if err != nil {
+				return matches, fmt.Errorf("epub: %s: scan '%s': %w", epubReader.Name, item.Href, err)
+			}
+			found = append(found, scanned...)
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Offset < found[j].Offset })
+		for _, match := range found {
+			match.Document = item.Href
+			match.Context = matchContext(text, match.Offset, match.Offset+len(match.Text))
+			matches = append(matches, match)
+		}
+	}
+
+	return matches, nil
+}
+
+// matchContext returns the text around the bytes from to of text.
+func matchContext(text string, from, to int) string {
+	if from < 0 || to > len(text) || from > to {
+		return ""
+	}
+
+	start := from
+	for i := 0; i < scanContext && start > 0; i++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := to
+	for i := 0; i < scanContext && end < len(text); i++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	return collapseSpace(text[start:end])
+}
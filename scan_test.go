@@ -0,0 +1,53 @@
+package epub
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestKeywordScanner(t *testing.T) {
+	scanner := KeywordScanner("words", []string{"dark", "dark night", "END", " "})
+	matches, err := scanner.Scan(context.Background(), "Darkness, dark night; the End, endless, weekend.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	for _, match := range matches {
+		texts = append(texts, match.Text)
+	}
+	if want := []string{"dark night", "End"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("Scan() = %q, want %q", texts, want)
+	}
+	if matches[1].Offset != 26 || matches[1].Rule != "words" {
+		t.Errorf("Scan()[1] = %+v", matches[1])
+	}
+}
+
+func TestScanText(t *testing.T) {
+	reader := openTestEpub(t)
+	matches, err := reader.ScanText(context.Background(),
+		KeywordScanner("weather", []string{"stormy"}),
+		PatternScanner("chapters", regexp.MustCompile(`Chapter \w+`)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ScanMatch{
+		{Rule: "chapters", Text: "Chapter One", Document: "chapter1.xhtml", Offset: 0, Context: "Chapter One It was a dark & stormy night."},
+		{Rule: "weather", Text: "stormy", Document: "chapter1.xhtml", Offset: 29, Context: "Chapter One It was a dark & stormy night."},
+		{Rule: "chapters", Text: "Chapter Two", Document: "chapter2.xhtml", Offset: 0, Context: "Chapter Two The end."},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("ScanText() = %+v", matches)
+	}
+
+	failing := ScannerFunc(func(ctx context.Context, text string) ([]ScanMatch, error) {
+		return nil, errors.New("service down")
+	})
+	if _, err := reader.ScanText(context.Background(), failing); err == nil {
+		t.Error("ScanText() with a failing scanner succeeded")
+	}
+}
@@ -0,0 +1,362 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of scrubbed content.
+const (
+	ScrubbedFile      = "file"
+	ScrubbedMetadata  = "metadata"
+	ScrubbedWatermark = "watermark"
+)
+
+// ScrubOptions tunes the scrubber.
+type ScrubOptions struct {
+	// EmbedAudit appends the audit record of the scrubbing to the audit log
+	// of the book at AuditPath. The record names what was removed, without
+	// the removed values.
+	EmbedAudit bool
+	// StripImageMetadata removes the EXIF, XMP and text metadata of the
+	// JPEG and PNG images, such as the GPS position of photos. See
+	// StripImageMetadata.
+	StripImageMetadata bool
+}
+
+// Scrubbed is something removed by the scrubber.
+type Scrubbed struct {
+	// Path is the file removed, or the file it was removed from.
+	Path string
+	// Kind is ScrubbedFile, ScrubbedMetadata or ScrubbedWatermark.
+	Kind string
+	// Text is the markup removed from Path, whitespace collapsed.
+	Text string
+}
+
+// ScrubResult reports what the scrubber removed.
+type ScrubResult struct {
+	Removed []Scrubbed
+	Audit   AuditRecord
+}
+
+var (
+	// vendorFiles are the files retailers and reading applications add to
+	// the books they sell or open.
+	vendorFiles = regexp.MustCompile(`(?i)^(itunesmetadata(-original)?\.plist|itunesartwork|META-INF/calibre_bookmarks\.txt)$`)
+	// watermarkNames match the names of watermark files, metas and
+	// elements.
+	watermarkNames = regexp.MustCompile(`(?i)watermark|receipt|purchase|transaction|customer|buyer|order[-_ ]?(id|number)`)
+	watermarkText  = regexp.MustCompile(`(?i)\b(licen[cs]ed|purchased|sold|bought|issued)\s+(to|by|for)\b`)
+	emailPattern   = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+)
+
+// WriteScrubbed writes to w a copy of the book cleaned of its vendor and
+// personal data, to be shared:
+//
+//   - the files retailers and applications add outside the manifest, such
+//     as iTunesMetadata.plist or calibre bookmarks, and watermark or
+//     receipt files;
+//   - the metadata naming a watermark, a purchase or a customer, and the
+//     metadata holding an email address;
+//   - in content documents, the comments naming a watermark or holding an
+//     email address, the elements whose id or class names a watermark, and
+//     the elements whose text licenses the book to an email address;
+//   - with StripImageMetadata, the metadata of the images.
+//
+// Manifest items are never removed, so that the package stays valid.
+func (epubReader *EpubReader) WriteScrubbed(w io.Writer, options ScrubOptions) (ScrubResult, error) {
+	result := ScrubResult{Audit: AuditRecord{Operation: "scrub", Time: time.Now().UTC()}}
+	replacements := make(map[string][]byte)
+
+	for _, name := range epubReader.FileNames() {
+		if _, ok := epubReader.itemByPath(name); ok || !isVendorFile(name) {
+			continue
+		}
+		replacements[name] = nil
+		size := int64(epubReader.fileSize(name))
+		result.Removed = append(result.Removed, Scrubbed{Path: name, Kind: ScrubbedFile})
+		result.Audit.Files = append(result.Audit.Files, AuditFile{Path: name, Change: AuditRemoved, Before: size})
+		result.Audit.SavedBytes += size
+	}
+
+	for _, rootfile := range epubReader.Rootfiles {
+		if err := epubReader.scrubFile(rootfile.FullPath, ScrubbedMetadata, scrubMetadata, replacements, &result); err != nil {
+			return result, err
+		}
+	}
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.MediaType != "application/xhtml+xml" && item.MediaType != "text/html" {
+			continue
+		}
+		if err := epubReader.scrubFile(epubReader.itemPath(item.Href), ScrubbedWatermark, scrubWatermark, replacements, &result); err != nil {
+			return result, err
+		}
+	}
+
+	if options.StripImageMetadata {
+		for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+			if !isImage(item) {
+				continue
+			}
+			name := epubReader.itemPath(item.Href)
+			buffer, err := epubReader.readFile(name)
+			if errors.Is(err, ErrorFileMissing) {
+				continue
+			}
+			if err != nil {
+				return result, err
+			}
+			stripped := StripImageMetadata(buffer.Bytes())
+			if len(stripped) == buffer.Len() {
+				continue
+			}
+			replacements[name] = stripped
+			result.Removed = append(result.Removed, Scrubbed{Path: name, Kind: ScrubbedMetadata})
+			result.Audit.Files = append(result.Audit.Files, AuditFile{Path: name, Change: AuditModified, Before: int64(buffer.Len()), After: int64(len(stripped))})
+			result.Audit.SavedBytes += int64(buffer.Len() - len(stripped))
+		}
+	}
+
+	if options.EmbedAudit {
+		var err error
+		if replacements[AuditPath], err = epubReader.appendAuditLog(result.Audit); err != nil {
+			return result, err
+		}
+	}
+
+	return result, epubReader.writeZip(w, replacements)
+}
+
+// ScrubFile scrubs the EPUB at filename in place, with the guarantees of
+// SafeWriteFile.
+func ScrubFile(filename string, options ScrubOptions) (ScrubResult, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return ScrubResult{}, err
+	}
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteScrubbed(&buffer, options)
+	reader.Close()
+	if err != nil {
+		return result, err
+	}
+
+	return result, SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+func isVendorFile(name string) bool {
+	if vendorFiles.MatchString(name) {
+		return true
+	}
+	base := path.Base(name)
+
+	return watermarkNames.MatchString(strings.TrimSuffix(base, path.Ext(base)))
+}
+
+// scrubFile removes from the file name the markup matching rule, and
+// records the removals as kind.
+func (epubReader *EpubReader) scrubFile(name, kind string, rule scrubRule, replacements map[string][]byte, result *ScrubResult) error {
+	buffer, err := epubReader.readFile(name)
+	if err != nil {
+		return err
+	}
+
+	scrubbed, removed, fields := scrubMarkup(buffer.Bytes(), rule)
+	if len(removed) == 0 {
+		return nil
+	}
+
+	replacements[name] = scrubbed
+	for _, text := range removed {
+		result.Removed = append(result.Removed, Scrubbed{Path: name, Kind: kind, Text: text})
+	}
+	saved := int64(buffer.Len() - len(scrubbed))
+	result.Audit.Files = append(result.Audit.Files, AuditFile{
+		Path:    name,
+		Change:  AuditModified,
+		Before:  int64(buffer.Len()),
+		After:   int64(len(scrubbed)),
+		Details: fields,
+	})
+	if kind == ScrubbedMetadata {
+		for _, field := range fields {
+			result.Audit.Metadata = append(result.Audit.Metadata, AuditMetadata{Field: field})
+		}
+	}
+	result.Audit.SavedBytes += saved
+
+	return nil
+}
+
+// scrubRule decides whether to remove a comment, when element is nil, or
+// an element, given the name of its parent and its text without the
+// removed descendants. It returns the name of what it removes.
+type scrubRule func(element *xml.StartElement, parent, text string) (string, bool)
+
+// scrubMetadata removes the vendor and personal metadata of a package
+// document.
+func scrubMetadata(element *xml.StartElement, parent, text string) (string, bool) {
+	if element == nil || parent != "metadata" {
+		return "", false
+	}
+
+	field := element.Name.Local
+	if element.Name.Local == "meta" {
+		field = attr(*element, "property")
+		if field == "" {
+			field = attr(*element, "name")
+		}
+		if watermarkNames.MatchString(field) {
+			return field, true
+		}
+	}
+	if emailPattern.MatchString(text) || emailPattern.MatchString(attr(*element, "content")) {
+		return field, true
+	}
+
+	return "", false
+}
+
+// scrubWatermark removes the watermarks of a content document.
+func scrubWatermark(element *xml.StartElement, parent, text string) (string, bool) {
+	if element == nil {
+		return "comment", watermarkNames.MatchString(text) || emailPattern.MatchString(text)
+	}
+
+	name := strings.ToLower(element.Name.Local)
+	switch {
+	case watermarkNames.MatchString(attr(*element, "id")) || watermarkNames.MatchString(attr(*element, "class")):
+	case name == "meta" && watermarkNames.MatchString(attr(*element, "name")):
+	case watermarkText.MatchString(text) && emailPattern.MatchString(text):
+	default:
+		return "", false
+	}
+
+	return name, true
+}
+
+// scrubFrame is an open element of scrubMarkup.
+type scrubFrame struct {
+	element xml.StartElement
+	start   int
+	text    strings.Builder
+}
+
+// scrubMarkup removes from the markup data the comments and the elements
+// matching rule, with their line when they are alone on it. It returns the
+// new markup, the removed markup and the names rule gave to the removals.
+// Markup that cannot be parsed is left unchanged.
+func scrubMarkup(data []byte, rule scrubRule) ([]byte, []string, []string) {
+	decoder := rawOffsets(newXHTMLDecoder(bytes.NewReader(data)))
+
+	type span struct {
+		start, end int
+		name       string
+	}
+	var spans []span
+	remove := func(start, end int, name string) {
+		// Drop the removals within the new one.
+		for len(spans) > 0 && spans[len(spans)-1].start >= start {
+			spans = spans[:len(spans)-1]
+		}
+		spans = append(spans, span{start, end, name})
+	}
+
+	var stack []*scrubFrame
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return data, nil, nil
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, &scrubFrame{element: t.Copy(), start: offset})
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			frame := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].element.Name.Local
+			}
+			if name, ok := rule(&frame.element, parent, frame.text.String()); ok {
+				remove(frame.start, int(decoder.InputOffset()), name)
+			} else if len(stack) > 0 {
+				stack[len(stack)-1].text.WriteString(frame.text.String())
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.Comment:
+			if name, ok := rule(nil, "", string(t)); ok {
+				remove(offset, int(decoder.InputOffset()), name)
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return data, nil, nil
+	}
+
+	output := editedBuffer(data)
+	var removed, names []string
+	last := 0
+	for _, s := range spans {
+		removed = append(removed, collapseSpace(string(data[s.start:s.end])))
+		names = append(names, s.name)
+		start, end := lineSpan(data, s.start, s.end)
+		if start < last {
+			start = last
+		}
+		output.Write(data[last:start])
+		last = end
+	}
+	output.Write(data[last:])
+	sort.Strings(names)
+
+	return output.Bytes(), removed, names
+}
+
+// lineSpan extends the span from start to end to its whole line, if it is
+// alone on it.
+func lineSpan(data []byte, start, end int) (int, int) {
+	lineStart := start
+	for lineStart > 0 && (data[lineStart-1] == ' ' || data[lineStart-1] == '\t') {
+		lineStart--
+	}
+	if lineStart > 0 && data[lineStart-1] != '\n' {
+		return start, end
+	}
+
+	lineEnd := end
+	for lineEnd < len(data) && (data[lineEnd] == ' ' || data[lineEnd] == '\t' || data[lineEnd] == '\r') {
+		lineEnd++
+	}
+	switch {
+	case lineEnd == len(data):
+		return lineStart, lineEnd
+	case data[lineEnd] == '\n':
+		return lineStart, lineEnd + 1
+	}
+
+	return start, end
+}
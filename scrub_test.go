@@ -0,0 +1,111 @@
+package epub
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteScrubbed(t *testing.T) {
+	opf := strings.Replace(testOPF, `<meta name="cover" content="cover-image"/>`, `<meta name="cover" content="cover-image"/>
+    <meta name="booxtream:watermark" content="W-1234"/>
+    <dc:rights>Licensed to jane@example.com</dc:rights>`, 1)
+	reader := openTestEpub(t,
+		testFile{"OEBPS/content.opf", opf},
+		testFile{"OEBPS/chapter1.xhtml", `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>Chapter 1</title></head>
+<body>
+  <!-- order 42, jane@example.com -->
+  <h1>Chapter 1</h1>
+  <div class="wm-watermark"><p>Copy 42</p></div>
+  <p>It was a dark and stormy night.</p>
+  <p class="footer">This book is licensed to <a href="mailto:jane@example.com">jane@example.com</a>.</p>
+  <p>Write to <a href="mailto:author@example.com">the author</a>.</p>
+</body>
+</html>`},
+		testFile{"iTunesMetadata.plist", "<plist/>"},
+		testFile{"META-INF/calibre_bookmarks.txt", "bookmarks"},
+	)
+
+	var buffer bytes.Buffer
+	result, err := reader.WriteScrubbed(&buffer, ScrubOptions{EmbedAudit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []string
+	for _, scrubbed := range result.Removed {
+		removed = append(removed, scrubbed.Kind+" "+scrubbed.Path)
+	}
+	want := []string{
+		"file iTunesMetadata.plist",
+		"file META-INF/calibre_bookmarks.txt",
+		"metadata OEBPS/content.opf",
+		"metadata OEBPS/content.opf",
+		"watermark OEBPS/chapter1.xhtml",
+		"watermark OEBPS/chapter1.xhtml",
+		"watermark OEBPS/chapter1.xhtml",
+	}
+	if !reflect.DeepEqual(removed, want) {
+		t.Errorf("Removed = %v", removed)
+	}
+	if fields := result.Audit.Metadata; len(fields) != 2 || fields[0].Field != "booxtream:watermark" || fields[1].Field != "rights" {
+		t.Errorf("Audit.Metadata = %+v", fields)
+	}
+
+	scrubbed, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := scrubbed.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+	for _, name := range []string{"iTunesMetadata.plist", "META-INF/calibre_bookmarks.txt"} {
+		if scrubbed.hasFile(name) {
+			t.Errorf("%s not removed", name)
+		}
+	}
+	chapter, err := scrubbed.readFile("OEBPS/chapter1.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<body>
+  <h1>Chapter 1</h1>
+  <p>It was a dark and stormy night.</p>
+  <p>Write to <a href="mailto:author@example.com">the author</a>.</p>
+</body>`; !strings.Contains(chapter.String(), want) {
+		t.Errorf("chapter1.xhtml = %s", chapter)
+	}
+
+	audit, err := scrubbed.readFile(AuditPath)
+	if err != nil || strings.Contains(audit.String(), "example.com") {
+		t.Errorf("audit log = %s, %v", audit, err)
+	}
+	if got := firstTitle(scrubbed.Rootfiles[0].Metadata.Title); got != "The Test Book" {
+		t.Errorf("Title = %q", got)
+	}
+}
+
+func TestScrubFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(filename, buildTestEpub(t, testFile{"iTunesArtwork", "jpeg"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ScrubFile(filename, ScrubOptions{})
+	if err != nil || len(result.Removed) != 1 {
+		t.Fatalf("ScrubFile() = %+v, %v", result, err)
+	}
+
+	reader, err := OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if reader.hasFile("iTunesArtwork") || reader.hasFile(AuditPath) {
+		t.Errorf("FileNames() = %v", reader.FileNames())
+	}
+}
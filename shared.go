@@ -0,0 +1,143 @@
+package epub
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrBookClosed occurs when a shared book is used after Close.
+var ErrBookClosed = errors.New("epub: book closed")
+
+// SharedBook is an opened book serving concurrent requests, as in a
+// multi-user book server. Its methods are safe for concurrent use: each
+// request gets its own item readers, the table of contents is parsed once,
+// and Close waits for the reads in flight.
+//
+// The reader of a shared book must not be changed once shared, by
+// SelectVersion or ApplyQuirks for instance.
+type SharedBook struct {
+	reader *EpubReaderCloser
+
+	mutex  sync.RWMutex
+	closed bool
+	reads  sync.WaitGroup
+
+	tocOnce sync.Once
+	toc     []TOCEntry
+	tocErr  error
+}
+
+// OpenShared opens the book at filename to be shared.
+func OpenShared(filename string) (*SharedBook, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSharedBook(reader), nil
+}
+
+// NewSharedBook shares reader, which is closed by the Close method of the
+// shared book.
+func NewSharedBook(reader *EpubReaderCloser) *SharedBook {
+	return &SharedBook{reader: reader}
+}
+
+// begin registers a read, unless the book is closed.
+func (book *SharedBook) begin() error {
+	book.mutex.RLock()
+	defer book.mutex.RUnlock()
+
+	if book.closed {
+		return fmt.Errorf("epub: %s: %w", book.reader.Name, ErrBookClosed)
+	}
+	book.reads.Add(1)
+
+	return nil
+}
+
+// Open returns a reader of the file name of the container. The book is
+// not closed until the reader is.
+func (book *SharedBook) Open(name string) (io.ReadCloser, error) {
+	if err := book.begin(); err != nil {
+		return nil, err
+	}
+
+	reader, err := book.reader.openFile(name)
+	if err != nil {
+		book.reads.Done()
+		return nil, err
+	}
+
+	return &sharedFile{ReadCloser: reader, done: book.reads.Done}, nil
+}
+
+// OpenItem returns a reader of the manifest item with the given id.
+func (book *SharedBook) OpenItem(id string) (io.ReadCloser, error) {
+	item, ok := book.reader.ItemByID(id)
+	if !ok {
+		return nil, fmt.Errorf("epub: %s: '%s': %w", book.reader.Name, id, ErrNoItem)
+	}
+
+	return book.Open(book.reader.itemPath(item.Href))
+}
+
+// Do calls fn with the reader of the book, which is not closed until fn
+// returns. fn must not change the reader.
+func (book *SharedBook) Do(fn func(epubReader *EpubReader) error) error {
+	if err := book.begin(); err != nil {
+		return err
+	}
+	defer book.reads.Done()
+
+	return fn(&book.reader.EpubReader)
+}
+
+// TOC returns the table of contents of the book, parsed on the first call.
+// Callers must not change the entries.
+func (book *SharedBook) TOC() ([]TOCEntry, error) {
+	err := book.Do(func(epubReader *EpubReader) error {
+		book.tocOnce.Do(func() {
+			book.toc, book.tocErr = epubReader.TOC()
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return book.toc, book.tocErr
+}
+
+// Close waits for the reads in flight, including the open item readers,
+// then closes the book. Reads started after Close fail with ErrBookClosed.
+// Close may be called several times.
+func (book *SharedBook) Close() {
+	book.mutex.Lock()
+	if book.closed {
+		book.mutex.Unlock()
+		return
+	}
+	book.closed = true
+	book.mutex.Unlock()
+
+	book.reads.Wait()
+	book.reader.Close()
+}
+
+// sharedFile is an item reader of a shared book, which ends its read when
+// closed.
+type sharedFile struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (file *sharedFile) Close() error {
+	err := file.ReadCloser.Close()
+	file.once.Do(file.done)
+
+	return err
+}
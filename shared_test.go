@@ -0,0 +1,73 @@
+package epub
+
+import (
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedBook(t *testing.T) {
+	book, err := OpenShared("testdata/sample3.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reader, err := book.OpenItem("chapter1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer reader.Close()
+			if data, err := ioutil.ReadAll(reader); err != nil || len(data) == 0 {
+				t.Errorf("ReadAll() = %d bytes, %v", len(data), err)
+			}
+			if toc, err := book.TOC(); err != nil || len(toc) != 2 {
+				t.Errorf("TOC() = %v, %v", toc, err)
+			}
+			book.Do(func(epubReader *EpubReader) error {
+				if metadata := epubReader.Metadata(); len(metadata.Titles) == 0 {
+					t.Error("Metadata() has no title")
+				}
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	// Close waits for the open item reader.
+	reader, err := book.Open("OEBPS/chapter2.xhtml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	go func() {
+		book.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("Close() returned with a read in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if _, err := ioutil.ReadAll(reader); err != nil {
+		t.Errorf("read while closing: %v", err)
+	}
+	reader.Close()
+	reader.Close()
+	<-closed
+
+	if _, err := book.Open("OEBPS/chapter2.xhtml"); !errors.Is(err, ErrBookClosed) {
+		t.Errorf("Open() after Close() = %v", err)
+	}
+	if err := book.Do(func(*EpubReader) error { return nil }); !errors.Is(err, ErrBookClosed) {
+		t.Errorf("Do() after Close() = %v", err)
+	}
+	book.Close()
+}
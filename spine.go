@@ -0,0 +1,15 @@
+package epub
+
+// SpineItems returns the manifest items of the spine, in reading order.
+// Itemrefs without a matching manifest item are skipped.
+func (epubReader *EpubReader) SpineItems() []Item {
+	var items []Item
+
+	for _, itemref := range epubReader.Rootfiles[0].Spine.Itemref {
+		if item, ok := epubReader.ItemByID(itemref.Idref); ok {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,295 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RuleSpineLink is the RuleID of the issues WriteWithSpineOrder reports:
+// links leading to the next or the previous document of the spine, which
+// no longer do once it is reordered.
+const RuleSpineLink = "spine-link"
+
+// ErrBadSpineOrder occurs when the idrefs given to WriteWithSpineOrder are
+// not those of the spine.
+var ErrBadSpineOrder = errors.New("epub: not an order of the spine")
+
+var playOrderPattern = regexp.MustCompile(`playOrder\s*=\s*("[^"]*"|'[^']*')`)
+
+// WriteWithSpineOrder writes to w a copy of the book whose spine lists the
+// itemrefs in the order of idrefs, such as to move the front matter after
+// the content as some stores want. Itemrefs keep their attributes, and the
+// playOrder of the NCX is renumbered to follow the new reading order. The
+// returned issues, of rule RuleSpineLink, are the links that led to the
+// next or the previous document, by their rel or their target, and no
+// longer do.
+func (epubReader *EpubReader) WriteWithSpineOrder(w io.Writer, idrefs []string) ([]Issue, error) {
+	rootfile := epubReader.Rootfiles[0]
+	var old []string
+	for _, itemref := range rootfile.Spine.Itemref {
+		old = append(old, itemref.Idref)
+	}
+	if !isPermutation(old, idrefs) {
+		return nil, fmt.Errorf("epub: %s: %w: %v", epubReader.Name, ErrBadSpineOrder, idrefs)
+	}
+
+	issues, err := epubReader.spineLinkIssues(old, idrefs)
+	if err != nil {
+		return nil, err
+	}
+
+	replacements := make(map[string][]byte)
+	buffer, err := epubReader.readFile(rootfile.FullPath)
+	if err != nil {
+		return issues, err
+	}
+	if replacements[rootfile.FullPath], err = epubReader.packageWithSpineOrder(buffer.Bytes(), old, idrefs); err != nil {
+		return issues, err
+	}
+
+	if item, ok := epubReader.ncxItem(); ok {
+		var spine []string
+		for _, idref := range idrefs {
+			if item, ok := epubReader.ItemByID(idref); ok {
+				spine = append(spine, epubReader.itemPath(item.Href))
+			}
+		}
+		name := epubReader.itemPath(item.Href)
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return issues, err
+		}
+		replacements[name] = ncxWithPlayOrder(name, buffer.Bytes(), spine)
+	}
+
+	return issues, epubReader.writeZip(w, replacements)
+}
+
+// SetSpineOrderFile reorders the spine of the EPUB at filename in place,
+// as WriteWithSpineOrder does, with the guarantees of SafeWriteFile.
+func SetSpineOrderFile(filename string, idrefs []string) ([]Issue, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	issues, err := reader.WriteWithSpineOrder(&buffer, idrefs)
+	reader.Close()
+	if err != nil {
+		return issues, err
+	}
+
+	return issues, SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// isPermutation reports whether b holds the strings of a in another order.
+func isPermutation(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, s := range a {
+		counts[s]++
+	}
+	for _, s := range b {
+		if counts[s]--; counts[s] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// packageWithSpineOrder returns the package document data with its
+// itemrefs, listed in old, moved to the order of idrefs.
+func (epubReader *EpubReader) packageWithSpineOrder(data []byte, old, idrefs []string) ([]byte, error) {
+	spans := findElements(data, func(stack []xml.StartElement) bool {
+		n := len(stack)
+		return n > 1 && stack[n-1].Name.Local == "itemref" && stack[n-2].Name.Local == "spine"
+	}, -1)
+	if len(spans) != len(old) {
+		return nil, fmt.Errorf("epub: %s: '%s': cannot locate the itemrefs", epubReader.Name, epubReader.Rootfiles[0].FullPath)
+	}
+
+	markup := make(map[string][]string)
+	for i, span := range spans {
+		markup[old[i]] = append(markup[old[i]], string(data[span.start:span.end]))
+	}
+	var b bytes.Buffer
+	end := 0
+	for i, span := range spans {
+		b.Write(data[end:span.start])
+		b.WriteString(markup[idrefs[i]][0])
+		markup[idrefs[i]] = markup[idrefs[i]][1:]
+		end = span.end
+	}
+	b.Write(data[end:])
+
+	return b.Bytes(), nil
+}
+
+// ncxWithPlayOrder returns the NCX name, whose content is data, with the
+// playOrder of its navPoints, pageTargets and navTargets renumbered to
+// follow spine, the zip paths of the spine documents. Targets outside the
+// spine come last, and targets in the same document keep their order.
+// Markup that cannot be parsed is left unchanged.
+func ncxWithPlayOrder(name string, data []byte, spine []string) []byte {
+	type target struct {
+		start, end int
+		src        string
+	}
+	var targets []target
+
+	decoder := rawOffsets(newXHTMLDecoder(bytes.NewReader(data)))
+	// open holds the targets whose content is still to be found.
+	var open []int
+	for {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return data
+		}
+		if t, ok := token.(xml.StartElement); ok {
+			if attr(t, "playOrder") != "" {
+				targets = append(targets, target{start: offset, end: int(decoder.InputOffset())})
+				open = append(open, len(targets)-1)
+			} else if t.Name.Local == "content" && len(open) > 0 {
+				targets[open[len(open)-1]].src = attr(t, "src")
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	position := make(map[string]int)
+	for i, document := range spine {
+		if _, ok := position[document]; !ok {
+			position[document] = i
+		}
+	}
+	rank := func(t target) int {
+		if i, ok := position[resolvePath(name, t.src)]; ok && t.src != "" {
+			return i
+		}
+		return len(spine)
+	}
+	order := make([]int, len(targets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return rank(targets[order[i]]) < rank(targets[order[j]]) })
+
+	// Targets with the same src share their playOrder.
+	playOrders := make(map[int]int)
+	bySrc := make(map[string]int)
+	for _, i := range order {
+		src := targets[i].src
+		if n, ok := bySrc[src]; ok && src != "" {
+			playOrders[i] = n
+			continue
+		}
+		playOrders[i] = len(bySrc) + 1
+		bySrc[src] = playOrders[i]
+	}
+
+	output := editedBuffer(data)
+	end := 0
+	for i, t := range targets {
+		output.Write(data[end:t.start])
+		output.WriteString(playOrderPattern.ReplaceAllLiteralString(string(data[t.start:t.end]), `playOrder="`+strconv.Itoa(playOrders[i])+`"`))
+		end = t.end
+	}
+	output.Write(data[end:])
+
+	return output.Bytes()
+}
+
+// spineLinkIssues returns the links of the spine documents that lead to
+// the next or the previous document in the order old, but not in the
+// order idrefs.
+func (epubReader *EpubReader) spineLinkIssues(old, idrefs []string) ([]Issue, error) {
+	// documents returns the zip paths of the documents of order, and their
+	// positions.
+	documents := func(order []string) ([]string, map[string]int) {
+		names := make([]string, len(order))
+		positions := make(map[string]int)
+		for i, idref := range order {
+			if item, ok := epubReader.ItemByID(idref); ok {
+				names[i] = epubReader.itemPath(item.Href)
+				positions[names[i]] = i
+			}
+		}
+		return names, positions
+	}
+	oldNames, _ := documents(old)
+	newNames, newPositions := documents(idrefs)
+	at := func(names []string, i int) string {
+		if i < 0 || i >= len(names) {
+			return ""
+		}
+		return names[i]
+	}
+
+	var issues []Issue
+	for i, name := range oldNames {
+		if name == "" {
+			continue
+		}
+		document, err := epubReader.parseDocument(name)
+		if errors.Is(err, ErrorFileMissing) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		document.walk(func(n *domNode) bool {
+			if n.name != "a" && n.name != "area" {
+				return true
+			}
+			href := n.attr("href")
+			target, ok := localReference(name, href)
+			if !ok {
+				return true
+			}
+			rels := " " + strings.Join(strings.Fields(strings.ToLower(n.attr("rel"))), " ") + " "
+			whole := !strings.Contains(href, "#")
+			for _, step := range []struct {
+				direction int
+				rel       string
+				relation  string
+			}{{1, "next", "next"}, {-1, "prev", "previous"}} {
+				if !strings.Contains(rels, " "+step.rel+" ") && !(whole && target == at(oldNames, i+step.direction)) {
+					continue
+				}
+				if now := at(newNames, newPositions[name]+step.direction); now != target {
+					if now == "" {
+						now = "none"
+					}
+					issues = append(issues, Issue{
+						RuleID:   RuleSpineLink,
+						Severity: SeverityWarning,
+						Path:     name,
+						Message:  fmt.Sprintf("link '%s' leads to the %s document, which is now '%s'", href, step.relation, now),
+					})
+				}
+			}
+			return true
+		})
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,65 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteWithSpineOrder(t *testing.T) {
+	opf := strings.Replace(testOPF, `<itemref idref="chapter1"/>`, `<itemref idref="chapter1" linear="yes"/>`, 1)
+	chapter1 := strings.Replace(testChapter1, "</body>", `<p><a href="chapter2.xhtml">Next chapter</a> <a href="chapter2.xhtml#end">Notes</a></p>
+</body>`, 1)
+	chapter2 := strings.Replace(testChapter2, "</body>", `<p><a rel="prev" href="chapter1.xhtml#top">Back</a></p>
+</body>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/chapter1.xhtml", chapter1}, testFile{"OEBPS/chapter2.xhtml", chapter2})
+
+	if _, err := reader.WriteWithSpineOrder(&bytes.Buffer{}, []string{"chapter2", "chapter2"}); !errors.Is(err, ErrBadSpineOrder) {
+		t.Errorf("WriteWithSpineOrder(chapter2, chapter2) = %v, want ErrBadSpineOrder", err)
+	}
+
+	var buffer bytes.Buffer
+	issues, err := reader.WriteWithSpineOrder(&buffer, []string{"chapter2", "chapter1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, issue := range issues {
+		if issue.RuleID != RuleSpineLink || issue.Severity != SeverityWarning {
+			t.Errorf("issue %s", issue)
+		}
+		messages = append(messages, issue.Path+": "+issue.Message)
+	}
+	want := []string{
+		"OEBPS/chapter1.xhtml: link 'chapter2.xhtml' leads to the next document, which is now 'none'",
+		"OEBPS/chapter2.xhtml: link 'chapter1.xhtml#top' leads to the previous document, which is now 'none'",
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("WriteWithSpineOrder() issues = %q", messages)
+	}
+
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spine := written.Rootfiles[0].Spine.Itemref; len(spine) != 2 || spine[0].Idref != "chapter2" || spine[1].Idref != "chapter1" {
+		t.Errorf("spine = %+v", spine)
+	}
+	if document, _ := written.readFile("OEBPS/content.opf"); !strings.Contains(document.String(), `<itemref idref="chapter2"/>
+    <itemref idref="chapter1" linear="yes"/>`) {
+		t.Errorf("content.opf =\n%s", document)
+	}
+	if ncx, _ := written.readFile("OEBPS/toc.ncx"); !strings.Contains(ncx.String(), `<navPoint id="np1" playOrder="2">`) || !strings.Contains(ncx.String(), `<navPoint id="np2" playOrder="1">`) {
+		t.Errorf("toc.ncx =\n%s", ncx)
+	}
+}
+
+// TestNCXWithPlayOrderEmpty checks that an empty NCX stays a file, which
+// writeZip would remove if it were nil.
+func TestNCXWithPlayOrderEmpty(t *testing.T) {
+	if data := ncxWithPlayOrder("OEBPS/toc.ncx", []byte{}, nil); data == nil {
+		t.Error("ncxWithPlayOrder() = nil")
+	}
+}
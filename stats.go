@@ -0,0 +1,122 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ChapterStat describes the weight of a spine document.
+type ChapterStat struct {
+	ID   string
+	Href string
+	// Size is the uncompressed size of the document.
+	Size uint64
+	// Images is the number of image references, ImageBytes the uncompressed
+	// size of the distinct images referenced.
+	Images     int
+	ImageBytes uint64
+	// Scripted is set when the document contains script elements or is
+	// declared as scripted in the manifest.
+	Scripted bool
+	Elements int
+	MaxDepth int
+	// Complexity is a rough estimate of the rendering cost of the document:
+	// one point per element, ten per nesting level, one per KiB of text and
+	// images, and a hundred if scripted.
+	Complexity int
+	// Language and Script are detected in the text of the document, see
+	// DetectLanguage and ChapterLanguages.
+	Language string
+	Script   string
+}
+
+// ChapterStats returns statistics for each document of the spine, in reading
+// order.
+func (epubReader *EpubReader) ChapterStats() ([]ChapterStat, error) {
+	var stats []ChapterStat
+
+	for _, item := range epubReader.SpineItems() {
+		stat, err := epubReader.chapterStat(item)
+		if err != nil {
+			return stats, err
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+func (epubReader *EpubReader) chapterStat(item Item) (ChapterStat, error) {
+	stat := ChapterStat{ID: item.ID, Href: item.Href}
+	name := epubReader.itemPath(item.Href)
+
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return stat, err
+	}
+	defer reader.Close()
+	stat.Size = epubReader.fileSize(name)
+	stat.Scripted = epubReader.ItemHasProperty(item, ItemVocabulary+"scripted")
+
+	images := make(map[string]bool)
+	depth, skipped := 0, 0
+	var text strings.Builder
+	decoder := newXHTMLDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stat, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stat.Elements++
+			depth++
+			if depth > stat.MaxDepth {
+				stat.MaxDepth = depth
+			}
+
+			if skipped > 0 || skippedElements[strings.ToLower(t.Name.Local)] {
+				skipped++
+			}
+			switch strings.ToLower(t.Name.Local) {
+			case "script":
+				stat.Scripted = true
+			case "img":
+				stat.Images++
+				images[resolvePath(name, attr(t, "src"))] = true
+			case "image":
+				stat.Images++
+				images[resolvePath(name, attr(t, "href"))] = true
+			}
+		case xml.EndElement:
+			depth--
+			if skipped > 0 {
+				skipped--
+			}
+		case xml.CharData:
+			if skipped == 0 {
+				text.Write(t)
+			}
+		}
+	}
+
+	for image := range images {
+		stat.ImageBytes += epubReader.fileSize(image)
+	}
+
+	detection := DetectLanguage(text.String())
+	stat.Language, stat.Script = detection.Language, detection.Script
+
+	stat.Complexity = stat.Elements + 10*stat.MaxDepth + int((stat.Size+stat.ImageBytes)/1024)
+	if stat.Scripted {
+		stat.Complexity += 100
+	}
+
+	return stat, nil
+}
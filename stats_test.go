@@ -0,0 +1,29 @@
+package epub
+
+import (
+	"testing"
+)
+
+func TestChapterStats(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/chapter2.xhtml", `<html><body>
+<p>Two pictures of the same cover<br>
+<img src="images/cover.jpg"><img src="images/cover.jpg"></p>
+<script>alert("hi")</script>
+</body></html>`})
+
+	stats, err := reader.ChapterStats()
+	if err != nil {
+		t.Fatalf("ChapterStats() = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("ChapterStats() = %v", stats)
+	}
+
+	if stats[0].ID != "chapter1" || stats[0].Images != 1 || stats[0].Scripted || stats[0].Size != uint64(len(testChapter1)) {
+		t.Errorf("ChapterStats()[0] = %+v", stats[0])
+	}
+	cover := reader.Files["OEBPS/images/cover.jpg"].UncompressedSize64
+	if stats[1].Images != 2 || stats[1].ImageBytes != cover || !stats[1].Scripted {
+		t.Errorf("ChapterStats()[1] = %+v", stats[1])
+	}
+}
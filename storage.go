@@ -0,0 +1,349 @@
+package epub
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Storage gives access to the files of a book container. Names are slash
+// separated paths relative to the root of the container, as in a zip
+// archive: "mimetype", "META-INF/container.xml", ...
+type Storage interface {
+	// Open returns the content of the file name. It returns an error
+	// wrapping fs.ErrNotExist when there is no such file.
+	Open(name string) (io.ReadCloser, error)
+	Stat(name string) (fs.FileInfo, error)
+	// Names returns the names of the files, without directories, in the
+	// order of the container.
+	Names() []string
+}
+
+// OpenStorage opens the book held by storage. name identifies the book in
+// errors and logs.
+func OpenStorage(name string, storage Storage) (*EpubReader, error) {
+	reader := &EpubReader{Name: name}
+	if err := reader.init(storage); err != nil {
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// Storage returns the container of the book.
+func (epubReader *EpubReader) Storage() Storage {
+	return epubReader.storage
+}
+
+// stat returns information about the file name of the container.
+func (epubReader *EpubReader) stat(name string) (fs.FileInfo, bool) {
+	info, err := epubReader.storage.Stat(name)
+
+	return info, err == nil
+}
+
+func (epubReader *EpubReader) hasFile(name string) bool {
+	_, ok := epubReader.stat(name)
+
+	return ok
+}
+
+// fileSize returns the size of the file name, 0 if it does not exist.
+func (epubReader *EpubReader) fileSize(name string) uint64 {
+	if info, ok := epubReader.stat(name); ok && info.Size() > 0 {
+		return uint64(info.Size())
+	}
+
+	return 0
+}
+
+// zipStorage is the Storage of zip archives.
+type zipStorage struct {
+	reader *zip.Reader
+	// raw is the archive itself.
+	raw   io.ReaderAt
+	files map[string]*zip.File
+	names []string
+}
+
+// NewZipStorage returns the Storage of the zip archive of the given size
+// read from r. When the archive has several entries with the same name, the
+// last one wins.
+func NewZipStorage(r io.ReaderAt, size int64) (Storage, error) {
+	reader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("epub: open zip: %w", err)
+	}
+
+	return newZipStorage(reader, r), nil
+}
+
+func newZipStorage(reader *zip.Reader, raw io.ReaderAt) *zipStorage {
+	storage := &zipStorage{
+		reader: reader,
+		raw:    raw,
+		files:  make(map[string]*zip.File),
+		names:  make([]string, 0, len(reader.File)),
+	}
+	for _, f := range reader.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		if _, ok := storage.files[f.Name]; !ok {
+			storage.names = append(storage.names, f.Name)
+		}
+		storage.files[f.Name] = f
+	}
+
+	return storage
+}
+
+func (storage *zipStorage) Open(name string) (io.ReadCloser, error) {
+	file, ok := storage.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file.Open()
+}
+
+func (storage *zipStorage) Stat(name string) (fs.FileInfo, error) {
+	file, ok := storage.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file.FileInfo(), nil
+}
+
+func (storage *zipStorage) Names() []string {
+	return storage.names
+}
+
+func (storage *zipStorage) FS() fs.FS {
+	return storage.reader
+}
+
+// memoryStorage is a Storage held in memory.
+type memoryStorage struct {
+	files map[string]*memoryFile
+	names []string
+}
+
+type memoryFile struct {
+	name    string
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (file *memoryFile) Name() string       { return path.Base(file.name) }
+func (file *memoryFile) Size() int64        { return int64(len(file.data)) }
+func (file *memoryFile) Mode() fs.FileMode  { return file.mode }
+func (file *memoryFile) ModTime() time.Time { return file.modTime }
+func (file *memoryFile) IsDir() bool        { return false }
+func (file *memoryFile) Sys() interface{}   { return nil }
+
+// TarStorageOptions tunes NewTarStorageWith.
+type TarStorageOptions struct {
+	// MaxItemSize is the largest entry read, in bytes; larger entries fail
+	// with ErrItemTooLarge. It defaults to 256 MiB.
+	MaxItemSize int64
+}
+
+// NewTarStorage reads a tar archive, such as a book packaged by a publishing
+// workflow, into a Storage, as NewTarStorageWith with no options does.
+func NewTarStorage(r io.Reader) (Storage, error) {
+	return NewTarStorageWith(r, TarStorageOptions{})
+}
+
+// NewTarStorageWith reads a tar archive into a Storage. Directories, links
+// and other special entries are ignored; entries with absolute names or
+// names escaping the root of the archive fail with ErrUnsafePath.
+func NewTarStorageWith(r io.Reader, options TarStorageOptions) (Storage, error) {
+	if options.MaxItemSize <= 0 {
+		options.MaxItemSize = 1 << 28
+	}
+	storage := &memoryStorage{files: make(map[string]*memoryFile)}
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return storage, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("epub: read tar: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if _, err := entryPath(header.Name); err != nil {
+			return nil, fmt.Errorf("epub: read tar: '%s': %w", header.Name, err)
+		}
+
+		data, err := ioutil.ReadAll(io.LimitReader(reader, options.MaxItemSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("epub: read tar: %w", err)
+		}
+		if int64(len(data)) > options.MaxItemSize {
+			return nil, fmt.Errorf("epub: read tar: '%s': %w: more than %d bytes", header.Name, ErrItemTooLarge, options.MaxItemSize)
+		}
+		name := strings.TrimPrefix(path.Clean(header.Name), "./")
+		if _, ok := storage.files[name]; !ok {
+			storage.names = append(storage.names, name)
+		}
+		storage.files[name] = &memoryFile{name: name, data: data, mode: header.FileInfo().Mode(), modTime: header.ModTime}
+	}
+}
+
+func (storage *memoryStorage) Open(name string) (io.ReadCloser, error) {
+	file, ok := storage.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(file.data)), nil
+}
+
+func (storage *memoryStorage) Stat(name string) (fs.FileInfo, error) {
+	file, ok := storage.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return file, nil
+}
+
+func (storage *memoryStorage) Names() []string {
+	return storage.names
+}
+
+// storageFS adapts a Storage to fs.FS, synthesizing directories from the
+// file names.
+type storageFS struct {
+	storage Storage
+}
+
+func (fsys storageFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if info, err := fsys.storage.Stat(name); err == nil && !info.IsDir() {
+		reader, err := fsys.storage.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &storageFile{ReadCloser: reader, info: info}, nil
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	entries := make(map[string]fs.DirEntry)
+	for _, file := range fsys.storage.Names() {
+		if !strings.HasPrefix(file, prefix) {
+			continue
+		}
+		rest := file[len(prefix):]
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			entries[rest[:i]] = fs.FileInfoToDirEntry(dirInfo(rest[:i]))
+		} else if info, err := fsys.storage.Stat(file); err == nil {
+			entries[rest] = fs.FileInfoToDirEntry(info)
+		}
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	dir := &storageDir{info: dirInfo(path.Base(name))}
+	for _, entry := range entries {
+		dir.entries = append(dir.entries, entry)
+	}
+	sort.Slice(dir.entries, func(i, j int) bool { return dir.entries[i].Name() < dir.entries[j].Name() })
+
+	return dir, nil
+}
+
+type storageFile struct {
+	io.ReadCloser
+	info fs.FileInfo
+}
+
+func (file *storageFile) Stat() (fs.FileInfo, error) { return file.info, nil }
+
+type dirInfo string
+
+func (info dirInfo) Name() string       { return string(info) }
+func (info dirInfo) Size() int64        { return 0 }
+func (info dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (info dirInfo) ModTime() time.Time { return time.Time{} }
+func (info dirInfo) IsDir() bool        { return true }
+func (info dirInfo) Sys() interface{}   { return nil }
+
+type storageDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+}
+
+func (dir *storageDir) Stat() (fs.FileInfo, error) { return dir.info, nil }
+func (dir *storageDir) Close() error               { return nil }
+
+func (dir *storageDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: dir.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (dir *storageDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := dir.entries
+		dir.entries = nil
+		return entries, nil
+	}
+	if len(dir.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(dir.entries) {
+		n = len(dir.entries)
+	}
+	entries := dir.entries[:n]
+	dir.entries = dir.entries[n:]
+
+	return entries, nil
+}
+
+// checkMimetype verifies that the archive starts with the mimetype entry,
+// stored uncompressed and without extra field, so that its content can be
+// found at offset 38 by tools sniffing file types.
+func (storage *zipStorage) checkMimetype() error {
+	header := make([]byte, 38+len(epubMimetype))
+	if _, err := storage.raw.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("archive too short: %w", err)
+	}
+
+	switch {
+	case string(header[:4]) != "PK\x03\x04":
+		return errors.New("archive does not start with a local file header")
+	case binary.LittleEndian.Uint16(header[26:]) != uint16(len(mimetypePath)) || string(header[30:38]) != mimetypePath:
+		return errors.New("first entry is not the mimetype")
+	case binary.LittleEndian.Uint16(header[8:]) != zip.Store:
+		return errors.New("mimetype entry is compressed")
+	case binary.LittleEndian.Uint16(header[28:]) != 0:
+		return errors.New("mimetype entry has an extra field")
+	case string(header[38:]) != epubMimetype:
+		return fmt.Errorf("mimetype at offset 38 is not %s", epubMimetype)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package epub
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTar repacks the test book as a tar archive.
+func testTar(t *testing.T) []byte {
+	t.Helper()
+
+	data := buildTestEpub(t)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	writer.WriteHeader(&tar.Header{Name: "./META-INF/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, file := range zipReader.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.WriteHeader(&tar.Header{Name: "./" + file.Name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))})
+		writer.Write(content)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestTarStorage(t *testing.T) {
+	storage, err := NewTarStorage(bytes.NewReader(testTar(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := OpenStorage("book.tar", storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipped := openTestEpub(t)
+
+	if firstTitle(reader.Rootfiles[0].Metadata.Title) != "The Test Book" {
+		t.Errorf("Title = %q", firstTitle(reader.Rootfiles[0].Metadata.Title))
+	}
+	if !reflect.DeepEqual(reader.FileNames(), zipped.FileNames()) {
+		t.Errorf("FileNames() = %v, want %v", reader.FileNames(), zipped.FileNames())
+	}
+	if reader.Files != nil || reader.Storage() != storage {
+		t.Error("tar storage exposed as zip")
+	}
+
+	stats, err := reader.ChapterStats()
+	if err != nil || len(stats) != 2 || stats[0].ImageBytes == 0 {
+		t.Errorf("ChapterStats() = %+v, %v", stats, err)
+	}
+
+	if _, err := storage.Open("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing) = %v", err)
+	}
+}
+
+// TestTarStorageUnsafe checks that tar entries too large or escaping the
+// root are refused.
+func TestTarStorageUnsafe(t *testing.T) {
+	archive := func(name string, size int) []byte {
+		var buffer bytes.Buffer
+		writer := tar.NewWriter(&buffer)
+		writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(size), Typeflag: tar.TypeReg})
+		writer.Write(bytes.Repeat([]byte("x"), size))
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.Bytes()
+	}
+
+	for _, name := range []string{"../outside.xhtml", "OEBPS/../../outside.xhtml", "/etc/passwd"} {
+		if _, err := NewTarStorage(bytes.NewReader(archive(name, 1))); !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("NewTarStorage(%s) = %v, want ErrUnsafePath", name, err)
+		}
+	}
+	if _, err := NewTarStorageWith(bytes.NewReader(archive("OEBPS/big.jpg", 1025)), TarStorageOptions{MaxItemSize: 1024}); !errors.Is(err, ErrItemTooLarge) {
+		t.Errorf("NewTarStorageWith() = %v, want ErrItemTooLarge", err)
+	}
+	if _, err := NewTarStorageWith(bytes.NewReader(archive("OEBPS/big.jpg", 1024)), TarStorageOptions{MaxItemSize: 1024}); err != nil {
+		t.Errorf("NewTarStorageWith() at the limit = %v", err)
+	}
+}
+
+func TestStorageFS(t *testing.T) {
+	storage, err := NewTarStorage(bytes.NewReader(testTar(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := storageFS{storage}
+
+	var names []string
+	err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 8 || names[0] != "META-INF/container.xml" {
+		t.Errorf("WalkDir() = %v", names)
+	}
+
+	if data, err := fs.ReadFile(fsys, "mimetype"); err != nil || string(data) != epubMimetype {
+		t.Errorf("ReadFile(mimetype) = %q, %v", data, err)
+	}
+	if _, err := fsys.Open("OEBPS/missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Open(missing) = %v", err)
+	}
+}
+
+func TestCheckMimetype(t *testing.T) {
+	if issues := openTestEpub(t).Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+
+	data := buildTestEpub(t)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		// method is the compression of the mimetype entry, written last
+		// when misordered.
+		method     uint16
+		misordered bool
+		renamed    bool
+		want       string
+	}{
+		{"misordered", zip.Store, true, false, "first entry is not the mimetype"},
+		{"compressed", zip.Deflate, false, false, "mimetype entry is compressed"},
+		{"renamed", zip.Store, false, true, "first entry is not the mimetype"},
+	} {
+		var buffer bytes.Buffer
+		writer := zip.NewWriter(&buffer)
+		files := append([]*zip.File(nil), zipReader.File...)
+		if test.misordered {
+			files = append(files[1:], files[0])
+		}
+		for _, file := range files {
+			method := uint16(zip.Deflate)
+			if file.Name == mimetypePath {
+				method = test.method
+				if test.renamed {
+					// A first entry whose name starts with mimetype.
+					copyZipEntry(t, writer, &zip.FileHeader{Name: mimetypePath + ".bak", Method: zip.Store}, file)
+				}
+			}
+			copyZipEntry(t, writer, &zip.FileHeader{Name: file.Name, Method: method}, file)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		reader, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		issues := reader.Validate()
+		if len(issues) != 1 || issues[0].RuleID != "mimetype-first" || issues[0].Message != test.want {
+			t.Errorf("%s: Validate() = %v", test.name, issues)
+		}
+	}
+}
+
+// TestWrittenMimetype checks that the books written by EpubWriter and
+// WriteEdited start with a stored mimetype entry without extra field, even
+// when the one of the original book has one.
+func TestWrittenMimetype(t *testing.T) {
+	data := buildTestEpub(t)
+	zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original bytes.Buffer
+	writer := zip.NewWriter(&original)
+	for _, file := range zipReader.File {
+		// The modification time adds an extra field.
+		copyZipEntry(t, writer, &zip.FileHeader{Name: file.Name, Method: file.Method, Modified: time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)}, file)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := OpenBuffer(original.Bytes(), int64(original.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reader.storage.(*zipStorage).checkMimetype(); err == nil {
+		t.Fatal("checkMimetype() of the original book succeeded")
+	}
+
+	var edited bytes.Buffer
+	if err := reader.WriteEdited(&edited); err != nil {
+		t.Fatal(err)
+	}
+	epubWriter := NewWriter(WriterMetadata{Title: "The Test Book"})
+	if err := epubWriter.AddItem("chapter1.xhtml", "application/xhtml+xml", strings.NewReader(testChapter1)); err != nil {
+		t.Fatal(err)
+	}
+	var written bytes.Buffer
+	if _, err := epubWriter.WriteTo(&written); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, book := range map[string][]byte{"WriteEdited": edited.Bytes(), "EpubWriter": written.Bytes()} {
+		reader, err := OpenBuffer(book, int64(len(book)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reader.storage.(*zipStorage).checkMimetype(); err != nil {
+			t.Errorf("%s: checkMimetype() = %v", name, err)
+		}
+	}
+}
+
+func copyZipEntry(t *testing.T, writer *zip.Writer, header *zip.FileHeader, file *zip.File) {
+	t.Helper()
+
+	r, err := file.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := writer.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(content)
+}
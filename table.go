@@ -0,0 +1,157 @@
+package epub
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Table is an HTML table of a content document.
+type Table struct {
+	// Href is the content document, Index the position of the table in it,
+	// from 0, and ID its id attribute, if any.
+	Href  string
+	Index int
+	ID    string
+	// Offset is the position of the table in the text of the document, in
+	// runes.
+	Offset  int
+	Caption string
+	// Rows holds the text of the cells. Cells spanning several columns or
+	// rows are repeated as empty cells so that rows line up.
+	Rows [][]string
+	// HeaderRows is the number of leading rows made of th cells or found in
+	// thead.
+	HeaderRows int
+}
+
+// Fragment returns a reference to the table, relative to the package
+// document, e.g. "chapter1.xhtml#t1".
+func (table Table) Fragment() string {
+	if table.ID == "" {
+		return table.Href
+	}
+
+	return table.Href + "#" + table.ID
+}
+
+// WriteCSV writes the rows of the table as CSV.
+func (table Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.WriteAll(table.Rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
+
+// Tables returns the tables of the spine documents, in reading order.
+// Nested tables are returned separately, after the table containing them.
+func (epubReader *EpubReader) Tables() ([]Table, error) {
+	var tables []Table
+
+	for _, item := range epubReader.SpineItems() {
+		name := epubReader.itemPath(item.Href)
+		reader, err := epubReader.openFile(name)
+		if err != nil {
+			return tables, err
+		}
+		document, err := parseDOM(reader)
+		reader.Close()
+		if err != nil {
+			return tables, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+		}
+
+		index := 0
+		document.walk(func(n *domNode) bool {
+			if n.name == "table" {
+				tables = append(tables, parseTable(n, item.Href, index))
+				index++
+			}
+			return true
+		})
+	}
+
+	return tables, nil
+}
+
+func parseTable(node *domNode, href string, index int) Table {
+	table := Table{Href: href, Index: index, ID: node.attr("id"), Offset: node.start}
+	// spans holds, per column, the number of rows still covered by a cell
+	// with a rowspan.
+	var spans []int
+	headers := true
+
+	node.walk(func(n *domNode) bool {
+		if n != node && n.name == "table" {
+			return false
+		}
+		switch n.name {
+		case "caption":
+			table.Caption = collapseSpace(n.textContent())
+			return false
+		case "tr":
+			row, header := tableRow(n, &spans)
+			if headers && (header || n.parent.name == "thead") {
+				table.HeaderRows++
+			} else {
+				headers = false
+			}
+			table.Rows = append(table.Rows, row)
+			return false
+		}
+		return true
+	})
+
+	return table
+}
+
+// tableRow returns the cells of a row and whether they are all th cells.
+func tableRow(tr *domNode, spans *[]int) ([]string, bool) {
+	var row []string
+	header := true
+	column := 0
+
+	skipSpanned := func() {
+		for column < len(*spans) && (*spans)[column] > 0 {
+			(*spans)[column]--
+			row = append(row, "")
+			column++
+		}
+	}
+
+	for _, cell := range tr.elements() {
+		if cell.name != "td" && cell.name != "th" {
+			continue
+		}
+		header = header && cell.name == "th"
+		skipSpanned()
+
+		colspan := spanAttr(cell, "colspan")
+		rowspan := spanAttr(cell, "rowspan")
+		for i := 0; i < colspan; i++ {
+			if i == 0 {
+				row = append(row, collapseSpace(cell.textContent()))
+			} else {
+				row = append(row, "")
+			}
+			for len(*spans) <= column {
+				*spans = append(*spans, 0)
+			}
+			(*spans)[column] = rowspan - 1
+			column++
+		}
+	}
+	skipSpanned()
+
+	return row, header && len(row) > 0
+}
+
+func spanAttr(cell *domNode, name string) int {
+	if n, err := strconv.Atoi(cell.attr(name)); err == nil && n > 1 && n <= 1000 {
+		return n
+	}
+
+	return 1
+}
@@ -0,0 +1,56 @@
+package epub
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+const testTables = `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<p>Prices:</p>
+<table id="prices">
+  <caption>Fruit  prices</caption>
+  <thead><tr><th>Fruit</th><th>Price</th><th>Stock</th></tr></thead>
+  <tbody>
+    <tr><td rowspan="2">Apple</td><td>1.00</td><td>10</td></tr>
+    <tr><td colspan="2">sold out, "soon"</td></tr>
+    <tr><td>Pear</td><td>2.00</td><td><table><tr><td>nested</td></tr></table></td></tr>
+  </tbody>
+</table>
+</body></html>`
+
+func TestTables(t *testing.T) {
+	tables, err := openTestEpub(t, testFile{"OEBPS/chapter2.xhtml", testTables}).Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("Tables() returned %d tables, want 2", len(tables))
+	}
+
+	table := tables[0]
+	if table.Fragment() != "chapter2.xhtml#prices" || table.Caption != "Fruit prices" || table.HeaderRows != 1 || table.Offset == 0 {
+		t.Errorf("table = %+v", table)
+	}
+	want := [][]string{
+		{"Fruit", "Price", "Stock"},
+		{"Apple", "1.00", "10"},
+		{"", `sold out, "soon"`, ""},
+		{"Pear", "2.00", "nested"},
+	}
+	if !reflect.DeepEqual(table.Rows, want) {
+		t.Errorf("Rows = %q, want %q", table.Rows, want)
+	}
+	if tables[1].Index != 1 || !reflect.DeepEqual(tables[1].Rows, [][]string{{"nested"}}) {
+		t.Errorf("nested table = %+v", tables[1])
+	}
+
+	var b bytes.Buffer
+	if err := table.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	csv := "Fruit,Price,Stock\nApple,1.00,10\n,\"sold out, \"\"soon\"\"\",\nPear,2.00,nested\n"
+	if b.String() != csv {
+		t.Errorf("WriteCSV() = %q, want %q", b.String(), csv)
+	}
+}
@@ -0,0 +1,183 @@
+package epub
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// CorpusStats counts the features and anomalies of a corpus of books, for
+// quality dashboards.
+type CorpusStats struct {
+	Books int `json:"books"`
+	// Unreadable counts the files that did not open as books; the other
+	// counts are about the books that did.
+	Unreadable int `json:"unreadable"`
+	// Versions counts the books by EPUB version.
+	Versions map[string]int `json:"versions"`
+	// Producers counts the books by producer, or by the tool named in
+	// their generator meta when it is not a known producer, or "unknown".
+	Producers map[string]int `json:"producers"`
+	NoISBN    int            `json:"noISBN"`
+	NoCover   int            `json:"noCover"`
+	// NoTOC counts the books without a table of contents, or with one
+	// that had to be guessed from the spine.
+	NoTOC int `json:"noTOC"`
+	// BrokenSpine counts the books whose spine refers to items missing
+	// from the manifest or from the archive.
+	BrokenSpine int `json:"brokenSpine"`
+	// MissingFiles counts the books whose manifest lists files missing
+	// from the archive.
+	MissingFiles int `json:"missingFiles"`
+	// Issues counts the validation issues by rule.
+	Issues map[string]int `json:"issues"`
+}
+
+// generatorVersion matches the version and the notes following the name
+// of a tool in a generator meta.
+var generatorVersion = regexp.MustCompile(`\s*(?:[(\[]|v?\d).*$`)
+
+// Add counts the book.
+func (stats *CorpusStats) Add(epubReader *EpubReader) {
+	stats.init()
+	stats.Books++
+
+	stats.Versions[epubReader.Rootfiles[0].Version]++
+
+	producer := epubReader.Producer().Name
+	if producer == "" {
+		producer = generatorVersion.ReplaceAllString(epubReader.metaValue("generator"), "")
+	}
+	if producer == "" {
+		producer = "unknown"
+	}
+	stats.Producers[producer]++
+
+	if _, err := epubReader.GetISBN(); err != nil {
+		stats.NoISBN++
+	}
+	if _, ok := epubReader.Cover(); !ok {
+		stats.NoCover++
+	}
+	if toc, err := epubReader.TOC(); err != nil || len(toc) == 0 || toc[0].Heuristic {
+		stats.NoTOC++
+	}
+
+	manifest := epubReader.Rootfiles[0].Manifest.Item
+	for _, itemref := range epubReader.Rootfiles[0].Spine.Itemref {
+		item, ok := epubReader.ItemByID(itemref.Idref)
+		if !ok || !epubReader.hasFile(epubReader.itemPath(item.Href)) {
+			stats.BrokenSpine++
+			break
+		}
+	}
+	for _, item := range manifest {
+		if _, local := localReference(epubReader.Rootfiles[0].FullPath, item.Href); local && !epubReader.hasFile(epubReader.itemPath(item.Href)) {
+			stats.MissingFiles++
+			break
+		}
+	}
+
+	for _, issue := range epubReader.Validate() {
+		stats.Issues[issue.RuleID]++
+	}
+}
+
+// AddUnreadable counts a file that did not open as a book.
+func (stats *CorpusStats) AddUnreadable() {
+	stats.init()
+	stats.Unreadable++
+}
+
+func (stats *CorpusStats) init() {
+	if stats.Versions == nil {
+		stats.Versions = make(map[string]int)
+		stats.Producers = make(map[string]int)
+		stats.Issues = make(map[string]int)
+	}
+}
+
+// TopProducers returns the producers of the most books first, at most n
+// of them, or all of them if n is 0.
+func (stats *CorpusStats) TopProducers(n int) []string {
+	producers := make([]string, 0, len(stats.Producers))
+	for producer := range stats.Producers {
+		producers = append(producers, producer)
+	}
+	sort.Slice(producers, func(i, j int) bool {
+		a, b := producers[i], producers[j]
+		return stats.Producers[a] > stats.Producers[b] || stats.Producers[a] == stats.Producers[b] && a < b
+	})
+	if n > 0 && n < len(producers) {
+		producers = producers[:n]
+	}
+
+	return producers
+}
+
+// CollectCorpusStats counts the given files and the .epub files found in
+// the given directories, opening up to workers books concurrently.
+func CollectCorpusStats(paths []string, workers int) (CorpusStats, error) {
+	var stats CorpusStats
+	stats.init()
+
+	filenames, err := epubPaths(paths)
+	if err != nil {
+		return stats, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	filenamesChan := make(chan string)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filename := range filenamesChan {
+				var book CorpusStats
+				reader, err := OpenReader(filename)
+				if err != nil {
+					book.AddUnreadable()
+				} else {
+					book.Add(&reader.EpubReader)
+					reader.Close()
+				}
+
+				mutex.Lock()
+				stats.merge(book)
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, filename := range filenames {
+		filenamesChan <- filename
+	}
+	close(filenamesChan)
+	wg.Wait()
+
+	return stats, nil
+}
+
+// merge adds the counts of other to stats.
+func (stats *CorpusStats) merge(other CorpusStats) {
+	stats.Books += other.Books
+	stats.Unreadable += other.Unreadable
+	stats.NoISBN += other.NoISBN
+	stats.NoCover += other.NoCover
+	stats.NoTOC += other.NoTOC
+	stats.BrokenSpine += other.BrokenSpine
+	stats.MissingFiles += other.MissingFiles
+	for key, n := range other.Versions {
+		stats.Versions[key] += n
+	}
+	for key, n := range other.Producers {
+		stats.Producers[key] += n
+	}
+	for key, n := range other.Issues {
+		stats.Issues[key] += n
+	}
+}
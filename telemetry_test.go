@@ -0,0 +1,49 @@
+package epub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCorpusStats(t *testing.T) {
+	dir := t.TempDir()
+	opf := strings.Replace(testOPF, `<meta name="cover"`, `<meta name="generator" content="calibre (5.10.1) [https://calibre-ebook.com]"/>
+    <meta name="cover"`, 1)
+	opf = strings.Replace(opf, `opf:scheme="ISBN">9780306406157`, `opf:scheme="uuid">urn:uuid:5b4bbf4b-0c7c-4bd5-8bdb-1c5c0b7f3a41`, 1)
+	books := map[string][]byte{
+		"a.epub":      buildTestEpub(t),
+		"b.epub":      buildTestEpub3(t),
+		"c.epub":      buildTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/chapter2.xhtml", ""}),
+		"broken.epub": []byte("not a zip"),
+		"ignored.txt": []byte("not a book"),
+	}
+	for name, book := range books {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), book, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := CollectCorpusStats([]string{dir}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Books != 3 || stats.Unreadable != 1 {
+		t.Errorf("Books, Unreadable = %d, %d", stats.Books, stats.Unreadable)
+	}
+	if want := map[string]int{"2.0": 2, "3.0": 1}; !reflect.DeepEqual(stats.Versions, want) {
+		t.Errorf("Versions = %v", stats.Versions)
+	}
+	if want := map[string]int{"calibre": 1, "unknown": 2}; !reflect.DeepEqual(stats.Producers, want) {
+		t.Errorf("Producers = %v", stats.Producers)
+	}
+	if got := stats.TopProducers(1); !reflect.DeepEqual(got, []string{"unknown"}) {
+		t.Errorf("TopProducers(1) = %v", got)
+	}
+	if stats.NoISBN != 1 || stats.NoCover != 0 || stats.NoTOC != 0 || stats.BrokenSpine != 1 || stats.MissingFiles != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,368 @@
+package epub
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TextOptions tunes text extraction.
+type TextOptions struct {
+	// PreserveVerse keeps the line structure of poems, verses and
+	// preformatted blocks: line breaks and line elements end lines, and
+	// stanzas are separated by blank lines. A block is verse when its
+	// epub:type or class names a poem, verse or stanza; it is preformatted
+	// when it is a pre element or styled with white-space: pre, pre-wrap or
+	// pre-line, inline or in a stylesheet of the book.
+	PreserveVerse bool
+	// Ruby selects the text kept of ruby annotations, such as the furigana
+	// of Japanese books.
+	Ruby RubyMode
+}
+
+// RubyMode is the way text extraction handles ruby annotations.
+type RubyMode int
+
+// Ruby modes.
+const (
+	// RubyAsWritten keeps the text of ruby elements as it is written: base
+	// text, annotations and rp fallback parentheses, if any.
+	RubyAsWritten RubyMode = iota
+	// RubyBase keeps the base text only, as searched and displayed.
+	RubyBase
+	// RubyAnnotation replaces the annotated base text with its
+	// annotations, the reading of text-to-speech.
+	RubyAnnotation
+	// RubyBracketed follows each annotated base text with its annotations
+	// in parentheses: 漢字(かんじ).
+	RubyBracketed
+)
+
+// Break levels of a textWriter, ordered by strength.
+const (
+	breakNone = iota
+	breakSpace
+	breakLine
+	breakParagraph
+)
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"body": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"figcaption": true, "figure": true, "footer": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "tr": true, "ul": true,
+}
+
+var skippedElements = map[string]bool{"head": true, "script": true, "style": true}
+
+var (
+	cssRulePattern  = regexp.MustCompile(`([^{}]+)\{([^}]*)\}`)
+	cssClassPattern = regexp.MustCompile(`\.([A-Za-z_][\w-]*)`)
+	whiteSpacePre   = regexp.MustCompile(`(?i)white-space\s*:\s*pre`)
+)
+
+// ExtractText returns the text of the spine documents, in reading order.
+// Blocks are separated by blank lines and white space is collapsed, unless
+// options preserve it.
+func (epubReader *EpubReader) ExtractText(options TextOptions) (string, error) {
+	extractor := epubReader.newTextExtractor(options)
+	var chapters []string
+
+	for _, item := range epubReader.SpineItems() {
+		text, err := extractor.itemText(item)
+		if err != nil {
+			return strings.Join(chapters, "\n\n"), err
+		}
+		if text != "" {
+			chapters = append(chapters, text)
+		}
+	}
+
+	return strings.Join(chapters, "\n\n"), nil
+}
+
+// Text returns the plain text of the book for indexing: ExtractText with
+// the default options.
+func (epubReader *EpubReader) Text() (string, error) {
+	return epubReader.ExtractText(TextOptions{})
+}
+
+// ChapterText returns the plain text of the spine document idref, like
+// Text. It returns ErrBadItemref when the spine has no such itemref.
+func (epubReader *EpubReader) ChapterText(idref string) (string, error) {
+	for _, itemref := range epubReader.Rootfiles[0].Spine.Itemref {
+		if itemref.Idref != idref {
+			continue
+		}
+		if item, ok := epubReader.ItemByID(idref); ok {
+			return epubReader.ItemText(item, TextOptions{})
+		}
+	}
+
+	return "", fmt.Errorf("epub: %s: %w: '%s'", epubReader.Name, ErrBadItemref, idref)
+}
+
+// ItemText returns the text of a content document, like ExtractText.
+func (epubReader *EpubReader) ItemText(item Item, options TextOptions) (string, error) {
+	return epubReader.newTextExtractor(options).itemText(item)
+}
+
+type textExtractor struct {
+	epubReader *EpubReader
+	options    TextOptions
+	// preClasses are the classes the stylesheets give a white-space: pre*
+	// property.
+	preClasses map[string]bool
+}
+
+func (epubReader *EpubReader) newTextExtractor(options TextOptions) *textExtractor {
+	extractor := &textExtractor{epubReader: epubReader, options: options, preClasses: make(map[string]bool)}
+	if !options.PreserveVerse {
+		return extractor
+	}
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.MediaType != "text/css" {
+			continue
+		}
+		css, err := epubReader.readFile(epubReader.itemPath(item.Href))
+		if err != nil {
+			continue
+		}
+		for _, rule := range cssRulePattern.FindAllStringSubmatch(css.String(), -1) {
+			if !whiteSpacePre.MatchString(rule[2]) {
+				continue
+			}
+			for _, class := range cssClassPattern.FindAllStringSubmatch(rule[1], -1) {
+				extractor.preClasses[class[1]] = true
+			}
+		}
+	}
+
+	return extractor
+}
+
+func (extractor *textExtractor) itemText(item Item) (_ string, err error) {
+	name := extractor.epubReader.itemPath(item.Href)
+	end := startSpan("extract", name)
+	defer func() { end(err) }()
+
+	reader, err := extractor.epubReader.openFile(name)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	document, err := parseDOM(reader)
+	if err != nil {
+		return "", fmt.Errorf("epub: %s: parse '%s': %w", extractor.epubReader.Name, name, err)
+	}
+
+	var writer textWriter
+	extractor.render(&writer, document, false, false)
+
+	return writer.b.String(), nil
+}
+
+// render writes the text of node. verse is set inside verse blocks, pre
+// inside preformatted ones.
+func (extractor *textExtractor) render(writer *textWriter, node *domNode, verse, pre bool) {
+	if node.name == "" {
+		if pre {
+			writer.writePre(node.text)
+		} else {
+			writer.write(node.text)
+		}
+		return
+	}
+	if skippedElements[node.name] {
+		return
+	}
+	if node.name == "ruby" && extractor.options.Ruby != RubyAsWritten {
+		extractor.renderRuby(writer, node)
+		return
+	}
+	if node.name == "br" {
+		if verse || pre {
+			writer.brk(breakLine)
+		} else {
+			writer.brk(breakSpace)
+		}
+		return
+	}
+
+	level := breakNone
+	if blockElements[node.name] {
+		level = breakParagraph
+		if verse && !node.hasLineStructure() {
+			level = breakLine
+		}
+	}
+	if extractor.options.PreserveVerse {
+		if extractor.isPreformatted(node) {
+			pre = true
+		} else if isVerse(node) {
+			verse = true
+			if level == breakNone {
+				level = breakParagraph
+			}
+		}
+	}
+
+	writer.brk(level)
+	for _, child := range node.children {
+		extractor.render(writer, child, verse, pre)
+	}
+	writer.brk(level)
+}
+
+// renderRuby writes the text of a ruby element, as selected by the ruby
+// mode. Each base text is paired with the rt or rtc annotations that
+// follow it; rp fallback parentheses are left out.
+func (extractor *textExtractor) renderRuby(writer *textWriter, node *domNode) {
+	var base strings.Builder
+	emit := func(annotation string) {
+		text := collapseSpace(base.String())
+		base.Reset()
+		switch {
+		case annotation == "" || extractor.options.Ruby == RubyBase:
+		case extractor.options.Ruby == RubyAnnotation:
+			text = annotation
+		default:
+			text += "(" + annotation + ")"
+		}
+		if text != "" {
+			writer.flush()
+			writer.b.WriteString(text)
+		}
+	}
+
+	for _, child := range node.children {
+		switch child.name {
+		case "rp":
+		case "rt", "rtc":
+			var annotation strings.Builder
+			child.walk(func(n *domNode) bool {
+				if n.name == "" {
+					annotation.WriteString(n.text)
+				}
+				return n.name != "rp"
+			})
+			emit(collapseSpace(annotation.String()))
+		default:
+			base.WriteString(child.textContent())
+		}
+	}
+	emit("")
+}
+
+func (extractor *textExtractor) isPreformatted(node *domNode) bool {
+	if node.name == "pre" || whiteSpacePre.MatchString(node.attr("style")) {
+		return true
+	}
+	for _, class := range strings.Fields(node.attr("class")) {
+		if extractor.preClasses[class] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isVerse reports whether the epub:type or class of node marks a poem, a
+// verse or a stanza.
+func isVerse(node *domNode) bool {
+	for _, a := range node.attrs {
+		if a.Name.Local != "type" || a.Name.Space == "" {
+			continue
+		}
+		for _, semantic := range strings.Fields(a.Value) {
+			if i := strings.IndexByte(semantic, ':'); i >= 0 {
+				semantic = semantic[i+1:]
+			}
+			if semantic == "poem" || semantic == "verse" {
+				return true
+			}
+		}
+	}
+	for _, class := range strings.Fields(strings.ToLower(node.attr("class"))) {
+		for _, v := range verseClasses {
+			if strings.Contains(class, v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasLineStructure reports whether node contains line breaks or blocks, as
+// a stanza does, rather than being a single line of verse.
+func (node *domNode) hasLineStructure() bool {
+	return node.find(func(n *domNode) bool {
+		return n != node && (n.name == "br" || blockElements[n.name])
+	}) != nil
+}
+
+// textWriter accumulates extracted text, turning requested breaks into
+// separators only between words.
+type textWriter struct {
+	b       strings.Builder
+	pending int
+}
+
+func (writer *textWriter) brk(level int) {
+	if level > writer.pending {
+		writer.pending = level
+	}
+}
+
+func (writer *textWriter) flush() {
+	if writer.b.Len() > 0 {
+		switch writer.pending {
+		case breakSpace:
+			writer.b.WriteByte(' ')
+		case breakLine:
+			writer.b.WriteByte('\n')
+		case breakParagraph:
+			writer.b.WriteString("\n\n")
+		}
+	}
+	writer.pending = breakNone
+}
+
+// write writes text with its white space collapsed.
+func (writer *textWriter) write(text string) {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		if text != "" {
+			writer.brk(breakSpace)
+		}
+		return
+	}
+
+	if strings.TrimLeft(text, " \t\r\n") != text {
+		writer.brk(breakSpace)
+	}
+	writer.flush()
+	writer.b.WriteString(strings.Join(words, " "))
+	if strings.TrimRight(text, " \t\r\n") != text {
+		writer.brk(breakSpace)
+	}
+}
+
+// writePre writes preformatted text, keeping its lines and indentation.
+func (writer *textWriter) writePre(text string) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if i > 0 {
+			writer.brk(breakLine)
+		}
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			writer.flush()
+			writer.b.WriteString(line)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package epub
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPoem = `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Poems</title><style>p { margin: 0 }</style></head>
+<body>
+  <h1>The Tyger</h1>
+  <section epub:type="z3998:poem">
+    <div><p>Tyger Tyger, burning bright,</p><p>In the forests of the night;</p></div>
+    <div><p>What immortal hand or eye,</p><p>Could frame thy fearful symmetry?</p></div>
+  </section>
+  <p class="stanza">Roses are red,<br/>violets are blue.</p>
+  <p class="listing">x = 1
+  y = 2</p>
+  <pre>
+if x {
+    y()
+}</pre>
+</body>
+</html>`
+
+func TestExtractText(t *testing.T) {
+	files := []testFile{
+		{"OEBPS/chapter1.xhtml", testPoem},
+		{"OEBPS/style.css", ".listing, pre.code { white-space: pre-wrap; }"},
+	}
+
+	for _, test := range []struct {
+		options TextOptions
+		want    string
+	}{
+		{TextOptions{}, "The Tyger\n\n" +
+			"Tyger Tyger, burning bright,\n\nIn the forests of the night;\n\nWhat immortal hand or eye,\n\nCould frame thy fearful symmetry?\n\n" +
+			"Roses are red, violets are blue.\n\nx = 1 y = 2\n\nif x { y() }\n\nChapter Two\n\nThe end."},
+		{TextOptions{PreserveVerse: true}, "The Tyger\n\n" +
+			"Tyger Tyger, burning bright,\nIn the forests of the night;\n\nWhat immortal hand or eye,\nCould frame thy fearful symmetry?\n\n" +
+			"Roses are red,\nviolets are blue.\n\nx = 1\n  y = 2\n\nif x {\n    y()\n}\n\nChapter Two\n\nThe end."},
+	} {
+		text, err := openTestEpub(t, files...).ExtractText(test.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != test.want {
+			t.Errorf("ExtractText(%+v) =\n%q\nwant\n%q", test.options, text, test.want)
+		}
+	}
+}
+
+func TestExtractTextRuby(t *testing.T) {
+	reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body>
+<p><ruby>漢<rt>かん</rt>字<rt>じ</rt></ruby>を<ruby>
+  勉強<rp>(</rp><rt>べんきょう</rt><rp>)</rp>
+</ruby>する。<ruby><rb>東</rb><rb>京</rb><rtc><rt>とう</rt><rt>きょう</rt></rtc></ruby></p>
+</body></html>`})
+
+	for _, test := range []struct {
+		mode RubyMode
+		want string
+	}{
+		{RubyAsWritten, "漢かん字じを 勉強(べんきょう) する。東京とうきょう"},
+		{RubyBase, "漢字を勉強する。東京"},
+		{RubyAnnotation, "かんじをべんきょうする。とうきょう"},
+		{RubyBracketed, "漢(かん)字(じ)を勉強(べんきょう)する。東京(とうきょう)"},
+	} {
+		text, err := reader.ItemText(reader.SpineItems()[0], TextOptions{Ruby: test.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != test.want {
+			t.Errorf("ItemText(%d) = %q, want %q", test.mode, text, test.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	reader := openTestEpub(t)
+	if text, err := reader.Text(); err != nil || text != "Chapter One\n\nIt was a dark & stormy night.\n\nChapter Two\n\nThe end." {
+		t.Errorf("Text() = %q, %v", text, err)
+	}
+	if text, err := reader.ChapterText("chapter2"); err != nil || text != "Chapter Two\n\nThe end." {
+		t.Errorf("ChapterText() = %q, %v", text, err)
+	}
+	if _, err := reader.ChapterText("css"); !errors.Is(err, ErrBadItemref) {
+		t.Errorf("ChapterText() of an item outside the spine = %v, want ErrBadItemref", err)
+	}
+}
+
+// TestChapterTextCharset checks that the single byte encodings of older
+// books are decoded.
+func TestChapterTextCharset(t *testing.T) {
+	for _, test := range []struct {
+		charset, body, want string
+	}{
+		{"windows-1252", "\x93Caf\xe9\x94 \x96 5\x80", "\u201cCaf\u00e9\u201d \u2013 5\u20ac"},
+		{"ISO-8859-1", "Caf\xe9", "Caf\u00e9"},
+	} {
+		chapter := `<?xml version="1.0" encoding="` + test.charset + `"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>One</title></head><body><p>` + test.body + `</p></body></html>`
+		reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", chapter})
+		if text, err := reader.ChapterText("chapter1"); err != nil || !strings.Contains(text, test.want) {
+			t.Errorf("%s: ChapterText() = %q, %v", test.charset, text, err)
+		}
+	}
+}
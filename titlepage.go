@@ -0,0 +1,214 @@
+package epub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GuessedFromTitlePage is the source of metadata guessed from the title
+// page of a book.
+const GuessedFromTitlePage = "title-page"
+
+// maxTitlePageDocuments bounds the spine documents searched for a title
+// page.
+const maxTitlePageDocuments = 3
+
+// maxTitlePageText is the length in runes above which a text is taken for
+// a paragraph rather than a title or a name.
+const maxTitlePageText = 200
+
+var bylinePattern = regexp.MustCompile(`(?i)^by\s+(.+)$`)
+
+// GuessMetadataFromTitlePage guesses the title and author of the book from
+// its title page: the document of the titlepage landmark, or else the
+// first spine document marked as a title page, or else the first spine
+// document with text. Titles are found, from the most to the least
+// reliable, by the title semantic, a title class, an h1 or another
+// heading; authors by the author semantic, an author class or a "By"
+// line. The confidence is lower for the first spine document than for a
+// declared title page, and for headings than for semantics.
+func (epubReader *EpubReader) GuessMetadataFromTitlePage() (MetadataGuess, bool) {
+	for _, name := range epubReader.titlePageLandmarks() {
+		if guess, ok := epubReader.guessTitlePage(name, 1); ok {
+			return guess, true
+		}
+	}
+
+	items := epubReader.SpineItems()
+	if len(items) > maxTitlePageDocuments {
+		items = items[:maxTitlePageDocuments]
+	}
+	var first *domNode
+	for _, item := range items {
+		document, err := epubReader.parseDocument(epubReader.itemPath(item.Href))
+		if err != nil {
+			continue
+		}
+		if document.find(func(n *domNode) bool { return hasSemantic(n, "titlepage") }) != nil {
+			if guess, ok := guessTitlePage(document, 1); ok {
+				return guess, true
+			}
+		}
+		if first == nil && collapseSpace(document.textContent()) != "" {
+			first = document
+		}
+	}
+	if first != nil {
+		return guessTitlePage(first, 0.6)
+	}
+
+	return MetadataGuess{Source: GuessedFromTitlePage}, false
+}
+
+// titlePageLandmarks returns the zip paths of the documents of the
+// titlepage landmarks of the navigation document and of the guide.
+func (epubReader *EpubReader) titlePageLandmarks() []string {
+	var names []string
+
+	if item, ok := epubReader.navItem(); ok {
+		name := epubReader.itemPath(item.Href)
+		if nav, err := epubReader.parseDocument(name); err == nil {
+			nav.walk(func(n *domNode) bool {
+				if n.name == "a" && hasSemantic(n, "titlepage") && n.attr("href") != "" {
+					names = append(names, resolvePath(name, n.attr("href")))
+				}
+				return true
+			})
+		}
+	}
+	for _, reference := range epubReader.Guide() {
+		if reference.Landmark == "titlepage" {
+			names = append(names, epubReader.itemPath(reference.Href))
+		}
+	}
+
+	return names
+}
+
+// parseDocument parses the content document stored in the zip entry name.
+func (epubReader *EpubReader) parseDocument(name string) (*domNode, error) {
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return parseDOM(reader)
+}
+
+func (epubReader *EpubReader) guessTitlePage(name string, weight float64) (MetadataGuess, bool) {
+	document, err := epubReader.parseDocument(name)
+	if err != nil {
+		return MetadataGuess{Source: GuessedFromTitlePage}, false
+	}
+
+	return guessTitlePage(document, weight)
+}
+
+// guessTitlePage finds the title and author of a title page, with a
+// confidence scaled by weight, the confidence in the document being the
+// title page.
+func guessTitlePage(document *domNode, weight float64) (MetadataGuess, bool) {
+	guess := MetadataGuess{Source: GuessedFromTitlePage}
+
+	body := document.find(func(n *domNode) bool { return n.name == "body" })
+	if body == nil {
+		return guess, false
+	}
+
+	titleConfidence := 0.0
+	for _, rule := range []struct {
+		match      func(*domNode) bool
+		confidence float64
+	}{
+		{func(n *domNode) bool { return titlePageRole(n, "title", "fulltitle") }, 0.9},
+		{func(n *domNode) bool {
+			return titlePageClass(n, "title") && !titlePageClass(n, "subtitle", "titlepage", "title-page")
+		}, 0.7},
+		{func(n *domNode) bool { return n.name == "h1" }, 0.6},
+		{func(n *domNode) bool { return n.name == "h2" || n.name == "h3" }, 0.4},
+	} {
+		if text, ok := titlePageText(body, rule.match); ok {
+			guess.Title, titleConfidence = text, rule.confidence
+			break
+		}
+	}
+	if guess.Title == "" {
+		return guess, false
+	}
+
+	authorConfidence := 1.0
+	for _, rule := range []struct {
+		match      func(*domNode) bool
+		confidence float64
+	}{
+		{func(n *domNode) bool { return titlePageRole(n, "author") }, 0.8},
+		{func(n *domNode) bool { return titlePageClass(n, "author", "auteur", "creator") }, 0.7},
+		{func(n *domNode) bool {
+			return len(n.elements()) == 0 && bylinePattern.MatchString(collapseSpace(n.textContent()))
+		}, 0.5},
+	} {
+		if text, ok := titlePageText(body, rule.match); ok {
+			if match := bylinePattern.FindStringSubmatch(text); match != nil {
+				text = match[1]
+			}
+			guess.Author, authorConfidence = text, rule.confidence
+			break
+		}
+	}
+
+	guess.Confidence = titleConfidence
+	if authorConfidence < guess.Confidence {
+		guess.Confidence = authorConfidence
+	}
+	guess.Confidence *= weight
+
+	return guess, true
+}
+
+// titlePageText returns the text of the first element matching, if it is
+// short enough to be a title or a name.
+func titlePageText(body *domNode, match func(*domNode) bool) (string, bool) {
+	node := body.find(func(n *domNode) bool {
+		return n.name != "" && match(n) && collapseSpace(n.textContent()) != ""
+	})
+	if node == nil {
+		return "", false
+	}
+	text := collapseSpace(node.textContent())
+
+	return text, len([]rune(text)) <= maxTitlePageText
+}
+
+// titlePageRole reports whether the epub:type of node includes one of the
+// semantics, with or without a vocabulary prefix such as z3998.
+func titlePageRole(node *domNode, semantics ...string) bool {
+	for _, a := range node.attrs {
+		if a.Name.Local != "type" || a.Name.Space == "" {
+			continue
+		}
+		for _, semantic := range strings.Fields(a.Value) {
+			if i := strings.IndexByte(semantic, ':'); i >= 0 {
+				semantic = semantic[i+1:]
+			}
+			if containsString(semantics, semantic) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// titlePageClass reports whether a class of node contains one of the names.
+func titlePageClass(node *domNode, names ...string) bool {
+	for _, class := range strings.Fields(strings.ToLower(node.attr("class"))) {
+		for _, name := range names {
+			if strings.Contains(class, name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGuessMetadataFromTitlePage(t *testing.T) {
+	page := func(body string) string {
+		return `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Title Page</title></head>
+<body>` + body + `</body>
+</html>`
+	}
+	guide := strings.Replace(testOPF, "</package>", `<guide><reference type="title-page" href="chapter2.xhtml"/></guide>
+</package>`, 1)
+
+	for _, test := range []struct {
+		name       string
+		files      []testFile
+		title      string
+		author     string
+		confidence float64
+	}{
+		{"semantics in a guide title page", []testFile{{"OEBPS/content.opf", guide}, {"OEBPS/chapter2.xhtml", page(
+			`<section epub:type="titlepage"><p epub:type="fulltitle">The Left Hand of Darkness</p><p epub:type="z3998:author">Ursula K. Le Guin</p></section>`)}},
+			"The Left Hand of Darkness", "Ursula K. Le Guin", 0.8},
+		{"classes in a spine title page", []testFile{{"OEBPS/chapter1.xhtml", page(
+			`<div epub:type="titlepage" class="titlepage"><p class="book-title">Dune</p><p class="author">Frank Herbert</p></div>`)}},
+			"Dune", "Frank Herbert", 0.7},
+		{"byline in the first document", []testFile{{"OEBPS/chapter1.xhtml", page(
+			`<h1>Solaris</h1><p>By Stanisław Lem</p><p>Translated from the Polish.</p>`)}},
+			"Solaris", "Stanisław Lem", 0.3},
+		{"heading only", nil, "Chapter One", "", 0.36},
+	} {
+		reader := openTestEpub(t, test.files...)
+		guess, ok := reader.GuessMetadataFromTitlePage()
+		if !ok || guess.Title != test.title || guess.Author != test.author || math.Abs(guess.Confidence-test.confidence) > 1e-9 || guess.Source != GuessedFromTitlePage {
+			t.Errorf("%s: GuessMetadataFromTitlePage() = %+v, %v", test.name, guess, ok)
+		}
+		reader.Close()
+	}
+
+	empty := page(`<p></p>`)
+	reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", empty}, testFile{"OEBPS/chapter2.xhtml", empty})
+	if guess, ok := reader.GuessMetadataFromTitlePage(); ok {
+		t.Errorf("GuessMetadataFromTitlePage() = %+v, want none", guess)
+	}
+}
+
+func TestLibraryBookTitlePageGuess(t *testing.T) {
+	opf := strings.Replace(testOPF, "<dc:title>The Test Book</dc:title>", "", 1)
+	filename := filepath.Join(t.TempDir(), "book.epub")
+	if err := os.WriteFile(filename, buildTestEpub(t, testFile{"OEBPS/content.opf", opf}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The heading of the first document is more telling than the file name.
+	if book := readLibraryBook(filename); book.Title != "Chapter One" || book.Guess == nil || book.Guess.Source != GuessedFromTitlePage {
+		t.Errorf("readLibraryBook() = %+v, guess %+v", book, book.Guess)
+	}
+}
@@ -0,0 +1,327 @@
+package epub
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const ncxMediaType = "application/x-dtbncx+xml"
+
+// TOCEntry is an entry of the table of contents.
+type TOCEntry struct {
+	Title string
+	// Href is the target of the entry, relative to the package document,
+	// with its fragment.
+	// Href is empty for headings, entries of a navigation document with a
+	// span instead of a link, which only group their children.
+	Href     string
+	Children []TOCEntry
+	// Heuristic is set when the book has no navigation document nor NCX
+	// and the entry was derived from the headings of a spine document.
+	Heuristic bool
+	// Hidden is set for entries of the navigation document that are, or
+	// are nested in elements that are, marked with the hidden attribute.
+	// Reading systems do not display them.
+	Hidden bool
+	// OutsideSpine is set when the entry links to a document which is not
+	// in the spine, or to a remote resource.
+	OutsideSpine bool
+	// Truncated is set when the entry had children deeper than
+	// TOCOptions.MaxDepth, which were dropped.
+	Truncated bool
+}
+
+// HasLink reports whether the entry links to a document, rather than being
+// a heading.
+func (entry TOCEntry) HasLink() bool {
+	return entry.Href != ""
+}
+
+// TOCOptions tunes TOCWith.
+type TOCOptions struct {
+	// MaxDepth is the number of levels of entries returned, 64 if zero.
+	MaxDepth int
+}
+
+// defaultTOCDepth guards against pathological nesting.
+const defaultTOCDepth = 64
+
+type ncxNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	NavPoints []ncxNavPoint `xml:"navPoint"`
+}
+
+// TOC returns the table of contents of the book, from the EPUB 3
+// navigation document, or else the NCX referenced by the spine. When the
+// book has neither, or they are empty, an entry is derived for each spine
+// document from its first heading or its title element, with Heuristic
+// set.
+func (epubReader *EpubReader) TOC() ([]TOCEntry, error) {
+	return epubReader.TOCWith(TOCOptions{})
+}
+
+// TOCWith is like TOC, with options.
+func (epubReader *EpubReader) TOCWith(options TOCOptions) ([]TOCEntry, error) {
+	var entries []TOCEntry
+	var err error
+
+	if item, ok := epubReader.navItem(); ok {
+		if entries, err = epubReader.navTOC(item, options); err != nil || len(entries) > 0 {
+			return entries, err
+		}
+	}
+	// EPUB 3 books may keep an NCX for older reading systems, which is
+	// used when their navigation document lists no entries.
+	if item, ok := epubReader.ncxItem(); ok {
+		if entries, err = epubReader.ncxTOC(item, options); err != nil || len(entries) > 0 {
+			return entries, err
+		}
+	}
+
+	return epubReader.heuristicTOC(), nil
+}
+
+// navItem returns the navigation document of the manifest, if it exists in
+// the zip.
+func (epubReader *EpubReader) navItem() (Item, bool) {
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if epubReader.ItemHasProperty(item, ItemVocabulary+"nav") && epubReader.hasItemFile(item) {
+			return item, true
+		}
+	}
+
+	return Item{}, false
+}
+
+// ncxItem returns the NCX referenced by the spine, or else the first NCX
+// of the manifest, if it exists in the zip.
+func (epubReader *EpubReader) ncxItem() (Item, bool) {
+	if item, ok := epubReader.ItemByID(epubReader.Rootfiles[0].Spine.Toc); ok && item.MediaType == ncxMediaType && epubReader.hasItemFile(item) {
+		return item, true
+	}
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if item.MediaType == ncxMediaType && epubReader.hasItemFile(item) {
+			return item, true
+		}
+	}
+
+	return Item{}, false
+}
+
+func (epubReader *EpubReader) hasItemFile(item Item) bool {
+	return epubReader.hasFile(epubReader.itemPath(item.Href))
+}
+
+func (epubReader *EpubReader) ncxTOC(item Item, options TOCOptions) ([]TOCEntry, error) {
+	name := epubReader.itemPath(item.Href)
+	buffer, err := epubReader.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var ncx struct {
+		NavPoints []ncxNavPoint `xml:"navMap>navPoint"`
+	}
+	if err := decodeXML(name, buffer.Bytes(), &ncx); err != nil {
+		return nil, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+	}
+
+	return epubReader.newTOCBuilder(name, options).ncxPoints(ncx.NavPoints, 1), nil
+}
+
+func (epubReader *EpubReader) navTOC(item Item, options TOCOptions) ([]TOCEntry, error) {
+	name := epubReader.itemPath(item.Href)
+	reader, err := epubReader.openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	document, err := parseDOM(reader)
+	if err != nil {
+		return nil, fmt.Errorf("epub: %s: parse '%s': %w", epubReader.Name, name, err)
+	}
+
+	nav := document.find(func(n *domNode) bool {
+		return n.name == "nav" && hasSemantic(n, "toc")
+	})
+	if nav == nil {
+		nav = document.find(func(n *domNode) bool { return n.name == "nav" })
+	}
+	if nav == nil {
+		return nil, nil
+	}
+
+	return epubReader.newTOCBuilder(name, options).navList(firstChild(nav, "ol"), 1, isHidden(nav)), nil
+}
+
+// tocBuilder converts the entries of the navigation document or NCX name.
+type tocBuilder struct {
+	epubReader *EpubReader
+	name       string
+	maxDepth   int
+	// spine holds the zip paths of the spine documents.
+	spine map[string]bool
+}
+
+func (epubReader *EpubReader) newTOCBuilder(name string, options TOCOptions) *tocBuilder {
+	builder := &tocBuilder{epubReader: epubReader, name: name, maxDepth: options.MaxDepth, spine: make(map[string]bool)}
+	if builder.maxDepth <= 0 {
+		builder.maxDepth = defaultTOCDepth
+	}
+	for _, item := range epubReader.SpineItems() {
+		builder.spine[epubReader.itemPath(item.Href)] = true
+	}
+
+	return builder
+}
+
+// link sets the href of entry from a reference of the document.
+func (builder *tocBuilder) link(entry *TOCEntry, href string) {
+	if href == "" {
+		return
+	}
+	entry.Href = builder.epubReader.packageHref(builder.name, href)
+	entry.OutsideSpine = strings.Contains(href, "://") || !builder.spine[resolvePath(builder.name, href)]
+	if strings.HasPrefix(href, "#") {
+		entry.OutsideSpine = !builder.spine[builder.name]
+	}
+}
+
+func (builder *tocBuilder) ncxPoints(points []ncxNavPoint, depth int) []TOCEntry {
+	var entries []TOCEntry
+
+	for _, point := range points {
+		entry := TOCEntry{Title: collapseSpace(point.Label)}
+		builder.link(&entry, point.Content.Src)
+		if depth < builder.maxDepth {
+			entry.Children = builder.ncxPoints(point.NavPoints, depth+1)
+		} else {
+			entry.Truncated = len(point.NavPoints) > 0
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// navList returns the entries of an ol element of a navigation document,
+// at the given depth. hidden is set when an ancestor is hidden.
+func (builder *tocBuilder) navList(ol *domNode, depth int, hidden bool) []TOCEntry {
+	if ol == nil {
+		return nil
+	}
+	hidden = hidden || isHidden(ol)
+
+	var entries []TOCEntry
+	for _, li := range ol.elements() {
+		if li.name != "li" {
+			continue
+		}
+		entry := TOCEntry{Hidden: hidden || isHidden(li)}
+		if a := firstChild(li, "a"); a != nil {
+			entry.Title = collapseSpace(a.textContent())
+			builder.link(&entry, a.attr("href"))
+		} else if span := firstChild(li, "span"); span != nil {
+			entry.Title = collapseSpace(span.textContent())
+		}
+
+		children := firstChild(li, "ol")
+		if depth < builder.maxDepth {
+			entry.Children = builder.navList(children, depth+1, entry.Hidden)
+		} else {
+			entry.Truncated = children != nil && firstChild(children, "li") != nil
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// isHidden reports whether node has the hidden attribute.
+func isHidden(node *domNode) bool {
+	for _, a := range node.attrs {
+		if a.Name.Local == "hidden" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (epubReader *EpubReader) heuristicTOC() []TOCEntry {
+	var entries []TOCEntry
+
+	for _, item := range epubReader.SpineItems() {
+		if title, ok := epubReader.headingTitle(item); ok {
+			entries = append(entries, TOCEntry{Title: title, Href: item.Href, Heuristic: true})
+		}
+	}
+
+	return entries
+}
+
+// firstChild returns the first element child of node with the given name.
+func firstChild(node *domNode, name string) *domNode {
+	for _, child := range node.elements() {
+		if child.name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// hasSemantic reports whether the epub:type of node includes semantic.
+func hasSemantic(node *domNode, semantic string) bool {
+	for _, a := range node.attrs {
+		if a.Name.Local == "type" && a.Name.Space != "" {
+			for _, field := range strings.Fields(a.Value) {
+				if field == semantic {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// packageHref returns href, a reference relative to the zip entry base,
+// relative to the package document instead, keeping its fragment.
+func (epubReader *EpubReader) packageHref(base, href string) string {
+	if href == "" || strings.Contains(href, "://") {
+		return href
+	}
+
+	fragment := ""
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href, fragment = href[:i], href[i:]
+	}
+	if href == "" {
+		href = path.Base(base)
+	}
+
+	return relativePath(path.Dir(epubReader.Rootfiles[0].FullPath), resolvePath(base, href)) + fragment
+}
+
+// relativePath returns the slash separated path of target relative to the
+// directory dir, both being zip paths.
+func relativePath(dir, target string) string {
+	if dir == "." || dir == "" {
+		return target
+	}
+
+	from := strings.Split(dir, "/")
+	to := strings.Split(target, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+
+	return strings.Repeat("../", len(from)-common) + strings.Join(to[common:], "/")
+}
@@ -0,0 +1,135 @@
+package epub
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testNav = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body>
+<nav epub:type="landmarks"><ol><li><a href="text/chapter1.xhtml">Start</a></li></ol></nav>
+<nav epub:type="toc">
+  <h1>Contents</h1>
+  <ol>
+    <li><a href="text/chapter1.xhtml">Chapter
+      One</a>
+      <ol><li><a href="text/chapter1.xhtml#s1">Section 1</a></li></ol>
+    </li>
+    <li><span>Part Two</span>
+      <ol><li><a href="text/chapter2.xhtml">Chapter Two</a></li></ol>
+    </li>
+  </ol>
+</nav>
+</body>
+</html>`
+
+func TestTOC(t *testing.T) {
+	want := []TOCEntry{
+		{Title: "Chapter One", Href: "chapter1.xhtml"},
+		{Title: "Chapter Two", Href: "chapter2.xhtml"},
+	}
+	if toc, err := openTestEpub(t).TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("NCX TOC() = %+v, %v", toc, err)
+	}
+
+	opf := strings.Replace(testOPF, `<item id="ncx"`, `<item id="nav" href="nav/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ncx"`, 1)
+	opf = strings.Replace(opf, `version="2.0"`, `version="3.0"`, 1)
+	nav := testFile{"OEBPS/nav/nav.xhtml", strings.ReplaceAll(testNav, `href="text/`, `href="../`)}
+	want = []TOCEntry{
+		{Title: "Chapter One", Href: "chapter1.xhtml", Children: []TOCEntry{{Title: "Section 1", Href: "chapter1.xhtml#s1"}}},
+		{Title: "Part Two", Children: []TOCEntry{{Title: "Chapter Two", Href: "chapter2.xhtml"}}},
+	}
+	if toc, err := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, nav).TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("nav TOC() = %+v, %v", toc, err)
+	}
+
+	empty := testFile{"OEBPS/nav/nav.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml"><body><nav/></body></html>`}
+	want = []TOCEntry{
+		{Title: "Chapter One", Href: "chapter1.xhtml"},
+		{Title: "Chapter Two", Href: "chapter2.xhtml"},
+	}
+	if toc, err := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, empty).TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("empty nav TOC() = %+v, %v", toc, err)
+	}
+
+	want = []TOCEntry{
+		{Title: "Chapter One", Href: "chapter1.xhtml", Heuristic: true},
+		{Title: "Chapter Two", Href: "chapter2.xhtml", Heuristic: true},
+	}
+	if toc, err := openTestEpub(t, testFile{"OEBPS/toc.ncx", ""}).TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("heuristic TOC() = %+v, %v", toc, err)
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	for _, test := range []struct{ dir, target, want string }{
+		{".", "a/b.xhtml", "a/b.xhtml"},
+		{"OEBPS", "OEBPS/text/a.xhtml", "text/a.xhtml"},
+		{"OEBPS/opf", "OEBPS/text/a.xhtml", "../text/a.xhtml"},
+		{"OEBPS", "other.xhtml", "../other.xhtml"},
+	} {
+		if got := relativePath(test.dir, test.target); got != test.want {
+			t.Errorf("relativePath(%q, %q) = %q, want %q", test.dir, test.target, got, test.want)
+		}
+	}
+}
+
+const testNavEdgeCases = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<body>
+<nav epub:type="toc">
+  <ol>
+    <li><span>Part One</span>
+      <ol>
+        <li><a href="chapter1.xhtml">Level 2</a>
+          <ol><li><a href="chapter1.xhtml#l3">Level 3</a>
+            <ol><li><a href="chapter1.xhtml#l4">Level 4</a></li></ol>
+          </li></ol>
+        </li>
+      </ol>
+    </li>
+    <li><a href="notes.xhtml">Notes</a></li>
+    <li><a href="https://example.com/errata">Errata</a></li>
+    <li hidden=""><a href="chapter2.xhtml">Hidden</a></li>
+  </ol>
+  <ol hidden="hidden"><li><a href="chapter2.xhtml">Ignored second list</a></li></ol>
+</nav>
+</body>
+</html>`
+
+func TestTOCEdgeCases(t *testing.T) {
+	opf := strings.Replace(testOPF, `<item id="ncx"`, `<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="notes" href="notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ncx"`, 1)
+	opf = strings.Replace(opf, `version="2.0"`, `version="3.0"`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/nav.xhtml", testNavEdgeCases})
+
+	toc, err := reader.TOCWith(TOCOptions{MaxDepth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TOCEntry{
+		{Title: "Part One", Children: []TOCEntry{
+			{Title: "Level 2", Href: "chapter1.xhtml", Children: []TOCEntry{
+				{Title: "Level 3", Href: "chapter1.xhtml#l3", Truncated: true},
+			}},
+		}},
+		{Title: "Notes", Href: "notes.xhtml", OutsideSpine: true},
+		{Title: "Errata", Href: "https://example.com/errata", OutsideSpine: true},
+		{Title: "Hidden", Href: "chapter2.xhtml", Hidden: true},
+	}
+	if !reflect.DeepEqual(toc, want) {
+		t.Errorf("TOCWith() = %+v", toc)
+	}
+	if toc[0].HasLink() || !toc[1].HasLink() {
+		t.Error("HasLink() mismatch")
+	}
+
+	toc, err = reader.TOC()
+	if err != nil || len(toc[0].Children[0].Children[0].Children) != 1 {
+		t.Errorf("TOC() = %+v, %v", toc, err)
+	}
+}
@@ -0,0 +1,365 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrBadTOCPath occurs when the path of an entry given to
+	// InsertTOCEntry or MoveTOCEntry does not exist.
+	ErrBadTOCPath = errors.New("epub: no such table of contents entry")
+
+	// ErrNoTOCDocument occurs when writing the table of contents of a book
+	// with neither a navigation document nor an NCX.
+	ErrNoTOCDocument = errors.New("epub: no navigation document nor NCX")
+)
+
+// InsertTOCEntry inserts entry in entries at the given path: the indexes
+// of its ancestors, then its index among its siblings, which may be their
+// number to append it. Entries are edited in place, as are the titles of
+// the entries returned by TOC to rename them.
+func InsertTOCEntry(entries []TOCEntry, at []int, entry TOCEntry) ([]TOCEntry, error) {
+	siblings, ok := tocSiblings(&entries, at)
+	if !ok || at[len(at)-1] < 0 || at[len(at)-1] > len(*siblings) {
+		return entries, fmt.Errorf("%w: %v", ErrBadTOCPath, at)
+	}
+
+	i := at[len(at)-1]
+	*siblings = append((*siblings)[:i], append([]TOCEntry{entry}, (*siblings)[i:]...)...)
+
+	return entries, nil
+}
+
+// MoveTOCEntry moves the entry at the path from, with its children, to the
+// path to, to reorder or nest entries. to is a path of the entries once
+// the entry is removed, as InsertTOCEntry takes.
+func MoveTOCEntry(entries []TOCEntry, from, to []int) ([]TOCEntry, error) {
+	siblings, ok := tocSiblings(&entries, from)
+	if !ok || from[len(from)-1] < 0 || from[len(from)-1] >= len(*siblings) {
+		return entries, fmt.Errorf("%w: %v", ErrBadTOCPath, from)
+	}
+
+	i := from[len(from)-1]
+	entry := (*siblings)[i]
+	*siblings = append((*siblings)[:i], (*siblings)[i+1:]...)
+
+	return InsertTOCEntry(entries, to, entry)
+}
+
+// tocSiblings returns the siblings of the entry at the path at.
+func tocSiblings(entries *[]TOCEntry, at []int) (*[]TOCEntry, bool) {
+	if len(at) == 0 {
+		return nil, false
+	}
+	for _, i := range at[:len(at)-1] {
+		if i < 0 || i >= len(*entries) {
+			return nil, false
+		}
+		entries = &(*entries)[i].Children
+	}
+
+	return entries, true
+}
+
+// WriteWithTOC writes to w a copy of the book whose table of contents is
+// entries, such as those TOC returns once edited: the toc nav of the
+// navigation document and the navMap of the NCX are both rewritten when
+// the book has them, so that they stay in sync. The rest of the documents
+// is kept as is. Headings, entries without Href, are dropped from the NCX
+// when none of their children has a link.
+func (epubReader *EpubReader) WriteWithTOC(w io.Writer, entries []TOCEntry) error {
+	replacements := make(map[string][]byte)
+
+	if item, ok := epubReader.navItem(); ok {
+		name := epubReader.itemPath(item.Href)
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return err
+		}
+		if replacements[name], err = epubReader.navWithTOC(name, buffer.Bytes(), entries); err != nil {
+			return err
+		}
+	}
+	if item, ok := epubReader.ncxItem(); ok {
+		name := epubReader.itemPath(item.Href)
+		buffer, err := epubReader.readFile(name)
+		if err != nil {
+			return err
+		}
+		if replacements[name], err = epubReader.ncxWithTOC(name, buffer.Bytes(), entries); err != nil {
+			return err
+		}
+	}
+	if len(replacements) == 0 {
+		return fmt.Errorf("epub: %s: %w", epubReader.Name, ErrNoTOCDocument)
+	}
+
+	return epubReader.writeZip(w, replacements)
+}
+
+// SetTOCFile replaces the table of contents of the EPUB at filename with
+// entries, in place, with the guarantees of SafeWriteFile.
+func SetTOCFile(filename string, entries []TOCEntry) error {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	err = reader.WriteWithTOC(&buffer, entries)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	return SafeWriteFile(filename, SaveOptions{}, func(w io.Writer) error {
+		_, err := buffer.WriteTo(w)
+		return err
+	})
+}
+
+// navWithTOC returns the navigation document name, whose content is data,
+// with the list of its toc nav replaced by entries.
+func (epubReader *EpubReader) navWithTOC(name string, data []byte, entries []TOCEntry) ([]byte, error) {
+	isNav := func(element xml.StartElement) bool {
+		if strings.ToLower(element.Name.Local) != "nav" {
+			return false
+		}
+		for _, semantic := range epubType(element) {
+			if semantic == "toc" {
+				return true
+			}
+		}
+		return false
+	}
+	nav, ok := findElement(data, func(stack []xml.StartElement) bool { return isNav(stack[len(stack)-1]) })
+	if !ok {
+		isNav = func(element xml.StartElement) bool { return strings.ToLower(element.Name.Local) == "nav" }
+		if nav, ok = findElement(data, func(stack []xml.StartElement) bool { return isNav(stack[len(stack)-1]) }); !ok {
+			return nil, fmt.Errorf("epub: %s: '%s': no toc nav", epubReader.Name, name)
+		}
+	}
+
+	list, ok := findElement(data, func(stack []xml.StartElement) bool {
+		n := len(stack)
+		return n > 1 && strings.ToLower(stack[n-1].Name.Local) == "ol" && isNav(stack[n-2])
+	})
+	if !ok || list.start < nav.inner || list.end > nav.innerEnd {
+		list = markupSpan{start: nav.innerEnd, end: nav.innerEnd}
+	}
+
+	var b strings.Builder
+	epubReader.writeNavList(&b, name, entries, lineIndent(data, list.start), false)
+
+	return splice(data, list.start, list.end, b.String()), nil
+}
+
+// writeNavList writes entries as the ol element of a navigation document.
+func (epubReader *EpubReader) writeNavList(b *strings.Builder, name string, entries []TOCEntry, indent string, hidden bool) {
+	b.WriteString("<ol>\n")
+	for _, entry := range entries {
+		b.WriteString(indent + "  <li")
+		if entry.Hidden && !hidden {
+			b.WriteString(` hidden=""`)
+		}
+		b.WriteString(">")
+		if entry.HasLink() {
+			b.WriteString(`<a href="` + escapeXML(epubReader.documentHref(name, entry.Href)) + `">` + escapeXML(entry.Title) + "</a>")
+		} else {
+			b.WriteString("<span>" + escapeXML(entry.Title) + "</span>")
+		}
+		if len(entry.Children) > 0 {
+			b.WriteString("\n" + indent + "    ")
+			epubReader.writeNavList(b, name, entry.Children, indent+"    ", hidden || entry.Hidden)
+			b.WriteString("\n" + indent + "  ")
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString(indent + "</ol>")
+}
+
+// ncxWithTOC returns the NCX name, whose content is data, with the
+// navPoints of its navMap replaced by entries.
+func (epubReader *EpubReader) ncxWithTOC(name string, data []byte, entries []TOCEntry) ([]byte, error) {
+	navMap, ok := findElement(data, func(stack []xml.StartElement) bool { return stack[len(stack)-1].Name.Local == "navMap" })
+	if !ok {
+		return nil, fmt.Errorf("epub: %s: '%s': no navMap", epubReader.Name, name)
+	}
+
+	indent := lineIndent(data, navMap.start)
+	var b strings.Builder
+	playOrder := 0
+	epubReader.writeNavPoints(&b, name, entries, indent+"  ", &playOrder)
+	b.WriteString(indent)
+	if navMap.inner == navMap.end {
+		// A self-closing navMap.
+		return splice(data, navMap.start, navMap.end, "<navMap>\n"+b.String()+"</navMap>"), nil
+	}
+
+	return splice(data, navMap.inner, navMap.innerEnd, "\n"+b.String()), nil
+}
+
+// writeNavPoints writes entries as the navPoints of an NCX, numbered from
+// playOrder.
+func (epubReader *EpubReader) writeNavPoints(b *strings.Builder, name string, entries []TOCEntry, indent string, playOrder *int) {
+	for _, entry := range entries {
+		href := firstTOCHref(entry)
+		if href == "" {
+			continue
+		}
+		*playOrder++
+		order := strconv.Itoa(*playOrder)
+		b.WriteString(indent + `<navPoint id="navPoint-` + order + `" playOrder="` + order + `">` + "\n")
+		b.WriteString(indent + "  <navLabel><text>" + escapeXML(entry.Title) + "</text></navLabel>\n")
+		b.WriteString(indent + `  <content src="` + escapeXML(epubReader.documentHref(name, href)) + `"/>` + "\n")
+		epubReader.writeNavPoints(b, name, entry.Children, indent+"  ", playOrder)
+		b.WriteString(indent + "</navPoint>\n")
+	}
+}
+
+// firstTOCHref returns the href of entry, or else the first href of its
+// descendants.
+func firstTOCHref(entry TOCEntry) string {
+	if entry.HasLink() {
+		return entry.Href
+	}
+	for _, child := range entry.Children {
+		if href := firstTOCHref(child); href != "" {
+			return href
+		}
+	}
+
+	return ""
+}
+
+// documentHref returns href, a reference relative to the package document,
+// relative to the document name instead, keeping its fragment.
+func (epubReader *EpubReader) documentHref(name, href string) string {
+	if strings.Contains(href, "://") {
+		return href
+	}
+
+	fragment := ""
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href, fragment = href[:i], href[i:]
+	}
+	if href == "" {
+		return fragment
+	}
+
+	return escapeHref(relativePath(path.Dir(name), epubReader.itemPath(href))) + fragment
+}
+
+// markupSpan locates an element in markup: its start tag begins at start,
+// its content runs from inner to innerEnd and it ends at end. A
+// self-closing element has an empty content ending at end.
+type markupSpan struct {
+	start, inner, innerEnd, end int
+	element                     xml.StartElement
+}
+
+// findElement returns the span of the first element of data for which
+// match, given the open elements from the root, returns true.
+func findElement(data []byte, match func(stack []xml.StartElement) bool) (markupSpan, bool) {
+	spans := findElements(data, match, 1)
+	if len(spans) == 0 {
+		return markupSpan{}, false
+	}
+
+	return spans[0], true
+}
+
+// findElements returns the spans of the first n elements of data, all of
+// them if n is negative, for which match returns true, elements nested in
+// a matching element aside. Markup that cannot be parsed ends the search.
+func findElements(data []byte, match func(stack []xml.StartElement) bool, n int) []markupSpan {
+	return decoderElements(newXHTMLDecoder(bytes.NewReader(data)), match, n)
+}
+
+// findXMLElements is findElements for XML documents such as the package
+// document, whose meta and link elements are not void as in XHTML.
+func findXMLElements(data []byte, match func(stack []xml.StartElement) bool, n int) []markupSpan {
+	return decoderElements(newXMLDecoder(bytes.NewReader(data)), match, n)
+}
+
+// decoderElements returns the spans findElements returns, read from
+// decoder.
+func decoderElements(decoder *xml.Decoder, match func(stack []xml.StartElement) bool, n int) []markupSpan {
+	rawOffsets(decoder)
+	var stack []xml.StartElement
+	var spans, matches []markupSpan
+	found := -1
+	for n < 0 || len(matches) < n {
+		offset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t)
+			spans = append(spans, markupSpan{start: offset, inner: int(decoder.InputOffset()), element: t})
+			if found < 0 && match(stack) {
+				found = len(stack) - 1
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			if len(stack)-1 == found {
+				span := spans[found]
+				span.innerEnd, span.end = offset, int(decoder.InputOffset())
+				matches = append(matches, span)
+				found = -1
+			}
+			stack, spans = stack[:len(stack)-1], spans[:len(spans)-1]
+		}
+	}
+
+	return matches
+}
+
+// rawOffsets makes the byte offsets of decoder those of the data it reads,
+// whatever its encoding, for the markup to be edited in place. It returns
+// decoder.
+func rawOffsets(decoder *xml.Decoder) *xml.Decoder {
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	return decoder
+}
+
+// editedBuffer returns the buffer of data once edited. Its content is
+// never nil, which writeZip takes for a removed file.
+func editedBuffer(data []byte) *bytes.Buffer {
+	return bytes.NewBuffer(make([]byte, 0, len(data)))
+}
+
+// lineIndent returns the whitespace before offset of data on its line, or
+// nothing when other text precedes offset.
+func lineIndent(data []byte, offset int) string {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	if indent := data[start:offset]; len(bytes.TrimLeft(indent, " \t")) == 0 {
+		return string(indent)
+	}
+
+	return ""
+}
+
+// splice returns data with its bytes from start to end replaced by s.
+func splice(data []byte, start, end int, s string) []byte {
+	result := make([]byte, 0, len(data)-(end-start)+len(s))
+	result = append(result, data[:start]...)
+	result = append(result, s...)
+
+	return append(result, data[end:]...)
+}
@@ -0,0 +1,81 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteWithTOC(t *testing.T) {
+	book := buildTestEpub3(t)
+	reader, err := OpenBuffer(book, int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	toc, err := reader.TOC()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rename, reorder, nest and add entries.
+	toc[0].Title = "Chapter 1 & Prologue"
+	toc, err = MoveTOCEntry(toc, []int{1, 0}, []int{1})
+	if err == nil {
+		toc, err = MoveTOCEntry(toc, []int{0, 0}, []int{1, 0})
+	}
+	if err == nil {
+		toc, err = InsertTOCEntry(toc, []int{3}, TOCEntry{Title: "Cover", Href: "images/cover.jpg"})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TOCEntry{
+		{Title: "Chapter 1 & Prologue", Href: "chapter1.xhtml"},
+		{Title: "Chapter Two", Href: "chapter2.xhtml", Children: []TOCEntry{{Title: "Section 1", Href: "chapter1.xhtml#s1"}}},
+		{Title: "Part Two"},
+		{Title: "Cover", Href: "images/cover.jpg", OutsideSpine: true},
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteWithTOC(&buffer, toc); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if toc, err := written.TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("nav TOC() = %+v, %v", toc, err)
+	}
+	if landmarks, _ := written.readFile("OEBPS/nav.xhtml"); !strings.Contains(landmarks.String(), `<nav epub:type="landmarks">`) || !strings.Contains(landmarks.String(), "<h1>Contents</h1>") {
+		t.Errorf("nav.xhtml lost its other content:\n%s", landmarks)
+	}
+
+	item, _ := written.ncxItem()
+	ncx, err := written.ncxTOC(item, TOCOptions{})
+	wantNCX := []TOCEntry{want[0], want[1], want[3]}
+	if err != nil || !reflect.DeepEqual(ncx, wantNCX) {
+		t.Errorf("NCX TOC() = %+v, %v", ncx, err)
+	}
+	if document, _ := written.readFile("OEBPS/toc.ncx"); !strings.Contains(document.String(), `<navPoint id="navPoint-4" playOrder="4">`) || !strings.Contains(document.String(), "<docTitle>") {
+		t.Errorf("toc.ncx =\n%s", document)
+	}
+}
+
+func TestWriteWithTOCErrors(t *testing.T) {
+	toc := []TOCEntry{{Title: "One"}}
+	if _, err := InsertTOCEntry(toc, []int{0, 1}, TOCEntry{}); !errors.Is(err, ErrBadTOCPath) {
+		t.Errorf("InsertTOCEntry([0 1]) = %v, want ErrBadTOCPath", err)
+	}
+	if _, err := MoveTOCEntry(toc, []int{1}, []int{0}); !errors.Is(err, ErrBadTOCPath) {
+		t.Errorf("MoveTOCEntry([1]) = %v, want ErrBadTOCPath", err)
+	}
+
+	opf := strings.Replace(testOPF, `<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>`, "", 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/toc.ncx", ""})
+	if err := reader.WriteWithTOC(&bytes.Buffer{}, toc); !errors.Is(err, ErrNoTOCDocument) {
+		t.Errorf("WriteWithTOC() = %v, want ErrNoTOCDocument", err)
+	}
+}
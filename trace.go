@@ -0,0 +1,142 @@
+package epub
+
+import (
+	"context"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tracer receives spans around the costly operations of the package:
+// opening a book ("open"), decoding the container and package documents
+// ("decode"), reading zip entries ("read") and parsing content documents
+// ("parse"), and extracting text ("extract").
+type Tracer interface {
+	// StartSpan is called when an operation starts on the file or entry
+	// name, which is empty when unknown; the returned function is called
+	// when it ends.
+	StartSpan(operation, name string) func(err error)
+	// Count adds n to a counter, e.g. "bytes-read".
+	Count(counter string, n int64)
+}
+
+type tracerHolder struct{ Tracer }
+
+var tracer atomic.Value
+
+// SetTracer installs the tracer of the package, or removes it when t is
+// nil. Tracing is disabled by default.
+func SetTracer(t Tracer) {
+	tracer.Store(tracerHolder{t})
+}
+
+// startSpan starts a span on the installed tracer.
+func startSpan(operation, name string) func(err error) {
+	if holder, ok := tracer.Load().(tracerHolder); ok && holder.Tracer != nil {
+		return holder.StartSpan(operation, name)
+	}
+
+	return func(error) {}
+}
+
+func count(counter string, n int64) {
+	if holder, ok := tracer.Load().(tracerHolder); ok && holder.Tracer != nil {
+		holder.Count(counter, n)
+	}
+}
+
+// PprofTracer labels the goroutine running an operation with
+// epub.operation, so that CPU profiles can be broken down per operation
+// with pprof's -tagfocus and -tagshow options. Spans are not nested: the
+// label is cleared when any span ends.
+type PprofTracer struct{}
+
+// StartSpan implements Tracer.
+func (PprofTracer) StartSpan(operation, name string) func(err error) {
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("epub.operation", operation))
+	pprof.SetGoroutineLabels(ctx)
+
+	return func(error) { pprof.SetGoroutineLabels(context.Background()) }
+}
+
+// Count implements Tracer.
+func (PprofTracer) Count(string, int64) {}
+
+// OperationStat sums up the spans of an operation.
+type OperationStat struct {
+	Operation string
+	Count     int
+	Errors    int
+	Total     time.Duration
+	Max       time.Duration
+}
+
+// StatsTracer aggregates spans and counters in memory; it is safe for
+// concurrent use.
+type StatsTracer struct {
+	mu         sync.Mutex
+	operations map[string]*OperationStat
+	counters   map[string]int64
+}
+
+// StartSpan implements Tracer.
+func (stats *StatsTracer) StartSpan(operation, name string) func(err error) {
+	start := time.Now()
+
+	return func(err error) {
+		elapsed := time.Since(start)
+
+		stats.mu.Lock()
+		defer stats.mu.Unlock()
+		if stats.operations == nil {
+			stats.operations = make(map[string]*OperationStat)
+		}
+		stat, ok := stats.operations[operation]
+		if !ok {
+			stat = &OperationStat{Operation: operation}
+			stats.operations[operation] = stat
+		}
+		stat.Count++
+		if err != nil {
+			stat.Errors++
+		}
+		stat.Total += elapsed
+		if elapsed > stat.Max {
+			stat.Max = elapsed
+		}
+	}
+}
+
+// Count implements Tracer.
+func (stats *StatsTracer) Count(counter string, n int64) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if stats.counters == nil {
+		stats.counters = make(map[string]int64)
+	}
+	stats.counters[counter] += n
+}
+
+// Operations returns the statistics of the operations, sorted by name.
+func (stats *StatsTracer) Operations() []OperationStat {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	operations := make([]OperationStat, 0, len(stats.operations))
+	for _, stat := range stats.operations {
+		operations = append(operations, *stat)
+	}
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Operation < operations[j].Operation })
+
+	return operations
+}
+
+// Counter returns the value of a counter.
+func (stats *StatsTracer) Counter(counter string) int64 {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	return stats.counters[counter]
+}
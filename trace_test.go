@@ -0,0 +1,136 @@
+package epub
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatsTracer(t *testing.T) {
+	stats := new(StatsTracer)
+	SetTracer(stats)
+	defer SetTracer(nil)
+
+	reader := openTestEpub(t)
+	if _, err := reader.ExtractText(TextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, stat := range stats.Operations() {
+		counts[stat.Operation] = stat.Count
+	}
+	want := map[string]int{"open": 1, "decode": 2, "read": 3, "parse": 2, "extract": 2}
+	for operation, n := range want {
+		if counts[operation] != n {
+			t.Errorf("%s spans = %d, want %d (%v)", operation, counts[operation], n, counts)
+		}
+	}
+	if stats.Counter("bytes-read") == 0 {
+		t.Error("bytes-read = 0")
+	}
+
+	SetTracer(PprofTracer{})
+	openTestEpub(t)
+}
+
+// benchmarkBook returns a book of the given number of chapters, each about
+// 3000 words in 30 paragraphs with a heading and a table, the size of a
+// typical novel chapter.
+func benchmarkBook(b *testing.B, chapters int) []byte {
+	var manifest, spine strings.Builder
+	files := []testFile{{"OEBPS/chapter1.xhtml", ""}, {"OEBPS/chapter2.xhtml", ""}}
+	paragraph := "<p>" + strings.Repeat("The quick brown fox jumps over the lazy dog &amp; runs. ", 10) + "</p>\n"
+
+	for i := 1; i <= chapters; i++ {
+		fmt.Fprintf(&manifest, `<item id="c%d" href="text/c%d.xhtml" media-type="application/xhtml+xml"/>`, i, i)
+		fmt.Fprintf(&spine, `<itemref idref="c%d"/>`, i)
+		files = append(files, testFile{fmt.Sprintf("OEBPS/text/c%d.xhtml", i), fmt.Sprintf(
+			`<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Chapter %d</title></head><body><h1>Chapter %d</h1>%s<table><tr><td>a</td><td>b</td></tr></table></body></html>`,
+			i, i, strings.Repeat(paragraph, 30))})
+	}
+
+	opf := strings.Replace(testOPF, `<item id="chapter1"`, manifest.String()+`<item id="chapter1"`, 1)
+	opf = strings.Replace(opf, `<itemref idref="chapter1"/>`, spine.String(), 1)
+	opf = strings.Replace(opf, `<itemref idref="chapter2"/>`, "", 1)
+	files = append(files, testFile{"OEBPS/content.opf", opf})
+
+	return buildTestEpub(b, files...)
+}
+
+// BenchmarkOpenBuffer opens a book with and without pooled decoding; the
+// pooled variant allocates fewer bytes per open.
+func BenchmarkOpenBuffer(b *testing.B) {
+	data := benchmarkBook(b, 30)
+
+	for _, pooled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("pooled=%v", pooled), func(b *testing.B) {
+			SetPooledDecoding(pooled)
+			defer SetPooledDecoding(false)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := OpenBuffer(data, int64(len(data))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkExtractText(b *testing.B) {
+	data := benchmarkBook(b, 30)
+	reader, err := OpenBuffer(data, int64(len(data)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := reader.ExtractText(TextOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkChapterStats(b *testing.B) {
+	data := benchmarkBook(b, 30)
+	reader, err := OpenBuffer(data, int64(len(data)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := reader.ChapterStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidatePaths validates a library of 16 books with various
+// worker counts, to pick one for a machine.
+func BenchmarkValidatePaths(b *testing.B) {
+	dir := b.TempDir()
+	data := benchmarkBook(b, 10)
+	for i := 0; i < 16; i++ {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("book%02d.epub", i)), data, 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ValidatePaths([]string{dir}, workers, ValidateOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package epub
+
+import "strings"
+
+// The keys of the meta elements of the original title and language, the
+// first one written by SetTranslation.
+var (
+	originalTitleKeys    = []string{"original-title", "originaltitle", "original_title"}
+	originalLanguageKeys = []string{"original-language", "originallanguage", "original_language"}
+)
+
+// Translation links a translated book to its original work.
+type Translation struct {
+	// Source is dc:source, the identifier or description of the resource
+	// the book is derived from, such as the ISBN of the original.
+	Source           string
+	OriginalTitle    string
+	OriginalLanguage string
+	// Translator is the creator or contributor with the trl role.
+	Translator string
+}
+
+// Translation returns the translation metadata of the book, from
+// dc:source and the original-title and original-language meta
+// conventions. ok is false when the book declares none of them.
+func (epubReader *EpubReader) Translation() (translation Translation, ok bool) {
+	metadata := epubReader.Rootfiles[0].Metadata
+
+	translation.Source = strings.TrimSpace(firstText(metadata.Source))
+	translation.OriginalTitle = epubReader.metaValue(originalTitleKeys...)
+	translation.OriginalLanguage = epubReader.metaValue(originalLanguageKeys...)
+	for _, creator := range epubReader.creators() {
+		if strings.EqualFold(creator.Role, "trl") {
+			translation.Translator = strings.TrimSpace(creator.Text)
+			break
+		}
+	}
+
+	return translation, translation != Translation{}
+}
+
+// SetTranslation sets the translation metadata of the book, written by
+// WriteEdited and SaveAs: dc:source, original-title and original-language
+// meta elements, and the translator, a contributor with the trl role
+// replacing the creators and contributors with it. Empty fields are
+// removed.
+func (epubReader *EpubReader) SetTranslation(translation Translation) {
+	metadata := &epubReader.Rootfiles[0].Metadata
+	if translation.Source != strings.TrimSpace(firstText(metadata.Source)) {
+		metadata.Source = nil
+		if translation.Source != "" {
+			metadata.Source = []DCElement{{Text: translation.Source}}
+		}
+	}
+	metadata.Meta = setMeta(metadata.Meta, originalTitleKeys, translation.OriginalTitle)
+	metadata.Meta = setMeta(metadata.Meta, originalLanguageKeys, translation.OriginalLanguage)
+
+	if current, _ := epubReader.Translation(); current.Translator == translation.Translator {
+		return
+	}
+	metadata.Creator = withoutRole(metadata.Creator, "trl")
+	metadata.Contributor = withoutRole(metadata.Contributor, "trl")
+	if translation.Translator != "" {
+		metadata.Contributor = append(metadata.Contributor, Creator{DCElement: DCElement{Text: translation.Translator}, Role: "trl"})
+	}
+}
+
+// setMeta returns metas without the elements whose key is one of keys,
+// and with a meta element named keys[0] holding value, if any.
+func setMeta(metas []Meta, keys []string, value string) []Meta {
+	var kept []Meta
+	for _, meta := range metas {
+		if meta.Refines != "" || !containsString(keys, metaKey(meta.Property, meta.Name)) {
+			kept = append(kept, meta)
+		}
+	}
+	if value != "" {
+		kept = append(kept, Meta{Name: keys[0], Content: value})
+	}
+
+	return kept
+}
+
+// withoutRole returns creators without those with role.
+func withoutRole(creators []Creator, role string) []Creator {
+	var kept []Creator
+	for _, creator := range creators {
+		if !strings.EqualFold(creator.Role, role) {
+			kept = append(kept, creator)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,71 @@
+package epub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTranslation(t *testing.T) {
+	if translation, ok := openTestEpub(t).Translation(); ok {
+		t.Errorf("Translation() = %+v, want none", translation)
+	}
+
+	opf := strings.Replace(testOPF, "<dc:language>en</dc:language>", `<dc:language>en</dc:language>
+    <dc:source>urn:isbn:9782070360024</dc:source>
+    <dc:contributor opf:role="trl">Stuart Gilbert</dc:contributor>
+    <meta property="calibre:original-title">L'Étranger</meta>
+    <meta name="original-language" content="fr"/>`, 1)
+
+	translation, ok := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Translation()
+	want := Translation{
+		Source:           "urn:isbn:9782070360024",
+		OriginalTitle:    "L'Étranger",
+		OriginalLanguage: "fr",
+		Translator:       "Stuart Gilbert",
+	}
+	if !ok || translation != want {
+		t.Errorf("Translation() = %+v, %v, want %+v", translation, ok, want)
+	}
+}
+
+func TestSetTranslation(t *testing.T) {
+	opf := strings.Replace(testOPF, "<dc:language>en</dc:language>", `<dc:language>en</dc:language>
+    <meta name="calibre:original_title" content="L'Etranger"/>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf})
+	want := Translation{
+		Source:           "urn:isbn:9782070360024",
+		OriginalTitle:    "L'Étranger",
+		OriginalLanguage: "fr",
+		Translator:       "Stuart Gilbert",
+	}
+	reader.SetTranslation(want)
+	if translation, ok := reader.Translation(); !ok || translation != want {
+		t.Errorf("Translation() = %+v, %v, want %+v", translation, ok, want)
+	}
+
+	var buffer bytes.Buffer
+	if err := reader.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if translation, ok := written.Translation(); !ok || translation != want {
+		document, _ := written.readFile("OEBPS/content.opf")
+		t.Errorf("Translation() = %+v, %v, want %+v in\n%s", translation, ok, want, document)
+	}
+
+	written.SetTranslation(Translation{})
+	buffer.Reset()
+	if err := written.WriteEdited(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	if written, err = OpenBuffer(buffer.Bytes(), int64(buffer.Len())); err != nil {
+		t.Fatal(err)
+	}
+	if translation, ok := written.Translation(); ok {
+		t.Errorf("Translation() = %+v, want none", translation)
+	}
+}
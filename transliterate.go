@@ -0,0 +1,192 @@
+package epub
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Transliterator converts text to the Latin script, for sort keys and file
+// names.
+type Transliterator interface {
+	Transliterate(text string) string
+}
+
+// TransliteratorFunc adapts a function to the Transliterator interface.
+type TransliteratorFunc func(text string) string
+
+// Transliterate returns f(text).
+func (f TransliteratorFunc) Transliterate(text string) string {
+	return f(text)
+}
+
+// BasicTransliterator transliterates Cyrillic, Greek, Japanese kana and
+// Hangul to Latin letters, and removes the diacritics of Latin letters.
+// Other characters, such as Han ideographs whose reading depends on the
+// language and the word, are kept: a dictionary-based Transliterator is
+// needed for them.
+var BasicTransliterator Transliterator = TransliteratorFunc(transliterate)
+
+// transliterations maps letters to their Latin transliteration; lowercase
+// letters are added by init.
+var transliterations = map[rune]string{
+	// Latin
+	'Æ': "AE", 'Œ': "OE", 'Ø': "O", 'Đ': "D", 'Ð': "D", 'Ł': "L", 'Þ': "Th", 'ß': "ss",
+	// Cyrillic
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "Yo", 'Ж': "Zh",
+	'З': "Z", 'И': "I", 'Й': "Y", 'К': "K", 'Л': "L", 'М': "M", 'Н': "N", 'О': "O",
+	'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U", 'Ф': "F", 'Х': "Kh", 'Ц': "Ts",
+	'Ч': "Ch", 'Ш': "Sh", 'Щ': "Shch", 'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "Yu",
+	'Я': "Ya", 'Є': "Ye", 'І': "I", 'Ї': "Yi", 'Ґ': "G", 'Ў': "U",
+	// Greek
+	'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I", 'Θ': "Th",
+	'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X", 'Ο': "O", 'Π': "P",
+	'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F", 'Χ': "Ch", 'Ψ': "Ps", 'Ω': "O",
+	'Ά': "A", 'Έ': "E", 'Ή': "I", 'Ί': "I", 'Ό': "O", 'Ύ': "Y", 'Ώ': "O", 'Ϊ': "I", 'Ϋ': "Y",
+	'ς': "s", 'ΐ': "i", 'ΰ': "y",
+}
+
+// latinLetters lists Latin letters with diacritics after their base letter.
+var latinLetters = []string{
+	"AÀÁÂÃÄÅĀĂĄǍ", "CÇĆĈĊČ", "DĎ", "EÈÉÊËĒĔĖĘĚ", "GĜĞĠĢ", "HĤĦ", "IÌÍÎÏĨĪĬĮİǏ",
+	"JĴ", "KĶ", "LĹĻĽĿ", "NÑŃŅŇ", "OÒÓÔÕÖŌŎŐǑ", "RŔŖŘ", "SŚŜŞŠȘ", "TŢŤŦȚ",
+	"UÙÚÛÜŨŪŬŮŰŲǓ", "WŴ", "YÝŶŸ", "ZŹŻŽ",
+}
+
+// kana lists the hiragana syllables and their Hepburn romanization;
+// katakana are mapped to hiragana.
+const kana = "あa いi うu えe おo かka きki くku けke こko さsa しshi すsu せse そso " +
+	"たta ちchi つtsu てte とto なna にni ぬnu ねne のno はha ひhi ふfu へhe ほho " +
+	"まma みmi むmu めme もmo やya ゆyu よyo らra りri るru れre ろro わwa ゐi ゑe をo んn " +
+	"がga ぎgi ぐgu げge ごgo ざza じji ずzu ぜze ぞzo だda ぢji づzu でde どdo " +
+	"ばba びbi ぶbu べbe ぼbo ぱpa ぴpi ぷpu ぺpe ぽpo ぁa ぃi ぅu ぇe ぉo ゔvu ゃya ゅyu ょyo ゎwa"
+
+var kanaRomaji = make(map[rune]string)
+
+// Revised Romanization of the jamo of Hangul syllables.
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+func init() {
+	for _, letters := range latinLetters {
+		base, size := utf8.DecodeRuneInString(letters)
+		for _, r := range letters[size:] {
+			transliterations[r] = string(base)
+		}
+	}
+	lowercase := make(map[rune]string)
+	for r, latin := range transliterations {
+		if lower := unicode.ToLower(r); lower != r {
+			lowercase[lower] = strings.ToLower(latin)
+		}
+	}
+	for r, latin := range lowercase {
+		transliterations[r] = latin
+	}
+	for _, syllable := range strings.Fields(kana) {
+		r, size := utf8.DecodeRuneInString(syllable)
+		kanaRomaji[r] = syllable[size:]
+	}
+}
+
+func transliterate(text string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		r := toHiragana(runes[i])
+		if latin, ok := transliterations[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case r >= 0xAC00 && r <= 0xD7A3:
+			s := int(r - 0xAC00)
+			b.WriteString(hangulInitials[s/588] + hangulMedials[s%588/28] + hangulFinals[s%28])
+		case r == 'っ' && i+1 < len(runes):
+			// The small tsu doubles the consonant that follows.
+			next := kanaRomaji[toHiragana(runes[i+1])]
+			if strings.HasPrefix(next, "ch") {
+				b.WriteByte('t')
+			} else if next != "" && !strings.ContainsAny(next[:1], "aiueon") {
+				b.WriteByte(next[0])
+			}
+		case r == 'ー':
+			// The prolonged sound mark repeats the previous vowel.
+			if s := b.String(); s != "" && strings.ContainsAny(s[len(s)-1:], "aiueo") {
+				b.WriteByte(s[len(s)-1])
+			}
+		case kanaRomaji[r] != "":
+			romaji := kanaRomaji[r]
+			if i+1 < len(runes) && strings.HasSuffix(romaji, "i") && romaji != "i" {
+				// Syllables in i combine with a small ya, yu or yo.
+				switch next := toHiragana(runes[i+1]); next {
+				case 'ゃ', 'ゅ', 'ょ':
+					romaji = strings.TrimSuffix(romaji, "i")
+					if romaji != "sh" && romaji != "ch" && romaji != "j" {
+						romaji += "y"
+					}
+					romaji += kanaRomaji[next][1:]
+					i++
+				}
+			}
+			b.WriteString(romaji)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+
+	return r
+}
+
+// maxFilename is the length of file names in bytes most file systems
+// accept, with room for an extension.
+const maxFilename = 200
+
+// SafeFilename returns name as a file name valid on all common systems:
+// transliterated with transliterator unless it is nil, with the path
+// separators and the characters Windows forbids replaced by underscores,
+// without leading or trailing spaces and dots, and shortened to 200 bytes.
+func SafeFilename(name string, transliterator Transliterator) string {
+	if transliterator != nil {
+		name = transliterator.Transliterate(name)
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, collapseSpace(name))
+	if len(name) > maxFilename {
+		end := maxFilename
+		for end > 0 && !utf8.RuneStart(name[end]) {
+			end--
+		}
+		name = name[:end]
+	}
+
+	return windowsComponent(strings.TrimLeft(name, " ."))
+}
+
+// SortKey returns the key text is sorted on: transliterated with
+// transliterator unless it is nil, and folded to lowercase.
+func SortKey(text string, transliterator Transliterator) string {
+	if transliterator != nil {
+		text = transliterator.Transliterate(text)
+	}
+
+	return strings.ToLower(text)
+}
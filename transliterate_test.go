@@ -0,0 +1,66 @@
+package epub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	for _, test := range []struct {
+		text, want string
+	}{
+		{"Лев Толстой", "Lev Tolstoy"},
+		{"Достоевский", "Dostoevskiy"},
+		{"Αριστοτέλης", "Aristotelis"},
+		{"Émile Zoë Çelik", "Emile Zoe Celik"},
+		{"Øresund straße", "Oresund strasse"},
+		{"ひらがな カタカナ", "hiragana katakana"},
+		{"きょうと がっこう まっちゃ", "kyouto gakkou matcha"},
+		{"コーヒー", "koohii"},
+		{"한국", "hanguk"},
+		{"東京", "東京"},
+	} {
+		if got := BasicTransliterator.Transliterate(test.text); got != test.want {
+			t.Errorf("Transliterate(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestSafeFilename(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		transliterator Transliterator
+		want           string
+	}{
+		{"Война и мир", BasicTransliterator, "Voyna i mir"},
+		{"Война и мир", nil, "Война и мир"},
+		{`What? A "title": yes/no`, nil, "What_ A _title__ yes_no"},
+		{" .hidden. ", nil, "hidden"},
+		{"con.epub", nil, "con_.epub"},
+		{"a\tb\x01c\\d", nil, "a b_c_d"},
+		{"...", nil, "_"},
+	} {
+		if got := SafeFilename(test.name, test.transliterator); got != test.want {
+			t.Errorf("SafeFilename(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	if got := SafeFilename(strings.Repeat("é", 150), nil); len(got) != maxFilename {
+		t.Errorf("long name shortened to %d bytes, want %d", len(got), maxFilename)
+	}
+}
+
+func TestQueryTransliterate(t *testing.T) {
+	library := &Library{Books: []LibraryBook{
+		{Path: "a", Title: "Zoo"},
+		{Path: "b", Title: "Анна Каренина"},
+		{Path: "c", Title: "Bleak House"},
+	}}
+
+	if got := pagePaths(library.Query().SortBy(SortByTitle, false).Run()); got != "cab" {
+		t.Errorf("sorted without transliteration: %q", got)
+	}
+	if got := pagePaths(library.Query().Transliterate(BasicTransliterator).SortBy(SortByTitle, false).Run()); got != "bca" {
+		t.Errorf("sorted with transliteration: %q", got)
+	}
+}
@@ -0,0 +1,443 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Severity grades validation issues.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (severity Severity) String() string {
+	switch severity {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(severity))
+}
+
+// Issue is a problem found while validating a book.
+type Issue struct {
+	RuleID   string
+	Severity Severity
+	// Path is the zip entry the issue is about, if any.
+	Path    string
+	Message string
+	// Err is the error the issue stands for, such as ErrBadManifest, if
+	// any, for callers to test with errors.Is.
+	Err error
+}
+
+func (issue Issue) String() string {
+	if issue.Path != "" {
+		return fmt.Sprintf("%s %s: %s: %s", issue.Severity, issue.RuleID, issue.Path, issue.Message)
+	}
+
+	return fmt.Sprintf("%s %s: %s", issue.Severity, issue.RuleID, issue.Message)
+}
+
+// Rule is a validation check. Its ID is stable and can be used to filter or
+// report issues.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	// Versions lists the major EPUB versions the rule applies to; an empty
+	// list applies the rule to all versions.
+	Versions []int
+	Check    func(epubReader *EpubReader) []Issue
+}
+
+// AppliesTo reports whether the rule checks books of the given major version.
+func (rule Rule) AppliesTo(version int) bool {
+	if len(rule.Versions) == 0 {
+		return true
+	}
+	for _, v := range rule.Versions {
+		if v == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateOptions tunes validation.
+type ValidateOptions struct {
+	// Strict reports warnings as errors.
+	Strict bool
+	// Quiet only reports errors.
+	Quiet bool
+}
+
+// RuleContainer is reported when a file cannot be opened as an EPUB at all.
+const RuleContainer = "container"
+
+var builtinRules = []Rule{
+	{
+		ID:          RuleContainer,
+		Severity:    SeverityError,
+		Description: "The file is a zip archive with a mimetype, a container and a parsable package document.",
+	},
+	{
+		ID:          "mimetype-first",
+		Severity:    SeverityError,
+		Description: "A zip container starts with the mimetype entry, stored uncompressed, its content at offset 38.",
+		Check:       checkMimetypeFirst,
+	},
+	{
+		ID:          "spine-empty",
+		Severity:    SeverityError,
+		Description: "The spine has at least one itemref.",
+		Check:       checkSpineEmpty,
+	},
+	{
+		ID:          "spine-itemref",
+		Severity:    SeverityError,
+		Description: "Every spine itemref references a manifest item.",
+		Check:       checkSpineItemref,
+	},
+	{
+		ID:          "manifest-file",
+		Severity:    SeverityError,
+		Description: "Every local manifest item exists in the container.",
+		Check:       checkManifestFiles,
+	},
+	{
+		ID:          "duplicate-id",
+		Severity:    SeverityError,
+		Description: "The id attributes of the package document are unique.",
+		Check:       checkDuplicateIDs,
+	},
+	{
+		ID:          "unique-identifier",
+		Severity:    SeverityError,
+		Description: "The unique-identifier attribute of the package references a non-empty dc:identifier.",
+		Check:       checkUniqueIdentifier,
+	},
+	{
+		ID:          "foreign-resource",
+		Severity:    SeverityWarning,
+		Description: "Manifest items of a foreign media type have a fallback to a core media type.",
+		Check:       checkForeignResources,
+	},
+	{
+		ID:          "ncx-required",
+		Severity:    SeverityError,
+		Description: "An EPUB 2 spine references an NCX document through its toc attribute.",
+		Versions:    []int{2},
+		Check:       checkNCXRequired,
+	},
+	{
+		ID:          "nav-required",
+		Severity:    SeverityError,
+		Description: "An EPUB 3 manifest declares a navigation document with the nav property.",
+		Versions:    []int{3},
+		Check:       checkNavRequired,
+	},
+}
+
+// Rules returns the built-in and registered validation rules, sorted by ID.
+func Rules() []Rule {
+	customRulesMu.RLock()
+	rules := append(append([]Rule(nil), builtinRules...), customRules...)
+	customRulesMu.RUnlock()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return rules
+}
+
+// Validate checks the book against the rules for its EPUB version and
+// returns the issues found, ordered by rule ID and path.
+func (epubReader *EpubReader) Validate() []Issue {
+	return epubReader.ValidateWith(ValidateOptions{})
+}
+
+// ValidateWith is like Validate, with options.
+func (epubReader *EpubReader) ValidateWith(options ValidateOptions) []Issue {
+	var issues []Issue
+	version := epubReader.MajorVersion()
+
+	for _, rule := range Rules() {
+		if rule.Check == nil || !rule.AppliesTo(version) {
+			continue
+		}
+		for _, issue := range rule.Check(epubReader) {
+			issue.RuleID = rule.ID
+			issue.Severity = rule.Severity
+			if issue = options.apply(issue); issue.RuleID != "" {
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].RuleID != issues[j].RuleID {
+			return issues[i].RuleID < issues[j].RuleID
+		}
+		return issues[i].Path < issues[j].Path
+	})
+
+	return issues
+}
+
+// apply grades issue according to the options, and returns the zero Issue
+// when it should not be reported.
+func (options ValidateOptions) apply(issue Issue) Issue {
+	if options.Strict && issue.Severity == SeverityWarning {
+		issue.Severity = SeverityError
+	}
+	if options.Quiet && issue.Severity < SeverityError {
+		return Issue{}
+	}
+
+	return issue
+}
+
+// MajorVersion returns the major EPUB version declared by the package
+// document, defaulting to 2 when the version attribute is missing or
+// invalid.
+func (epubReader *EpubReader) MajorVersion() int {
+	version := epubReader.Rootfiles[0].Version
+	if i := strings.IndexByte(version, '.'); i >= 0 {
+		version = version[:i]
+	}
+	if major, err := strconv.Atoi(strings.TrimSpace(version)); err == nil && major > 0 {
+		return major
+	}
+
+	return 2
+}
+
+func checkMimetypeFirst(epubReader *EpubReader) []Issue {
+	storage, ok := epubReader.storage.(*zipStorage)
+	if !ok {
+		return nil
+	}
+	if err := storage.checkMimetype(); err != nil {
+		return []Issue{{Path: mimetypePath, Message: err.Error()}}
+	}
+
+	return nil
+}
+
+func checkSpineEmpty(epubReader *EpubReader) []Issue {
+	if len(epubReader.Rootfiles[0].Spine.Itemref) == 0 {
+		return []Issue{{Path: epubReader.Rootfiles[0].FullPath, Message: ErrNoItemref.Error(), Err: ErrNoItemref}}
+	}
+
+	return nil
+}
+
+func checkSpineItemref(epubReader *EpubReader) []Issue {
+	var issues []Issue
+
+	for _, itemref := range epubReader.Rootfiles[0].Spine.Itemref {
+		if _, ok := epubReader.ItemByID(itemref.Idref); !ok {
+			issues = append(issues, Issue{
+				Path:    epubReader.Rootfiles[0].FullPath,
+				Message: fmt.Sprintf("%s: '%s'", ErrBadItemref.Error(), itemref.Idref),
+				Err:     ErrBadItemref,
+			})
+		}
+	}
+
+	return issues
+}
+
+func checkManifestFiles(epubReader *EpubReader) []Issue {
+	var issues []Issue
+
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if strings.Contains(item.Href, ":") || item.Href == "" {
+			// Remote resources and data URLs.
+			continue
+		}
+		if name := epubReader.itemPath(item.Href); !epubReader.hasFile(name) {
+			issues = append(issues, Issue{
+				Path:    name,
+				Message: fmt.Sprintf("%s: '%s'", ErrBadManifest.Error(), item.ID),
+				Err:     ErrBadManifest,
+			})
+		}
+	}
+
+	return issues
+}
+
+func checkDuplicateIDs(epubReader *EpubReader) []Issue {
+	name := epubReader.Rootfiles[0].FullPath
+	buffer, err := epubReader.readFile(name)
+	if err != nil {
+		return nil
+	}
+
+	var issues []Issue
+	counts := make(map[string]int)
+	decoder := newXMLDecoder(bytes.NewReader(buffer.Bytes()))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local != "id" {
+				continue
+			}
+			if counts[attr.Value]++; counts[attr.Value] == 2 {
+				issues = append(issues, Issue{Path: name, Message: fmt.Sprintf("duplicate id '%s'", attr.Value)})
+			}
+		}
+	}
+
+	return issues
+}
+
+func checkUniqueIdentifier(epubReader *EpubReader) []Issue {
+	pkg := epubReader.Rootfiles[0].Package
+	if pkg.UniqueIdentifier == "" {
+		return []Issue{{Path: epubReader.Rootfiles[0].FullPath, Message: "package has no unique-identifier attribute"}}
+	}
+	for _, identifier := range pkg.Metadata.Identifier {
+		if identifier.ID == pkg.UniqueIdentifier && strings.TrimSpace(identifier.Text) != "" {
+			return nil
+		}
+	}
+
+	return []Issue{{Path: epubReader.Rootfiles[0].FullPath, Message: fmt.Sprintf("unique-identifier '%s' references no dc:identifier", pkg.UniqueIdentifier)}}
+}
+
+func checkNCXRequired(epubReader *EpubReader) []Issue {
+	spine := epubReader.Rootfiles[0].Spine
+	if item, ok := epubReader.ItemByID(spine.Toc); ok && item.MediaType == ncxMediaType {
+		return nil
+	}
+
+	return []Issue{{Path: epubReader.Rootfiles[0].FullPath, Message: "spine has no NCX table of contents"}}
+}
+
+func checkNavRequired(epubReader *EpubReader) []Issue {
+	for _, item := range epubReader.Rootfiles[0].Manifest.Item {
+		if epubReader.ItemHasProperty(item, ItemVocabulary+"nav") {
+			return nil
+		}
+	}
+
+	return []Issue{{Path: epubReader.Rootfiles[0].FullPath, Message: "manifest has no navigation document"}}
+}
+
+// FileReport holds the validation issues of one file.
+type FileReport struct {
+	Path   string
+	Issues []Issue
+}
+
+// HasErrors reports whether the report contains an error.
+func (report FileReport) HasErrors() bool {
+	for _, issue := range report.Issues {
+		if issue.Severity >= SeverityError {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateFile opens and validates the EPUB at filename. A file that cannot
+// be opened is reported as a RuleContainer issue.
+func ValidateFile(filename string, options ValidateOptions) FileReport {
+	report := FileReport{Path: filename}
+
+	reader, err := OpenReader(filename)
+	if err != nil {
+		report.Issues = []Issue{{RuleID: RuleContainer, Severity: SeverityError, Message: err.Error()}}
+		return report
+	}
+	defer reader.Close()
+
+	report.Issues = reader.ValidateWith(options)
+
+	return report
+}
+
+// ValidatePaths validates the given files, and the .epub files found in the
+// given directories, using up to workers concurrent goroutines. Reports are
+// sorted by path.
+func ValidatePaths(paths []string, workers int, options ValidateOptions) ([]FileReport, error) {
+	filenames, err := epubPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	reports := make([]FileReport, len(filenames))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				reports[i] = ValidateFile(filenames[i], options)
+			}
+		}()
+	}
+	for i := range filenames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Path < reports[j].Path })
+
+	return reports, nil
+}
+
+// epubPaths returns the given files and the .epub files found in the given
+// directories.
+func epubPaths(paths []string) ([]string, error) {
+	var filenames []string
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == root && !info.IsDir() {
+				filenames = append(filenames, path)
+			} else if !info.IsDir() && strings.EqualFold(filepath.Ext(path), ".epub") {
+				filenames = append(filenames, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filenames, nil
+}
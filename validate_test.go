@@ -0,0 +1,136 @@
+package epub
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if issues := openTestEpub(t).Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %v", issues)
+	}
+
+	opf := strings.Replace(testOPF, `<itemref idref="chapter2"/>`, `<itemref idref="chapter3"/>`, 1)
+	issues := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate()
+	if len(issues) != 1 || issues[0].RuleID != "spine-itemref" || issues[0].Severity != SeverityError || !errors.Is(issues[0].Err, ErrBadItemref) {
+		t.Errorf("Validate() = %v", issues)
+	}
+}
+
+func TestValidateStructure(t *testing.T) {
+	opf := strings.Replace(testOPF, `unique-identifier="bookid"`, `unique-identifier="uid"`, 1)
+	opf = strings.Replace(opf, `<item id="css" href="style.css"`, `<item id="chapter1" href="missing.xhtml" media-type="application/xhtml+xml"/>
+    <item id="remote" href="https://example.com/font.woff" media-type="font/woff"/>
+    <item id="css" href="style.css"`, 1)
+	issues := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate()
+
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.RuleID+" "+issue.Path+" "+issue.Message)
+	}
+	want := []string{
+		"duplicate-id OEBPS/content.opf duplicate id 'chapter1'",
+		"manifest-file OEBPS/missing.xhtml epub: manifest references non-existent item: 'chapter1'",
+		"unique-identifier OEBPS/content.opf unique-identifier 'uid' references no dc:identifier",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Validate() = %q", got)
+	}
+	if !errors.Is(issues[1].Err, ErrBadManifest) {
+		t.Errorf("manifest-file Err = %v", issues[1].Err)
+	}
+
+	opf = strings.Replace(testOPF, ` unique-identifier="bookid"`, "", 1)
+	issues = openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate()
+	if len(issues) != 1 || issues[0].RuleID != "unique-identifier" {
+		t.Errorf("Validate() without unique-identifier = %v", issues)
+	}
+}
+
+func TestValidatePaths(t *testing.T) {
+	dir := t.TempDir()
+	opf := strings.Replace(testOPF, `<itemref idref="chapter2"/>`, `<itemref idref="chapter3"/>`, 1)
+	files := map[string][]byte{
+		"good.epub":       buildTestEpub(t),
+		"sub/bad.epub":    buildTestEpub(t, testFile{"OEBPS/content.opf", opf}),
+		"sub/broken.EPUB": []byte("not a zip"),
+		"notes.txt":       []byte("ignored"),
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := ValidatePaths([]string{dir}, 2, ValidateOptions{})
+	if err != nil {
+		t.Fatalf("ValidatePaths() = %v", err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("ValidatePaths() = %v", reports)
+	}
+	if reports[0].HasErrors() || !reports[1].HasErrors() || reports[2].Issues[0].RuleID != RuleContainer {
+		t.Errorf("ValidatePaths() = %v", reports)
+	}
+
+	var junit bytes.Buffer
+	if err := WriteJUnit(&junit, reports); err != nil {
+		t.Fatalf("WriteJUnit() = %v", err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(junit.Bytes(), &suites); err != nil || len(suites.Suites) != 3 || suites.Suites[1].Failures != 1 {
+		t.Errorf("WriteJUnit() = %s, %v", junit.String(), err)
+	}
+
+	var sarif bytes.Buffer
+	if err := WriteSARIF(&sarif, reports); err != nil {
+		t.Fatalf("WriteSARIF() = %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil || len(log.Runs[0].Results) != 2 {
+		t.Errorf("WriteSARIF() = %s, %v", sarif.String(), err)
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	opf := strings.Replace(testOPF, `<spine toc="ncx">`, `<spine>`, 1)
+	issues := openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate()
+	if len(issues) != 1 || issues[0].RuleID != "ncx-required" {
+		t.Errorf("Validate(EPUB 2 without NCX) = %v", issues)
+	}
+
+	opf = strings.Replace(opf, `version="2.0"`, `version="3.0"`, 1)
+	issues = openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate()
+	if len(issues) != 1 || issues[0].RuleID != "nav-required" {
+		t.Errorf("Validate(EPUB 3 without nav) = %v", issues)
+	}
+
+	opf = strings.Replace(opf, `href="chapter1.xhtml"`, `href="chapter1.xhtml" properties="nav"`, 1)
+	if issues = openTestEpub(t, testFile{"OEBPS/content.opf", opf}).Validate(); len(issues) != 0 {
+		t.Errorf("Validate(EPUB 3 with nav) = %v", issues)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	warning := Issue{RuleID: "w", Severity: SeverityWarning}
+
+	if issue := (ValidateOptions{Strict: true}).apply(warning); issue.Severity != SeverityError {
+		t.Errorf("Strict: %v", issue)
+	}
+	if issue := (ValidateOptions{Quiet: true}).apply(warning); issue.RuleID != "" {
+		t.Errorf("Quiet: %v", issue)
+	}
+	if issue := (ValidateOptions{Strict: true, Quiet: true}).apply(warning); issue.RuleID == "" {
+		t.Errorf("Strict and Quiet: %v", issue)
+	}
+}
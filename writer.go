@@ -0,0 +1,428 @@
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// The files EpubWriter generates, relative to the package document.
+const (
+	writerPackagePath = "OEBPS/content.opf"
+	writerNavHref     = "nav.xhtml"
+)
+
+// ErrBadHref occurs when an item added to an EpubWriter has an invalid or
+// duplicate href, or when OpenItem is given a href outside the container.
+var ErrBadHref = errors.New("epub: invalid href")
+
+// WriterMetadata is the metadata of a book created with EpubWriter.
+type WriterMetadata struct {
+	// Identifier is the unique identifier of the book, a random urn:uuid
+	// when empty.
+	Identifier string
+	Title      string
+	// Language is "und" when empty.
+	Language    string
+	Creators    []string
+	Publisher   string
+	Description string
+	Subjects    []string
+	// Date is the publication date, such as 2021 or 2021-03-14.
+	Date string
+	// Cover is the href of the item holding the cover image, if any.
+	Cover string
+	// Modified is the dcterms:modified date, the current time when zero.
+	Modified time.Time
+	// Meta are other meta elements of the package, such as the
+	// rendition:layout property of fixed-layout books.
+	Meta []Meta
+	// Links are the link elements of the metadata, such as those of
+	// metadata records.
+	Links []Link
+	// Prefix is the prefix attribute of the package, declaring the
+	// vocabularies of the properties of Meta and of the spine.
+	Prefix string
+	// PageProgressionDirection is the page-progression-direction of the
+	// spine, such as "rtl".
+	PageProgressionDirection string
+}
+
+// WriterItemOptions tunes an item added with AddItemWith.
+type WriterItemOptions struct {
+	// ID is the manifest id of the item, item-N when empty.
+	ID string
+	// Title is the entry of an XHTML document in the navigation document,
+	// its first heading or title when empty.
+	Title string
+	// SpineProperties are the properties of the itemref of an XHTML
+	// document, such as rendition:page-spread-left.
+	SpineProperties string
+}
+
+// EpubWriter creates an EPUB 3 book. XHTML documents are added to the
+// spine in the order they are added, and listed by a generated navigation
+// document under their first heading or title.
+type EpubWriter struct {
+	Metadata WriterMetadata
+	items    []writerItem
+}
+
+type writerItem struct {
+	Item
+	options WriterItemOptions
+	data    []byte
+	// open returns the content of the items added by ImportComic, read
+	// when the book is written rather than kept in memory.
+	open func() (io.ReadCloser, error)
+}
+
+// NewWriter returns a writer of a book with the given metadata.
+func NewWriter(metadata WriterMetadata) *EpubWriter {
+	return &EpubWriter{Metadata: metadata}
+}
+
+// AddItem adds to the book the file href, relative to the package
+// document, with the content of r.
+func (epubWriter *EpubWriter) AddItem(href, mediaType string, r io.Reader) error {
+	return epubWriter.AddItemWith(href, mediaType, r, WriterItemOptions{})
+}
+
+// AddItemWith is like AddItem, with options.
+func (epubWriter *EpubWriter) AddItemWith(href, mediaType string, r io.Reader, options WriterItemOptions) error {
+	if err := epubWriter.checkItem(href, options); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("epub: add '%s': %w", href, err)
+	}
+	epubWriter.addItem(href, mediaType, options, data, nil)
+
+	return nil
+}
+
+// checkItem returns an error when an item cannot be added with href and
+// options.
+func (epubWriter *EpubWriter) checkItem(href string, options WriterItemOptions) error {
+	if href == "" || path.IsAbs(href) || strings.ContainsAny(href, "#?\\") || path.Clean(href) != href || href == "." || href == ".." || strings.HasPrefix(href, "../") {
+		return fmt.Errorf("%w: '%s'", ErrBadHref, href)
+	}
+	if href == writerNavHref || href == path.Base(writerPackagePath) {
+		return fmt.Errorf("%w: '%s' is reserved", ErrBadHref, href)
+	}
+	for _, item := range epubWriter.items {
+		if strings.EqualFold(item.Href, href) {
+			return fmt.Errorf("%w: '%s' already added", ErrBadHref, href)
+		}
+		if options.ID != "" && item.ID == options.ID {
+			return fmt.Errorf("epub: add '%s': duplicate id '%s'", href, options.ID)
+		}
+	}
+	if options.ID == "nav" {
+		return fmt.Errorf("epub: add '%s': id 'nav' is reserved", href)
+	}
+
+	return nil
+}
+
+// addItem adds an item checked by checkItem, whose content is data or else
+// is read from open.
+func (epubWriter *EpubWriter) addItem(href, mediaType string, options WriterItemOptions, data []byte, open func() (io.ReadCloser, error)) {
+	id := options.ID
+	if id == "" {
+		id = fmt.Sprintf("item-%d", len(epubWriter.items)+1)
+	}
+	epubWriter.items = append(epubWriter.items, writerItem{
+		Item:    Item{ID: id, Href: href, MediaType: mediaType},
+		options: options,
+		data:    data,
+		open:    open,
+	})
+}
+
+type writerNavEntry struct {
+	Href  string
+	Title string
+}
+
+type writerItemref struct {
+	Idref      string
+	Properties string
+}
+
+// WriteTo writes the book to w: the stored mimetype entry first, then
+// container.xml, the package document, the navigation document and the
+// items. It implements io.WriterTo. The random identifier of a book
+// without one is kept in Metadata, for every copy to share it.
+func (epubWriter *EpubWriter) WriteTo(w io.Writer) (int64, error) {
+	metadata := epubWriter.writerMetadata()
+	counter := &countingWriter{w: w}
+	writer := zip.NewWriter(counter)
+	err := epubWriter.writeFiles(metadata, func(name string, method uint16, write func(io.Writer) error) error {
+		header := &zip.FileHeader{Name: name, Method: method}
+		if name != mimetypePath {
+			// The mimetype entry must not have extra fields, which the
+			// modification time would add.
+			header.Modified = metadata.Modified
+		}
+		entry, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		return write(entry)
+	})
+	if err != nil {
+		return counter.n, err
+	}
+	err = writer.Close()
+
+	return counter.n, err
+}
+
+// WriteDir writes the book unpacked below dir, creating it if needed, as
+// OpenDir reads it. The files are checked as ExtractAll checks entries:
+// none is written through a symbolic link.
+func (epubWriter *EpubWriter) WriteDir(dir string) error {
+	return epubWriter.writeFiles(epubWriter.writerMetadata(), func(name string, _ uint16, write func(io.Writer) error) error {
+		rel, err := entryPath(name)
+		if err != nil {
+			return err
+		}
+		if err := checkNoSymlink(dir, rel); err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(longPath(filepath.Dir(target)), 0o755); err != nil {
+			return err
+		}
+		file, err := os.Create(longPath(target))
+		if err != nil {
+			return err
+		}
+		if err := write(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	})
+}
+
+// writerMetadata returns Metadata, its identifier and modification time
+// set.
+func (epubWriter *EpubWriter) writerMetadata() WriterMetadata {
+	metadata := epubWriter.Metadata
+	if metadata.Identifier == "" {
+		metadata.Identifier = randomURN()
+		epubWriter.Metadata.Identifier = metadata.Identifier
+	}
+	if metadata.Modified.IsZero() {
+		metadata.Modified = time.Now()
+	}
+
+	return metadata
+}
+
+// writeFiles generates the files of the book in order, calling create with
+// the name, the zip compression method and the content of each.
+func (epubWriter *EpubWriter) writeFiles(metadata WriterMetadata, create func(name string, method uint16, write func(io.Writer) error) error) error {
+	book := struct {
+		WriterMetadata
+		Items []Item
+		Spine []writerItemref
+		Nav   []writerNavEntry
+	}{WriterMetadata: metadata}
+	for _, item := range epubWriter.items {
+		title := item.Href
+		if item.Href == metadata.Cover {
+			item.Properties = "cover-image"
+		}
+		item.Href = escapeHref(item.Href)
+		book.Items = append(book.Items, item.Item)
+		if item.MediaType != "application/xhtml+xml" {
+			continue
+		}
+		book.Spine = append(book.Spine, writerItemref{Idref: item.ID, Properties: item.options.SpineProperties})
+		if item.options.Title != "" {
+			title = item.options.Title
+		} else if document, err := parseDOM(bytes.NewReader(item.data)); err == nil {
+			if heading, ok := documentHeading(document); ok {
+				title = heading
+			}
+		}
+		book.Nav = append(book.Nav, writerNavEntry{Href: item.Href, Title: title})
+	}
+	if len(book.Spine) == 0 {
+		return fmt.Errorf("epub: %s: %w", metadata.Title, ErrNoItemref)
+	}
+
+	file := func(name string, method uint16, write func(io.Writer) error) error {
+		if err := create(name, method, write); err != nil {
+			return fmt.Errorf("epub: %s: write '%s': %w", metadata.Title, name, err)
+		}
+		return nil
+	}
+	write := func(data []byte) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}
+	}
+	execute := func(t *template.Template) func(io.Writer) error {
+		return func(w io.Writer) error { return t.Execute(w, book) }
+	}
+
+	if err := file(mimetypePath, zip.Store, write([]byte(epubMimetype))); err != nil {
+		return err
+	}
+	if err := file(containerPath, zip.Deflate, write([]byte(containerTemplate))); err != nil {
+		return err
+	}
+	if err := file(writerPackagePath, zip.Deflate, execute(writerPackage)); err != nil {
+		return err
+	}
+	if err := file(path.Join(path.Dir(writerPackagePath), writerNavHref), zip.Deflate, execute(writerNav)); err != nil {
+		return err
+	}
+	for _, item := range epubWriter.items {
+		method := uint16(zip.Deflate)
+		if isImage(item.Item) || strings.HasPrefix(item.MediaType, "audio/") || strings.HasPrefix(item.MediaType, "video/") {
+			// Already compressed.
+			method = zip.Store
+		}
+		content := write(item.data)
+		if item.open != nil {
+			content = copyFrom(item.open)
+		}
+		if err := file(path.Join(path.Dir(writerPackagePath), item.Href), method, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyFrom returns a function copying the content open returns.
+func copyFrom(open func() (io.ReadCloser, error)) func(io.Writer) error {
+	return func(w io.Writer) error {
+		r, err := open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(w, r)
+		return err
+	}
+}
+
+// escapeHref percent-encodes the characters of a path that may not appear
+// in a URL, such as spaces.
+func escapeHref(href string) string {
+	return (&url.URL{Path: href}).EscapedPath()
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (writer *countingWriter) Write(p []byte) (int, error) {
+	n, err := writer.w.Write(p)
+	writer.n += int64(n)
+
+	return n, err
+}
+
+// containerTemplate is the container.xml of the books created, whose
+// package document is writerPackagePath.
+const containerTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+// templateFuncs are the functions of the templates of the books created.
+var templateFuncs = template.FuncMap{
+	"xml":      escapeXML,
+	"modified": func(t time.Time) string { return t.UTC().Format("2006-01-02T15:04:05Z") },
+	"link":     Link.markup,
+}
+
+var writerPackage = template.Must(template.New("package").Funcs(templateFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid"{{if .Prefix}} prefix="{{xml .Prefix}}"{{end}}>
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="bookid">{{xml .Identifier}}</dc:identifier>
+    <dc:title>{{xml .Title}}</dc:title>
+    <dc:language>{{if .Language}}{{xml .Language}}{{else}}und{{end}}</dc:language>
+{{- range .Creators}}
+    <dc:creator>{{xml .}}</dc:creator>
+{{- end}}
+{{- if .Publisher}}
+    <dc:publisher>{{xml .Publisher}}</dc:publisher>
+{{- end}}
+{{- if .Description}}
+    <dc:description>{{xml .Description}}</dc:description>
+{{- end}}
+{{- range .Subjects}}
+    <dc:subject>{{xml .}}</dc:subject>
+{{- end}}
+{{- if .Date}}
+    <dc:date>{{xml .Date}}</dc:date>
+{{- end}}
+    <meta property="dcterms:modified">{{modified .Modified}}</meta>
+{{- range .Meta}}
+{{- if .Property}}
+    <meta property="{{xml .Property}}"{{if .Refines}} refines="{{xml .Refines}}"{{end}}{{if .Scheme}} scheme="{{xml .Scheme}}"{{end}}>{{xml .Text}}</meta>
+{{- else}}
+    <meta name="{{xml .Name}}" content="{{xml .Content}}"/>
+{{- end}}
+{{- end}}
+{{- range .Links}}
+    {{link .}}
+{{- end}}
+{{- range .Items}}{{if .Properties}}
+    <meta name="cover" content="{{.ID}}"/>
+{{- end}}{{end}}
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+{{- range .Items}}
+    <item id="{{.ID}}" href="{{xml .Href}}" media-type="{{xml .MediaType}}"{{if .Properties}} properties="{{.Properties}}"{{end}}/>
+{{- end}}
+  </manifest>
+  <spine{{if .PageProgressionDirection}} page-progression-direction="{{xml .PageProgressionDirection}}"{{end}}>
+{{- range .Spine}}
+    <itemref idref="{{.Idref}}"{{if .Properties}} properties="{{xml .Properties}}"{{end}}/>
+{{- end}}
+  </spine>
+</package>
+`))
+
+var writerNav = template.Must(template.New("nav").Funcs(templateFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>{{xml .Title}}</title></head>
+<body>
+<nav epub:type="toc" id="toc">
+<h1>{{xml .Title}}</h1>
+<ol>
+{{- range .Nav}}
+<li><a href="{{xml .Href}}">{{xml .Title}}</a></li>
+{{- end}}
+</ol>
+</nav>
+</body>
+</html>
+`))
@@ -0,0 +1,130 @@
+package epub
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEpubWriter(t *testing.T) {
+	writer := NewWriter(WriterMetadata{
+		Title:    "A & B",
+		Language: "en",
+		Creators: []string{"Jane Doe", "John Roe"},
+		Cover:    "images/cover.jpg",
+		Links:    []Link{{Href: "https://example.com/onix.xml", Rel: "record", MediaType: "application/xml", Properties: "onix"}},
+		Modified: time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC),
+	})
+	for _, item := range []struct{ href, mediaType, content string }{
+		{"text/chapter 1.xhtml", "application/xhtml+xml", `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>One</title></head><body><h1>Chapter One</h1><img src="../images/cover.jpg" alt="cover"/></body></html>`},
+		{"text/chapter2.xhtml", "application/xhtml+xml", `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Two</title></head><body><p>Text</p></body></html>`},
+		{"images/cover.jpg", "image/jpeg", "\xff\xd8\xff\xe0 not really a jpeg"},
+	} {
+		if err := writer.AddItem(item.href, item.mediaType, strings.NewReader(item.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, href := range []string{"text/chapter2.xhtml", "../outside.xhtml", "/abs.xhtml", "a/./b.xhtml", "nav.xhtml", "x.xhtml#f", ""} {
+		if err := writer.AddItem(href, "application/xhtml+xml", strings.NewReader("")); !errors.Is(err, ErrBadHref) {
+			t.Errorf("AddItem(%q) = %v, want ErrBadHref", href, err)
+		}
+	}
+
+	var buffer bytes.Buffer
+	n, err := writer.WriteTo(&buffer)
+	if err != nil || n != int64(buffer.Len()) {
+		t.Fatalf("WriteTo() = %d, %v; wrote %d bytes", n, err, buffer.Len())
+	}
+	if !bytes.HasPrefix(buffer.Bytes()[30:], []byte(mimetypePath+epubMimetype)) {
+		t.Errorf("mimetype is not the first stored entry: %q", buffer.Bytes()[:70])
+	}
+	if !strings.HasPrefix(writer.Metadata.Identifier, "urn:uuid:") {
+		t.Errorf("Identifier = %q, want a urn:uuid", writer.Metadata.Identifier)
+	}
+
+	reader, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if issues := reader.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %+v", issues)
+	}
+	if title := firstTitle(reader.Rootfiles[0].Metadata.Title); title != "A & B" {
+		t.Errorf("Title = %q", title)
+	}
+	if href, mediaType, _, err := reader.GetCover(); err != nil || href != "images/cover.jpg" || mediaType != "image/jpeg" {
+		t.Errorf("GetCover() = %q, %q, %v", href, mediaType, err)
+	}
+	if links := reader.Links(); len(links) != 1 || links[0] != writer.Metadata.Links[0] {
+		t.Errorf("Links() = %+v", links)
+	}
+	want := []TOCEntry{
+		{Title: "Chapter One", Href: "text/chapter 1.xhtml"},
+		{Title: "Two", Href: "text/chapter2.xhtml"},
+	}
+	if toc, err := reader.TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("TOC() = %+v, %v", toc, err)
+	}
+	if text, err := reader.ItemText(reader.SpineItems()[0], TextOptions{}); err != nil || !strings.Contains(text, "Chapter One") {
+		t.Errorf("ItemText() = %q, %v", text, err)
+	}
+
+	if _, err := NewWriter(WriterMetadata{Title: "Empty"}).WriteTo(&buffer); !errors.Is(err, ErrNoItemref) {
+		t.Errorf("WriteTo() of an empty book = %v, want ErrNoItemref", err)
+	}
+}
+
+// TestEpubWriterDir checks that a book written to a directory opens as the
+// same book written to a zip file.
+func TestEpubWriterDir(t *testing.T) {
+	writer := NewWriter(WriterMetadata{Title: "Unpacked", Modified: time.Date(2021, 3, 14, 15, 9, 26, 0, time.UTC)})
+	for _, item := range []struct{ href, mediaType, content string }{
+		{"text/chapter 1.xhtml", "application/xhtml+xml", `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>One</title></head><body><h1>Chapter One</h1></body></html>`},
+		{"images/cover.jpg", "image/jpeg", "\xff\xd8\xff\xe0 not really a jpeg"},
+	} {
+		if err := writer.AddItem(item.href, item.mediaType, strings.NewReader(item.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	if err := writer.WriteDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	unpacked, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unpacked.Close()
+	var buffer bytes.Buffer
+	if _, err := writer.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	zipped, err := OpenBuffer(buffer.Bytes(), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zipped.Close()
+
+	if issues := unpacked.Validate(); len(issues) != 0 {
+		t.Errorf("Validate() = %+v", issues)
+	}
+	// The files of a directory are sorted.
+	names := zipped.FileNames()
+	sort.Strings(names[1:])
+	if !reflect.DeepEqual(unpacked.FileNames(), names) {
+		t.Errorf("FileNames() = %v, want %v", unpacked.FileNames(), names)
+	}
+	if unpacked.Rootfiles[0].Metadata.Identifier[0].Text != zipped.Rootfiles[0].Metadata.Identifier[0].Text {
+		t.Error("the copies have different identifiers")
+	}
+	toc, err := unpacked.TOC()
+	if want, _ := zipped.TOC(); err != nil || !reflect.DeepEqual(toc, want) {
+		t.Errorf("TOC() = %+v, %v, want %+v", toc, err, want)
+	}
+}
@@ -0,0 +1,60 @@
+package epub
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// newXHTMLDecoder returns a lenient decoder for content documents, which are
+// often served as XHTML without being well-formed XML.
+func newXHTMLDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(newEntityGuard(r))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charsetReader
+
+	return decoder
+}
+
+// charsetReader converts the single byte encodings found in older books to
+// UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1", "iso_8859-1":
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	case "windows-1252", "cp1252":
+		// Not ISO-8859-1: 0x80 to 0x9F are quotes, dashes and the euro.
+		return charmap.Windows1252.NewDecoder().Reader(input), nil
+	}
+
+	return nil, fmt.Errorf("epub: unsupported charset %s", charset)
+}
+
+// attr returns the value of the attribute with the given local name.
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// epubType returns the space separated epub:type semantics of an element.
+func epubType(element xml.StartElement) []string {
+	for _, a := range element.Attr {
+		if a.Name.Local == "type" && a.Name.Space != "" {
+			return strings.Fields(a.Value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package epub
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrUnsafeXML occurs when an XML document of the book declares entities,
+// the vector of external entity (XXE) and entity expansion attacks.
+var ErrUnsafeXML = errors.New("epub: unsafe XML: entity declaration")
+
+// markupPrefixes are the starts of markup whose content the guard must
+// tell apart: comments and CDATA sections, whose content is text, and entity
+// declarations.
+var markupPrefixes = []string{"<!--", "<![CDATA[", "<!ENTITY"}
+
+// States of entityGuard.
+const (
+	guardText = iota
+	// guardOpen follows a '<' whose markup is not yet known.
+	guardOpen
+	guardTag
+	guardComment
+	guardCDATA
+	guardInstruction
+	// guardDirective is inside a directive such as a DOCTYPE, which may
+	// hold declarations in its internal subset.
+	guardDirective
+)
+
+// entityGuard reads r, failing with ErrUnsafeXML once it reads an entity
+// declaration, in the internal subset of a DOCTYPE or as a directive of its
+// own, what encoding/xml returns as an xml.Directive token. Mentions in
+// comments, CDATA sections and text are left alone.
+//
+// The decoders of encoding/xml never fetch external DTDs nor expand the
+// entities a document declares, but only report them as undefined when
+// they are referenced. Refusing the declarations makes hostile uploads fail
+// explicitly, whatever the decoder, its Strict and Entity settings, or the
+// code that reads its tokens. The guard lexes the bytes as they are read
+// rather than the tokens of a decoder, so that the offsets of the decoder
+// reading through it stay those of the document.
+type entityGuard struct {
+	r     io.Reader
+	state int
+	// markup holds the bytes of the markup being recognized, from its '<'.
+	markup []byte
+	// inDirective is set when the markup being recognized, or the comment
+	// or instruction being read, is inside a directive.
+	inDirective bool
+	// depth is the bracket depth of the directive, quote the quote of the
+	// literal it is in, if any.
+	depth int
+	quote byte
+	err   error
+}
+
+func newEntityGuard(r io.Reader) io.Reader {
+	return &entityGuard{r: r}
+}
+
+func (guard *entityGuard) Read(p []byte) (int, error) {
+	if guard.err != nil {
+		return 0, guard.err
+	}
+
+	n, err := guard.r.Read(p)
+	for _, b := range p[:n] {
+		if !guard.scan(b) {
+			guard.err = ErrUnsafeXML
+			return 0, guard.err
+		}
+	}
+
+	return n, err
+}
+
+// scan advances the guard past b, and reports whether the document is
+// still safe.
+func (guard *entityGuard) scan(b byte) bool {
+	switch guard.state {
+	case guardText, guardTag:
+		if b == '<' {
+			guard.open(b, false)
+		} else if b == '>' {
+			guard.state = guardText
+		}
+	case guardOpen:
+		return guard.recognize(b)
+	case guardComment:
+		if guard.ends(b, "-->") {
+			guard.close()
+		}
+	case guardCDATA:
+		if guard.ends(b, "]]>") {
+			guard.close()
+		}
+	case guardInstruction:
+		if guard.ends(b, "?>") {
+			guard.close()
+		}
+	case guardDirective:
+		switch {
+		case guard.quote != 0:
+			if b == guard.quote {
+				guard.quote = 0
+			}
+		case b == '"' || b == '\'':
+			guard.quote = b
+		case b == '[':
+			guard.depth++
+		case b == ']':
+			guard.depth--
+		case b == '<' && guard.depth > 0:
+			guard.open(b, true)
+		case b == '>' && guard.depth <= 0:
+			guard.state, guard.depth = guardText, 0
+		}
+	}
+
+	return true
+}
+
+// open starts the recognition of markup at '<'.
+func (guard *entityGuard) open(b byte, inDirective bool) {
+	guard.state, guard.inDirective = guardOpen, inDirective
+	guard.markup = append(guard.markup[:0], b)
+}
+
+// recognize adds b to the markup being recognized and enters its state,
+// once known.
+func (guard *entityGuard) recognize(b byte) bool {
+	guard.markup = append(guard.markup, b)
+	markup := string(guard.markup)
+	switch {
+	case markup == "<!ENTITY":
+		return false
+	case markup == "<!--":
+		guard.state = guardComment
+		guard.markup = guard.markup[:0]
+		return true
+	case markup == "<![CDATA[" && !guard.inDirective:
+		guard.state = guardCDATA
+		guard.markup = guard.markup[:0]
+		return true
+	case markup == "<?":
+		guard.state = guardInstruction
+		guard.markup = guard.markup[:0]
+		return true
+	}
+	for _, prefix := range markupPrefixes {
+		if strings.HasPrefix(prefix, markup) {
+			return true
+		}
+	}
+
+	switch {
+	case guard.inDirective:
+		// Other declarations, such as <!ELEMENT, are read as part of the
+		// directive.
+		guard.state = guardDirective
+		return guard.scan(b)
+	case strings.HasPrefix(markup, "<!"):
+		guard.state, guard.depth, guard.quote = guardDirective, 0, 0
+		return guard.scan(b)
+	}
+	guard.state = guardTag
+
+	return guard.scan(b)
+}
+
+// ends adds b to the last bytes read and reports whether they end with
+// delimiter.
+func (guard *entityGuard) ends(b byte, delimiter string) bool {
+	guard.markup = append(guard.markup, b)
+	if n := len(guard.markup); n > len(delimiter) {
+		guard.markup = append(guard.markup[:0], guard.markup[n-len(delimiter):]...)
+	}
+
+	return string(guard.markup) == delimiter
+}
+
+// close ends a comment, CDATA section or processing instruction.
+func (guard *entityGuard) close() {
+	guard.state = guardText
+	if guard.inDirective {
+		guard.state = guardDirective
+	}
+	guard.markup = guard.markup[:0]
+}
+
+// newXMLDecoder returns a strict decoder for the XML documents of the
+// book, such as the container, the package document or the NCX, refusing
+// entity declarations.
+func newXMLDecoder(r io.Reader) *xml.Decoder {
+	return xml.NewDecoder(newEntityGuard(r))
+}
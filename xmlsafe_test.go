@@ -0,0 +1,146 @@
+package epub
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+)
+
+// xxeDoctype declares an external entity reading a local file, and
+// entities expanding to a billion copies of a string.
+const xxeDoctype = `<!DOCTYPE root [
+  <!ENTITY xxe SYSTEM "file:///etc/passwd">
+  <!ENTITY lol "lol">
+  <!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+  <!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+]>
+`
+
+// withXXE returns the XML document xml with xxeDoctype and a reference to
+// its entities in the text of the first element named element.
+func withXXE(xml, element string) string {
+	xml = strings.Replace(xml, `?>`, "?>\n"+xxeDoctype, 1)
+	start := strings.Index(xml, "<"+element)
+	end := start + strings.IndexByte(xml[start:], '>') + 1
+
+	return xml[:end] + "&xxe;&lol2;" + xml[end:]
+}
+
+func TestUnsafeXML(t *testing.T) {
+	for _, file := range []testFile{
+		{containerPath, withXXE(testContainer, "rootfiles")},
+		{"OEBPS/content.opf", withXXE(testOPF, "dc:title")},
+	} {
+		book := buildTestEpub(t, file)
+		if _, err := OpenBuffer(book, int64(len(book))); !errors.Is(err, ErrUnsafeXML) {
+			t.Errorf("%s: OpenBuffer() = %v, want ErrUnsafeXML", file.name, err)
+		}
+	}
+
+	reader := openTestEpub(t, testFile{"OEBPS/toc.ncx", withXXE(testNCX, "text")})
+	if _, err := reader.TOC(); !errors.Is(err, ErrUnsafeXML) {
+		t.Errorf("NCX: TOC() = %v, want ErrUnsafeXML", err)
+	}
+
+	nav := withXXE(strings.ReplaceAll(testNav, `href="text/`, `href="`), "a")
+	book := buildTestEpub3(t, testFile{"OEBPS/nav.xhtml", nav})
+	reader, err := OpenBuffer(book, int64(len(book)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.TOC(); !errors.Is(err, ErrUnsafeXML) {
+		t.Errorf("nav: TOC() = %v, want ErrUnsafeXML", err)
+	}
+
+	reader = openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", withXXE(testChapter1, "p")})
+	if _, err := reader.parseDocument("OEBPS/chapter1.xhtml"); !errors.Is(err, ErrUnsafeXML) {
+		t.Errorf("content document: parseDocument() = %v, want ErrUnsafeXML", err)
+	}
+
+	opf := strings.Replace(testOPF, `<item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml"/>`, `<item id="chapter1" href="chapter1.xhtml" media-type="application/xhtml+xml" media-overlay="smil"/>
+    <item id="smil" href="chapter1.smil" media-type="application/smil+xml"/>`, 1)
+	smil := withXXE(`<?xml version="1.0" encoding="UTF-8"?>
+<smil xmlns="http://www.w3.org/ns/SMIL" version="3.0"><body><par><text src="chapter1.xhtml#p1"/><audio src="chapter1.mp3" clipBegin="0s" clipEnd="1s"/></par></body></smil>`, "body")
+	reader = openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/chapter1.smil", smil})
+	if _, err := reader.MediaOverlay("chapter1"); !errors.Is(err, ErrUnsafeXML) {
+		t.Errorf("SMIL: MediaOverlay() = %v, want ErrUnsafeXML", err)
+	}
+}
+
+// TestExternalDTD checks that the external DTD of a document is not
+// fetched.
+func TestExternalDTD(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		io.WriteString(w, `<!ENTITY title "Fetched">`)
+	}))
+	defer server.Close()
+
+	doctype := `<!DOCTYPE package SYSTEM "` + server.URL + `/package.dtd">`
+	opf := strings.Replace(testOPF, `?>`, "?>\n"+doctype, 1)
+	chapter := strings.Replace(testChapter1, "<html", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "`+server.URL+`/xhtml11.dtd">
+<html`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/content.opf", opf}, testFile{"OEBPS/chapter1.xhtml", chapter})
+	if title := firstTitle(reader.Rootfiles[0].Metadata.Title); title != "The Test Book" {
+		t.Errorf("title = %q", title)
+	}
+	if _, err := reader.parseDocument("OEBPS/chapter1.xhtml"); err != nil {
+		t.Errorf("parseDocument() = %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("%d requests for the DTD", n)
+	}
+}
+
+// TestEntityGuard checks that declarations split across reads are found.
+func TestEntityGuard(t *testing.T) {
+	document := "<?xml version=\"1.0\"?>\n" + xxeDoctype + "<root>&xxe;</root>"
+	if _, err := io.ReadAll(newEntityGuard(iotest.OneByteReader(strings.NewReader(document)))); !errors.Is(err, ErrUnsafeXML) {
+		t.Errorf("ReadAll() = %v, want ErrUnsafeXML", err)
+	}
+	safe := "<?xml version=\"1.0\"?>\n<root>&lt;!ENTITY</root>"
+	if data, err := io.ReadAll(newEntityGuard(iotest.HalfReader(strings.NewReader(safe)))); err != nil || string(data) != safe {
+		t.Errorf("ReadAll() = %q, %v", data, err)
+	}
+}
+
+// TestEntityMentions checks that entity declarations quoted in text,
+// comments and CDATA sections are read.
+func TestEntityMentions(t *testing.T) {
+	chapter := strings.Replace(testChapter1, "</body>", `<!-- <!ENTITY comment "y"> -->
+<pre><![CDATA[<!DOCTYPE root [<!ENTITY x "y">]>]]></pre>
+<p>&lt;!ENTITY text "y"&gt;</p>
+</body>`, 1)
+	reader := openTestEpub(t, testFile{"OEBPS/chapter1.xhtml", chapter})
+	text, err := reader.ChapterText("chapter1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, `<!ENTITY x "y">`) || !strings.Contains(text, `<!ENTITY text "y">`) {
+		t.Errorf("ChapterText() = %q", text)
+	}
+
+	for _, document := range []string{
+		`<?xml version="1.0"?><!DOCTYPE root SYSTEM "root.dtd" [<!-- <!ENTITY --> <!ELEMENT root (#PCDATA)>]><root>']>'</root>`,
+		`<?xml version="1.0"?><!DOCTYPE root [<!ATTLIST root a CDATA "<!ENTITY">]><root/>`,
+		`<?xml version="1.0"?><root a="1"><?pi <!ENTITY ?></root>`,
+	} {
+		if data, err := io.ReadAll(newEntityGuard(iotest.OneByteReader(strings.NewReader(document)))); err != nil || string(data) != document {
+			t.Errorf("ReadAll(%s) = %q, %v", document, data, err)
+		}
+	}
+	for _, document := range []string{
+		`<?xml version="1.0"?><!DOCTYPE root [<!ELEMENT root (#PCDATA)><!-- x --><!ENTITY x "y">]><root/>`,
+		`<?xml version="1.0"?><!ENTITY x "y"><root/>`,
+	} {
+		if _, err := io.ReadAll(newEntityGuard(strings.NewReader(document))); !errors.Is(err, ErrUnsafeXML) {
+			t.Errorf("ReadAll(%s) = %v, want ErrUnsafeXML", document, err)
+		}
+	}
+}